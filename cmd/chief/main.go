@@ -12,7 +12,9 @@ import (
 	"github.com/izdrail/chief/internal/cmd"
 	"github.com/izdrail/chief/internal/config"
 	"github.com/izdrail/chief/internal/git"
+	"github.com/izdrail/chief/internal/loop"
 	"github.com/izdrail/chief/internal/notify"
+	"github.com/izdrail/chief/internal/notify/dispatch"
 	"github.com/izdrail/chief/internal/prd"
 	"github.com/izdrail/chief/internal/tui"
 )
@@ -47,9 +49,27 @@ func main() {
 		case "list":
 			runList()
 			return
+		case "prompt":
+			runPrompt()
+			return
+		case "lint":
+			runLint()
+			return
+		case "import":
+			runImport()
+			return
+		case "report":
+			runReport()
+			return
+		case "doctor":
+			runDoctor()
+			return
 		case "serve":
 			runServe()
 			return
+		case "fake-llm":
+			runFakeLLM()
+			return
 		case "help":
 			printHelp()
 			return
@@ -276,6 +296,140 @@ func runList() {
 	}
 }
 
+func runPrompt() {
+	// Parse arguments: chief prompt show [name] [--template <name>]
+	if len(os.Args) < 3 || os.Args[2] != "show" {
+		fmt.Fprintln(os.Stderr, "Usage: chief prompt show [name] [--template <name>]")
+		os.Exit(1)
+	}
+
+	opts := cmd.PromptOptions{}
+	args := os.Args[3:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--template" && i+1 < len(args):
+			i++
+			opts.Template = args[i]
+		case strings.HasPrefix(arg, "--template="):
+			opts.Template = strings.TrimPrefix(arg, "--template=")
+		case !strings.HasPrefix(arg, "-") && opts.Name == "":
+			opts.Name = arg
+		}
+	}
+
+	if err := cmd.RunPromptShow(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runLint() {
+	opts := cmd.LintOptions{}
+
+	// Parse arguments: chief lint [name|path/to/prd.json]
+	if len(os.Args) > 2 && !strings.HasPrefix(os.Args[2], "-") {
+		opts.Name = os.Args[2]
+	}
+
+	if err := cmd.RunLint(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runImport() {
+	opts := cmd.ImportOptions{}
+
+	// Parse arguments: chief import <file> [name] [--format <format>] [--project <name>] [--force]
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--force":
+			opts.Force = true
+		case arg == "--format" && i+1 < len(args):
+			i++
+			opts.Format = args[i]
+		case strings.HasPrefix(arg, "--format="):
+			opts.Format = strings.TrimPrefix(arg, "--format=")
+		case arg == "--project" && i+1 < len(args):
+			i++
+			opts.Project = args[i]
+		case strings.HasPrefix(arg, "--project="):
+			opts.Project = strings.TrimPrefix(arg, "--project=")
+		case !strings.HasPrefix(arg, "-") && opts.File == "":
+			opts.File = arg
+		case !strings.HasPrefix(arg, "-") && opts.Name == "":
+			opts.Name = arg
+		}
+	}
+
+	if opts.File == "" {
+		fmt.Fprintln(os.Stderr, "Usage: chief import <file> [name] [--format markdown|csv|github|jira] [--project <name>] [--force]")
+		os.Exit(1)
+	}
+
+	if err := cmd.RunImport(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runReport() {
+	opts := cmd.ReportOptions{}
+
+	// Parse arguments: chief report [name] [--format md|html|json] [--output <file>]
+	args := os.Args[2:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--format" && i+1 < len(args):
+			i++
+			opts.Format = args[i]
+		case strings.HasPrefix(arg, "--format="):
+			opts.Format = strings.TrimPrefix(arg, "--format=")
+		case (arg == "--output" || arg == "-o") && i+1 < len(args):
+			i++
+			opts.Output = args[i]
+		case strings.HasPrefix(arg, "--output="):
+			opts.Output = strings.TrimPrefix(arg, "--output=")
+		case !strings.HasPrefix(arg, "-") && opts.Name == "":
+			opts.Name = arg
+		}
+	}
+
+	if err := cmd.RunReport(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runDoctor() {
+	if err := cmd.RunDoctor(cmd.DoctorOptions{}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runFakeLLM() {
+	opts := cmd.FakeLLMOptions{}
+
+	// Parse arguments: chief fake-llm <script.yaml> [addr]
+	args := os.Args[2:]
+	if len(args) > 0 {
+		opts.Script = args[0]
+	}
+	if len(args) > 1 {
+		opts.Addr = args[1]
+	}
+
+	if err := cmd.RunFakeLLM(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
 func runServe() {
 	opts := cmd.ServeOptions{
 		Addr:     os.Getenv("CHIEF_ADDR"),
@@ -293,6 +447,32 @@ func runServe() {
 	}
 }
 
+// setupNotifications creates the notification dispatcher from the project
+// config, or returns nil when no channels are configured. Delivery failures
+// are written to .chief/notify.log so they don't disturb the TUI.
+func setupNotifications() *dispatch.Dispatcher {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	cfg, err := config.Load(cwd)
+	if err != nil || len(cfg.Notify.Channels) == 0 {
+		return nil
+	}
+	dispatcher, err := dispatch.New(cfg.Notify)
+	if err != nil {
+		log.Printf("Warning: notifications disabled: %v", err)
+		return nil
+	}
+	if f, err := os.OpenFile(filepath.Join(cwd, ".chief", "notify.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err == nil {
+		logger := log.New(f, "", log.LstdFlags)
+		dispatcher.SetErrorHandler(func(channel string, err error) {
+			logger.Printf("notification via %s failed: %v", channel, err)
+		})
+	}
+	return dispatcher
+}
+
 func runTUIWithOptions(opts *TUIOptions) {
 	prdPath := opts.PRDPath
 
@@ -416,8 +596,18 @@ func runTUIWithOptions(opts *TUIOptions) {
 		}
 	}
 
+	// Route loop events to the notification channels in .chief/config.yaml
+	dispatcher := setupNotifications()
+	if dispatcher != nil {
+		app.SetEventCallback(func(event loop.ManagerEvent) {
+			dispatcher.HandleEvent(event.PRDName, event.Event)
+		})
+	}
+
 	p := tea.NewProgram(app, tea.WithAltScreen())
 	model, err := p.Run()
+	dispatcher.Wait()
+	app.Close()
 	if err != nil {
 		fmt.Printf("Error running program: %v\n", err)
 		os.Exit(1)
@@ -469,6 +659,12 @@ Commands:
   edit [name] [options]     Edit an existing PRD interactively
   status [name]             Show progress for a PRD (default: main)
   list                      List all PRDs with progress
+  prompt show [name]        Print the rendered agent prompt for a PRD
+  lint [name|path]          Validate PRDs (default: all in .chief/prds/)
+  import <file> [name]      Create a PRD from a Markdown, CSV, GitHub or Jira export
+  report [name]             Write a run report for a PRD (--format md|html|json)
+  doctor                    Check git, Ollama, config and the store, and suggest fixes
+  fake-llm <script> [addr]  Serve a scripted fake model over the Ollama API for testing
   help                      Show this help message
 
 Global Options:
@@ -508,6 +704,13 @@ Examples:
   chief status              Show progress for default PRD
   chief status auth         Show progress for auth PRD
   chief list                List all PRDs with progress
+  chief prompt show auth    Show the prompt the next auth iteration receives
+  chief lint                Validate every PRD in .chief/prds/
+  chief lint auth           Validate the auth PRD
+  chief import backlog.csv auth
+                            Import a CSV backlog into .chief/prds/auth/
+  chief report auth --format html -o auth.html
+                            Write an HTML report for the auth PRD
   chief --version           Show version number`)
 }
 
@@ -3,8 +3,10 @@
 package embed
 
 import (
+	"bytes"
 	_ "embed"
 	"strings"
+	"text/template"
 )
 
 //go:embed prompt.txt
@@ -25,9 +27,27 @@ var detectSetupPromptTemplate string
 //go:embed generate_prd_prompt.txt
 var generatePRDPromptTemplate string
 
+//go:embed resolve_conflicts_prompt.txt
+var resolveConflictsPromptTemplate string
+
+//go:embed prd.schema.json
+var prdSchema []byte
+
+// AgentPromptTemplate returns the default agent prompt as a text/template source.
+// See the prompts package for the data it is rendered with.
+func AgentPromptTemplate() string {
+	return promptTemplate
+}
+
 // GetPrompt returns the agent prompt with the PRD path substituted.
+// Optional sections (current story, learnings, repository map) are omitted.
 func GetPrompt(prdPath string) string {
-	return strings.ReplaceAll(promptTemplate, "{{PRD_PATH}}", prdPath)
+	tmpl := template.Must(template.New("agent").Parse(promptTemplate))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, map[string]any{"PRDPath": prdPath}); err != nil {
+		return strings.ReplaceAll(promptTemplate, "{{.PRDPath}}", prdPath)
+	}
+	return buf.String()
 }
 
 // GetInitPrompt returns the PRD generator prompt with the PRD directory and optional context substituted.
@@ -60,3 +80,19 @@ func GetGeneratePRDPrompt(prdDir, name, description string) string {
 	result = strings.ReplaceAll(result, "{{NAME}}", name)
 	return strings.ReplaceAll(result, "{{DESCRIPTION}}", description)
 }
+
+// PRDSchema returns the JSON Schema for prd.json.
+func PRDSchema() []byte {
+	return prdSchema
+}
+
+// GetResolveConflictsPrompt returns the prompt for resolving the conflicts a
+// merge of branch left in files.
+func GetResolveConflictsPrompt(branch string, files []string) string {
+	list := make([]string, len(files))
+	for i, f := range files {
+		list[i] = "- `" + f + "`"
+	}
+	result := strings.ReplaceAll(resolveConflictsPromptTemplate, "{{BRANCH}}", branch)
+	return strings.ReplaceAll(result, "{{FILES}}", strings.Join(list, "\n"))
+}
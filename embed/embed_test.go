@@ -1,6 +1,9 @@
 package embed
 
 import (
+	"bytes"
+	"encoding/json"
+	"os"
 	"strings"
 	"testing"
 )
@@ -108,3 +111,30 @@ func TestGetEditPrompt(t *testing.T) {
 		t.Error("Expected prompt to contain the PRD directory path")
 	}
 }
+
+func TestGetResolveConflictsPrompt(t *testing.T) {
+	prompt := GetResolveConflictsPrompt("chief/auth", []string{"a.go", "docs/b.md"})
+	for _, want := range []string{"`chief/auth`", "- `a.go`\n- `docs/b.md`", ">>>>>>> chief/auth"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt is missing %q", want)
+		}
+	}
+	if strings.Contains(prompt, "{{") {
+		t.Error("prompt has unsubstituted placeholders")
+	}
+}
+
+func TestPRDSchemaMatchesPublishedCopy(t *testing.T) {
+	published, err := os.ReadFile("../docs/public/prd.schema.json")
+	if err != nil {
+		t.Fatalf("failed to read published schema: %v", err)
+	}
+	if !bytes.Equal(published, PRDSchema()) {
+		t.Error("docs/public/prd.schema.json is out of date; copy embed/prd.schema.json over it")
+	}
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(PRDSchema(), &schema); err != nil {
+		t.Fatalf("embedded schema is not valid JSON: %v", err)
+	}
+}
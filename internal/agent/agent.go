@@ -8,15 +8,21 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/izdrail/chief/internal/ollama"
 	"github.com/izdrail/chief/internal/tools"
 )
 
+// ContextLength is the context window, in tokens, the agent asks the model for.
+const ContextLength = 32768
+
 // AgentOptions configures the agent's behavior.
 type AgentOptions struct {
 	MaxToolRounds int
 	WorkDir       string
+	Handlers      []tools.Handler // Caller-provided tools offered alongside the built-in ones
 }
 
 // AgentEvent represents a streaming event from the agent.
@@ -25,12 +31,30 @@ type AgentEvent struct {
 	ToolName   string
 	ToolInput  map[string]interface{}
 	ToolResult string
+	Notice     string        // How a failed request was recovered from, e.g. by falling back to another model
+	QueueWait  time.Duration // How long a request waited for a slot on the LLM endpoint
 	Error      error
 	Done       bool
+
+	// Message is each assistant and tool message as it's added to the
+	// conversation, so callers can keep a transcript. Elapsed is how long the
+	// model took to answer, or the tool to run; Usage is set for assistant
+	// messages when the server reports it.
+	Message *ollama.Message
+	Elapsed time.Duration
+	Usage   ollama.Usage
 }
 
+// keepToolResults is how many of the latest tool results compaction leaves intact.
+const keepToolResults = 4
+
+// compactedToolResult replaces tool output removed by compaction.
+const compactedToolResult = "[Earlier tool output removed to fit the context window]"
+
 // RunAgent drives the agentic loop: sending messages to Ollama,
-// executing tools as requested, and feeding results back.
+// executing tools as requested, and feeding results back. Time requests spend
+// waiting for a slot on the endpoint is reported as QueueWait events, in place
+// of any queue-wait handler on ctx.
 func RunAgent(
 	ctx context.Context,
 	client *ollama.Client,
@@ -43,10 +67,22 @@ func RunAgent(
 		opts.MaxToolRounds = 50
 	}
 
+	// The wait is reported on the request's goroutine while it holds the
+	// slot, so it's only recorded there and sent on by streamRound
+	queued := new(atomic.Int64)
+	ctx = ollama.WithQueueWaitHandler(ctx, func(wait time.Duration) {
+		queued.Add(int64(wait))
+	})
+
 	go func() {
 		defer close(ch)
 
 		toolDefs := tools.Definitions()
+		handlers := make(map[string]tools.Handler, len(opts.Handlers))
+		for _, h := range opts.Handlers {
+			toolDefs = append(toolDefs, h.Definition)
+			handlers[h.Name()] = h
+		}
 
 		for round := 0; round < opts.MaxToolRounds; round++ {
 			// Check context
@@ -55,43 +91,25 @@ func RunAgent(
 				return
 			}
 
-			req := ollama.ChatRequest{
-				Model:    client.Model,
-				Messages: messages,
-				Tools:    toolDefs,
-				Stream:   true,
-				Options: &ollama.Options{
-					NumCtx: 32768,
-				},
-			}
-
-			// Collect the full assistant response
-			var textBuilder strings.Builder
-			var toolCalls []ollama.ToolCall
-			var streamErr error
-
-			stream := client.ChatStream(ctx, req)
-			for event := range stream {
-				if event.Error != nil {
-					streamErr = event.Error
-					break
+			// Collect the full assistant response, compacting the conversation
+			// or falling back to another model when the request fails in a way
+			// that allows it
+			started := time.Now()
+			assistantText, toolCalls, usage, streamErr := streamRound(ctx, client, messages, toolDefs, queued, ch)
+			for streamErr != nil {
+				notice, ok := recoverRequest(client, &messages, streamErr)
+				if !ok || ctx.Err() != nil {
+					ch <- AgentEvent{Error: fmt.Errorf("stream error: %w", streamErr)}
+					return
 				}
-				if event.TextDelta != "" {
-					textBuilder.WriteString(event.TextDelta)
-					ch <- AgentEvent{TextDelta: event.TextDelta}
-				}
-				if len(event.ToolCalls) > 0 {
-					toolCalls = append(toolCalls, event.ToolCalls...)
+				if assistantText != "" {
+					// The retry answers from the start
+					notice += "; the partial answer above is discarded"
 				}
+				ch <- AgentEvent{Notice: notice}
+				assistantText, toolCalls, usage, streamErr = streamRound(ctx, client, messages, toolDefs, queued, ch)
 			}
 
-			if streamErr != nil {
-				ch <- AgentEvent{Error: fmt.Errorf("stream error: %w", streamErr)}
-				return
-			}
-
-			assistantText := textBuilder.String()
-
 			// Add assistant message to history
 			assistantMsg := ollama.Message{
 				Role:      "assistant",
@@ -99,6 +117,7 @@ func RunAgent(
 				ToolCalls: toolCalls,
 			}
 			messages = append(messages, assistantMsg)
+			ch <- AgentEvent{Message: &assistantMsg, Elapsed: time.Since(started), Usage: usage}
 
 			// If no tool calls, the model is done
 			if len(toolCalls) == 0 {
@@ -120,11 +139,19 @@ func RunAgent(
 					ToolInput: argsMap,
 				}
 
-				result, err := tools.Execute(toolName, toolArgs, opts.WorkDir)
+				var result string
+				var err error
+				toolStarted := time.Now()
+				if h, ok := handlers[toolName]; ok {
+					result, err = h.Execute(toolArgs)
+				} else {
+					result, err = tools.Execute(toolName, toolArgs, opts.WorkDir)
+				}
 				if err != nil {
 					result = fmt.Sprintf("Tool error: %v", err)
 				}
 
+				elapsed := time.Since(toolStarted)
 				ch <- AgentEvent{ToolResult: result}
 
 				// Add tool result to messages
@@ -132,12 +159,14 @@ func RunAgent(
 				if toolID == "" {
 					toolID = toolName
 				}
-				messages = append(messages, ollama.Message{
+				toolMsg := ollama.Message{
 					Role:       "tool",
 					Content:    result,
 					ToolCallID: toolID,
 					Name:       toolName,
-				})
+				}
+				messages = append(messages, toolMsg)
+				ch <- AgentEvent{Message: &toolMsg, Elapsed: elapsed}
 			}
 
 			// Continue the loop to let the model process tool results
@@ -149,6 +178,105 @@ func RunAgent(
 	return ch
 }
 
+// streamRound sends the conversation to the model and collects its response,
+// forwarding text and the time it waited for a slot as they arrive. When the
+// request fails it returns the text forwarded before the failure, after the
+// request has been canceled and its stream drained.
+func streamRound(ctx context.Context, client *ollama.Client, messages []ollama.Message, toolDefs []ollama.Tool, queued *atomic.Int64, ch chan<- AgentEvent) (string, []ollama.ToolCall, ollama.Usage, error) {
+	req := ollama.ChatRequest{
+		Model:    client.Model,
+		Messages: messages,
+		Tools:    toolDefs,
+		Stream:   true,
+		Options: &ollama.Options{
+			NumCtx: ContextLength,
+		},
+	}
+
+	var textBuilder strings.Builder
+	var toolCalls []ollama.ToolCall
+	var usage ollama.Usage
+	ctx, cancel := context.WithCancel(ctx)
+	stream := client.ChatStream(ctx, req)
+	defer func() {
+		// Wait for the request to let go of its slot before a retry asks
+		// for one
+		cancel()
+		for range stream {
+		}
+	}()
+	for event := range stream {
+		if wait := time.Duration(queued.Swap(0)); wait > 0 {
+			ch <- AgentEvent{QueueWait: wait}
+		}
+		if event.Error != nil {
+			return textBuilder.String(), nil, ollama.Usage{}, event.Error
+		}
+		if event.Done {
+			usage = event.Usage
+		}
+		if event.TextDelta != "" {
+			textBuilder.WriteString(event.TextDelta)
+			ch <- AgentEvent{TextDelta: event.TextDelta}
+		}
+		if len(event.ToolCalls) > 0 {
+			toolCalls = append(toolCalls, event.ToolCalls...)
+		}
+	}
+	return textBuilder.String(), toolCalls, usage, nil
+}
+
+// recoverRequest acts on a failed request's error class: it compacts the
+// conversation after a context overflow, or switches the client to its next
+// fallback when the model or endpoint is unavailable. It returns a notice
+// describing what it did, or false when there's nothing more it can do and the
+// error should be returned to the caller.
+func recoverRequest(client *ollama.Client, messages *[]ollama.Message, err error) (string, bool) {
+	class := ollama.Classify(err)
+	switch class.Action() {
+	case ollama.ActionCompact:
+		compacted, removed := compactMessages(*messages)
+		if removed == 0 {
+			return "", false
+		}
+		*messages = compacted
+		return fmt.Sprintf("Context window exceeded; removed %d earlier tool result(s) and retrying", removed), true
+	case ollama.ActionFallback:
+		failed := client.Model
+		target, ok := client.NextFallback()
+		if !ok {
+			return "", false
+		}
+		return fmt.Sprintf("%s (%s); falling back to %s at %s", failed, class, target.Model, target.BaseURL), true
+	}
+	return "", false
+}
+
+// compactMessages replaces the content of all but the latest keepToolResults
+// tool results with a placeholder. The prompt and the assistant's messages are
+// kept, so the model still knows what it did. It returns the compacted
+// conversation and the number of results it replaced.
+func compactMessages(messages []ollama.Message) ([]ollama.Message, int) {
+	var toolIdx []int
+	for i, m := range messages {
+		if m.Role == "tool" && m.Content != compactedToolResult {
+			toolIdx = append(toolIdx, i)
+		}
+	}
+	if len(toolIdx) <= keepToolResults {
+		return messages, 0
+	}
+
+	compacted := make([]ollama.Message, len(messages))
+	copy(compacted, messages)
+	removed := 0
+	for _, i := range toolIdx[:len(toolIdx)-keepToolResults] {
+		compacted[i].Content = compactedToolResult
+		removed++
+	}
+	return compacted, removed
+}
+
 // RunInteractive starts an interactive session with the Ollama agent.
 func RunInteractive(ctx context.Context, client *ollama.Client, initialPrompt string, opts AgentOptions) error {
 	messages := []ollama.Message{
@@ -176,6 +304,9 @@ func RunInteractive(ctx context.Context, client *ollama.Client, initialPrompt st
 			if event.ToolName != "" {
 				fmt.Printf("\n[agent uses tool: %s]\n", event.ToolName)
 			}
+			if event.Notice != "" {
+				fmt.Printf("\n[%s]\n", event.Notice)
+			}
 		}
 
 		if streamErr != nil {
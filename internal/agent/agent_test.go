@@ -0,0 +1,236 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/izdrail/chief/internal/ollama"
+)
+
+// fakeOllama answers every chat request that handler doesn't fail with a
+// single "done" message.
+func fakeOllama(t *testing.T, handler func(w http.ResponseWriter, req ollama.ChatRequest) bool) *ollama.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollama.ChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if handler(w, req) {
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"done"},"done":true}` + "\n"))
+	}))
+	t.Cleanup(srv.Close)
+	return &ollama.Client{BaseURL: srv.URL, Model: "primary", HTTPClient: srv.Client(), Admission: ollama.NewAdmission(1)}
+}
+
+// drain collects an agent run's notices, text and final error.
+func drain(stream <-chan AgentEvent) (notices []string, text string, err error) {
+	for event := range stream {
+		if event.Notice != "" {
+			notices = append(notices, event.Notice)
+		}
+		text += event.TextDelta
+		if event.Error != nil {
+			err = event.Error
+		}
+	}
+	return notices, text, err
+}
+
+func TestRunAgentFallsBack(t *testing.T) {
+	client := fakeOllama(t, func(w http.ResponseWriter, req ollama.ChatRequest) bool {
+		if req.Model == "primary" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model \"primary\" not found, try pulling it first"}`))
+			return true
+		}
+		return false
+	})
+	client.Fallbacks = []ollama.Target{{Model: "backup"}}
+
+	notices, text, err := drain(RunAgent(context.Background(), client, []ollama.Message{{Role: "user", Content: "hi"}}, AgentOptions{}))
+	if err != nil {
+		t.Fatalf("RunAgent() error: %v", err)
+	}
+	if text != "done" || len(notices) != 1 || client.Model != "backup" {
+		t.Errorf("text = %q, notices = %v, model = %s", text, notices, client.Model)
+	}
+
+	// With no fallbacks left the error is returned
+	client.Model = "primary"
+	_, _, err = drain(RunAgent(context.Background(), client, []ollama.Message{{Role: "user", Content: "hi"}}, AgentOptions{}))
+	if ollama.Classify(err) != ollama.ErrorModelNotFound {
+		t.Errorf("expected a model-not-found error, got %v", err)
+	}
+}
+
+func TestRunAgentDiscardsPartialAnswer(t *testing.T) {
+	client := fakeOllama(t, func(w http.ResponseWriter, req ollama.ChatRequest) bool {
+		if req.Model == "primary" {
+			w.Write([]byte(`{"message":{"role":"assistant","content":"half an "},"done":false}` + "\n"))
+			w.Write([]byte(`{"error":"model \"primary\" not found, try pulling it first"}` + "\n"))
+			return true
+		}
+		return false
+	})
+	client.Fallbacks = []ollama.Target{{Model: "backup"}}
+
+	var notices []string
+	var answer string
+	for event := range RunAgent(context.Background(), client, []ollama.Message{{Role: "user", Content: "hi"}}, AgentOptions{}) {
+		if event.Notice != "" {
+			notices = append(notices, event.Notice)
+		}
+		if event.Message != nil {
+			answer = event.Message.Content
+		}
+		if event.Error != nil {
+			t.Fatal(event.Error)
+		}
+	}
+	if len(notices) != 1 || !strings.Contains(notices[0], "partial answer above is discarded") {
+		t.Errorf("notices = %v; want one saying the partial answer is discarded", notices)
+	}
+	if answer != "done" {
+		t.Errorf("answer = %q, want only the retry's", answer)
+	}
+}
+
+func TestRunAgentCompactsContext(t *testing.T) {
+	var requests int
+	client := fakeOllama(t, func(w http.ResponseWriter, req ollama.ChatRequest) bool {
+		requests++
+		full := 0
+		for _, m := range req.Messages {
+			if m.Role == "tool" && m.Content != compactedToolResult {
+				full++
+			}
+		}
+		if full > keepToolResults {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"input length exceeds the context length"}`))
+			return true
+		}
+		return false
+	})
+
+	messages := []ollama.Message{{Role: "user", Content: "prompt"}}
+	for i := 0; i < 6; i++ {
+		messages = append(messages,
+			ollama.Message{Role: "assistant", ToolCalls: []ollama.ToolCall{{Function: ollama.FunctionCall{Name: "Read"}}}},
+			ollama.Message{Role: "tool", Content: fmt.Sprintf("output %d", i)})
+	}
+
+	notices, _, err := drain(RunAgent(context.Background(), client, messages, AgentOptions{}))
+	if err != nil {
+		t.Fatalf("RunAgent() error: %v", err)
+	}
+	if requests != 2 || len(notices) != 1 {
+		t.Errorf("requests = %d, notices = %v; want one compaction", requests, notices)
+	}
+	if messages[2].Content != "output 0" {
+		t.Error("compaction modified the caller's messages")
+	}
+}
+
+func TestRunAgentReportsQueueWait(t *testing.T) {
+	client := fakeOllama(t, func(http.ResponseWriter, ollama.ChatRequest) bool { return false })
+	release, _, err := client.Admission.Acquire(context.Background(), "other", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(50*time.Millisecond, release)
+
+	// The wait arrives in the stream, ahead of the answer it held up
+	var waits []time.Duration
+	var text string
+	for event := range RunAgent(context.Background(), client, []ollama.Message{{Role: "user", Content: "hi"}}, AgentOptions{}) {
+		if event.QueueWait > 0 {
+			if text != "" {
+				t.Error("QueueWait reported after the answer")
+			}
+			waits = append(waits, event.QueueWait)
+		}
+		text += event.TextDelta
+		if event.Error != nil {
+			t.Fatal(event.Error)
+		}
+	}
+	if len(waits) != 1 || waits[0] < 25*time.Millisecond || text != "done" {
+		t.Errorf("waits = %v, text = %q; want one wait of about 50ms", waits, text)
+	}
+}
+
+func TestCompactMessages(t *testing.T) {
+	messages := []ollama.Message{{Role: "user", Content: "prompt"}}
+	for i := 0; i < keepToolResults; i++ {
+		messages = append(messages, ollama.Message{Role: "tool", Content: "output"})
+	}
+	if _, removed := compactMessages(messages); removed != 0 {
+		t.Errorf("compacted %d results when only the latest ones were left", removed)
+	}
+}
+
+func TestRunAgentReplaysCassette(t *testing.T) {
+	cassette, err := ollama.LoadCassette(filepath.Join("testdata", "write-file.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer := ollama.NewReplayer(cassette)
+	client := &ollama.Client{BaseURL: "http://replay", Model: "qwen2.5-coder", HTTPClient: &http.Client{Transport: replayer}, Admission: ollama.NewAdmission(1)}
+	workDir := t.TempDir()
+
+	_, text, err := drain(RunAgent(context.Background(), client, []ollama.Message{{Role: "user", Content: "Create hello.txt"}}, AgentOptions{WorkDir: workDir}))
+	if err != nil {
+		t.Fatalf("RunAgent() error: %v", err)
+	}
+	if text != "I'll create the file.Created hello.txt." || replayer.Remaining() != 0 {
+		t.Errorf("text = %q, %d interactions left", text, replayer.Remaining())
+	}
+	if data, err := os.ReadFile(filepath.Join(workDir, "hello.txt")); err != nil || string(data) != "hello, world\n" {
+		t.Errorf("hello.txt = %q, %v", data, err)
+	}
+}
+
+func TestRunAgentReportsMessages(t *testing.T) {
+	cassette, err := ollama.LoadCassette(filepath.Join("testdata", "write-file.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &ollama.Client{BaseURL: "http://replay", Model: "qwen2.5-coder", HTTPClient: &http.Client{Transport: ollama.NewReplayer(cassette)}, Admission: ollama.NewAdmission(1)}
+
+	var roles []string
+	var toolCall, toolResult ollama.Message
+	for event := range RunAgent(context.Background(), client, []ollama.Message{{Role: "user", Content: "Create hello.txt"}}, AgentOptions{WorkDir: t.TempDir()}) {
+		if event.Error != nil {
+			t.Fatalf("RunAgent() error: %v", event.Error)
+		}
+		if msg := event.Message; msg != nil {
+			roles = append(roles, msg.Role)
+			if len(msg.ToolCalls) > 0 {
+				toolCall = *msg
+			}
+			if msg.Role == "tool" {
+				toolResult = *msg
+			}
+		}
+	}
+
+	if strings.Join(roles, ",") != "assistant,tool,assistant" {
+		t.Errorf("messages = %v", roles)
+	}
+	if toolCall.ToolCalls[0].Function.Name != "Write" || toolResult.Name != "Write" {
+		t.Errorf("tool call %+v, result %+v", toolCall, toolResult)
+	}
+}
@@ -0,0 +1,462 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izdrail/chief/internal/agent"
+	"github.com/izdrail/chief/internal/config"
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/git"
+	"github.com/izdrail/chief/internal/notify/dispatch"
+	"github.com/izdrail/chief/internal/ollama"
+	"github.com/izdrail/chief/internal/prd"
+)
+
+// minGitVersion is the oldest git with `git worktree remove`, which cleaning
+// up PRD worktrees relies on.
+var minGitVersion = [2]int{2, 17}
+
+// CheckStatus is the outcome of a doctor check.
+type CheckStatus int
+
+const (
+	CheckOK   CheckStatus = iota // Nothing to do
+	CheckWarn                    // Chief works, but something may go wrong or is missing
+	CheckFail                    // Runs will fail until this is fixed
+	CheckSkip                    // Not checked because an earlier check failed or it doesn't apply
+)
+
+// Check is the result of one doctor check.
+type Check struct {
+	Name   string
+	Status CheckStatus
+	Detail string // What was found
+	Fix    string // How to fix it (empty when nothing needs doing)
+}
+
+// DoctorOptions contains configuration for the doctor command.
+type DoctorOptions struct {
+	BaseDir string         // Project directory (default: current directory)
+	Client  *ollama.Client // Ollama client (default: ollama.NewClient())
+	Timeout time.Duration  // Timeout for each Ollama request (default: 10s)
+}
+
+// RunDoctor checks the environment Chief runs in and prints a fix for each
+// problem. It returns an error if any check failed; warnings alone do not fail.
+func RunDoctor(opts DoctorOptions) error {
+	if opts.BaseDir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		opts.BaseDir = cwd
+	}
+
+	checks := Diagnose(opts)
+	failed, warned := 0, 0
+	for _, c := range checks {
+		fmt.Printf("%s %-16s %s\n", c.Status.symbol(), c.Name, c.Detail)
+		if c.Fix != "" && (c.Status == CheckWarn || c.Status == CheckFail) {
+			fmt.Printf("  %-16s → %s\n", "", c.Fix)
+		}
+		switch c.Status {
+		case CheckFail:
+			failed++
+		case CheckWarn:
+			warned++
+		}
+	}
+
+	fmt.Println()
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed, %d warning(s)", failed, warned)
+	}
+	if warned > 0 {
+		fmt.Printf("No problems that stop a run, %d warning(s)\n", warned)
+	} else {
+		fmt.Println("Everything looks good")
+	}
+	return nil
+}
+
+// Diagnose runs every doctor check.
+func Diagnose(opts DoctorOptions) []Check {
+	if opts.Client == nil {
+		opts.Client = ollama.NewClient()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	cfg, configCheck := checkConfig(opts.BaseDir)
+
+	var checks []Check
+	gitCheck := checkGitVersion()
+	checks = append(checks, gitCheck)
+	isRepo := gitCheck.Status != CheckFail && git.IsGitRepo(opts.BaseDir)
+	checks = append(checks, checkRepo(opts.BaseDir, isRepo)...)
+	checks = append(checks, checkGH(cfg))
+	checks = append(checks, checkOllama(opts.Client, opts.Timeout)...)
+	checks = append(checks, configCheck)
+	checks = append(checks, checkWorktrees(opts.BaseDir, isRepo))
+	checks = append(checks, checkStore(opts.BaseDir))
+	return checks
+}
+
+func (s CheckStatus) symbol() string {
+	switch s {
+	case CheckOK:
+		return "✓"
+	case CheckWarn:
+		return "!"
+	case CheckFail:
+		return "✗"
+	default:
+		return "-"
+	}
+}
+
+var gitVersionPattern = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// checkGitVersion checks that git is installed and new enough.
+func checkGitVersion() Check {
+	c := Check{Name: "git"}
+	out, err := exec.Command("git", "--version").Output()
+	if err != nil {
+		c.Status = CheckFail
+		c.Detail = "git is not installed"
+		c.Fix = "Install git from https://git-scm.com/downloads"
+		return c
+	}
+	c.Detail = strings.TrimSpace(string(out))
+
+	m := gitVersionPattern.FindStringSubmatch(c.Detail)
+	if m == nil {
+		c.Status = CheckWarn
+		c.Fix = "Couldn't read the git version; make sure git is 2.17 or newer"
+		return c
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	if major < minGitVersion[0] || (major == minGitVersion[0] && minor < minGitVersion[1]) {
+		c.Status = CheckFail
+		c.Fix = fmt.Sprintf("Upgrade git to %d.%d or newer; older versions can't remove PRD worktrees", minGitVersion[0], minGitVersion[1])
+	}
+	return c
+}
+
+// checkRepo checks the repository state and that .chief is ignored.
+func checkRepo(baseDir string, isRepo bool) []Check {
+	if !isRepo {
+		return []Check{
+			{
+				Name:   "repository",
+				Status: CheckWarn,
+				Detail: "not a git repository",
+				Fix:    "Run 'git init' so Chief can commit stories and use worktrees",
+			},
+			{Name: ".chief ignored", Status: CheckSkip, Detail: "not a git repository"},
+		}
+	}
+
+	repo := Check{Name: "repository"}
+	branch, err := git.GetCurrentBranch(baseDir)
+	switch {
+	case err != nil || branch == "":
+		repo.Status = CheckWarn
+		repo.Detail = "no commits yet"
+		repo.Fix = "Make an initial commit so Chief can create branches and worktrees"
+	case branch == "HEAD":
+		repo.Status = CheckWarn
+		repo.Detail = "HEAD is detached"
+		repo.Fix = "Check out a branch before starting a loop"
+	case gitRefExists(baseDir, "MERGE_HEAD"):
+		repo.Status = CheckWarn
+		repo.Detail = fmt.Sprintf("on %s with a merge in progress", branch)
+		repo.Fix = "Finish the merge with 'git commit', or abort it with 'git merge --abort'"
+	case gitRefExists(baseDir, "REBASE_HEAD"):
+		repo.Status = CheckWarn
+		repo.Detail = fmt.Sprintf("on %s with a rebase in progress", branch)
+		repo.Fix = "Finish the rebase with 'git rebase --continue', or abort it with 'git rebase --abort'"
+	default:
+		repo.Detail = "on " + branch
+		if dirty := uncommittedFiles(baseDir); dirty > 0 {
+			repo.Status = CheckWarn
+			repo.Detail += fmt.Sprintf(" with %d uncommitted file(s)", dirty)
+			repo.Fix = "Commit or stash your changes; loops running in this directory commit everything they find"
+		}
+	}
+
+	ignored := Check{Name: ".chief ignored", Detail: ".chief/ is ignored by git"}
+	if !git.IsChiefIgnored(baseDir) {
+		ignored.Status = CheckWarn
+		ignored.Detail = ".chief/ is not ignored by git"
+		ignored.Fix = "Add '.chief/' to .gitignore so worktrees and logs aren't committed"
+	}
+	return []Check{repo, ignored}
+}
+
+func gitRefExists(dir, ref string) bool {
+	cmd := exec.Command("git", "rev-parse", "-q", "--verify", ref)
+	cmd.Dir = dir
+	return cmd.Run() == nil
+}
+
+// uncommittedFiles returns the number of changed and untracked files.
+func uncommittedFiles(dir string) int {
+	cmd := exec.Command("git", "status", "--porcelain")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return 0
+	}
+	trimmed := strings.TrimSpace(string(out))
+	if trimmed == "" {
+		return 0
+	}
+	return len(strings.Split(trimmed, "\n"))
+}
+
+// checkGH checks the GitHub CLI, which is only required for onComplete.createPR.
+func checkGH(cfg *config.Config) Check {
+	c := Check{Name: "gh CLI"}
+	required := cfg != nil && cfg.OnComplete.CreatePR
+	installed, authenticated, _ := git.CheckGHCLI()
+	switch {
+	case !installed && required:
+		c.Status = CheckFail
+		c.Detail = "not installed, but onComplete.createPR is enabled"
+		c.Fix = "Install gh from https://cli.github.com and run 'gh auth login'"
+	case !installed:
+		c.Status = CheckSkip
+		c.Detail = "not installed (only needed for onComplete.createPR)"
+	case !authenticated && required:
+		c.Status = CheckFail
+		c.Detail = "not authenticated, but onComplete.createPR is enabled"
+		c.Fix = "Run 'gh auth login'"
+	case !authenticated:
+		c.Status = CheckWarn
+		c.Detail = "installed but not authenticated"
+		c.Fix = "Run 'gh auth login' before enabling onComplete.createPR"
+	default:
+		c.Detail = "installed and authenticated"
+	}
+	return c
+}
+
+// checkOllama checks that the server is reachable and that the configured
+// model is installed, supports tools and fits the agent's context window.
+func checkOllama(client *ollama.Client, timeout time.Duration) []Check {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	reach := Check{Name: "ollama", Detail: "reachable at " + client.BaseURL}
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		reach.Status = CheckFail
+		reach.Detail = fmt.Sprintf("can't reach %s: %v", client.BaseURL, err)
+		reach.Fix = "Start Ollama with 'ollama serve', or set OLLAMA_HOST to your server's URL"
+		skipped := "ollama is unreachable"
+		return []Check{
+			reach,
+			{Name: "model", Status: CheckSkip, Detail: skipped},
+			{Name: "tool support", Status: CheckSkip, Detail: skipped},
+			{Name: "context length", Status: CheckSkip, Detail: skipped},
+		}
+	}
+
+	model := Check{Name: "model", Detail: client.Model + " is installed"}
+	if !ollama.HasModel(models, client.Model) {
+		model.Status = CheckFail
+		model.Detail = client.Model + " is not installed"
+		model.Fix = fmt.Sprintf("Run 'ollama pull %s'", client.Model)
+		if len(models) > 0 {
+			var names []string
+			for _, m := range models {
+				names = append(names, m.Name)
+			}
+			sort.Strings(names)
+			model.Fix += ", or set OLLAMA_MODEL to one of: " + strings.Join(names, ", ")
+		}
+		skipped := client.Model + " is not installed"
+		return []Check{
+			reach,
+			model,
+			{Name: "tool support", Status: CheckSkip, Detail: skipped},
+			{Name: "context length", Status: CheckSkip, Detail: skipped},
+		}
+	}
+
+	tools := Check{Name: "tool support"}
+	ctxLen := Check{Name: "context length"}
+	details, err := client.ShowModel(ctx, client.Model)
+	if err != nil {
+		tools.Status = CheckWarn
+		tools.Detail = fmt.Sprintf("couldn't inspect %s: %v", client.Model, err)
+		ctxLen.Status = CheckSkip
+		ctxLen.Detail = tools.Detail
+		return []Check{reach, model, tools, ctxLen}
+	}
+
+	switch supported, known := details.SupportsTools(); {
+	case !known:
+		tools.Status = CheckWarn
+		tools.Detail = "the server doesn't report model capabilities"
+		tools.Fix = "Upgrade Ollama so Chief can confirm the model supports tool calling"
+	case !supported:
+		tools.Status = CheckFail
+		tools.Detail = client.Model + " does not support tool calling"
+		tools.Fix = "Set OLLAMA_MODEL to a model with tool support, such as qwen2.5-coder or llama3.1"
+	default:
+		tools.Detail = client.Model + " supports tool calling"
+	}
+
+	switch n := details.ContextLength(); {
+	case n == 0:
+		ctxLen.Status = CheckWarn
+		ctxLen.Detail = fmt.Sprintf("unknown; iterations request %d tokens", agent.ContextLength)
+	case n < agent.ContextLength:
+		ctxLen.Status = CheckWarn
+		ctxLen.Detail = fmt.Sprintf("%s supports %d tokens but iterations request %d", client.Model, n, agent.ContextLength)
+		ctxLen.Fix = "Use a model with a longer context window, or long iterations will lose their earliest messages"
+	default:
+		ctxLen.Detail = fmt.Sprintf("%s supports %d tokens; iterations request %d", client.Model, n, agent.ContextLength)
+	}
+	return []Check{reach, model, tools, ctxLen}
+}
+
+// checkConfig loads and validates .chief/config.yaml. It returns the config,
+// or nil if it couldn't be loaded.
+func checkConfig(baseDir string) (*config.Config, Check) {
+	c := Check{Name: "config"}
+	if !config.Exists(baseDir) {
+		c.Detail = "no .chief/config.yaml (using defaults)"
+		return config.Default(), c
+	}
+
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		c.Status = CheckFail
+		c.Detail = fmt.Sprintf(".chief/config.yaml is invalid: %v", err)
+		c.Fix = "Fix the YAML in .chief/config.yaml, or edit it with the settings screen (,)"
+		return nil, c
+	}
+	if len(cfg.Notify.Channels) > 0 {
+		if _, err := dispatch.New(cfg.Notify); err != nil {
+			c.Status = CheckFail
+			c.Detail = err.Error()
+			c.Fix = "Fix the notify section of .chief/config.yaml"
+			return cfg, c
+		}
+	}
+	if _, err := ollama.ToolCallParsersFor(cfg.LLM.ToolCallFormat, ""); err != nil {
+		c.Status = CheckFail
+		c.Detail = err.Error()
+		c.Fix = "Set llm.toolCallFormat in .chief/config.yaml to one of the listed formats"
+		return cfg, c
+	}
+	c.Detail = ".chief/config.yaml is valid"
+	return cfg, c
+}
+
+// checkWorktrees looks for PRD worktrees left behind by deleted or finished
+// PRDs, and worktree directories git no longer tracks.
+func checkWorktrees(baseDir string, isRepo bool) Check {
+	c := Check{Name: "worktrees"}
+	onDisk := git.DetectOrphanedWorktrees(baseDir)
+	if len(onDisk) == 0 {
+		c.Detail = "no PRD worktrees"
+		return c
+	}
+
+	tracked := make(map[string]bool)
+	if isRepo {
+		if worktrees, err := git.ListWorktrees(baseDir); err == nil {
+			for _, wt := range worktrees {
+				if !wt.Prunable {
+					tracked[evalPath(wt.Path)] = true
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(onDisk))
+	for name := range onDisk {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var leftovers, stale []string
+	for _, name := range names {
+		path := onDisk[name]
+		if isRepo && !tracked[evalPath(path)] {
+			stale = append(stale, name)
+			continue
+		}
+		p, err := prd.LoadPRD(filepath.Join(baseDir, ".chief", "prds", name, "prd.json"))
+		if err != nil {
+			leftovers = append(leftovers, name+" (PRD deleted)")
+		} else if p.AllComplete() {
+			leftovers = append(leftovers, name+" (PRD complete)")
+		}
+	}
+
+	switch {
+	case len(stale) > 0:
+		c.Status = CheckWarn
+		c.Detail = "not tracked by git: " + strings.Join(stale, ", ")
+		c.Fix = "Delete the directories under .chief/worktrees/ and run 'git worktree prune'"
+	case len(leftovers) > 0:
+		c.Status = CheckWarn
+		c.Detail = "left over: " + strings.Join(leftovers, ", ")
+		c.Fix = "Merge what you need, then clean them from the PRD picker (l, then c) or with 'git worktree remove'"
+	default:
+		c.Detail = fmt.Sprintf("%d PRD worktree(s) in use", len(onDisk))
+	}
+	return c
+}
+
+// evalPath resolves symlinks so paths from git and from the filesystem compare equal.
+func evalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// checkStore opens the SQLite store and runs its integrity check.
+func checkStore(baseDir string) Check {
+	c := Check{Name: "sqlite"}
+	path := filepath.Join(baseDir, ".chief", "chief.db")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		c.Detail = "no .chief/chief.db yet (created on first run)"
+		return c
+	}
+
+	store, err := db.NewStore(path)
+	if err != nil {
+		c.Status = CheckFail
+		c.Detail = err.Error()
+		c.Fix = "Move .chief/chief.db aside; Chief creates a new one and re-syncs PRDs from disk"
+		return c
+	}
+	defer store.Close()
+
+	if err := store.IntegrityCheck(); err != nil {
+		c.Status = CheckFail
+		c.Detail = err.Error()
+		c.Fix = "Restore .chief/chief.db from a backup, or move it aside so Chief creates a new one"
+		return c
+	}
+	c.Detail = ".chief/chief.db is healthy"
+	return c
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/ollama"
+)
+
+// fakeOllama serves /api/tags and /api/show for one installed model.
+func fakeOllama(t *testing.T, installed string, capabilities []string, contextLength int) *ollama.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"models": []map[string]string{{"name": installed, "model": installed}},
+			})
+		case "/api/show":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"capabilities": capabilities,
+				"model_info":   map[string]interface{}{"qwen2.context_length": contextLength},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return &ollama.Client{BaseURL: srv.URL, Model: "qwen2.5-coder", HTTPClient: srv.Client()}
+}
+
+func checksByName(checks []Check) map[string]Check {
+	m := make(map[string]Check)
+	for _, c := range checks {
+		m[c.Name] = c
+	}
+	return m
+}
+
+func TestCheckOllama(t *testing.T) {
+	tests := []struct {
+		name         string
+		installed    string
+		capabilities []string
+		ctxLen       int
+		want         map[string]CheckStatus
+	}{
+		{
+			name:         "healthy",
+			installed:    "qwen2.5-coder:latest",
+			capabilities: []string{"completion", "tools"},
+			ctxLen:       131072,
+			want:         map[string]CheckStatus{"ollama": CheckOK, "model": CheckOK, "tool support": CheckOK, "context length": CheckOK},
+		},
+		{
+			name:      "model missing",
+			installed: "llama3.1:8b",
+			want:      map[string]CheckStatus{"ollama": CheckOK, "model": CheckFail, "tool support": CheckSkip, "context length": CheckSkip},
+		},
+		{
+			name:         "no tools and short context",
+			installed:    "qwen2.5-coder:latest",
+			capabilities: []string{"completion"},
+			ctxLen:       4096,
+			want:         map[string]CheckStatus{"tool support": CheckFail, "context length": CheckWarn},
+		},
+		{
+			name:      "old server",
+			installed: "qwen2.5-coder:latest",
+			want:      map[string]CheckStatus{"tool support": CheckWarn, "context length": CheckWarn},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fakeOllama(t, tt.installed, tt.capabilities, tt.ctxLen)
+			got := checksByName(checkOllama(client, 5*time.Second))
+			for name, status := range tt.want {
+				if got[name].Status != status {
+					t.Errorf("%s: status = %v (%s), want %v", name, got[name].Status, got[name].Detail, status)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckOllamaUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	client := &ollama.Client{BaseURL: srv.URL, Model: "qwen2.5-coder", HTTPClient: http.DefaultClient}
+
+	got := checksByName(checkOllama(client, time.Second))
+	if got["ollama"].Status != CheckFail || got["ollama"].Fix == "" {
+		t.Errorf("expected a failure with a fix, got %+v", got["ollama"])
+	}
+	if got["model"].Status != CheckSkip {
+		t.Errorf("model check should be skipped, got %+v", got["model"])
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	if _, c := checkConfig(tmpDir); c.Status != CheckOK {
+		t.Errorf("missing config: %+v", c)
+	}
+
+	configPath := filepath.Join(tmpDir, ".chief", "config.yaml")
+	os.MkdirAll(filepath.Dir(configPath), 0755)
+
+	os.WriteFile(configPath, []byte("worktree: [unclosed"), 0644)
+	if cfg, c := checkConfig(tmpDir); c.Status != CheckFail || cfg != nil {
+		t.Errorf("invalid YAML: %+v", c)
+	}
+
+	os.WriteFile(configPath, []byte("notify:\n  channels:\n    - type: pager\n"), 0644)
+	if _, c := checkConfig(tmpDir); c.Status != CheckFail || !strings.Contains(c.Detail, "pager") {
+		t.Errorf("invalid notify channel: %+v", c)
+	}
+}
+
+func TestCheckStore(t *testing.T) {
+	tmpDir := t.TempDir()
+	if c := checkStore(tmpDir); c.Status != CheckOK {
+		t.Errorf("missing store: %+v", c)
+	}
+
+	dbPath := filepath.Join(tmpDir, ".chief", "chief.db")
+	os.MkdirAll(filepath.Dir(dbPath), 0755)
+	store, err := db.NewStore(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	if c := checkStore(tmpDir); c.Status != CheckOK {
+		t.Errorf("healthy store: %+v", c)
+	}
+
+	os.WriteFile(dbPath, []byte("not a database"), 0644)
+	if c := checkStore(tmpDir); c.Status != CheckFail {
+		t.Errorf("corrupt store: %+v", c)
+	}
+}
+
+func TestCheckWorktrees(t *testing.T) {
+	tmpDir := t.TempDir()
+	if c := checkWorktrees(tmpDir, false); c.Status != CheckOK {
+		t.Errorf("no worktrees: %+v", c)
+	}
+
+	// Worktrees for a deleted, a complete and an active PRD
+	for _, name := range []string{"gone", "done", "active"} {
+		os.MkdirAll(filepath.Join(tmpDir, ".chief", "worktrees", name), 0755)
+	}
+	for name, passes := range map[string]bool{"done": true, "active": false} {
+		prdDir := filepath.Join(tmpDir, ".chief", "prds", name)
+		os.MkdirAll(prdDir, 0755)
+		prdJSON := fmt.Sprintf(`{"project": "P", "userStories": [{"id": "US-001", "title": "S", "priority": 1, "passes": %t}]}`, passes)
+		os.WriteFile(filepath.Join(prdDir, "prd.json"), []byte(prdJSON), 0644)
+	}
+
+	c := checkWorktrees(tmpDir, false)
+	if c.Status != CheckWarn {
+		t.Fatalf("expected a warning, got %+v", c)
+	}
+	if !strings.Contains(c.Detail, "gone (PRD deleted)") || !strings.Contains(c.Detail, "done (PRD complete)") || strings.Contains(c.Detail, "active") {
+		t.Errorf("unexpected detail: %s", c.Detail)
+	}
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/izdrail/chief/internal/fakellm"
+)
+
+// DefaultFakeLLMAddr is where `chief fake-llm` listens by default, next to
+// Ollama's own port.
+const DefaultFakeLLMAddr = "127.0.0.1:11435"
+
+// FakeLLMOptions contains configuration for the fake-llm command.
+type FakeLLMOptions struct {
+	Script string // Path to the YAML script
+	Addr   string
+}
+
+// RunFakeLLM serves a scripted fake model over the Ollama API until the
+// process is stopped.
+func RunFakeLLM(opts FakeLLMOptions) error {
+	if opts.Script == "" {
+		return fmt.Errorf("usage: chief fake-llm <script.yaml> [addr]")
+	}
+	if opts.Addr == "" {
+		opts.Addr = DefaultFakeLLMAddr
+	}
+
+	script, err := fakellm.LoadScript(opts.Script)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Fake LLM following %s (%d rules) on http://%s\n", opts.Script, len(script.Rules), opts.Addr)
+	fmt.Printf("Point chief at it with: OLLAMA_HOST=http://%s OLLAMA_MODEL=%s\n", opts.Addr, script.Model)
+	return http.ListenAndServe(opts.Addr, fakellm.NewServer(script))
+}
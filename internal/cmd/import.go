@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/izdrail/chief/internal/importer"
+	"github.com/izdrail/chief/internal/prd"
+)
+
+// ImportOptions contains configuration for the import command.
+type ImportOptions struct {
+	File    string // File to import (required)
+	Name    string // PRD name (default: "main")
+	Format  string // Input format (default: detected from the file)
+	Project string // Project name override
+	BaseDir string // Base directory for .chief/prds/ (default: current directory)
+	Force   bool   // Overwrite an existing PRD
+}
+
+// RunImport converts a backlog exported from another planning tool into a PRD.
+// It writes prd.md in the structured format alongside prd.json, so the PRD can
+// be edited by hand or with 'chief edit' afterwards.
+func RunImport(opts ImportOptions) error {
+	if opts.File == "" {
+		return fmt.Errorf("no file to import")
+	}
+	if opts.Name == "" {
+		opts.Name = "main"
+	}
+	if opts.BaseDir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		opts.BaseDir = cwd
+	}
+	if !isValidPRDName(opts.Name) {
+		return fmt.Errorf("invalid PRD name %q: must contain only letters, numbers, hyphens, and underscores", opts.Name)
+	}
+
+	data, err := os.ReadFile(opts.File)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", opts.File, err)
+	}
+
+	var format importer.Format
+	if opts.Format != "" {
+		format, err = importer.ParseFormat(opts.Format)
+	} else {
+		format, err = importer.Detect(opts.File, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	prdDir := filepath.Join(opts.BaseDir, ".chief", "prds", opts.Name)
+	prdJsonPath := filepath.Join(prdDir, "prd.json")
+	if _, err := os.Stat(prdJsonPath); err == nil && !opts.Force {
+		return fmt.Errorf("PRD already exists at %s. Use --force to overwrite it", prdJsonPath)
+	}
+
+	defaultProject := strings.TrimSuffix(filepath.Base(opts.File), filepath.Ext(opts.File))
+	result, err := importer.Import(format, data, importer.Options{Project: opts.Project, DefaultProject: defaultProject})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(prdDir, 0755); err != nil {
+		return fmt.Errorf("failed to create PRD directory: %w", err)
+	}
+	// prd.md must convert back without an LLM, or every later edit would fail
+	md := prd.RenderMarkdown(result.PRD)
+	if _, err := prd.ParseMarkdown(md); err != nil {
+		return fmt.Errorf("imported PRD does not render to a valid prd.md: %w", err)
+	}
+	// prd.md is written first so prd.json is newer and no conversion is triggered
+	if err := os.WriteFile(filepath.Join(prdDir, "prd.md"), []byte(md), 0644); err != nil {
+		return fmt.Errorf("failed to write prd.md: %w", err)
+	}
+	if err := result.PRD.Save(prdJsonPath); err != nil {
+		return err
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	fmt.Printf("Imported %d stories from %s (%s) into %s\n", len(result.PRD.UserStories), opts.File, format, prdDir)
+
+	diags, err := prd.Lint(prdJsonPath)
+	if err != nil {
+		return err
+	}
+	for _, d := range diags {
+		fmt.Println(d.String())
+	}
+	if prd.HasErrors(diags) {
+		return fmt.Errorf("imported PRD has errors; fix them before running 'chief %s'", opts.Name)
+	}
+	return nil
+}
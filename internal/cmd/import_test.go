@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/izdrail/chief/internal/prd"
+)
+
+func TestRunImport(t *testing.T) {
+	tmpDir := t.TempDir()
+	csvPath := filepath.Join(tmpDir, "backlog.csv")
+	csv := "id,title,acceptance criteria,priority\nUS-1,Second,Works,low\nUS-2,First,Works,high\n"
+	if err := os.WriteFile(csvPath, []byte(csv), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := ImportOptions{File: csvPath, Name: "backlog", BaseDir: tmpDir}
+	if err := RunImport(opts); err != nil {
+		t.Fatalf("RunImport() error: %v", err)
+	}
+
+	prdDir := filepath.Join(tmpDir, ".chief", "prds", "backlog")
+	p, err := prd.LoadPRD(filepath.Join(prdDir, "prd.json"))
+	if err != nil {
+		t.Fatalf("failed to load imported PRD: %v", err)
+	}
+	if p.Project != "backlog" || len(p.UserStories) != 2 || p.UserStories[1].Priority != 1 {
+		t.Errorf("unexpected PRD: %+v", p)
+	}
+	if needs, err := prd.NeedsConversion(prdDir); err != nil || needs {
+		t.Errorf("expected no conversion to be needed, got %v, %v", needs, err)
+	}
+
+	if err := RunImport(opts); err == nil {
+		t.Error("expected an error when the PRD already exists")
+	}
+	opts.Force = true
+	if err := RunImport(opts); err != nil {
+		t.Errorf("expected --force to overwrite, got %v", err)
+	}
+}
+
+func TestRunImport_HeadingsInDescription(t *testing.T) {
+	tmpDir := t.TempDir()
+	csvPath := filepath.Join(tmpDir, "backlog.csv")
+	csv := "id,title,description\nUS-1,Login,\"## Context\nUsers sign in.\n### Notes\nNone.\"\nUS-2,Logout,Bye\n"
+	if err := os.WriteFile(csvPath, []byte(csv), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RunImport(ImportOptions{File: csvPath, Name: "backlog", BaseDir: tmpDir}); err != nil {
+		t.Fatalf("RunImport() error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(tmpDir, ".chief", "prds", "backlog", "prd.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := prd.ParseMarkdown(string(data))
+	if err != nil {
+		t.Fatalf("imported prd.md does not parse: %v\n%s", err, data)
+	}
+	if len(p.UserStories) != 2 || p.UserStories[0].Description != "## Context\nUsers sign in.\n### Notes\nNone." {
+		t.Errorf("unexpected PRD: %+v", p)
+	}
+}
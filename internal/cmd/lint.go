@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/izdrail/chief/internal/prd"
+)
+
+// LintOptions contains configuration for the lint command.
+type LintOptions struct {
+	Name    string // PRD name or path to a prd.json (default: every PRD in .chief/prds/)
+	BaseDir string // Base directory for .chief/prds/ (default: current directory)
+}
+
+// RunLint validates PRDs against the prd.json schema and semantic rules and
+// prints a file:line diagnostic for each problem. It returns an error if any
+// PRD has errors; warnings alone do not fail.
+func RunLint(opts LintOptions) error {
+	if opts.BaseDir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		opts.BaseDir = cwd
+	}
+
+	paths, err := lintPaths(opts)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		fmt.Println("No PRDs found. Run 'chief new' to create one.")
+		return nil
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, path := range paths {
+		diags, err := prd.Lint(path)
+		if err != nil {
+			return err
+		}
+		for _, d := range diags {
+			if rel, err := filepath.Rel(opts.BaseDir, d.File); err == nil && !strings.HasPrefix(rel, "..") {
+				d.File = rel
+			}
+			fmt.Println(d.String())
+			if d.Severity == prd.SeverityError {
+				errorCount++
+			} else {
+				warningCount++
+			}
+		}
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("%d error(s), %d warning(s)", errorCount, warningCount)
+	}
+	if warningCount == 0 {
+		fmt.Printf("%d PRD(s) OK\n", len(paths))
+	}
+	return nil
+}
+
+// lintPaths returns the prd.json files to lint.
+func lintPaths(opts LintOptions) ([]string, error) {
+	if strings.HasSuffix(opts.Name, ".json") {
+		return []string{opts.Name}, nil
+	}
+
+	prdsDir := filepath.Join(opts.BaseDir, ".chief", "prds")
+	if opts.Name != "" {
+		path := filepath.Join(prdsDir, opts.Name, "prd.json")
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, fmt.Errorf("PRD %q not found at %s", opts.Name, path)
+		}
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(prdsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read PRDs directory: %w", err)
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(prdsDir, entry.Name(), "prd.json")
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
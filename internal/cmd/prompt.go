@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/prompts"
+)
+
+// PromptOptions contains configuration for the prompt command.
+type PromptOptions struct {
+	Name     string // PRD name (default: "main")
+	Template string // Template name (default: "agent")
+	BaseDir  string // Base directory for .chief/prds/ (default: current directory)
+}
+
+// RunPromptShow renders the prompt the next iteration of a PRD would receive and
+// prints it to stdout. The template source is reported on stderr.
+func RunPromptShow(opts PromptOptions) error {
+	if opts.Name == "" {
+		opts.Name = "main"
+	}
+	if opts.Template == "" {
+		opts.Template = prompts.Agent
+	}
+	if opts.BaseDir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		opts.BaseDir = cwd
+	}
+
+	prdPath := filepath.Join(opts.BaseDir, ".chief", "prds", opts.Name, "prd.json")
+	if _, err := os.Stat(prdPath); os.IsNotExist(err) {
+		return fmt.Errorf("PRD %q not found at %s", opts.Name, prdPath)
+	}
+
+	tmpl, source, err := prompts.Load(opts.Template, prdPath)
+	if err != nil {
+		return err
+	}
+	promptOpts := prompts.Options{PRDPath: prdPath}
+	dbPath := filepath.Join(opts.BaseDir, ".chief", "chief.db")
+	if _, err := os.Stat(dbPath); err == nil {
+		if store, err := db.NewStore(dbPath); err == nil {
+			defer store.Close()
+			promptOpts.Store = store
+		}
+	}
+	data, err := prompts.BuildData(promptOpts)
+	if err != nil {
+		return err
+	}
+	rendered, err := prompts.Execute(tmpl, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Template: %s\n\n", source)
+	fmt.Print(rendered)
+	return nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/git"
+	"github.com/izdrail/chief/internal/report"
+)
+
+// ReportOptions contains configuration for the report command.
+type ReportOptions struct {
+	Name    string // PRD name (default: "main")
+	Format  string // Output format: md, html or json (default: "md")
+	Output  string // File to write (default: stdout)
+	BaseDir string // Base directory for .chief/prds/ (default: current directory)
+}
+
+// RunReport writes a run report for a PRD. Commits and diff stats are read
+// from the PRD's worktree when it has one, otherwise from the base directory.
+func RunReport(opts ReportOptions) error {
+	if opts.Name == "" {
+		opts.Name = "main"
+	}
+	if opts.Format == "" {
+		opts.Format = "md"
+	}
+	if opts.BaseDir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		opts.BaseDir = cwd
+	}
+
+	prdPath := filepath.Join(opts.BaseDir, ".chief", "prds", opts.Name, "prd.json")
+	if _, err := os.Stat(prdPath); os.IsNotExist(err) {
+		return fmt.Errorf("PRD %q not found at %s", opts.Name, prdPath)
+	}
+
+	repoDir := opts.BaseDir
+	if worktree := git.WorktreePathForPRD(opts.BaseDir, opts.Name); git.IsWorktree(worktree) {
+		repoDir = worktree
+	}
+
+	reportOpts := report.Options{PRDPath: prdPath, RepoDir: repoDir}
+	dbPath := filepath.Join(opts.BaseDir, ".chief", "chief.db")
+	if _, err := os.Stat(dbPath); err == nil {
+		store, err := db.NewStore(dbPath)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", dbPath, err)
+		}
+		defer store.Close()
+		reportOpts.Store = store
+	}
+
+	r, err := report.Build(reportOpts)
+	if err != nil {
+		return err
+	}
+	data, err := r.Render(opts.Format)
+	if err != nil {
+		return err
+	}
+
+	if opts.Output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(opts.Output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Report written to %s\n", opts.Output)
+	return nil
+}
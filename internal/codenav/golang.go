@@ -0,0 +1,633 @@
+package codenav
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSignatureLen caps signatures so large struct or interface types stay readable.
+const maxSignatureLen = 200
+
+// GoProvider implements Provider for Go modules using go/parser and go/types.
+// Packages inside the module are type-checked from source; imports from
+// outside the module are stubbed, so cross-module identifiers resolve only
+// to their package, not to individual declarations.
+type GoProvider struct {
+	mu    sync.Mutex
+	cache map[string]*goProject
+}
+
+// goProject is a parsed and type-checked Go module.
+type goProject struct {
+	root        string
+	module      string
+	fingerprint string
+	fset        *token.FileSet
+	packages    []*goPackage
+	byImport    map[string]*goPackage
+	sources     map[string][]byte    // Absolute file path -> contents
+	signatures  map[token.Pos]string // Declaration name position -> source signature
+}
+
+// goPackage is a single Go package (one directory, one package clause).
+type goPackage struct {
+	dir        string // Absolute directory
+	importPath string
+	name       string
+	files      []*ast.File
+	pkg        *types.Package
+	info       *types.Info
+	state      int // 0 = unchecked, 1 = checking, 2 = checked
+}
+
+// Name returns "go".
+func (g *GoProvider) Name() string {
+	return "go"
+}
+
+// Detect reports whether root contains a go.mod file.
+func (g *GoProvider) Detect(root string) bool {
+	_, err := os.Stat(filepath.Join(root, "go.mod"))
+	return err == nil
+}
+
+// Symbols returns the top-level declarations and methods under path.
+func (g *GoProvider) Symbols(root, path, query string) ([]Symbol, error) {
+	proj, err := g.load(root)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := root
+	if path != "" && path != "." {
+		scope = path
+		if !filepath.IsAbs(scope) {
+			scope = filepath.Join(root, path)
+		}
+	}
+	query = strings.ToLower(query)
+
+	var result []Symbol
+	for _, p := range proj.packages {
+		for _, f := range p.files {
+			filename := proj.fset.Position(f.Pos()).Filename
+			if filename != scope && !strings.HasPrefix(filename, scope+string(filepath.Separator)) {
+				continue
+			}
+			for _, sym := range proj.fileSymbols(p, f) {
+				if query == "" || strings.Contains(strings.ToLower(sym.Name), query) {
+					result = append(result, sym)
+				}
+			}
+		}
+	}
+	sortSymbols(result)
+	return result, nil
+}
+
+// Definition returns declarations matching name ("Name", "pkg.Name" or "Type.Member").
+func (g *GoProvider) Definition(root, name string) ([]Symbol, error) {
+	proj, err := g.load(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Symbol
+	for _, t := range proj.resolve(name) {
+		result = append(result, proj.symbolFor(t.pkg, t.obj, t.qualified))
+	}
+	sortSymbols(result)
+	return result, nil
+}
+
+// References returns every usage site of the symbol identified by name.
+func (g *GoProvider) References(root, name string) ([]Reference, error) {
+	proj, err := g.load(root)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make(map[types.Object]bool)
+	for _, t := range proj.resolve(name) {
+		targets[t.obj] = true
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	var result []Reference
+	for _, p := range proj.packages {
+		for ident, obj := range p.info.Uses {
+			if !targets[obj] {
+				continue
+			}
+			pos := proj.fset.Position(ident.Pos())
+			result = append(result, Reference{
+				Location: proj.location(pos),
+				Line:     proj.sourceLine(pos),
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Location.File != result[j].Location.File {
+			return result[i].Location.File < result[j].Location.File
+		}
+		if result[i].Location.Line != result[j].Location.Line {
+			return result[i].Location.Line < result[j].Location.Line
+		}
+		return result[i].Location.Column < result[j].Location.Column
+	})
+	return result, nil
+}
+
+// PackageSymbols returns the exported top-level symbols and methods of every
+// non-test package, keyed by package directory relative to root.
+func (g *GoProvider) PackageSymbols(root string) (map[string][]Symbol, error) {
+	proj, err := g.load(root)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]Symbol)
+	for _, p := range proj.packages {
+		if strings.HasSuffix(p.name, "_test") {
+			continue
+		}
+		rel, err := filepath.Rel(proj.root, p.dir)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, f := range p.files {
+			if strings.HasSuffix(proj.fset.Position(f.Pos()).Filename, "_test.go") {
+				continue
+			}
+			for _, sym := range proj.fileSymbols(p, f) {
+				if isExportedName(sym.Name) {
+					result[rel] = append(result[rel], sym)
+				}
+			}
+		}
+		sortSymbols(result[rel])
+	}
+	return result, nil
+}
+
+// isExportedName reports whether every segment of a dotted name (e.g. "Type.Method") is exported.
+func isExportedName(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if !ast.IsExported(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// load returns the parsed project for root, reusing the cached copy while no .go file has changed.
+func (g *GoProvider) load(root string) (*goProject, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root: %w", err)
+	}
+
+	files, fingerprint, err := scanGoFiles(absRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cache == nil {
+		g.cache = make(map[string]*goProject)
+	}
+	if cached, ok := g.cache[absRoot]; ok && cached.fingerprint == fingerprint {
+		return cached, nil
+	}
+
+	proj, err := parseGoProject(absRoot, files)
+	if err != nil {
+		return nil, err
+	}
+	proj.fingerprint = fingerprint
+	g.cache[absRoot] = proj
+	return proj, nil
+}
+
+// scanGoFiles lists .go files under root and returns a fingerprint of their names, sizes and mtimes.
+func scanGoFiles(root string) ([]string, string, error) {
+	var files []string
+	var latest time.Time
+	var totalSize int64
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip unreadable entries
+		}
+		if d.IsDir() {
+			if path != root && skipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(d.Name(), ".go") {
+			return nil
+		}
+		files = append(files, path)
+		if info, err := d.Info(); err == nil {
+			totalSize += info.Size()
+			if info.ModTime().After(latest) {
+				latest = info.ModTime()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to scan project: %w", err)
+	}
+	return files, fmt.Sprintf("%d:%d:%d", len(files), totalSize, latest.UnixNano()), nil
+}
+
+// skipDir reports whether a directory should be excluded from analysis.
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata" || name == "node_modules"
+}
+
+// parseGoProject parses every file and type-checks all packages in the module.
+func parseGoProject(root string, files []string) (*goProject, error) {
+	proj := &goProject{
+		root:       root,
+		module:     readModulePath(root),
+		fset:       token.NewFileSet(),
+		byImport:   make(map[string]*goPackage),
+		sources:    make(map[string][]byte),
+		signatures: make(map[token.Pos]string),
+	}
+
+	// Group files by directory and package clause
+	type pkgKey struct{ dir, name string }
+	grouped := make(map[pkgKey]*goPackage)
+	var order []pkgKey
+	for _, path := range files {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		f, err := parser.ParseFile(proj.fset, path, src, parser.SkipObjectResolution)
+		if err != nil && f == nil {
+			continue
+		}
+		proj.sources[path] = src
+		proj.recordSignatures(f)
+		key := pkgKey{dir: filepath.Dir(path), name: f.Name.Name}
+		p, ok := grouped[key]
+		if !ok {
+			p = &goPackage{dir: key.dir, name: key.name}
+			grouped[key] = p
+			order = append(order, key)
+		}
+		p.files = append(p.files, f)
+	}
+
+	for _, key := range order {
+		p := grouped[key]
+		rel, _ := filepath.Rel(root, p.dir)
+		p.importPath = proj.module
+		if rel != "." {
+			p.importPath = proj.module + "/" + filepath.ToSlash(rel)
+		}
+		if strings.HasSuffix(p.name, "_test") {
+			p.importPath += "_test"
+		} else if _, exists := proj.byImport[p.importPath]; !exists {
+			proj.byImport[p.importPath] = p
+		}
+		proj.packages = append(proj.packages, p)
+	}
+
+	for _, p := range proj.packages {
+		proj.check(p)
+	}
+	return proj, nil
+}
+
+// readModulePath returns the module path declared in root/go.mod, or "main" if unavailable.
+func readModulePath(root string) string {
+	f, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "main"
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "module ")), `"`)
+		}
+	}
+	return "main"
+}
+
+// check type-checks a package, recursively checking module-local imports first.
+// Type errors are ignored: partial information is still useful for navigation.
+func (proj *goProject) check(p *goPackage) {
+	if p.state != 0 {
+		return
+	}
+	p.state = 1
+	p.info = &types.Info{
+		Defs: make(map[*ast.Ident]types.Object),
+		Uses: make(map[*ast.Ident]types.Object),
+	}
+	conf := types.Config{
+		Importer: projectImporter{proj},
+		Error:    func(error) {},
+	}
+	p.pkg, _ = conf.Check(p.importPath, proj.fset, p.files, p.info)
+	p.state = 2
+}
+
+// projectImporter resolves module-local imports from source and stubs everything else.
+type projectImporter struct {
+	proj *goProject
+}
+
+// Import implements types.Importer.
+func (im projectImporter) Import(path string) (*types.Package, error) {
+	if p, ok := im.proj.byImport[path]; ok {
+		if p.state == 1 {
+			return nil, fmt.Errorf("import cycle through %s", path)
+		}
+		im.proj.check(p)
+		return p.pkg, nil
+	}
+	stub := types.NewPackage(path, guessPackageName(path))
+	stub.MarkComplete()
+	return stub, nil
+}
+
+// guessPackageName derives a package name from an import path (e.g. "gopkg.in/yaml.v3" -> "yaml").
+func guessPackageName(path string) string {
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && len(name) >= 2 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = parts[len(parts)-2]
+	}
+	if idx := strings.Index(name, ".v"); idx > 0 {
+		name = name[:idx]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	return strings.NewReplacer("-", "", ".", "").Replace(name)
+}
+
+// fileSymbols returns the declarations in a single file.
+func (proj *goProject) fileSymbols(p *goPackage, f *ast.File) []Symbol {
+	var result []Symbol
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			obj := p.info.Defs[d.Name]
+			if obj == nil {
+				continue
+			}
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = receiverTypeName(d.Recv.List[0].Type) + "." + name
+			}
+			result = append(result, proj.symbolFor(p, obj, name))
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if obj := p.info.Defs[s.Name]; obj != nil {
+						result = append(result, proj.symbolFor(p, obj, s.Name.Name))
+					}
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.Name == "_" {
+							continue
+						}
+						if obj := p.info.Defs[n]; obj != nil {
+							result = append(result, proj.symbolFor(p, obj, n.Name))
+						}
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// receiverTypeName returns the base type name of a method receiver expression.
+func receiverTypeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(e.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(e.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return "?"
+}
+
+// target is a resolved symbol lookup result.
+type target struct {
+	pkg       *goPackage
+	obj       types.Object
+	qualified string
+}
+
+// resolve finds the objects named by a possibly-qualified name.
+func (proj *goProject) resolve(name string) []target {
+	name = strings.TrimSpace(name)
+	var result []target
+	seen := make(map[types.Object]bool)
+	add := func(p *goPackage, obj types.Object, qualified string) {
+		if obj == nil || seen[obj] || !obj.Pos().IsValid() {
+			return
+		}
+		seen[obj] = true
+		result = append(result, target{pkg: p, obj: obj, qualified: qualified})
+	}
+
+	parts := strings.Split(name, ".")
+	switch len(parts) {
+	case 1:
+		for _, p := range proj.packages {
+			if p.pkg == nil {
+				continue
+			}
+			add(p, p.pkg.Scope().Lookup(name), name)
+			// Also match methods with this name
+			for _, typeName := range p.pkg.Scope().Names() {
+				if tn, ok := p.pkg.Scope().Lookup(typeName).(*types.TypeName); ok {
+					if m := lookupMember(p.pkg, tn, name); m != nil {
+						if _, isFunc := m.(*types.Func); isFunc {
+							add(p, m, typeName+"."+name)
+						}
+					}
+				}
+			}
+		}
+	case 2:
+		qualifier, member := parts[0], parts[1]
+		for _, p := range proj.packages {
+			if p.pkg == nil {
+				continue
+			}
+			// pkg.Name
+			if p.name == qualifier || filepath.Base(strings.TrimSuffix(p.importPath, "_test")) == qualifier {
+				add(p, p.pkg.Scope().Lookup(member), name)
+			}
+			// Type.Member
+			if tn, ok := p.pkg.Scope().Lookup(qualifier).(*types.TypeName); ok {
+				add(p, lookupMember(p.pkg, tn, member), name)
+			}
+		}
+	case 3:
+		// pkg.Type.Member
+		for _, p := range proj.packages {
+			if p.pkg == nil || p.name != parts[0] {
+				continue
+			}
+			if tn, ok := p.pkg.Scope().Lookup(parts[1]).(*types.TypeName); ok {
+				add(p, lookupMember(p.pkg, tn, parts[2]), parts[1]+"."+parts[2])
+			}
+		}
+	}
+	return result
+}
+
+// lookupMember finds a field or method on a named type, including promoted members.
+func lookupMember(pkg *types.Package, tn *types.TypeName, member string) types.Object {
+	obj, _, _ := types.LookupFieldOrMethod(tn.Type(), true, pkg, member)
+	return obj
+}
+
+// recordSignatures renders the source signature of each top-level declaration in f.
+// Source signatures are preferred over go/types output because types from stubbed
+// imports print as "invalid type".
+func (proj *goProject) recordSignatures(f *ast.File) {
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			fd := *d
+			fd.Doc = nil
+			fd.Body = nil
+			proj.signatures[d.Name.Pos()] = proj.printNode(&fd)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					ts := *s
+					ts.Doc, ts.Comment = nil, nil
+					proj.signatures[s.Name.Pos()] = proj.printNode(&ast.GenDecl{Tok: token.TYPE, Specs: []ast.Spec{&ts}})
+				case *ast.ValueSpec:
+					vs := *s
+					vs.Doc, vs.Comment = nil, nil
+					sig := proj.printNode(&ast.GenDecl{Tok: d.Tok, Specs: []ast.Spec{&vs}})
+					for _, n := range s.Names {
+						proj.signatures[n.Pos()] = sig
+					}
+				}
+			}
+		}
+	}
+}
+
+// printNode formats an AST node on a single line.
+func (proj *goProject) printNode(node ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, proj.fset, node); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// symbolFor builds a Symbol for a type-checked object.
+func (proj *goProject) symbolFor(p *goPackage, obj types.Object, name string) Symbol {
+	sig, ok := proj.signatures[obj.Pos()]
+	if !ok || sig == "" {
+		sig = types.ObjectString(obj, types.RelativeTo(p.pkg))
+	}
+	if len(sig) > maxSignatureLen {
+		sig = sig[:maxSignatureLen-3] + "..."
+	}
+	return Symbol{
+		Name:      name,
+		Kind:      objectKind(obj),
+		Package:   p.name,
+		Signature: sig,
+		Location:  proj.location(proj.fset.Position(obj.Pos())),
+	}
+}
+
+// objectKind returns a short kind label for a types.Object.
+func objectKind(obj types.Object) string {
+	switch o := obj.(type) {
+	case *types.Func:
+		if sig, ok := o.Type().(*types.Signature); ok && sig.Recv() != nil {
+			return "method"
+		}
+		return "func"
+	case *types.TypeName:
+		if _, ok := o.Type().Underlying().(*types.Interface); ok {
+			return "interface"
+		}
+		return "type"
+	case *types.Const:
+		return "const"
+	case *types.Var:
+		if o.IsField() {
+			return "field"
+		}
+		return "var"
+	}
+	return "symbol"
+}
+
+// location converts a token position into a root-relative Location.
+func (proj *goProject) location(pos token.Position) Location {
+	file := pos.Filename
+	if rel, err := filepath.Rel(proj.root, file); err == nil {
+		file = rel
+	}
+	return Location{File: filepath.ToSlash(file), Line: pos.Line, Column: pos.Column}
+}
+
+// sourceLine returns the trimmed text of the line at pos.
+func (proj *goProject) sourceLine(pos token.Position) string {
+	src, ok := proj.sources[pos.Filename]
+	if !ok {
+		return ""
+	}
+	lines := strings.Split(string(src), "\n")
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[pos.Line-1])
+}
+
+// sortSymbols orders symbols by file and line.
+func sortSymbols(symbols []Symbol) {
+	sort.SliceStable(symbols, func(i, j int) bool {
+		if symbols[i].Location.File != symbols[j].Location.File {
+			return symbols[i].Location.File < symbols[j].Location.File
+		}
+		return symbols[i].Location.Line < symbols[j].Location.Line
+	})
+}
@@ -0,0 +1,141 @@
+package codenav
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeModule creates a small two-package Go module in a temp directory.
+func writeModule(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/demo\n\ngo 1.21\n",
+		"store/store.go": `package store
+
+// Store keeps values.
+type Store struct {
+	items map[string]int
+}
+
+// New creates a Store.
+func New() *Store {
+	return &Store{items: map[string]int{}}
+}
+
+// Put stores a value.
+func (s *Store) Put(key string, v int) {
+	s.items[key] = v
+}
+
+const Limit = 10
+`,
+		"main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/demo/store"
+)
+
+func main() {
+	s := store.New()
+	s.Put("a", store.Limit)
+	s.Put("b", 2)
+	fmt.Println(s)
+}
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestProviderFor_DetectsGo(t *testing.T) {
+	root := writeModule(t)
+	p, err := ProviderFor(root)
+	if err != nil {
+		t.Fatalf("ProviderFor() error: %v", err)
+	}
+	if p.Name() != "go" {
+		t.Errorf("expected go provider, got %s", p.Name())
+	}
+
+	if _, err := ProviderFor(t.TempDir()); err == nil {
+		t.Error("expected error for a directory without a known language")
+	}
+}
+
+func TestGoProvider_Symbols(t *testing.T) {
+	root := writeModule(t)
+	g := &GoProvider{}
+
+	symbols, err := g.Symbols(root, "store", "")
+	if err != nil {
+		t.Fatalf("Symbols() error: %v", err)
+	}
+
+	got := map[string]Symbol{}
+	for _, s := range symbols {
+		got[s.Name] = s
+	}
+	for _, name := range []string{"Store", "New", "Store.Put", "Limit"} {
+		if _, ok := got[name]; !ok {
+			t.Errorf("expected symbol %s, got %v", name, symbols)
+		}
+	}
+	if put := got["Store.Put"]; put.Kind != "method" || put.Location.File != "store/store.go" || put.Location.Line != 14 {
+		t.Errorf("unexpected Store.Put symbol: %+v", put)
+	}
+	if newSym := got["New"]; !strings.Contains(newSym.Signature, "func New() *Store") {
+		t.Errorf("unexpected signature: %q", newSym.Signature)
+	}
+
+	filtered, err := g.Symbols(root, "", "put")
+	if err != nil {
+		t.Fatalf("Symbols() error: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Name != "Store.Put" {
+		t.Errorf("expected only Store.Put, got %v", filtered)
+	}
+}
+
+func TestGoProvider_Definition(t *testing.T) {
+	root := writeModule(t)
+	g := &GoProvider{}
+
+	for _, query := range []string{"store.New", "Store.Put", "Put", "Limit"} {
+		defs, err := g.Definition(root, query)
+		if err != nil {
+			t.Fatalf("Definition(%s) error: %v", query, err)
+		}
+		if len(defs) != 1 || defs[0].Location.File != "store/store.go" {
+			t.Errorf("Definition(%s) = %v", query, defs)
+		}
+	}
+}
+
+func TestGoProvider_References(t *testing.T) {
+	root := writeModule(t)
+	g := &GoProvider{}
+
+	refs, err := g.References(root, "Store.Put")
+	if err != nil {
+		t.Fatalf("References() error: %v", err)
+	}
+	if len(refs) != 2 {
+		t.Fatalf("expected 2 references, got %v", refs)
+	}
+	if refs[0].Location.File != "main.go" || refs[0].Location.Line != 11 || !strings.Contains(refs[0].Line, `s.Put("a"`) {
+		t.Errorf("unexpected first reference: %+v", refs[0])
+	}
+}
@@ -0,0 +1,102 @@
+// Package codenav provides language-aware code navigation (symbols,
+// definitions and references) for the agent's tools. Each supported
+// language is implemented as a Provider; the registry picks the provider
+// that matches a project directory.
+package codenav
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Location is a position in a source file, relative to the project root.
+type Location struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// String returns the location in file:line format.
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// Symbol describes a declaration in the project.
+type Symbol struct {
+	Name      string   `json:"name"`      // Qualified name within the package (e.g. "Loop.Run")
+	Kind      string   `json:"kind"`      // func, method, type, var, const, field
+	Package   string   `json:"package"`   // Package or module name
+	Signature string   `json:"signature"` // Human-readable declaration signature
+	Location  Location `json:"location"`
+}
+
+// Reference is a usage site of a symbol.
+type Reference struct {
+	Location Location `json:"location"`
+	Line     string   `json:"line"` // Source line text, trimmed
+}
+
+// Provider implements code navigation for a single language.
+type Provider interface {
+	// Name returns the language name (e.g. "go").
+	Name() string
+	// Detect reports whether the provider applies to the project at root.
+	Detect(root string) bool
+	// Symbols returns declarations under path (a file or directory relative to root)
+	// whose names contain query. An empty query returns all symbols.
+	Symbols(root, path, query string) ([]Symbol, error)
+	// Definition returns the declarations matching name. Names may be qualified
+	// as "pkg.Name" or "Type.Method".
+	Definition(root, name string) ([]Symbol, error)
+	// References returns every usage site of the symbol identified by name.
+	References(root, name string) ([]Reference, error)
+}
+
+// PackageLister is implemented by providers that can summarize the exported
+// API of every package in a project, for use in repository maps.
+type PackageLister interface {
+	// PackageSymbols returns exported top-level symbols keyed by package
+	// directory relative to root.
+	PackageSymbols(root string) (map[string][]Symbol, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	providers  []Provider
+)
+
+// Register adds a provider to the registry. Providers are consulted in registration order.
+func Register(p Provider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	providers = append(providers, p)
+}
+
+// ProviderFor returns the first registered provider that detects the project at root.
+func ProviderFor(root string) (Provider, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, p := range providers {
+		if p.Detect(root) {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no code navigation provider for this project (supported: %s)", strings.Join(providerNames(), ", "))
+}
+
+// providerNames returns the sorted names of all registered providers.
+// Callers must hold registryMu.
+func providerNames() []string {
+	names := make([]string, 0, len(providers))
+	for _, p := range providers {
+		names = append(names, p.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(&GoProvider{})
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,6 +14,11 @@ const configFile = ".chief/config.yaml"
 type Config struct {
 	Worktree   WorktreeConfig   `yaml:"worktree"`
 	OnComplete OnCompleteConfig `yaml:"onComplete"`
+	RepoMap    RepoMapConfig    `yaml:"repoMap"`
+	Notify     NotifyConfig     `yaml:"notify"`
+	Scheduler  SchedulerConfig  `yaml:"scheduler"`
+	LLM        LLMConfig        `yaml:"llm"`
+	Logs       LogsConfig       `yaml:"logs"`
 }
 
 // WorktreeConfig holds worktree-related settings.
@@ -26,6 +32,75 @@ type OnCompleteConfig struct {
 	CreatePR bool `yaml:"createPR"`
 }
 
+// RepoMapConfig holds settings for the repository map injected into agent prompts.
+type RepoMapConfig struct {
+	Disable     bool `yaml:"disable"`
+	TokenBudget int  `yaml:"tokenBudget"` // 0 uses the default budget
+}
+
+// SchedulerConfig holds settings for queued and scheduled runs in chief serve.
+type SchedulerConfig struct {
+	MaxConcurrent int `yaml:"maxConcurrent"` // Loops allowed to run at once; 0 uses 1
+}
+
+// LLMConfig holds settings shared by every request to the LLM endpoints.
+type LLMConfig struct {
+	MaxConcurrent int            `yaml:"maxConcurrent"`       // Requests each endpoint serves at once across all loops; 0 uses 1
+	Endpoints     map[string]int `yaml:"endpoints,omitempty"` // MaxConcurrent overrides keyed by endpoint base URL
+	Fallbacks     []LLMFallback  `yaml:"fallbacks,omitempty"` // Tried in order when the model is missing or its endpoint is unreachable
+
+	ConnectTimeout    time.Duration `yaml:"connectTimeout,omitempty"`    // Limit for connecting to an endpoint; 0 uses 10s
+	FirstTokenTimeout time.Duration `yaml:"firstTokenTimeout,omitempty"` // Limit for a response to start; 0 uses 5m
+	IdleTimeout       time.Duration `yaml:"idleTimeout,omitempty"`       // Limit for a gap between streamed chunks; 0 uses 2m
+
+	ToolCallFormat string `yaml:"toolCallFormat,omitempty"` // Format of tool calls written in response text: auto, hermes, json, mistral or none
+}
+
+// LogsConfig holds limits on the logs kept during and after runs.
+type LogsConfig struct {
+	MemoryEntries int `yaml:"memoryEntries"` // Log entries the TUI and chief serve keep in memory, older ones are read from disk; 0 uses 1000
+	RetainDays    int `yaml:"retainDays"`    // Days agent logs are kept in the store; 0 uses 30
+	MaxEntries    int `yaml:"maxEntries"`    // Agent log entries kept in the store per PRD; 0 uses 10000
+	MaxFileMB     int `yaml:"maxFileMB"`     // Size ollama.log and chief serve's log files rotate at; 0 uses 10
+	MaxFiles      int `yaml:"maxFiles"`      // Rotated files kept besides the current one; 0 uses 3
+}
+
+// LLMFallback is a model, and optionally another endpoint, to fall back to.
+type LLMFallback struct {
+	Model string `yaml:"model,omitempty"` // Empty keeps the current model
+	URL   string `yaml:"url,omitempty"`   // Empty keeps the current endpoint
+}
+
+// NotifyConfig holds notification channels and the rules that route loop
+// events to them.
+type NotifyConfig struct {
+	Channels []NotifyChannel `yaml:"channels"`
+	Rules    []NotifyRule    `yaml:"rules"` // Empty routes the default events to every channel
+}
+
+// NotifyChannel is a destination for notifications.
+type NotifyChannel struct {
+	Name    string            `yaml:"name"`
+	Type    string            `yaml:"type"` // webhook, slack, mattermost, desktop or email
+	URL     string            `yaml:"url,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"` // Extra HTTP headers for webhook channels
+
+	// Email settings
+	SMTPHost    string   `yaml:"smtpHost,omitempty"`
+	SMTPPort    int      `yaml:"smtpPort,omitempty"` // 0 uses 587
+	Username    string   `yaml:"username,omitempty"`
+	PasswordEnv string   `yaml:"passwordEnv,omitempty"` // Environment variable holding the SMTP password
+	From        string   `yaml:"from,omitempty"`
+	To          []string `yaml:"to,omitempty"`
+}
+
+// NotifyRule sends the listed events to the listed channels.
+type NotifyRule struct {
+	Events   []string `yaml:"events"`             // storyCompleted, error, retriesExhausted, maxIterations, complete
+	Channels []string `yaml:"channels,omitempty"` // Channel names; empty means every channel
+	PRDs     []string `yaml:"prds,omitempty"`     // PRD names; empty means every PRD
+}
+
 // Default returns a Config with zero-value defaults.
 func Default() *Config {
 	return &Config{}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestDefault(t *testing.T) {
@@ -82,3 +83,35 @@ func TestExists(t *testing.T) {
 		t.Error("expected Exists to return true for existing config")
 	}
 }
+
+func TestLoadLLMTimeouts(t *testing.T) {
+	dir := t.TempDir()
+	chiefDir := filepath.Join(dir, ".chief")
+	if err := os.MkdirAll(chiefDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	yaml := "llm:\n  firstTokenTimeout: 15m\n  idleTimeout: 3m30s\n"
+	if err := os.WriteFile(filepath.Join(chiefDir, "config.yaml"), []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.LLM.FirstTokenTimeout != 15*time.Minute || cfg.LLM.IdleTimeout != 210*time.Second || cfg.LLM.ConnectTimeout != 0 {
+		t.Errorf("unexpected timeouts: %+v", cfg.LLM)
+	}
+
+	// Saved durations load back unchanged
+	if err := Save(dir, cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.LLM.IdleTimeout != cfg.LLM.IdleTimeout {
+		t.Errorf("idle timeout = %v after saving, want %v", loaded.LLM.IdleTimeout, cfg.LLM.IdleTimeout)
+	}
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// IterationRecord is the history of one loop iteration.
+type IterationRecord struct {
+	ID          int64
+	ProjectName string
+	Iteration   int
+	StoryID     string // Story the iteration worked on, empty if unknown
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	Attempts    int      // 1 plus the number of retries
+	Errors      []string // Error from each failed attempt
+	Failed      bool     // The iteration gave up after its last attempt
+	StoryPassed bool     // The story was marked as passing by this iteration
+	ToolCalls   int      // Tool calls made across all attempts
+	StartCommit string   // HEAD when the iteration started, empty outside a git repository
+	EndCommit   string   // HEAD when the iteration finished
+	Files       []string // Files the iteration changed, relative to the repository root
+	Transcript  string   // Path of the iteration's transcript, empty if none was written
+}
+
+// Duration returns how long the iteration took, including retries.
+func (r IterationRecord) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// AddIteration records a finished iteration.
+func (s *Store) AddIteration(r IterationRecord) error {
+	errs, _ := json.Marshal(r.Errors)
+	files, _ := json.Marshal(r.Files)
+	_, err := s.db.Exec(`
+		INSERT INTO iterations (project_name, iteration, story_id, started_at, finished_at, attempts, errors, failed, story_passed,
+			tool_calls, start_commit, end_commit, files, transcript)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.ProjectName, r.Iteration, r.StoryID, r.StartedAt.UTC(), r.FinishedAt.UTC(), r.Attempts, string(errs), r.Failed, r.StoryPassed,
+		r.ToolCalls, r.StartCommit, r.EndCommit, string(files), r.Transcript)
+	return err
+}
+
+// GetIterations returns the iteration history of a project, oldest first.
+func (s *Store) GetIterations(projectName string) ([]IterationRecord, error) {
+	rows, err := s.db.Query(`
+		SELECT id, project_name, iteration, story_id, started_at, finished_at, attempts, errors, failed, story_passed,
+			tool_calls, start_commit, end_commit, files, transcript
+		FROM iterations WHERE project_name = ? ORDER BY id ASC
+	`, projectName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []IterationRecord
+	for rows.Next() {
+		var r IterationRecord
+		var storyID, errs, startCommit, endCommit, files, transcript sql.NullString
+		var toolCalls sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.ProjectName, &r.Iteration, &storyID, &r.StartedAt, &r.FinishedAt, &r.Attempts, &errs, &r.Failed, &r.StoryPassed,
+			&toolCalls, &startCommit, &endCommit, &files, &transcript); err != nil {
+			return nil, err
+		}
+		r.StoryID = storyID.String
+		json.Unmarshal([]byte(errs.String), &r.Errors)
+		r.ToolCalls = int(toolCalls.Int64)
+		r.StartCommit = startCommit.String
+		r.EndCommit = endCommit.String
+		json.Unmarshal([]byte(files.String), &r.Files)
+		r.Transcript = transcript.String
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Learning is a reusable fact about the codebase recorded by the agent.
+type Learning struct {
+	ID          int64
+	ProjectName string
+	Content     string
+	Tags        []string
+	StoryIDs    []string
+	Occurrences int // Number of times the learning was recorded
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// AddLearning records a learning for a project. Learnings that differ only in
+// case, whitespace or punctuation are merged: the occurrence count is bumped
+// and the tags and story IDs are combined. Returns true if the learning is new.
+func (s *Store) AddLearning(projectName, storyID, content string, tags []string) (bool, error) {
+	content = strings.TrimSpace(content)
+	key := normalizeLearning(content)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	var tagsStr, storiesStr sql.NullString
+	err = tx.QueryRow("SELECT id, tags, story_ids FROM learnings WHERE project_name = ? AND normalized = ?", projectName, key).
+		Scan(&id, &tagsStr, &storiesStr)
+
+	switch {
+	case err == sql.ErrNoRows:
+		var storyIDs []string
+		if storyID != "" {
+			storyIDs = []string{storyID}
+		}
+		t, _ := json.Marshal(mergeStrings(nil, tags))
+		st, _ := json.Marshal(storyIDs)
+		if _, err := tx.Exec(`
+			INSERT INTO learnings (project_name, content, normalized, tags, story_ids)
+			VALUES (?, ?, ?, ?, ?)
+		`, projectName, content, key, string(t), string(st)); err != nil {
+			return false, err
+		}
+		return true, tx.Commit()
+	case err != nil:
+		return false, err
+	}
+
+	var existingTags, existingStories []string
+	json.Unmarshal([]byte(tagsStr.String), &existingTags)
+	json.Unmarshal([]byte(storiesStr.String), &existingStories)
+	if storyID != "" {
+		existingStories = mergeStrings(existingStories, []string{storyID})
+	}
+	t, _ := json.Marshal(mergeStrings(existingTags, tags))
+	st, _ := json.Marshal(existingStories)
+	if _, err := tx.Exec(`
+		UPDATE learnings SET tags = ?, story_ids = ?, occurrences = occurrences + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, string(t), string(st), id); err != nil {
+		return false, err
+	}
+	return false, tx.Commit()
+}
+
+// GetLearnings returns all learnings for a project, oldest first.
+func (s *Store) GetLearnings(projectName string) ([]Learning, error) {
+	rows, err := s.db.Query(`
+		SELECT id, project_name, content, tags, story_ids, occurrences, created_at, updated_at
+		FROM learnings WHERE project_name = ? ORDER BY id ASC
+	`, projectName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var learnings []Learning
+	for rows.Next() {
+		var l Learning
+		var tagsStr, storiesStr sql.NullString
+		if err := rows.Scan(&l.ID, &l.ProjectName, &l.Content, &tagsStr, &storiesStr, &l.Occurrences, &l.CreatedAt, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(tagsStr.String), &l.Tags)
+		json.Unmarshal([]byte(storiesStr.String), &l.StoryIDs)
+		learnings = append(learnings, l)
+	}
+	return learnings, rows.Err()
+}
+
+// DeleteLearning removes a single learning.
+func (s *Store) DeleteLearning(id int64) error {
+	_, err := s.db.Exec("DELETE FROM learnings WHERE id = ?", id)
+	return err
+}
+
+// normalizeLearning returns the deduplication key for a learning: lowercase
+// letters and digits separated by single spaces.
+func normalizeLearning(content string) string {
+	fields := strings.FieldsFunc(strings.ToLower(content), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// mergeStrings appends the non-empty values of add to base, skipping duplicates (case-insensitive).
+func mergeStrings(base, add []string) []string {
+	seen := make(map[string]bool, len(base))
+	for _, v := range base {
+		seen[strings.ToLower(v)] = true
+	}
+	for _, v := range add {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[strings.ToLower(v)] {
+			continue
+		}
+		seen[strings.ToLower(v)] = true
+		base = append(base, v)
+	}
+	return base
+}
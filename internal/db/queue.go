@@ -0,0 +1,190 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Run queue statuses.
+const (
+	QueueQueued   = "queued"
+	QueueRunning  = "running"
+	QueueDone     = "done"
+	QueueFailed   = "failed"
+	QueueCanceled = "canceled"
+)
+
+// Run queue sources.
+const (
+	SourceManual   = "manual"
+	SourceSchedule = "schedule"
+)
+
+// QueueEntry is a PRD run waiting in, or taken from, the run queue.
+type QueueEntry struct {
+	ID         int64     `json:"id"`
+	PRDName    string    `json:"prd"`
+	Priority   int       `json:"priority"` // Higher runs first; equal priorities run in FIFO order
+	Source     string    `json:"source"`   // SourceManual or SourceSchedule
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	EnqueuedAt time.Time `json:"enqueuedAt"`
+	StartedAt  time.Time `json:"startedAt,omitempty"`
+	FinishedAt time.Time `json:"finishedAt,omitempty"`
+}
+
+// Schedule runs a PRD on a cron expression.
+type Schedule struct {
+	PRDName  string    `json:"prd"`
+	Cron     string    `json:"cron"`
+	Priority int       `json:"priority"`
+	Enabled  bool      `json:"enabled"`
+	LastRun  time.Time `json:"lastRun,omitempty"` // When the schedule last enqueued a run
+}
+
+const queueColumns = "id, project_name, priority, source, status, error, enqueued_at, started_at, finished_at"
+
+// Enqueue adds a run for a PRD to the queue. A PRD that is already queued or
+// running is not queued twice; its existing entry is returned, with its
+// priority raised if the new one is higher.
+func (s *Store) Enqueue(prdName string, priority int, source string) (QueueEntry, error) {
+	existing, err := s.scanQueue(s.db.Query("SELECT "+queueColumns+" FROM run_queue WHERE project_name = ? AND status IN (?, ?) ORDER BY id LIMIT 1",
+		prdName, QueueQueued, QueueRunning))
+	if err != nil {
+		return QueueEntry{}, err
+	}
+	if len(existing) > 0 {
+		e := existing[0]
+		if e.Status == QueueQueued && priority > e.Priority {
+			if _, err := s.db.Exec("UPDATE run_queue SET priority = ? WHERE id = ?", priority, e.ID); err != nil {
+				return QueueEntry{}, err
+			}
+			e.Priority = priority
+		}
+		return e, nil
+	}
+
+	now := time.Now().UTC()
+	res, err := s.db.Exec("INSERT INTO run_queue (project_name, priority, source, status, enqueued_at) VALUES (?, ?, ?, ?, ?)",
+		prdName, priority, source, QueueQueued, now)
+	if err != nil {
+		return QueueEntry{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return QueueEntry{}, err
+	}
+	return QueueEntry{ID: id, PRDName: prdName, Priority: priority, Source: source, Status: QueueQueued, EnqueuedAt: now}, nil
+}
+
+// ListQueue returns the running entries followed by the queued ones in the
+// order they will start.
+func (s *Store) ListQueue() ([]QueueEntry, error) {
+	return s.scanQueue(s.db.Query(`SELECT `+queueColumns+` FROM run_queue WHERE status IN (?, ?)
+		ORDER BY CASE status WHEN ? THEN 0 ELSE 1 END, priority DESC, id ASC`,
+		QueueQueued, QueueRunning, QueueRunning))
+}
+
+// QueueHistory returns the most recently finished entries, newest first.
+func (s *Store) QueueHistory(limit int) ([]QueueEntry, error) {
+	return s.scanQueue(s.db.Query(`SELECT `+queueColumns+` FROM run_queue WHERE status IN (?, ?, ?)
+		ORDER BY finished_at DESC, id DESC LIMIT ?`,
+		QueueDone, QueueFailed, QueueCanceled, limit))
+}
+
+// StartQueueEntry marks a queued entry as running.
+func (s *Store) StartQueueEntry(id int64) error {
+	_, err := s.db.Exec("UPDATE run_queue SET status = ?, started_at = ? WHERE id = ? AND status = ?",
+		QueueRunning, time.Now().UTC(), id, QueueQueued)
+	return err
+}
+
+// FinishQueueEntry marks an entry as done, failed or canceled.
+func (s *Store) FinishQueueEntry(id int64, status, errMsg string) error {
+	_, err := s.db.Exec("UPDATE run_queue SET status = ?, error = ?, finished_at = ? WHERE id = ?",
+		status, errMsg, time.Now().UTC(), id)
+	return err
+}
+
+// CancelQueueEntry cancels an entry that has not started. It reports whether
+// an entry was canceled.
+func (s *Store) CancelQueueEntry(id int64) (bool, error) {
+	res, err := s.db.Exec("UPDATE run_queue SET status = ?, finished_at = ? WHERE id = ? AND status = ?",
+		QueueCanceled, time.Now().UTC(), id, QueueQueued)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RequeueRunning puts entries left running by a previous process back in the
+// queue, keeping their place.
+func (s *Store) RequeueRunning() error {
+	_, err := s.db.Exec("UPDATE run_queue SET status = ?, started_at = NULL WHERE status = ?", QueueQueued, QueueRunning)
+	return err
+}
+
+func (s *Store) scanQueue(rows *sql.Rows, err error) ([]QueueEntry, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []QueueEntry
+	for rows.Next() {
+		var e QueueEntry
+		var errMsg sql.NullString
+		var started, finished sql.NullTime
+		if err := rows.Scan(&e.ID, &e.PRDName, &e.Priority, &e.Source, &e.Status, &errMsg, &e.EnqueuedAt, &started, &finished); err != nil {
+			return nil, err
+		}
+		e.Error = errMsg.String
+		e.StartedAt = started.Time
+		e.FinishedAt = finished.Time
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// SetSchedule creates or replaces a PRD's schedule, keeping its last run.
+func (s *Store) SetSchedule(sched Schedule) error {
+	_, err := s.db.Exec(`
+		INSERT INTO schedules (project_name, cron, priority, enabled) VALUES (?, ?, ?, ?)
+		ON CONFLICT(project_name) DO UPDATE SET cron = excluded.cron, priority = excluded.priority, enabled = excluded.enabled
+	`, sched.PRDName, sched.Cron, sched.Priority, sched.Enabled)
+	return err
+}
+
+// DeleteSchedule removes a PRD's schedule.
+func (s *Store) DeleteSchedule(prdName string) error {
+	_, err := s.db.Exec("DELETE FROM schedules WHERE project_name = ?", prdName)
+	return err
+}
+
+// ListSchedules returns every schedule, ordered by PRD name.
+func (s *Store) ListSchedules() ([]Schedule, error) {
+	rows, err := s.db.Query("SELECT project_name, cron, priority, enabled, last_run FROM schedules ORDER BY project_name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []Schedule
+	for rows.Next() {
+		var sched Schedule
+		var lastRun sql.NullTime
+		if err := rows.Scan(&sched.PRDName, &sched.Cron, &sched.Priority, &sched.Enabled, &lastRun); err != nil {
+			return nil, err
+		}
+		sched.LastRun = lastRun.Time
+		schedules = append(schedules, sched)
+	}
+	return schedules, rows.Err()
+}
+
+// MarkScheduleRun records when a schedule last enqueued a run.
+func (s *Store) MarkScheduleRun(prdName string, at time.Time) error {
+	_, err := s.db.Exec("UPDATE schedules SET last_run = ? WHERE project_name = ?", at.UTC(), prdName)
+	return err
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "modernc.org/sqlite"
@@ -13,7 +14,19 @@ type Store struct {
 	db *sql.DB
 }
 
+// busyTimeout is how long a statement waits for another connection's write
+// to finish before failing with SQLITE_BUSY. Loops running side by side, and
+// the TUI or chief serve alongside them, write to the same database.
+const busyTimeout = "_pragma=busy_timeout(5000)"
+
 func NewStore(dsn string) (*Store, error) {
+	if !strings.Contains(dsn, "busy_timeout") {
+		if strings.Contains(dsn, "?") {
+			dsn += "&" + busyTimeout
+		} else {
+			dsn += "?" + busyTimeout
+		}
+	}
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -61,6 +74,62 @@ func (s *Store) migrate() error {
 			message TEXT NOT NULL,
 			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP
 		);`,
+		`CREATE TABLE IF NOT EXISTS learnings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			project_name TEXT NOT NULL,
+			content TEXT NOT NULL,
+			normalized TEXT NOT NULL,
+			tags TEXT,
+			story_ids TEXT,
+			occurrences INTEGER DEFAULT 1,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(project_name, normalized)
+		);`,
+		`CREATE TABLE IF NOT EXISTS iterations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			project_name TEXT NOT NULL,
+			iteration INTEGER NOT NULL,
+			story_id TEXT,
+			started_at DATETIME NOT NULL,
+			finished_at DATETIME NOT NULL,
+			attempts INTEGER DEFAULT 1,
+			errors TEXT,
+			failed BOOLEAN DEFAULT 0,
+			story_passed BOOLEAN DEFAULT 0
+		);`,
+		`CREATE TABLE IF NOT EXISTS transcripts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			project_name TEXT NOT NULL,
+			iteration INTEGER NOT NULL,
+			story_id TEXT,
+			path TEXT NOT NULL,
+			started_at DATETIME NOT NULL,
+			finished_at DATETIME NOT NULL,
+			messages INTEGER DEFAULT 0,
+			tool_calls INTEGER DEFAULT 0,
+			prompt_tokens INTEGER DEFAULT 0,
+			output_tokens INTEGER DEFAULT 0,
+			failed BOOLEAN DEFAULT 0
+		);`,
+		`CREATE TABLE IF NOT EXISTS run_queue (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			project_name TEXT NOT NULL,
+			priority INTEGER DEFAULT 0,
+			source TEXT NOT NULL,
+			status TEXT NOT NULL,
+			error TEXT,
+			enqueued_at DATETIME NOT NULL,
+			started_at DATETIME,
+			finished_at DATETIME
+		);`,
+		`CREATE TABLE IF NOT EXISTS schedules (
+			project_name TEXT PRIMARY KEY,
+			cron TEXT NOT NULL,
+			priority INTEGER DEFAULT 0,
+			enabled BOOLEAN DEFAULT 1,
+			last_run DATETIME
+		);`,
 	}
 
 	for _, q := range queries {
@@ -72,6 +141,12 @@ func (s *Store) migrate() error {
 	// Add title column if it doesn't exist (ignoring error if it already exists)
 	s.db.Exec("ALTER TABLE projects ADD COLUMN title TEXT;")
 	s.db.Exec("ALTER TABLE projects ADD COLUMN repo_url TEXT;")
+	s.db.Exec("ALTER TABLE iterations ADD COLUMN tool_calls INTEGER DEFAULT 0;")
+	s.db.Exec("ALTER TABLE iterations ADD COLUMN start_commit TEXT;")
+	s.db.Exec("ALTER TABLE iterations ADD COLUMN end_commit TEXT;")
+	s.db.Exec("ALTER TABLE iterations ADD COLUMN files TEXT;")
+	s.db.Exec("ALTER TABLE iterations ADD COLUMN transcript TEXT;")
+	s.db.Exec("CREATE INDEX IF NOT EXISTS idx_agent_logs_project ON agent_logs (project_name, id);")
 
 	return nil
 }
@@ -164,22 +239,72 @@ func (s *Store) AddLog(projectName, message string) error {
 }
 
 func (s *Store) GetLogs(projectName string, limit int) ([]string, error) {
-	rows, err := s.db.Query("SELECT message, timestamp FROM agent_logs WHERE project_name = ? ORDER BY timestamp DESC LIMIT ?", projectName, limit)
+	logs, _, err := s.GetLogPage(projectName, 0, limit)
+	return logs, err
+}
+
+// GetLogPage returns up to limit of a project's log lines, oldest first, from
+// before the log with ID before, or the newest when before is 0. It also
+// returns the cursor for the page before this one, or 0 when there is none.
+func (s *Store) GetLogPage(projectName string, before int64, limit int) ([]string, int64, error) {
+	query := "SELECT id, message, timestamp FROM agent_logs WHERE project_name = ? ORDER BY id DESC LIMIT ?"
+	args := []interface{}{projectName, limit}
+	if before > 0 {
+		query = "SELECT id, message, timestamp FROM agent_logs WHERE project_name = ? AND id < ? ORDER BY id DESC LIMIT ?"
+		args = []interface{}{projectName, before, limit}
+	}
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
 	var logs []string
+	var oldest int64
 	for rows.Next() {
 		var msg string
 		var ts time.Time
-		if err := rows.Scan(&msg, &ts); err != nil {
-			return nil, err
+		if err := rows.Scan(&oldest, &msg, &ts); err != nil {
+			return nil, 0, err
 		}
 		logs = append([]string{fmt.Sprintf("%s: %s", ts.Format(time.RFC3339), msg)}, logs...)
 	}
-	return logs, nil
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if len(logs) < limit {
+		oldest = 0
+	}
+	return logs, oldest, nil
+}
+
+// PruneLogs deletes agent logs older than maxAge, and all but the newest
+// maxPerProject logs of each project. A zero limit isn't applied. It returns
+// the number of logs deleted.
+func (s *Store) PruneLogs(maxAge time.Duration, maxPerProject int) (int64, error) {
+	var deleted int64
+	if maxAge > 0 {
+		res, err := s.db.Exec("DELETE FROM agent_logs WHERE timestamp < datetime('now', ?)",
+			fmt.Sprintf("-%d seconds", int64(maxAge.Seconds())))
+		if err != nil {
+			return 0, err
+		}
+		n, _ := res.RowsAffected()
+		deleted += n
+	}
+	if maxPerProject > 0 {
+		res, err := s.db.Exec(`DELETE FROM agent_logs WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY project_name ORDER BY id DESC) AS n FROM agent_logs
+			) WHERE n > ?
+		)`, maxPerProject)
+		if err != nil {
+			return deleted, err
+		}
+		n, _ := res.RowsAffected()
+		deleted += n
+	}
+	return deleted, nil
 }
 
 type ProjectInfo struct {
@@ -212,23 +337,32 @@ func (s *Store) ListProjects() ([]ProjectInfo, error) {
 	return projects, nil
 }
 
-// DeleteProject removes a project and all related data (stories, logs) from the database.
+// DeleteProject removes a project and all related data (stories, logs,
+// learnings, iteration history, transcript index, schedules and queued runs)
+// from the database. Data recorded under the name is removed even when the
+// project itself was never saved, as with loops that only keep learnings.
+// Transcript files live in the PRD directory and are left to the caller,
+// which removes them with it.
 func (s *Store) DeleteProject(name string) error {
-	id, _, _, _, err := s.GetProject(name)
-	if err != nil {
-		// Project doesn't exist in DB, that's fine
-		return nil
-	}
+	id, _, _, _, projectErr := s.GetProject(name)
 
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
 
-	// Delete stories
-	if _, err := tx.Exec("DELETE FROM user_stories WHERE project_id = ?", id); err != nil {
-		tx.Rollback()
-		return err
+	if projectErr == nil {
+		// Delete stories
+		if _, err := tx.Exec("DELETE FROM user_stories WHERE project_id = ?", id); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		// Delete project
+		if _, err := tx.Exec("DELETE FROM projects WHERE id = ?", id); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
 
 	// Delete logs
@@ -237,11 +371,59 @@ func (s *Store) DeleteProject(name string) error {
 		return err
 	}
 
-	// Delete project
-	if _, err := tx.Exec("DELETE FROM projects WHERE id = ?", id); err != nil {
+	// Delete learnings
+	if _, err := tx.Exec("DELETE FROM learnings WHERE project_name = ?", name); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Delete iteration history and the transcript index
+	if _, err := tx.Exec("DELETE FROM iterations WHERE project_name = ?", name); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM transcripts WHERE project_name = ?", name); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Delete the schedule and queued runs
+	if _, err := tx.Exec("DELETE FROM schedules WHERE project_name = ?", name); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM run_queue WHERE project_name = ? AND status = ?", name, QueueQueued); err != nil {
 		tx.Rollback()
 		return err
 	}
 
 	return tx.Commit()
 }
+
+// IntegrityCheck runs SQLite's integrity check and returns an error describing
+// any corruption it finds.
+func (s *Store) IntegrityCheck() error {
+	rows, err := s.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return err
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeleteProjectRemovesTranscripts(t *testing.T) {
+	store, err := NewStore(filepath.Join(t.TempDir(), "chief.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if _, err := store.SaveProject("auth", "Auth", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"auth", "billing"} {
+		if _, err := store.AddTranscript(TranscriptRecord{
+			ProjectName: name,
+			Iteration:   1,
+			Path:        filepath.Join(".chief", "prds", name, "transcripts", "iteration-1.jsonl"),
+			StartedAt:   time.Now(),
+			FinishedAt:  time.Now(),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// billing was never saved as a project; its transcripts still go.
+	for _, name := range []string{"auth", "billing"} {
+		if err := store.DeleteProject(name); err != nil {
+			t.Fatalf("DeleteProject(%q): %v", name, err)
+		}
+		records, err := store.GetTranscripts(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 0 {
+			t.Errorf("%s: %d transcripts left after DeleteProject", name, len(records))
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// TranscriptRecord indexes the transcript file of one loop iteration.
+type TranscriptRecord struct {
+	ID           int64
+	ProjectName  string
+	Iteration    int
+	StoryID      string // Story the iteration worked on, empty if unknown
+	Path         string // The JSONL transcript file
+	StartedAt    time.Time
+	FinishedAt   time.Time
+	Messages     int
+	ToolCalls    int
+	PromptTokens int
+	OutputTokens int
+	Failed       bool // The iteration gave up after its last attempt
+}
+
+// AddTranscript indexes a finished iteration's transcript.
+func (s *Store) AddTranscript(r TranscriptRecord) (int64, error) {
+	res, err := s.db.Exec(`
+		INSERT INTO transcripts (project_name, iteration, story_id, path, started_at, finished_at, messages, tool_calls, prompt_tokens, output_tokens, failed)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.ProjectName, r.Iteration, r.StoryID, r.Path, r.StartedAt.UTC(), r.FinishedAt.UTC(), r.Messages, r.ToolCalls, r.PromptTokens, r.OutputTokens, r.Failed)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// GetTranscripts returns the transcripts of a project, oldest first.
+func (s *Store) GetTranscripts(projectName string) ([]TranscriptRecord, error) {
+	rows, err := s.db.Query(`
+		SELECT id, project_name, iteration, story_id, path, started_at, finished_at, messages, tool_calls, prompt_tokens, output_tokens, failed
+		FROM transcripts WHERE project_name = ? ORDER BY id ASC
+	`, projectName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []TranscriptRecord
+	for rows.Next() {
+		r, err := scanTranscript(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// GetTranscript returns the transcript with the given ID.
+func (s *Store) GetTranscript(id int64) (TranscriptRecord, error) {
+	row := s.db.QueryRow(`
+		SELECT id, project_name, iteration, story_id, path, started_at, finished_at, messages, tool_calls, prompt_tokens, output_tokens, failed
+		FROM transcripts WHERE id = ?
+	`, id)
+	return scanTranscript(row)
+}
+
+func scanTranscript(row interface{ Scan(...interface{}) error }) (TranscriptRecord, error) {
+	var r TranscriptRecord
+	var storyID sql.NullString
+	err := row.Scan(&r.ID, &r.ProjectName, &r.Iteration, &storyID, &r.Path, &r.StartedAt, &r.FinishedAt,
+		&r.Messages, &r.ToolCalls, &r.PromptTokens, &r.OutputTokens, &r.Failed)
+	r.StoryID = storyID.String
+	return r, err
+}
@@ -0,0 +1,185 @@
+// Package fakellm provides a scripted stand-in for an Ollama server. It speaks
+// the /api/chat protocol and answers each request with the reply of the first
+// script rule matching the conversation, so agents, loops and the API server
+// can be driven through complete PRDs without a model.
+package fakellm
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/izdrail/chief/internal/ollama"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultModel is the model name the fake server reports when the script
+// doesn't name one.
+const DefaultModel = "fake"
+
+// Script is what the fake model says, written in YAML:
+//
+//	model: fake
+//	rules:
+//	  - when: {role: user, match: "Create hello"}
+//	    reply:
+//	      text: "I'll create the file."
+//	      toolCalls:
+//	        - name: Write
+//	          arguments: {file_path: hello.txt, content: "hello\n"}
+//	  - when: {afterTool: Write}
+//	    reply: {text: "Done."}
+type Script struct {
+	Model string `yaml:"model,omitempty"`
+	Rules []Rule `yaml:"rules"`
+	// Default answers requests no rule matches. Without it they fail with a
+	// 400 error, which stops the agent.
+	Default *Reply `yaml:"default,omitempty"`
+}
+
+// Rule is a reply and the conversations it answers.
+type Rule struct {
+	When  When  `yaml:"when"`
+	Reply Reply `yaml:"reply"`
+	Times int   `yaml:"times,omitempty"` // How many requests the rule answers; 0 means any number
+}
+
+// When selects conversations by their messages. Every set field must match;
+// an empty When matches any conversation.
+type When struct {
+	Role      string `yaml:"role,omitempty"`      // Role of the last message: user, assistant, tool or system
+	Match     string `yaml:"match,omitempty"`     // Regexp matched against the last message's content
+	Any       string `yaml:"any,omitempty"`       // Regexp matched against every message's content
+	AfterTool string `yaml:"afterTool,omitempty"` // The last message is the result of this tool
+	Model     string `yaml:"model,omitempty"`     // The request is for this model
+
+	match *regexp.Regexp
+	any   *regexp.Regexp
+}
+
+// Reply is one response. Text is streamed a word at a time unless Chunks
+// gives the pieces explicitly; tool calls arrive with the last chunk.
+type Reply struct {
+	Text      string        `yaml:"text,omitempty"`
+	Chunks    []string      `yaml:"chunks,omitempty"`
+	ToolCalls []ToolCall    `yaml:"toolCalls,omitempty"`
+	Delay     time.Duration `yaml:"delay,omitempty"` // Pause before each chunk
+	// Error fails the request with this message, as Ollama reports errors.
+	// With Status 200 the error arrives mid-stream, after any chunks.
+	Error  string `yaml:"error,omitempty"`
+	Status int    `yaml:"status,omitempty"` // HTTP status for Error; 0 uses 500
+}
+
+// ToolCall is a tool the reply calls.
+type ToolCall struct {
+	Name      string                 `yaml:"name"`
+	Arguments map[string]interface{} `yaml:"arguments,omitempty"`
+}
+
+// LoadScript reads a script file.
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read script: %w", err)
+	}
+	s, err := ParseScript(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// ParseScript parses and validates a YAML script.
+func ParseScript(data []byte) (*Script, error) {
+	var s Script
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse script: %w", err)
+	}
+	if s.Model == "" {
+		s.Model = DefaultModel
+	}
+	for i := range s.Rules {
+		if err := s.Rules[i].compile(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return &s, nil
+}
+
+func (r *Rule) compile() error {
+	var err error
+	if r.When.Match != "" {
+		if r.When.match, err = regexp.Compile(r.When.Match); err != nil {
+			return fmt.Errorf("match: %w", err)
+		}
+	}
+	if r.When.Any != "" {
+		if r.When.any, err = regexp.Compile(r.When.Any); err != nil {
+			return fmt.Errorf("any: %w", err)
+		}
+	}
+	for _, tc := range r.Reply.ToolCalls {
+		if tc.Name == "" {
+			return fmt.Errorf("tool call without a name")
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule answers a request for model with these
+// messages.
+func (w *When) matches(model string, messages []ollama.Message) bool {
+	if w.Model != "" && w.Model != model {
+		return false
+	}
+	var last ollama.Message
+	if len(messages) > 0 {
+		last = messages[len(messages)-1]
+	}
+	if w.Role != "" && w.Role != last.Role {
+		return false
+	}
+	if w.match != nil && !w.match.MatchString(last.Content) {
+		return false
+	}
+	if w.AfterTool != "" && (last.Role != "tool" || w.AfterTool != toolName(messages)) {
+		return false
+	}
+	if w.any != nil {
+		for _, m := range messages {
+			if w.any.MatchString(m.Content) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// toolName returns the tool whose result is the last message: the result's
+// name if it has one, or the last call the assistant made.
+func toolName(messages []ollama.Message) string {
+	last := messages[len(messages)-1]
+	if last.Name != "" {
+		return last.Name
+	}
+	for i := len(messages) - 2; i >= 0; i-- {
+		if calls := messages[i].ToolCalls; len(calls) > 0 {
+			return calls[len(calls)-1].Function.Name
+		}
+	}
+	return ""
+}
+
+// chunks returns the pieces the reply's text is streamed in.
+func (r *Reply) chunks() []string {
+	if len(r.Chunks) > 0 {
+		return r.Chunks
+	}
+	if r.Text == "" {
+		return nil
+	}
+	return strings.SplitAfter(r.Text, " ")
+}
@@ -0,0 +1,183 @@
+package fakellm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/izdrail/chief/internal/ollama"
+)
+
+// Server answers Ollama API requests from a script. It serves /api/chat,
+// and /api/tags and /api/show so `chief doctor` sees a tool-capable model.
+type Server struct {
+	script *Script
+
+	mu       sync.Mutex
+	used     []int // Requests answered by each rule
+	requests []ollama.ChatRequest
+}
+
+// NewServer returns a server that follows script.
+func NewServer(script *Script) *Server {
+	return &Server{script: script, used: make([]int, len(script.Rules))}
+}
+
+// Requests returns the chat requests the server has received, in order.
+func (s *Server) Requests() []ollama.ChatRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ollama.ChatRequest(nil), s.requests...)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/chat":
+		s.handleChat(w, r)
+	case "/api/tags":
+		// Ollama lists untagged models as :latest
+		name := s.script.Model
+		if !strings.Contains(name, ":") {
+			name += ":latest"
+		}
+		writeJSON(w, map[string]interface{}{
+			"models": []map[string]string{{"name": name, "model": name}},
+		})
+	case "/api/show":
+		writeJSON(w, map[string]interface{}{
+			"capabilities": []string{"completion", "tools"},
+			"model_info":   map[string]interface{}{"fake.context_length": 131072},
+		})
+	case "/api/version":
+		writeJSON(w, map[string]string{"version": "fake"})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleChat(w http.ResponseWriter, r *http.Request) {
+	var req ollama.ChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+
+	reply := s.reply(req)
+	if reply == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("fake-llm: no script rule matches the conversation (last message: %s)", describeLast(req.Messages)))
+		return
+	}
+	if reply.Error != "" && reply.Status != http.StatusOK {
+		status := reply.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		writeError(w, status, reply.Error)
+		return
+	}
+
+	model := req.Model
+	if model == "" {
+		model = s.script.Model
+	}
+	if !req.Stream {
+		time.Sleep(reply.Delay)
+		writeJSON(w, ollama.ChatResponse{Model: model, Message: reply.message(), Done: true, DoneReason: "stop"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	send := func(chunk interface{}) bool {
+		if reply.Delay > 0 {
+			select {
+			case <-time.After(reply.Delay):
+			case <-r.Context().Done():
+				return false
+			}
+		}
+		if enc.Encode(chunk) != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+
+	for _, text := range reply.chunks() {
+		chunk := ollama.ChatResponse{Model: model, Message: ollama.Message{Role: "assistant", Content: text}}
+		if !send(chunk) {
+			return
+		}
+	}
+	if reply.Error != "" {
+		send(ollama.ChatResponse{Model: model, Error: reply.Error})
+		return
+	}
+	last := reply.message()
+	last.Content = ""
+	send(ollama.ChatResponse{Model: model, Message: last, Done: true, DoneReason: "stop"})
+}
+
+// reply returns the reply to req from the first matching rule with requests
+// left, or the script's default.
+func (s *Server) reply(req ollama.ChatRequest) *Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	for i := range s.script.Rules {
+		rule := &s.script.Rules[i]
+		if rule.Times > 0 && s.used[i] >= rule.Times {
+			continue
+		}
+		if rule.When.matches(req.Model, req.Messages) {
+			s.used[i]++
+			return &rule.Reply
+		}
+	}
+	return s.script.Default
+}
+
+// message returns the reply as one assistant message with its tool calls.
+func (r *Reply) message() ollama.Message {
+	msg := ollama.Message{Role: "assistant", Content: strings.Join(r.chunks(), "")}
+	for _, tc := range r.ToolCalls {
+		args := tc.Arguments
+		if args == nil {
+			args = map[string]interface{}{}
+		}
+		data, _ := json.Marshal(args)
+		msg.ToolCalls = append(msg.ToolCalls, ollama.ToolCall{Type: "function", Function: ollama.FunctionCall{Name: tc.Name, Arguments: data}})
+	}
+	return msg
+}
+
+// describeLast summarizes the last message for an error.
+func describeLast(messages []ollama.Message) string {
+	if len(messages) == 0 {
+		return "none"
+	}
+	last := messages[len(messages)-1]
+	content := last.Content
+	if len(content) > 80 {
+		content = content[:80] + "..."
+	}
+	return fmt.Sprintf("%s %q", last.Role, content)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
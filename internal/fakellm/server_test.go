@@ -0,0 +1,153 @@
+package fakellm
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/izdrail/chief/internal/agent"
+	"github.com/izdrail/chief/internal/ollama"
+)
+
+// serve starts a fake server for script and returns it with a client for it.
+func serve(t *testing.T, script string) (*Server, *ollama.Client) {
+	t.Helper()
+	s, err := ParseScript([]byte(script))
+	if err != nil {
+		t.Fatalf("ParseScript() error: %v", err)
+	}
+	fake := NewServer(s)
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return fake, &ollama.Client{BaseURL: srv.URL, Model: s.Model, HTTPClient: srv.Client(), Admission: ollama.NewAdmission(1)}
+}
+
+func TestRunAgentFollowsScript(t *testing.T) {
+	fake, client := serve(t, `
+rules:
+  - when: {role: user, match: "hello\\.txt"}
+    reply:
+      text: "I'll create the file."
+      toolCalls:
+        - name: Write
+          arguments: {file_path: hello.txt, content: "hello\n"}
+  - when: {afterTool: Write}
+    reply: {text: "Created hello.txt."}
+`)
+	workDir := t.TempDir()
+
+	var text string
+	var tools []string
+	for event := range agent.RunAgent(context.Background(), client, []ollama.Message{{Role: "user", Content: "Create hello.txt"}}, agent.AgentOptions{WorkDir: workDir}) {
+		if event.Error != nil {
+			t.Fatalf("RunAgent() error: %v", event.Error)
+		}
+		text += event.TextDelta
+		if event.ToolName != "" {
+			tools = append(tools, event.ToolName)
+		}
+	}
+
+	if text != "I'll create the file.Created hello.txt." || strings.Join(tools, ",") != "Write" {
+		t.Errorf("text = %q, tools = %v", text, tools)
+	}
+	if data, err := os.ReadFile(filepath.Join(workDir, "hello.txt")); err != nil || string(data) != "hello\n" {
+		t.Errorf("hello.txt = %q, %v", data, err)
+	}
+	if n := len(fake.Requests()); n != 2 {
+		t.Errorf("server got %d requests, want 2", n)
+	}
+}
+
+func TestRuleMatching(t *testing.T) {
+	_, client := serve(t, `
+model: scripted
+rules:
+  - when: {any: "PRD: auth"}
+    times: 1
+    reply: {text: "first auth reply"}
+  - when: {any: "PRD: auth"}
+    reply: {text: "later auth reply"}
+default:
+  text: "fallback"
+`)
+	ask := func(prompt string) string {
+		msg, err := client.Chat(context.Background(), ollama.ChatRequest{Model: client.Model, Messages: []ollama.Message{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: "go"},
+		}})
+		if err != nil {
+			t.Fatalf("Chat() error: %v", err)
+		}
+		return msg.Content
+	}
+
+	for _, want := range []string{"first auth reply", "later auth reply", "later auth reply"} {
+		if got := ask("PRD: auth"); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	if got := ask("PRD: billing"); got != "fallback" {
+		t.Errorf("unmatched request got %q", got)
+	}
+}
+
+func TestScriptedErrors(t *testing.T) {
+	_, client := serve(t, `
+rules:
+  - when: {match: missing}
+    reply: {error: "model \"fake\" not found, try pulling it first", status: 404}
+  - when: {match: midstream}
+    reply: {text: "partial", error: "context window exceeded", status: 200}
+`)
+	classOf := func(prompt string) ollama.ErrorClass {
+		var err error
+		for event := range client.ChatStream(context.Background(), ollama.ChatRequest{Model: client.Model, Messages: []ollama.Message{{Role: "user", Content: prompt}}}) {
+			if event.Error != nil {
+				err = event.Error
+			}
+		}
+		return ollama.Classify(err)
+	}
+
+	if got := classOf("missing"); got != ollama.ErrorModelNotFound {
+		t.Errorf("missing model: %v", got)
+	}
+	if got := classOf("midstream"); got != ollama.ErrorContextOverflow {
+		t.Errorf("mid-stream error: %v", got)
+	}
+	// No rule and no default rejects the request, which stops the agent
+	if got := classOf("unscripted"); got != ollama.ErrorInvalidRequest {
+		t.Errorf("unscripted request: %v", got)
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	for _, script := range []string{
+		"rules: [{when: {match: \"(\"}}]",
+		"rules: [{reply: {toolCalls: [{arguments: {a: 1}}]}}]",
+		"rules: {not: a list}",
+	} {
+		if _, err := ParseScript([]byte(script)); err == nil {
+			t.Errorf("ParseScript(%q) succeeded", script)
+		}
+	}
+}
+
+func TestServesModelInfo(t *testing.T) {
+	_, client := serve(t, "model: scripted\nrules: []\n")
+	models, err := client.ListModels(context.Background())
+	if err != nil || !ollama.HasModel(models, "scripted") {
+		t.Fatalf("ListModels() = %v, %v", models, err)
+	}
+	details, err := client.ShowModel(context.Background(), "scripted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if supported, known := details.SupportsTools(); !supported || !known {
+		t.Error("expected the fake model to support tools")
+	}
+}
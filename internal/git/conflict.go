@@ -0,0 +1,312 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Conflict markers, as git writes them with the default marker size.
+const (
+	markerOurs   = "<<<<<<<"
+	markerBase   = "|||||||"
+	markerSplit  = "======="
+	markerTheirs = ">>>>>>>"
+)
+
+// Resolution is how a conflict hunk, or a whole file, is resolved.
+type Resolution int
+
+const (
+	Unresolved    Resolution = iota
+	ResolveOurs              // Keep the current branch's side
+	ResolveTheirs            // Take the merged branch's side
+	ResolveBoth              // Keep ours followed by theirs
+)
+
+// ConflictHunk is one conflict in a file: the lines each side of the merge
+// wants, and the common ancestor's when git wrote them (diff3 style).
+type ConflictHunk struct {
+	Ours        []string
+	Base        []string
+	Theirs      []string
+	OursLabel   string
+	BaseLabel   string
+	TheirsLabel string
+	HasBase     bool
+	Line        int // 1-based line of the hunk's opening marker
+	Resolution  Resolution
+}
+
+// lines returns the lines the hunk resolves to, or the hunk with its
+// markers while it's unresolved.
+func (h ConflictHunk) lines() []string {
+	switch h.Resolution {
+	case ResolveOurs:
+		return h.Ours
+	case ResolveTheirs:
+		return h.Theirs
+	case ResolveBoth:
+		return append(append([]string{}, h.Ours...), h.Theirs...)
+	}
+	lines := append([]string{marker(markerOurs, h.OursLabel)}, h.Ours...)
+	if h.HasBase {
+		lines = append(append(lines, marker(markerBase, h.BaseLabel)), h.Base...)
+	}
+	lines = append(append(lines, markerSplit), h.Theirs...)
+	return append(lines, marker(markerTheirs, h.TheirsLabel))
+}
+
+// marker returns a conflict marker line with its label.
+func marker(m, label string) string {
+	if label == "" {
+		return m
+	}
+	return m + " " + label
+}
+
+// ConflictFile is a file a merge left with conflicts, split into the text
+// around its conflict hunks.
+type ConflictFile struct {
+	Path  string // Relative to the repository root
+	Hunks []ConflictHunk
+	text  [][]string // text[i] precedes Hunks[i]; the last entry follows them all
+}
+
+// ParseConflictFile splits a conflicted file's contents into its hunks and
+// the text around them. A file without markers, as in a conflict between a
+// change and a deletion, has no hunks.
+func ParseConflictFile(path string, data []byte) *ConflictFile {
+	f := &ConflictFile{Path: path}
+	lines := strings.Split(string(data), "\n")
+	var text []string
+	for i := 0; i < len(lines); i++ {
+		h, end, ok := parseConflictHunk(lines, i)
+		if !ok {
+			text = append(text, lines[i])
+			continue
+		}
+		f.text = append(f.text, text)
+		f.Hunks = append(f.Hunks, h)
+		text = nil
+		i = end
+	}
+	f.text = append(f.text, text)
+	return f
+}
+
+// parseConflictHunk parses the hunk opening at lines[start], returning it
+// and the index of its closing marker.
+func parseConflictHunk(lines []string, start int) (ConflictHunk, int, bool) {
+	if !strings.HasPrefix(lines[start], markerOurs) {
+		return ConflictHunk{}, 0, false
+	}
+	h := ConflictHunk{OursLabel: strings.TrimSpace(lines[start][len(markerOurs):]), Line: start + 1}
+	section := &h.Ours
+	for i := start + 1; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case section == &h.Ours && strings.HasPrefix(line, markerBase):
+			section, h.HasBase = &h.Base, true
+			h.BaseLabel = strings.TrimSpace(line[len(markerBase):])
+		case section != &h.Theirs && line == markerSplit:
+			section = &h.Theirs
+		case section == &h.Theirs && strings.HasPrefix(line, markerTheirs):
+			h.TheirsLabel = strings.TrimSpace(line[len(markerTheirs):])
+			return h, i, true
+		default:
+			*section = append(*section, line)
+		}
+	}
+	return ConflictHunk{}, 0, false
+}
+
+// Before returns the lines between hunk i and the one before it.
+func (f *ConflictFile) Before(i int) []string {
+	return f.text[i]
+}
+
+// After returns the lines between hunk i and the one after it.
+func (f *ConflictFile) After(i int) []string {
+	return f.text[i+1]
+}
+
+// Resolved reports whether every hunk has a resolution.
+func (f *ConflictFile) Resolved() bool {
+	for _, h := range f.Hunks {
+		if h.Resolution == Unresolved {
+			return false
+		}
+	}
+	return true
+}
+
+// Content returns the file with its resolved hunks replaced by the lines
+// chosen for them; unresolved hunks keep their markers.
+func (f *ConflictFile) Content() []byte {
+	var lines []string
+	for i, h := range f.Hunks {
+		lines = append(lines, f.text[i]...)
+		lines = append(lines, h.lines()...)
+	}
+	lines = append(lines, f.text[len(f.Hunks)]...)
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// StartMerge merges a branch into the current branch. Unlike MergeBranch it
+// leaves a conflicted merge in progress, returning the conflicting files,
+// so the conflicts can be resolved and the merge finished or aborted.
+func StartMerge(repoDir, branch string) ([]string, error) {
+	cmd := exec.Command("git", "merge", branch)
+	cmd.Dir = repoDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if conflicts := parseConflicts(repoDir); len(conflicts) > 0 {
+			return conflicts, fmt.Errorf("merge conflict: %s", strings.TrimSpace(string(out)))
+		}
+		return nil, fmt.Errorf("merge failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil, nil
+}
+
+// MergeInProgress reports whether the repository is in the middle of a merge.
+func MergeInProgress(repoDir string) bool {
+	cmd := exec.Command("git", "rev-parse", "-q", "--verify", "MERGE_HEAD")
+	cmd.Dir = repoDir
+	return cmd.Run() == nil
+}
+
+// ConflictedFiles returns the files that still have unresolved conflicts.
+func ConflictedFiles(repoDir string) []string {
+	return parseConflicts(repoDir)
+}
+
+// ReadConflicts reads and parses the files that still have conflicts.
+func ReadConflicts(repoDir string) ([]*ConflictFile, error) {
+	var files []*ConflictFile
+	for _, path := range parseConflicts(repoDir) {
+		data, err := os.ReadFile(filepath.Join(repoDir, path))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		files = append(files, ParseConflictFile(path, data))
+	}
+	return files, nil
+}
+
+// SaveResolution writes a conflicted file's resolutions to disk and, once
+// every hunk is resolved, marks the file resolved.
+func SaveResolution(repoDir string, f *ConflictFile) error {
+	path := filepath.Join(repoDir, f.Path)
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path, f.Content(), mode); err != nil {
+		return err
+	}
+	if !f.Resolved() {
+		return nil
+	}
+	return MarkResolved(repoDir, f.Path)
+}
+
+// ResolveFile resolves a whole file by taking one side of the merge. A side
+// that deleted the file resolves it by deleting it.
+func ResolveFile(repoDir, path string, side Resolution) error {
+	flag, stage := "--ours", "2"
+	if side == ResolveTheirs {
+		flag, stage = "--theirs", "3"
+	} else if side != ResolveOurs {
+		return fmt.Errorf("a whole file can only be resolved to ours or theirs")
+	}
+
+	stages, err := unmergedStages(repoDir, path)
+	if err != nil {
+		return err
+	}
+	if len(stages) > 0 && !stages[stage] {
+		// The side deleted the file
+		cmd := exec.Command("git", "rm", "--quiet", "--", path)
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to resolve %s: %s", path, strings.TrimSpace(string(out)))
+		}
+		return nil
+	}
+
+	cmd := exec.Command("git", "checkout", flag, "--", path)
+	cmd.Dir = repoDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to resolve %s: %s", path, strings.TrimSpace(string(out)))
+	}
+	return MarkResolved(repoDir, path)
+}
+
+// unmergedStages returns the index stages a conflicted file has: "1" for the
+// merge base, "2" for ours and "3" for theirs. It is empty for a file without
+// conflicts.
+func unmergedStages(repoDir, path string) (map[string]bool, error) {
+	cmd := exec.Command("git", "ls-files", "-u", "--", path)
+	cmd.Dir = repoDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the conflicts in %s: %w", path, err)
+	}
+	stages := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		// <mode> <object> <stage>\t<path>
+		fields := strings.Fields(strings.SplitN(line, "\t", 2)[0])
+		if len(fields) == 3 {
+			stages[fields[2]] = true
+		}
+	}
+	return stages, nil
+}
+
+// MarkResolved stages a file as it is in the working tree, marking its
+// conflicts resolved.
+func MarkResolved(repoDir, path string) error {
+	cmd := exec.Command("git", "add", "--", path)
+	cmd.Dir = repoDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to stage %s: %s", path, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// UnresolveFile restores a file's conflicts as the merge left them,
+// discarding any resolution, staged or not.
+func UnresolveFile(repoDir, path string) error {
+	cmd := exec.Command("git", "checkout", "-m", "--", path)
+	cmd.Dir = repoDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restore the conflicts in %s: %s", path, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// FinishMerge commits a merge whose conflicts are all resolved.
+func FinishMerge(repoDir string) error {
+	if conflicts := parseConflicts(repoDir); len(conflicts) > 0 {
+		return fmt.Errorf("%d files still have conflicts", len(conflicts))
+	}
+	cmd := exec.Command("git", "commit", "--no-edit")
+	cmd.Dir = repoDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to commit the merge: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// AbortMerge abandons a merge in progress, restoring the branch as it was.
+func AbortMerge(repoDir string) error {
+	cmd := exec.Command("git", "merge", "--abort")
+	cmd.Dir = repoDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to abort the merge: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConflictFile(t *testing.T) {
+	data := "a\n<<<<<<< HEAD\nours\n||||||| base\nold\n=======\ntheirs 1\ntheirs 2\n>>>>>>> feature\nb\n<<<<<<< HEAD\n=======\nadded\n>>>>>>> feature\n"
+	f := ParseConflictFile("x.txt", []byte(data))
+	if len(f.Hunks) != 2 {
+		t.Fatalf("parsed %d hunks, want 2: %+v", len(f.Hunks), f.Hunks)
+	}
+	h := f.Hunks[0]
+	if h.OursLabel != "HEAD" || h.TheirsLabel != "feature" || !h.HasBase || h.Line != 2 ||
+		strings.Join(h.Ours, "|") != "ours" || strings.Join(h.Base, "|") != "old" || strings.Join(h.Theirs, "|") != "theirs 1|theirs 2" {
+		t.Errorf("first hunk = %+v", h)
+	}
+	if len(f.Hunks[1].Ours) != 0 || f.Hunks[1].HasBase {
+		t.Errorf("second hunk = %+v", f.Hunks[1])
+	}
+
+	// Unresolved hunks are written back as they were
+	if string(f.Content()) != data {
+		t.Errorf("Content() of an unresolved file = %q", f.Content())
+	}
+	f.Hunks[0].Resolution = ResolveBoth
+	if f.Resolved() {
+		t.Error("Resolved() with a hunk left")
+	}
+	f.Hunks[1].Resolution = ResolveOurs
+	if got, want := string(f.Content()), "a\nours\ntheirs 1\ntheirs 2\nb\n"; got != want {
+		t.Errorf("Content() = %q, want %q", got, want)
+	}
+	if f.Before(1)[0] != "b" || len(f.After(1)) != 1 {
+		t.Errorf("text around the second hunk: %q, %q", f.Before(1), f.After(1))
+	}
+}
+
+func TestResolveMerge(t *testing.T) {
+	dir := initTestRepo(t)
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s", args, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("a.txt", "one\n")
+	write("b.txt", "one\n")
+	run("add", ".")
+	run("commit", "-m", "base")
+	run("checkout", "-b", "feature")
+	write("a.txt", "feature\n")
+	write("b.txt", "feature\n")
+	run("commit", "-am", "feature")
+	run("checkout", "main")
+	write("a.txt", "main\n")
+	write("b.txt", "main\n")
+	run("commit", "-am", "main")
+
+	conflicts, err := StartMerge(dir, "feature")
+	if err == nil || len(conflicts) != 2 || !MergeInProgress(dir) {
+		t.Fatalf("StartMerge() = %v, %v; merge in progress: %v", conflicts, err, MergeInProgress(dir))
+	}
+	files, err := ReadConflicts(dir)
+	if err != nil || len(files) != 2 || len(files[0].Hunks) != 1 {
+		t.Fatalf("ReadConflicts() = %+v, %v", files, err)
+	}
+
+	files[0].Hunks[0].Resolution = ResolveTheirs
+	if err := SaveResolution(dir, files[0]); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(data) != "feature\n" {
+		t.Errorf("a.txt = %q", data)
+	}
+	if err := FinishMerge(dir); err == nil {
+		t.Error("FinishMerge() with b.txt still conflicted")
+	}
+
+	// Resolving, restoring and resolving again
+	if err := ResolveFile(dir, "b.txt", ResolveOurs); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnresolveFile(dir, "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ConflictedFiles(dir); len(got) != 1 || got[0] != "b.txt" {
+		t.Fatalf("ConflictedFiles() after UnresolveFile() = %v", got)
+	}
+	if err := ResolveFile(dir, "b.txt", ResolveOurs); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := FinishMerge(dir); err != nil {
+		t.Fatal(err)
+	}
+	if MergeInProgress(dir) {
+		t.Error("merge still in progress after FinishMerge()")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "b.txt")); string(data) != "main\n" {
+		t.Errorf("b.txt = %q", data)
+	}
+
+	// Aborting restores the branch
+	run("checkout", "-b", "other", "HEAD~1")
+	write("a.txt", "other\n")
+	run("commit", "-am", "other")
+	run("checkout", "main")
+	if _, err := StartMerge(dir, "other"); err == nil {
+		t.Fatal("expected a conflict")
+	}
+	if err := AbortMerge(dir); err != nil || MergeInProgress(dir) {
+		t.Errorf("AbortMerge() = %v; merge in progress: %v", err, MergeInProgress(dir))
+	}
+}
+
+func TestResolveFileOnlyDeletesADeletedSide(t *testing.T) {
+	dir := initTestRepo(t)
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s", args, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name))
+		return err == nil
+	}
+
+	write("a.txt", "one\n")
+	write("b.txt", "one\n")
+	run("add", ".")
+	run("commit", "-m", "base")
+	run("checkout", "-b", "feature")
+	write("a.txt", "feature\n")
+	run("rm", "--quiet", "b.txt")
+	run("commit", "-am", "feature")
+	run("checkout", "main")
+	write("a.txt", "main\n")
+	write("b.txt", "main\n")
+	run("commit", "-am", "main")
+	if _, err := StartMerge(dir, "feature"); err == nil {
+		t.Fatal("expected a conflict")
+	}
+
+	// A checkout that fails for another reason, here a broken smudge
+	// filter, keeps the file's conflicts so it can be restored
+	run("config", "filter.broken.smudge", "false")
+	run("config", "filter.broken.required", "true")
+	attributes := filepath.Join(dir, ".git", "info", "attributes")
+	if err := os.WriteFile(attributes, []byte("a.txt filter=broken\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ResolveFile(dir, "a.txt", ResolveOurs); err == nil {
+		t.Error("ResolveFile() with a broken filter succeeded")
+	}
+	os.Remove(attributes)
+	if got := ConflictedFiles(dir); len(got) != 2 {
+		t.Fatalf("ConflictedFiles() after a failed checkout = %v", got)
+	}
+	if err := UnresolveFile(dir, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if !exists("a.txt") {
+		t.Fatal("a.txt is gone after a failed checkout")
+	}
+	if err := ResolveFile(dir, "typo.txt", ResolveTheirs); err == nil {
+		t.Error("ResolveFile() of a missing path succeeded")
+	}
+
+	// Taking the side that deleted the file deletes it
+	if err := ResolveFile(dir, "b.txt", ResolveTheirs); err != nil {
+		t.Fatal(err)
+	}
+	if exists("b.txt") {
+		t.Error("b.txt still exists after taking the side that deleted it")
+	}
+	if err := ResolveFile(dir, "a.txt", ResolveTheirs); err != nil {
+		t.Fatal(err)
+	}
+	if got := ConflictedFiles(dir); len(got) != 0 {
+		t.Errorf("ConflictedFiles() = %v", got)
+	}
+}
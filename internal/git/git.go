@@ -2,10 +2,16 @@
 package git
 
 import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // GetCurrentBranch returns the current git branch name for a directory.
@@ -126,6 +132,184 @@ func GetDiffStats(dir string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// Commit is a commit with its diff stats.
+type Commit struct {
+	Hash         string    `json:"hash"`
+	Subject      string    `json:"subject"`
+	Date         time.Time `json:"date"`
+	Stories      []string  `json:"stories,omitempty"` // From "Story:" trailers
+	FilesChanged int       `json:"filesChanged"`
+	Insertions   int       `json:"insertions"`
+	Deletions    int       `json:"deletions"`
+}
+
+// StoryTrailer is the commit trailer that names the story a commit belongs to.
+const StoryTrailer = "Story"
+
+// HasStory reports whether the commit belongs to a story: it has a Story
+// trailer naming it, or its subject mentions the story ID as a whole word.
+func (c Commit) HasStory(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, s := range c.Stories {
+		if s == id {
+			return true
+		}
+	}
+	isWord := func(b byte) bool {
+		return b == '_' || b == '-' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+	}
+	for from := 0; ; {
+		i := strings.Index(c.Subject[from:], id)
+		if i < 0 {
+			return false
+		}
+		start, end := from+i, from+i+len(id)
+		if (start == 0 || !isWord(c.Subject[start-1])) && (end == len(c.Subject) || !isWord(c.Subject[end])) {
+			return true
+		}
+		from = start + 1
+	}
+}
+
+// BranchCommits returns the commits on the current branch since it diverged
+// from the default branch, newest first. On a protected branch, or when no
+// merge base is found, the last limit commits are returned instead.
+func BranchCommits(dir string, limit int) ([]Commit, error) {
+	args := []string{"log", "--no-color", "--format=" + commitLogFormat, "--shortstat"}
+	rangeArg := ""
+	if branch, err := GetCurrentBranch(dir); err == nil && !IsProtectedBranch(branch) {
+		if baseBranch, err := GetDefaultBranch(dir); err == nil && baseBranch != "" {
+			if mergeBase, err := getMergeBase(dir, baseBranch, "HEAD"); err == nil && mergeBase != "" {
+				rangeArg = mergeBase + "..HEAD"
+			}
+		}
+	}
+	if rangeArg == "" {
+		args = append(args, "-n", strconv.Itoa(limit), "HEAD")
+	} else {
+		args = append(args, rangeArg)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseCommitLog(string(output)), nil
+}
+
+// StoryCommits returns the commits BranchCommits finds that belong to a
+// story, newest first.
+func StoryCommits(dir, storyID string, limit int) ([]Commit, error) {
+	commits, err := BranchCommits(dir, limit)
+	if err != nil {
+		return nil, err
+	}
+	var matched []Commit
+	for _, c := range commits {
+		if c.HasStory(storyID) {
+			matched = append(matched, c)
+		}
+	}
+	return matched, nil
+}
+
+// commitLogFormat is the `git log --format` parseCommitLog reads: hash, date,
+// subject and the values of the commit's Story trailers.
+const commitLogFormat = "%x1e%H%x1f%aI%x1f%s%x1f%(trailers:key=" + StoryTrailer + ",valueonly,separator=%x1d)"
+
+// parseCommitLog parses `git log --format=<commitLogFormat> --shortstat` output.
+func parseCommitLog(output string) []Commit {
+	var commits []Commit
+	for _, entry := range strings.Split(output, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(entry), "\n")
+		fields := strings.SplitN(lines[0], "\x1f", 4)
+		if len(fields) < 3 {
+			continue
+		}
+		c := Commit{Hash: fields[0], Subject: fields[2]}
+		c.Date, _ = time.Parse(time.RFC3339, fields[1])
+		if len(fields) == 4 {
+			for _, value := range strings.Split(fields[3], "\x1d") {
+				for _, id := range strings.Split(value, ",") {
+					if id = strings.TrimSpace(id); id != "" {
+						c.Stories = append(c.Stories, id)
+					}
+				}
+			}
+		}
+		for _, line := range lines[1:] {
+			// " 3 files changed, 10 insertions(+), 2 deletions(-)"
+			for _, part := range strings.Split(line, ",") {
+				var n int
+				var what string
+				if _, err := fmt.Sscanf(strings.TrimSpace(part), "%d %s", &n, &what); err != nil {
+					continue
+				}
+				switch {
+				case strings.HasPrefix(what, "file"):
+					c.FilesChanged = n
+				case strings.HasPrefix(what, "insertion"):
+					c.Insertions = n
+				case strings.HasPrefix(what, "deletion"):
+					c.Deletions = n
+				}
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
+
+// WorkingTreeHash returns an identifier for the current contents of the working tree.
+// It is the tree hash of HEAD, suffixed with a hash of the uncommitted diff and the
+// untracked files when the working tree is dirty. Untracked files are identified by
+// their size and modification time, which change as they are edited.
+func WorkingTreeHash(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel", "HEAD^{tree}")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return "", fmt.Errorf("unexpected rev-parse output: %q", output)
+	}
+	top, tree := fields[0], fields[1]
+
+	statusCmd := exec.Command("git", "status", "--porcelain", "-z", "--untracked-files=all")
+	statusCmd.Dir = dir
+	status, err := statusCmd.Output()
+	if err != nil {
+		return "", err
+	}
+	if len(bytes.TrimSpace(status)) == 0 {
+		return tree, nil
+	}
+
+	diffCmd := exec.Command("git", "diff", "HEAD", "--no-color")
+	diffCmd.Dir = dir
+	diff, _ := diffCmd.Output()
+
+	h := sha1.New()
+	h.Write(status)
+	h.Write(diff)
+	for _, entry := range bytes.Split(status, []byte{0}) {
+		path, ok := bytes.CutPrefix(entry, []byte("?? "))
+		if !ok {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(top, string(path))); err == nil {
+			fmt.Fprintf(h, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return tree + "+" + hex.EncodeToString(h.Sum(nil)[:8]), nil
+}
+
 // getMergeBase returns the merge base commit between two refs.
 func getMergeBase(dir, ref1, ref2 string) (string, error) {
 	cmd := exec.Command("git", "merge-base", ref1, ref2)
@@ -147,6 +331,92 @@ func getDiffOutput(dir, from, to string) (string, error) {
 	}
 	return string(output), nil
 }
+
+// HeadCommit returns the hash of the commit HEAD points to.
+func HeadCommit(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ChangedFiles returns the repository-relative paths of the files that
+// differ from the commit from: files changed since then, committed or not,
+// and untracked files that aren't ignored. An empty to compares with the
+// working tree; otherwise the files changed between from and to are returned.
+func ChangedFiles(dir, from, to string) ([]string, error) {
+	args := []string{"diff", "--name-only", from}
+	if to != "" {
+		args = append(args, to)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	files := splitLines(string(output))
+
+	if to == "" {
+		cmd = exec.Command("git", "ls-files", "--others", "--exclude-standard", "--full-name", "--", ":/")
+		cmd.Dir = dir
+		untracked, err := cmd.Output()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, splitLines(string(untracked))...)
+	}
+	return files, nil
+}
+
+// splitLines returns the non-empty lines of output.
+func splitLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// DiffRange returns the diff from the commit from to the commit to, or to the
+// working tree when to is empty, limited to paths when any are given. Paths
+// are relative to the repository root, as ChangedFiles returns them.
+func DiffRange(dir, from, to string, paths ...string) (string, error) {
+	args := []string{"diff", "--no-color", from}
+	if to != "" {
+		args = append(args, to)
+	}
+	if len(paths) > 0 {
+		args = append(args, "--")
+		for _, p := range paths {
+			args = append(args, ":(top)"+p)
+		}
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// CommitDiff returns the changes a single commit made.
+func CommitDiff(dir, hash string) (string, error) {
+	cmd := exec.Command("git", "show", "--no-color", "--format=", hash)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
 // CloneRepo clones a repository to the target directory.
 func CloneRepo(url, targetDir, token string) error {
 	cloneURL := url
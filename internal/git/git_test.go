@@ -1,8 +1,11 @@
 package git
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -150,3 +153,121 @@ func TestIsProtectedBranch(t *testing.T) {
 		})
 	}
 }
+
+func TestBranchCommits(t *testing.T) {
+	dir := initTestRepo(t)
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s", args, out)
+		}
+	}
+
+	run("checkout", "-b", "chief/demo")
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nfunc A() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", ".")
+	run("commit", "-m", "feat: US-001 - Add A")
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("commit", "-am", "fix: US-001 - Trim A")
+
+	commits, err := BranchCommits(dir, 10)
+	if err != nil {
+		t.Fatalf("BranchCommits() error: %v", err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected the 2 branch commits, got %+v", commits)
+	}
+	if commits[0].Subject != "fix: US-001 - Trim A" || commits[0].Deletions != 2 || commits[0].FilesChanged != 1 {
+		t.Errorf("unexpected newest commit: %+v", commits[0])
+	}
+	if commits[1].Insertions != 3 || commits[1].Date.IsZero() || len(commits[1].Hash) != 40 {
+		t.Errorf("unexpected oldest commit: %+v", commits[1])
+	}
+}
+
+func TestStoryCommits(t *testing.T) {
+	dir := initTestRepo(t)
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s", args, out)
+		}
+	}
+
+	run("checkout", "-b", "chief/demo")
+	for i, msg := range []string{
+		"feat: US-1 - Add A",
+		"feat: complete 2 stories\n\nStory: US-2\nStory: US-10",
+		"fix: US-10 - Tidy",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte(fmt.Sprintf("package a // %d\n", i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", ".")
+		run("commit", "-m", msg)
+	}
+
+	for id, want := range map[string]int{"US-1": 1, "US-2": 1, "US-10": 2, "US-3": 0} {
+		commits, err := StoryCommits(dir, id, 10)
+		if err != nil || len(commits) != want {
+			t.Errorf("StoryCommits(%s) = %+v, %v; want %d commits", id, commits, err, want)
+		}
+	}
+	commits, _ := StoryCommits(dir, "US-2", 10)
+	if len(commits) == 1 && strings.Join(commits[0].Stories, ",") != "US-2,US-10" {
+		t.Errorf("Stories = %v, want both trailers", commits[0].Stories)
+	}
+
+	diff, err := CommitDiff(dir, commits[0].Hash)
+	if err != nil || !strings.Contains(diff, "+package a // 1") || strings.Contains(diff, "complete") {
+		t.Errorf("CommitDiff() = %q, %v", diff, err)
+	}
+}
+
+func TestChangedFilesAndDiffRange(t *testing.T) {
+	dir := initTestRepo(t)
+	start, err := HeadCommit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"add", "a.go"}, {"commit", "-m", "add a"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s", args, out)
+		}
+	}
+	end, _ := HeadCommit(dir)
+	if err := os.WriteFile(filepath.Join(dir, "new file.txt"), []byte("x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	committed, err := ChangedFiles(dir, start, end)
+	if err != nil || len(committed) != 1 || committed[0] != "a.go" {
+		t.Errorf("ChangedFiles(start, end) = %v, %v", committed, err)
+	}
+	all, err := ChangedFiles(dir, start, "")
+	if err != nil || len(all) != 2 || all[1] != "new file.txt" {
+		t.Errorf("ChangedFiles(start, working tree) = %v, %v", all, err)
+	}
+
+	diff, err := DiffRange(dir, start, end, "a.go")
+	if err != nil || !strings.Contains(diff, "+package a") {
+		t.Errorf("DiffRange() = %q, %v", diff, err)
+	}
+	if diff, _ := DiffRange(dir, start, end, "README.md"); diff != "" {
+		t.Errorf("DiffRange() limited to an unchanged path = %q", diff)
+	}
+}
@@ -217,23 +217,14 @@ func DetectOrphanedWorktrees(baseDir string) map[string]string {
 }
 
 // MergeBranch merges a branch into the current branch, returning conflicting file list on failure.
+// A conflicted merge is aborted; use StartMerge to resolve the conflicts instead.
 func MergeBranch(repoDir, branch string) ([]string, error) {
-	cmd := exec.Command("git", "merge", branch)
-	cmd.Dir = repoDir
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		// Parse conflicting files from merge output
-		conflicts := parseConflicts(repoDir)
-		if len(conflicts) > 0 {
-			// Abort the merge to leave a clean state
-			abortCmd := exec.Command("git", "merge", "--abort")
-			abortCmd.Dir = repoDir
-			_ = abortCmd.Run()
-			return conflicts, fmt.Errorf("merge conflict: %s", strings.TrimSpace(string(out)))
-		}
-		return nil, fmt.Errorf("merge failed: %s", strings.TrimSpace(string(out)))
+	conflicts, err := StartMerge(repoDir, branch)
+	if len(conflicts) > 0 {
+		// Abort the merge to leave a clean state
+		_ = AbortMerge(repoDir)
 	}
-	return nil, nil
+	return conflicts, err
 }
 
 // parseConflicts uses `git diff --name-only --diff-filter=U` to find conflicting files.
@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// csvColumns maps normalized header names to item fields.
+var csvColumns = map[string]string{
+	"id":                  "id",
+	"key":                 "id",
+	"issue key":           "id",
+	"story id":            "id",
+	"title":               "title",
+	"summary":             "title",
+	"name":                "title",
+	"story":               "title",
+	"task":                "title",
+	"description":         "description",
+	"details":             "description",
+	"body":                "description",
+	"acceptance criteria": "criteria",
+	"acceptancecriteria":  "criteria",
+	"criteria":            "criteria",
+	"ac":                  "criteria",
+	"priority":            "priority",
+	"prio":                "priority",
+	"rank":                "priority",
+	"order":               "priority",
+	"status":              "status",
+	"state":               "status",
+	"depends on":          "dependsOn",
+	"dependson":           "dependsOn",
+	"dependencies":        "dependsOn",
+	"blocked by":          "dependsOn",
+}
+
+// parseCSV reads a CSV file with a header row. Recognized columns are matched
+// case-insensitively (see csvColumns); a title column is required and other
+// columns are ignored. Acceptance criteria cells hold one criterion per line,
+// or several separated by semicolons.
+func parseCSV(data []byte, _ Options, b *builder) error {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("CSV input is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, header := range records[0] {
+		name := strings.ToLower(strings.TrimSpace(header))
+		name = strings.NewReplacer("_", " ", "-", " ").Replace(name)
+		if field, ok := csvColumns[name]; ok {
+			if _, dup := columns[field]; !dup {
+				columns[field] = i
+			}
+		}
+	}
+	if _, ok := columns["title"]; !ok {
+		return fmt.Errorf("CSV header has no title column (expected one of title, summary, name, story or task)")
+	}
+
+	cell := func(record []string, field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	for n, record := range records[1:] {
+		it := item{
+			id:    cell(record, "id"),
+			title: cell(record, "title"),
+		}
+		it.rank, _ = priorityRank(cell(record, "priority"))
+		it.passes = isDone(cell(record, "status"))
+
+		description := cell(record, "description")
+		if _, ok := columns["criteria"]; ok {
+			it.description = description
+			it.criteria = splitCell(cell(record, "criteria"))
+		} else {
+			it.description, it.criteria = splitCriteria(description)
+		}
+		for _, dep := range strings.FieldsFunc(cell(record, "dependsOn"), func(r rune) bool { return r == ',' || r == ';' || r == ' ' }) {
+			it.dependsOn = append(it.dependsOn, dep)
+		}
+		b.add(it, fmt.Sprintf("row %d", n+2))
+	}
+	return nil
+}
+
+// splitCell splits a criteria cell into items: one per line, or by semicolons
+// for single-line cells. List markers are removed.
+func splitCell(value string) []string {
+	parts := strings.Split(value, "\n")
+	if len(parts) == 1 {
+		parts = strings.Split(value, ";")
+	}
+	var items []string
+	for _, part := range parts {
+		if entry, ok := listItem(part); ok {
+			part = entry.text
+		}
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
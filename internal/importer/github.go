@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// githubItem is an item from `gh project item-list --format json`. Project
+// fields such as Status and Priority appear as lowercased top-level keys.
+type githubItem struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Status  string   `json:"status"`
+	Labels  []string `json:"labels"`
+	Content struct {
+		Type   string `json:"type"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+		Number int    `json:"number"`
+	} `json:"content"`
+	Fields map[string]json.RawMessage `json:"-"`
+}
+
+var (
+	githubDependsRe = regexp.MustCompile(`(?i)\b(?:depends on|blocked by)\b:?((?:\s*,?\s*(?:and\s+)?#\d+)+)`)
+	githubIssueRe   = regexp.MustCompile(`#(\d+)`)
+)
+
+// parseGitHub reads a GitHub Projects export: either the {"items": [...]}
+// object written by `gh project item-list --format json` or a bare item array.
+// Issues get IDs like GH-42 and "Depends on #41" in a body becomes a dependency.
+func parseGitHub(data []byte, _ Options, b *builder) error {
+	var raw []json.RawMessage
+	var export struct {
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(data, &export); err == nil && export.Items != nil {
+		raw = export.Items
+	} else if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse GitHub Projects export: expected {\"items\": [...]} or an item array")
+	}
+
+	for n, msg := range raw {
+		var gi githubItem
+		if err := json.Unmarshal(msg, &gi); err != nil {
+			return fmt.Errorf("failed to parse GitHub Projects item %d: %w", n+1, err)
+		}
+		if err := json.Unmarshal(msg, &gi.Fields); err != nil {
+			return fmt.Errorf("failed to parse GitHub Projects item %d: %w", n+1, err)
+		}
+
+		it := item{title: gi.Title, rank: defaultRank, passes: isDone(gi.Status)}
+		if it.title == "" {
+			it.title = gi.Content.Title
+		}
+		if gi.Content.Number > 0 {
+			it.id = fmt.Sprintf("GH-%d", gi.Content.Number)
+		}
+		if rank, ok := githubPriority(gi); ok {
+			it.rank = rank
+		}
+		it.description, it.criteria = splitCriteria(gi.Content.Body)
+		for _, m := range githubDependsRe.FindAllStringSubmatch(gi.Content.Body, -1) {
+			for _, ref := range githubIssueRe.FindAllStringSubmatch(m[1], -1) {
+				it.dependsOn = append(it.dependsOn, "GH-"+ref[1])
+			}
+		}
+		b.add(it, fmt.Sprintf("item %d", n+1))
+	}
+	return nil
+}
+
+// githubPriority reads the rank from a "priority" project field, falling back
+// to labels such as "P1" or "priority: high".
+func githubPriority(gi githubItem) (int, bool) {
+	if raw, ok := gi.Fields["priority"]; ok {
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err == nil {
+			if rank, ok := priorityRank(strings.TrimSpace(fmt.Sprint(value))); ok {
+				return rank, true
+			}
+		}
+	}
+	for _, label := range gi.Labels {
+		if rank, ok := priorityRank(label); ok {
+			return rank, true
+		}
+	}
+	return defaultRank, false
+}
@@ -0,0 +1,355 @@
+// Package importer converts backlogs kept in external planning tools into
+// PRDs. Each adapter reads one export format (Markdown task checklists, CSV,
+// GitHub Projects JSON or Jira issue JSON) and maps its items to user stories
+// with priorities and acceptance criteria.
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/izdrail/chief/internal/prd"
+)
+
+// Format identifies an import format.
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown" // Markdown task checklist
+	FormatCSV      Format = "csv"      // CSV with a header row
+	FormatGitHub   Format = "github"   // gh project item-list --format json
+	FormatJira     Format = "jira"     // Jira REST search results or issue array
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{FormatMarkdown, FormatCSV, FormatGitHub, FormatJira}
+
+// Options configures an import.
+type Options struct {
+	Project        string // Project name, overriding the one found in the source
+	DefaultProject string // Project name used when the source does not name one
+}
+
+// Result is the outcome of an import.
+type Result struct {
+	PRD      *prd.PRD
+	Warnings []string // Items that were skipped or adjusted
+}
+
+// adapter parses one format into items.
+type adapter func(data []byte, opts Options, r *builder) error
+
+var adapters = map[Format]adapter{
+	FormatMarkdown: parseMarkdown,
+	FormatCSV:      parseCSV,
+	FormatGitHub:   parseGitHub,
+	FormatJira:     parseJira,
+}
+
+// ParseFormat returns the Format named by s.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "md":
+		return FormatMarkdown, nil
+	case FormatMarkdown, FormatCSV, FormatGitHub, FormatJira:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown import format %q (use markdown, csv, github or jira)", s)
+}
+
+// Detect guesses the format of a file from its extension and, for JSON, its shape.
+func Detect(path string, data []byte) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return FormatMarkdown, nil
+	case ".csv":
+		return FormatCSV, nil
+	case ".json":
+		var probe interface{}
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return "", fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		first := probe
+		if obj, ok := probe.(map[string]interface{}); ok {
+			if _, ok := obj["issues"]; ok {
+				return FormatJira, nil
+			}
+			if _, ok := obj["items"]; ok {
+				return FormatGitHub, nil
+			}
+		}
+		if arr, ok := probe.([]interface{}); ok && len(arr) > 0 {
+			first = arr[0]
+		}
+		if obj, ok := first.(map[string]interface{}); ok {
+			if _, ok := obj["fields"]; ok {
+				return FormatJira, nil
+			}
+			if _, ok := obj["content"]; ok {
+				return FormatGitHub, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("cannot detect the format of %s; pass --format", path)
+}
+
+// Import converts data in the given format into a PRD.
+func Import(format Format, data []byte, opts Options) (*Result, error) {
+	parse, ok := adapters[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %q", format)
+	}
+	b := &builder{}
+	if err := parse(data, opts, b); err != nil {
+		return nil, err
+	}
+	if len(b.items) == 0 {
+		return nil, fmt.Errorf("no stories found in %s input", format)
+	}
+	switch {
+	case opts.Project != "":
+		b.project = opts.Project
+	case b.project == "":
+		b.project = opts.DefaultProject
+	}
+	return b.build(), nil
+}
+
+// item is a story as read from the source, before IDs and priorities are assigned.
+type item struct {
+	id          string
+	title       string
+	description string
+	criteria    []string
+	rank        int // Relative priority from the source; lower comes first
+	passes      bool
+	dependsOn   []string
+}
+
+// builder collects items and turns them into a PRD.
+type builder struct {
+	project     string // Project name found in the source
+	description string
+	items       []item
+	warnings    []string
+}
+
+func (b *builder) warn(format string, args ...interface{}) {
+	b.warnings = append(b.warnings, fmt.Sprintf(format, args...))
+}
+
+// add appends an item, skipping ones without a title.
+func (b *builder) add(it item, where string) {
+	it.title = strings.TrimSpace(it.title)
+	if it.title == "" {
+		b.warn("%s: skipped item without a title", where)
+		return
+	}
+	b.items = append(b.items, it)
+}
+
+// build assigns IDs, priorities and fallback criteria and resolves dependencies.
+func (b *builder) build() *Result {
+	used := make(map[string]bool)
+	for i := range b.items {
+		if id := b.items[i].id; id != "" {
+			if used[id] {
+				b.warn("duplicate ID %s; generated a new one for %q", id, b.items[i].title)
+				b.items[i].id = ""
+				continue
+			}
+			used[id] = true
+		}
+	}
+	next := 1
+	for i := range b.items {
+		if b.items[i].id != "" {
+			continue
+		}
+		for used[fmt.Sprintf("US-%03d", next)] {
+			next++
+		}
+		b.items[i].id = fmt.Sprintf("US-%03d", next)
+		used[b.items[i].id] = true
+	}
+
+	// Priorities follow the source's ranking; ties keep document order
+	order := make([]int, len(b.items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return b.items[order[i]].rank < b.items[order[j]].rank })
+	priority := make([]int, len(b.items))
+	for p, i := range order {
+		priority[i] = p + 1
+	}
+
+	p := &prd.PRD{Project: b.project, Description: b.description}
+	if p.Project == "" {
+		p.Project = "Imported backlog"
+	}
+	for i, it := range b.items {
+		if len(it.criteria) == 0 {
+			b.warn("%s: no acceptance criteria found; using the title", it.id)
+			it.criteria = []string{it.title}
+		}
+		var deps []string
+		for _, dep := range it.dependsOn {
+			switch {
+			case dep == it.id:
+			case !used[dep]:
+				b.warn("%s: dropped dependency on %s, which is not in the import", it.id, dep)
+			default:
+				deps = append(deps, dep)
+			}
+		}
+		p.UserStories = append(p.UserStories, prd.UserStory{
+			ID:                 it.id,
+			Title:              it.title,
+			Description:        strings.TrimSpace(it.description),
+			AcceptanceCriteria: it.criteria,
+			Priority:           priority[i],
+			Passes:             it.passes,
+			DependsOn:          deps,
+		})
+	}
+	return &Result{PRD: p, Warnings: b.warnings}
+}
+
+// defaultRank is the rank of items without a priority, equal to "medium".
+const defaultRank = 3
+
+var pNumberRe = regexp.MustCompile(`^p(\d+)$`)
+
+// priorityRank maps a priority value from a planning tool to a rank.
+// Numbers and P0-P9 are used as-is; named levels map from blocker (0) to lowest (5).
+func priorityRank(value string) (int, bool) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	v = strings.TrimPrefix(v, "priority:")
+	v = strings.TrimSpace(v)
+	if n, err := strconv.Atoi(v); err == nil {
+		return n, true
+	}
+	if m := pNumberRe.FindStringSubmatch(v); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n, true
+	}
+	switch v {
+	case "blocker":
+		return 0, true
+	case "critical", "highest", "urgent":
+		return 1, true
+	case "high", "major":
+		return 2, true
+	case "medium", "normal":
+		return 3, true
+	case "low", "minor":
+		return 4, true
+	case "lowest", "trivial":
+		return 5, true
+	}
+	return defaultRank, false
+}
+
+// isDone reports whether a status value from a planning tool means the item is complete.
+func isDone(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "done", "closed", "complete", "completed", "resolved", "finished", "shipped":
+		return true
+	}
+	return false
+}
+
+var (
+	headingRe  = regexp.MustCompile(`^(#{1,6}\s+|h[1-6]\.\s+)`)
+	checkboxRe = regexp.MustCompile(`^\[[ xX]\]\s*`)
+	numberedRe = regexp.MustCompile(`^\d+[.)]\s+`)
+)
+
+// listEntry is a parsed Markdown list item.
+type listEntry struct {
+	text     string
+	checkbox bool // Item starts with "[ ]" or "[x]"
+	checked  bool // Item starts with "[x]"
+}
+
+// listItem parses a Markdown list item ("- ", "* ", "+ " or "1. "), with or without a checkbox.
+func listItem(line string) (listEntry, bool) {
+	line = strings.TrimSpace(line)
+	switch {
+	case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "* "), strings.HasPrefix(line, "+ "):
+		line = line[2:]
+	case numberedRe.MatchString(line):
+		line = numberedRe.ReplaceAllString(line, "")
+	default:
+		return listEntry{}, false
+	}
+	line = strings.TrimSpace(line)
+	entry := listEntry{text: line}
+	if m := checkboxRe.FindString(line); m != "" {
+		entry.checkbox = true
+		entry.checked = m[1] != ' '
+		entry.text = strings.TrimSpace(line[len(m):])
+	}
+	return entry, true
+}
+
+// isCriteriaHeading reports whether line introduces an acceptance criteria list,
+// e.g. "## Acceptance Criteria", "**Acceptance criteria:**" or "h3. Acceptance Criteria".
+func isCriteriaHeading(line string) bool {
+	text := headingRe.ReplaceAllString(strings.TrimSpace(line), "")
+	text = strings.Trim(text, "*_: ")
+	text = strings.ToLower(text)
+	return text == "acceptance criteria" || text == "acceptance criterion" || text == "definition of done"
+}
+
+// splitCriteria separates acceptance criteria from an issue body. Criteria are
+// the list items under an "Acceptance Criteria" heading or, without one, the
+// body's checkbox items. The remaining text is returned as the description.
+func splitCriteria(body string) (string, []string) {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+
+	var description, criteria []string
+	inSection := false
+	hasSection := false
+	for _, line := range lines {
+		if isCriteriaHeading(line) {
+			inSection, hasSection = true, true
+			continue
+		}
+		if inSection {
+			if headingRe.MatchString(strings.TrimSpace(line)) {
+				inSection = false
+			} else if entry, ok := listItem(line); ok {
+				if entry.text != "" {
+					criteria = append(criteria, entry.text)
+				}
+				continue
+			} else if strings.TrimSpace(line) == "" {
+				continue
+			} else {
+				inSection = false
+			}
+		}
+		description = append(description, line)
+	}
+
+	if !hasSection {
+		description = description[:0]
+		for _, line := range lines {
+			if entry, ok := listItem(line); ok && entry.checkbox {
+				if entry.text != "" {
+					criteria = append(criteria, entry.text)
+				}
+				continue
+			}
+			description = append(description, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(description, "\n")), criteria
+}
@@ -0,0 +1,224 @@
+package importer
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/izdrail/chief/internal/prd"
+)
+
+// importOrFail imports data and fails the test on error.
+func importOrFail(t *testing.T, format Format, data string) *Result {
+	t.Helper()
+	result, err := Import(format, []byte(data), Options{DefaultProject: "Fallback"})
+	if err != nil {
+		t.Fatalf("Import(%s) error: %v", format, err)
+	}
+	return result
+}
+
+// story returns the story with id, failing the test if it is missing.
+func story(t *testing.T, p *prd.PRD, id string) prd.UserStory {
+	t.Helper()
+	for _, s := range p.UserStories {
+		if s.ID == id {
+			return s
+		}
+	}
+	t.Fatalf("story %s not found in %+v", id, p.UserStories)
+	return prd.UserStory{}
+}
+
+func TestImport_Markdown(t *testing.T) {
+	result := importOrFail(t, FormatMarkdown, `# Auth System
+Login and registration.
+
+## Backlog
+- [ ] AUTH-1: Users can register
+  Uses the existing session middleware.
+  - Form has email and password fields
+  - [ ] Duplicate emails are rejected
+- [x] Users can log in (P0)
+- A plain bullet is not a task
+
+## Later
+- [ ] Password reset (low)
+`)
+	p := result.PRD
+	if p.Project != "Auth System" || p.Description != "Login and registration." {
+		t.Errorf("unexpected project/description: %q / %q", p.Project, p.Description)
+	}
+	if len(p.UserStories) != 3 {
+		t.Fatalf("expected 3 stories, got %+v", p.UserStories)
+	}
+
+	register := story(t, p, "AUTH-1")
+	if register.Title != "Users can register" || register.Description != "Uses the existing session middleware." {
+		t.Errorf("unexpected story: %+v", register)
+	}
+	if !reflect.DeepEqual(register.AcceptanceCriteria, []string{"Form has email and password fields", "Duplicate emails are rejected"}) {
+		t.Errorf("unexpected criteria: %v", register.AcceptanceCriteria)
+	}
+
+	login := story(t, p, "US-001")
+	if login.Title != "Users can log in" || !login.Passes || login.Priority != 1 {
+		t.Errorf("expected a passed, first-priority login story, got %+v", login)
+	}
+	if reset := story(t, p, "US-002"); reset.Priority != 3 {
+		t.Errorf("expected low priority story last, got %d", reset.Priority)
+	}
+}
+
+func TestImport_CSV(t *testing.T) {
+	result := importOrFail(t, FormatCSV, "Key,Summary,Acceptance_Criteria,Priority,Status,Depends On\n"+
+		"PROJ-1,Export data,\"- CSV download\n- JSON download\",Low,Done,\n"+
+		"PROJ-2,Import data,Upload works; Errors are shown,High,To Do,PROJ-1 PROJ-9\n"+
+		",,,,,\n")
+	p := result.PRD
+	if p.Project != "Fallback" || len(p.UserStories) != 2 {
+		t.Fatalf("unexpected PRD: %+v", p)
+	}
+
+	export := story(t, p, "PROJ-1")
+	if !export.Passes || export.Priority != 2 || !reflect.DeepEqual(export.AcceptanceCriteria, []string{"CSV download", "JSON download"}) {
+		t.Errorf("unexpected story: %+v", export)
+	}
+	imp := story(t, p, "PROJ-2")
+	if imp.Priority != 1 || !reflect.DeepEqual(imp.AcceptanceCriteria, []string{"Upload works", "Errors are shown"}) {
+		t.Errorf("unexpected story: %+v", imp)
+	}
+	if !reflect.DeepEqual(imp.DependsOn, []string{"PROJ-1"}) {
+		t.Errorf("expected unknown dependency to be dropped, got %v", imp.DependsOn)
+	}
+
+	warnings := strings.Join(result.Warnings, "\n")
+	for _, want := range []string{"row 4: skipped item without a title", "dropped dependency on PROJ-9"} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("expected warning %q, got:\n%s", want, warnings)
+		}
+	}
+}
+
+func TestImport_CSVRequiresTitle(t *testing.T) {
+	if _, err := Import(FormatCSV, []byte("id,notes\n1,x\n"), Options{}); err == nil {
+		t.Error("expected an error for a CSV without a title column")
+	}
+}
+
+func TestImport_GitHub(t *testing.T) {
+	result := importOrFail(t, FormatGitHub, `{"items": [
+  {"id": "PVTI_1", "title": "Search", "status": "Todo", "priority": "P2",
+   "content": {"type": "Issue", "number": 12, "title": "Search",
+     "body": "Full-text search.\n\nDepends on #11\n\n## Acceptance Criteria\n- [ ] Results are ranked\n- [ ] Empty query shows hint"}},
+  {"id": "PVTI_2", "title": "Index", "status": "Done", "labels": ["P1"],
+   "content": {"type": "Issue", "number": 11, "body": "- [x] Indexer runs nightly"}},
+  {"id": "PVTI_3", "title": "Draft idea", "content": {"type": "DraftIssue", "body": ""}}
+], "totalCount": 3}`)
+	p := result.PRD
+
+	search := story(t, p, "GH-12")
+	if !reflect.DeepEqual(search.AcceptanceCriteria, []string{"Results are ranked", "Empty query shows hint"}) {
+		t.Errorf("unexpected criteria: %v", search.AcceptanceCriteria)
+	}
+	if !reflect.DeepEqual(search.DependsOn, []string{"GH-11"}) || !strings.HasPrefix(search.Description, "Full-text search.") {
+		t.Errorf("unexpected story: %+v", search)
+	}
+
+	index := story(t, p, "GH-11")
+	if !index.Passes || index.Priority != 1 || !reflect.DeepEqual(index.AcceptanceCriteria, []string{"Indexer runs nightly"}) {
+		t.Errorf("unexpected story: %+v", index)
+	}
+
+	draft := story(t, p, "US-001")
+	if !reflect.DeepEqual(draft.AcceptanceCriteria, []string{"Draft idea"}) {
+		t.Errorf("expected the title as fallback criterion, got %v", draft.AcceptanceCriteria)
+	}
+}
+
+func TestImport_Jira(t *testing.T) {
+	result := importOrFail(t, FormatJira, `{"issues": [
+  {"key": "SHOP-1", "fields": {
+    "summary": "Checkout", "project": {"name": "Shop"},
+    "priority": {"name": "Highest"},
+    "status": {"name": "In Review", "statusCategory": {"key": "indeterminate"}},
+    "description": {"type": "doc", "content": [
+      {"type": "paragraph", "content": [{"type": "text", "text": "Pay for the cart."}]},
+      {"type": "heading", "attrs": {"level": 3}, "content": [{"type": "text", "text": "Acceptance Criteria"}]},
+      {"type": "bulletList", "content": [
+        {"type": "listItem", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "Card payments work"}]}]}
+      ]}
+    ]},
+    "subtasks": [{"key": "SHOP-3", "fields": {"summary": "Receipt is emailed"}}],
+    "issuelinks": [{"type": {"name": "Blocks", "inward": "is blocked by", "outward": "blocks"}, "inwardIssue": {"key": "SHOP-2"}}]
+  }},
+  {"key": "SHOP-2", "fields": {
+    "summary": "Cart", "priority": {"name": "Low"},
+    "status": {"name": "Closed", "statusCategory": {"key": "done"}},
+    "description": "Cart page.\n\nh3. Acceptance Criteria\n* Items can be removed"
+  }},
+  {"key": "SHOP-3", "fields": {"summary": "Receipt is emailed", "issuetype": {"name": "Sub-task", "subtask": true}, "parent": {"key": "SHOP-1"}}}
+]}`)
+	p := result.PRD
+	if p.Project != "Shop" {
+		t.Errorf("expected the Jira project name, got %q", p.Project)
+	}
+	if len(p.UserStories) != 2 {
+		t.Fatalf("expected the sub-task to be folded into its parent, got %+v", p.UserStories)
+	}
+
+	checkout := story(t, p, "SHOP-1")
+	if checkout.Description != "Pay for the cart." || checkout.Priority != 1 || checkout.Passes {
+		t.Errorf("unexpected story: %+v", checkout)
+	}
+	if !reflect.DeepEqual(checkout.AcceptanceCriteria, []string{"Card payments work", "Receipt is emailed"}) {
+		t.Errorf("unexpected criteria: %v", checkout.AcceptanceCriteria)
+	}
+	if !reflect.DeepEqual(checkout.DependsOn, []string{"SHOP-2"}) {
+		t.Errorf("unexpected dependencies: %v", checkout.DependsOn)
+	}
+
+	cart := story(t, p, "SHOP-2")
+	if !cart.Passes || cart.Description != "Cart page." || !reflect.DeepEqual(cart.AcceptanceCriteria, []string{"Items can be removed"}) {
+		t.Errorf("unexpected story: %+v", cart)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		path string
+		data string
+		want Format
+	}{
+		{"backlog.md", "", FormatMarkdown},
+		{"backlog.CSV", "", FormatCSV},
+		{"export.json", `{"issues": []}`, FormatJira},
+		{"export.json", `[{"key": "A-1", "fields": {}}]`, FormatJira},
+		{"project.json", `{"items": [], "totalCount": 0}`, FormatGitHub},
+		{"project.json", `[{"content": {}}]`, FormatGitHub},
+	}
+	for _, tt := range tests {
+		got, err := Detect(tt.path, []byte(tt.data))
+		if err != nil || got != tt.want {
+			t.Errorf("Detect(%s, %s) = %q, %v; want %q", tt.path, tt.data, got, err, tt.want)
+		}
+	}
+	if _, err := Detect("notes.txt", nil); err == nil {
+		t.Error("expected an error for an unknown extension")
+	}
+}
+
+func TestImport_PassesLint(t *testing.T) {
+	result := importOrFail(t, FormatMarkdown, "- [ ] One\n- [ ] Two\n- [x] Three (high)\n")
+	data, err := json.Marshal(result.PRD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diags := prd.LintData("prd.json", data); len(diags) != 0 {
+		t.Errorf("expected imported PRD to pass lint, got %v", diags)
+	}
+	if result.PRD.Project != "Fallback" {
+		t.Errorf("expected the default project name, got %q", result.PRD.Project)
+	}
+}
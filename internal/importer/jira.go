@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jiraIssue is an issue from the Jira REST API (/rest/api/2 or /3 search results).
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string          `json:"summary"`
+		Description json.RawMessage `json:"description"` // Wiki text (v2) or an ADF document (v3)
+		Priority    *struct {
+			Name string `json:"name"`
+		} `json:"priority"`
+		Status *struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+		IssueType *struct {
+			Name    string `json:"name"`
+			Subtask bool   `json:"subtask"`
+		} `json:"issuetype"`
+		Parent *struct {
+			Key string `json:"key"`
+		} `json:"parent"`
+		Project *struct {
+			Name string `json:"name"`
+		} `json:"project"`
+		Subtasks []struct {
+			Key    string `json:"key"`
+			Fields struct {
+				Summary string `json:"summary"`
+			} `json:"fields"`
+		} `json:"subtasks"`
+		IssueLinks []struct {
+			Type struct {
+				Name    string `json:"name"`
+				Inward  string `json:"inward"`
+				Outward string `json:"outward"`
+			} `json:"type"`
+			InwardIssue *struct {
+				Key string `json:"key"`
+			} `json:"inwardIssue"`
+			OutwardIssue *struct {
+				Key string `json:"key"`
+			} `json:"outwardIssue"`
+		} `json:"issuelinks"`
+	} `json:"fields"`
+}
+
+// parseJira reads Jira issues: a search response ({"issues": [...]}) or a bare
+// issue array. Issue keys become story IDs, sub-tasks become acceptance
+// criteria of their parent, and "is blocked by" links become dependencies.
+func parseJira(data []byte, _ Options, b *builder) error {
+	var issues []jiraIssue
+	var search struct {
+		Issues []jiraIssue `json:"issues"`
+	}
+	if err := json.Unmarshal(data, &search); err == nil && search.Issues != nil {
+		issues = search.Issues
+	} else if err := json.Unmarshal(data, &issues); err != nil {
+		return fmt.Errorf("failed to parse Jira export: expected {\"issues\": [...]} or an issue array")
+	}
+
+	keys := make(map[string]bool)
+	for _, issue := range issues {
+		keys[issue.Key] = true
+	}
+
+	// "A blocks B" may be recorded on either issue; collect both directions
+	blockedBy := make(map[string][]string)
+	for _, issue := range issues {
+		for _, link := range issue.Fields.IssueLinks {
+			if !isBlockingLink(link.Type.Name, link.Type.Inward) {
+				continue
+			}
+			if link.InwardIssue != nil {
+				blockedBy[issue.Key] = appendUnique(blockedBy[issue.Key], link.InwardIssue.Key)
+			}
+			if link.OutwardIssue != nil {
+				blockedBy[link.OutwardIssue.Key] = appendUnique(blockedBy[link.OutwardIssue.Key], issue.Key)
+			}
+		}
+	}
+
+	for n, issue := range issues {
+		f := issue.Fields
+		if f.IssueType != nil && f.IssueType.Subtask && f.Parent != nil && keys[f.Parent.Key] {
+			continue // Imported as a criterion of its parent
+		}
+		if b.project == "" && f.Project != nil {
+			b.project = f.Project.Name
+		}
+
+		it := item{id: issue.Key, title: f.Summary, rank: defaultRank, dependsOn: blockedBy[issue.Key]}
+		if f.Priority != nil {
+			it.rank, _ = priorityRank(f.Priority.Name)
+		}
+		if f.Status != nil {
+			it.passes = f.Status.StatusCategory.Key == "done" || isDone(f.Status.Name)
+		}
+		it.description, it.criteria = splitCriteria(jiraDescription(f.Description))
+		for _, sub := range f.Subtasks {
+			it.criteria = append(it.criteria, sub.Fields.Summary)
+		}
+		b.add(it, fmt.Sprintf("issue %d", n+1))
+	}
+	return nil
+}
+
+// isBlockingLink reports whether a Jira link type expresses a blocking dependency.
+func isBlockingLink(name, inward string) bool {
+	return strings.EqualFold(name, "Blocks") || strings.Contains(strings.ToLower(inward), "blocked by") ||
+		strings.Contains(strings.ToLower(inward), "depends on")
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// jiraDescription returns a description as text, rendering Atlassian Document
+// Format documents to Markdown-like text.
+func jiraDescription(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	var doc adfNode
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return ""
+	}
+	var b strings.Builder
+	renderADF(&b, doc, "")
+	return strings.TrimSpace(b.String())
+}
+
+// adfNode is a node of an Atlassian Document Format document.
+type adfNode struct {
+	Type    string                 `json:"type"`
+	Text    string                 `json:"text"`
+	Attrs   map[string]interface{} `json:"attrs"`
+	Content []adfNode              `json:"content"`
+}
+
+// renderADF writes the text of an ADF node; prefix is the list marker for list items.
+func renderADF(b *strings.Builder, node adfNode, prefix string) {
+	switch node.Type {
+	case "text":
+		b.WriteString(node.Text)
+	case "hardBreak":
+		b.WriteString("\n")
+	case "heading":
+		level := 2
+		if l, ok := node.Attrs["level"].(float64); ok {
+			level = int(l)
+		}
+		b.WriteString(strings.Repeat("#", level) + " ")
+		renderADFChildren(b, node, "")
+		b.WriteString("\n")
+	case "paragraph", "codeBlock":
+		b.WriteString(prefix)
+		renderADFChildren(b, node, "")
+		b.WriteString("\n")
+	case "bulletList", "orderedList":
+		for _, child := range node.Content {
+			renderADF(b, child, "- ")
+		}
+	case "taskList":
+		for _, child := range node.Content {
+			marker := "- [ ] "
+			if child.Attrs["state"] == "DONE" {
+				marker = "- [x] "
+			}
+			b.WriteString(marker)
+			renderADFChildren(b, child, "")
+			b.WriteString("\n")
+		}
+	case "listItem":
+		for i, child := range node.Content {
+			if i == 0 {
+				renderADF(b, child, prefix)
+			} else {
+				renderADF(b, child, "  ")
+			}
+		}
+	default:
+		renderADFChildren(b, node, prefix)
+	}
+}
+
+func renderADFChildren(b *strings.Builder, node adfNode, prefix string) {
+	for _, child := range node.Content {
+		renderADF(b, child, prefix)
+	}
+}
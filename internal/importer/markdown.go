@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Markdown task checklists
+//
+//	# Project Name
+//	Optional description.
+//
+//	## Any section
+//	- [ ] AUTH-1: Users can register
+//	  Free text indented under an item is its description.
+//	  - Form has email and password fields
+//	  - Duplicate emails are rejected
+//	- [x] Users can log in (P1)
+//
+// Every top-level checkbox item becomes a story, checked items pass, and the
+// list items nested under it are its acceptance criteria. An optional leading
+// "ID:" sets the story ID, and a trailing "(P1)" or "(high)" its priority;
+// otherwise stories are prioritized in document order.
+
+var (
+	checklistIDRe       = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9]*-\d+)\s*[:—–-]\s*(.+)$`)
+	checklistPriorityRe = regexp.MustCompile(`\s*\(([^()]+)\)\s*$`)
+)
+
+func parseMarkdown(data []byte, _ Options, b *builder) error {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	var intro []string
+	var current *item
+	var currentLine int
+	seenSection := false // Past the title and introduction
+	titled := false
+	inFence := false
+
+	flush := func() {
+		if current != nil {
+			b.add(*current, fmt.Sprintf("line %d", currentLine))
+			current = nil
+		}
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		indented := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+
+		if !inFence && !indented {
+			if strings.HasPrefix(trimmed, "# ") && !seenSection && !titled {
+				b.project = strings.TrimSpace(trimmed[2:])
+				titled = true
+				continue
+			}
+			if headingRe.MatchString(trimmed) {
+				flush()
+				seenSection = true
+				continue
+			}
+			if entry, ok := listItem(trimmed); ok {
+				flush()
+				seenSection = true
+				if !entry.checkbox {
+					continue
+				}
+				it := item{title: entry.text, passes: entry.checked, rank: defaultRank}
+				if m := checklistPriorityRe.FindStringSubmatch(it.title); m != nil {
+					if rank, ok := priorityRank(m[1]); ok {
+						it.rank = rank
+						it.title = strings.TrimSpace(strings.TrimSuffix(it.title, m[0]))
+					}
+				}
+				if m := checklistIDRe.FindStringSubmatch(it.title); m != nil {
+					it.id, it.title = m[1], m[2]
+				}
+				current, currentLine = &it, i+1
+				continue
+			}
+		}
+
+		switch {
+		case current != nil && indented:
+			if entry, ok := listItem(trimmed); ok && !inFence {
+				if entry.text != "" {
+					current.criteria = append(current.criteria, entry.text)
+				}
+			} else {
+				current.description += trimmed + "\n"
+			}
+		case trimmed == "":
+		case !seenSection:
+			intro = append(intro, trimmed)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	b.description = strings.TrimSpace(strings.Join(intro, "\n"))
+	return nil
+}
@@ -0,0 +1,182 @@
+// Package learnings ranks, formats and exports the codebase learnings that
+// agents record through the RecordLearning tool. Learnings are stored in the
+// SQLite store; progress.md is generated from them for humans to read.
+package learnings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/prd"
+)
+
+// DefaultLimit is the number of learnings rendered into each iteration prompt.
+const DefaultLimit = 15
+
+// generatedMarker is the first line of a progress.md written by Export. Files
+// are recognized by its prefix, which is the same in older versions.
+const (
+	generatedPrefix = "<!-- Generated by Chief from the learnings store."
+	generatedMarker = generatedPrefix + " Edits above the Notes section are overwritten; record learnings with the RecordLearning tool. -->"
+)
+
+// notesMarker starts the part of a generated progress.md that belongs to its
+// readers; Export keeps everything after it.
+const notesMarker = "<!-- Notes below this line are kept when Chief regenerates this file. -->"
+
+// Top returns up to n learnings ranked by relevance to story: learnings recorded
+// for the story and learnings whose tags appear in the story text rank first,
+// then frequently recorded ones, then newer ones.
+func Top(all []db.Learning, story *prd.UserStory, n int) []db.Learning {
+	storyText := ""
+	if story != nil {
+		storyText = strings.ToLower(story.Title + " " + story.Description + " " + strings.Join(story.AcceptanceCriteria, " "))
+	}
+
+	score := func(l db.Learning) int {
+		s := l.Occurrences
+		if story != nil {
+			for _, id := range l.StoryIDs {
+				if id == story.ID {
+					s += 5
+				}
+			}
+			for _, tag := range l.Tags {
+				if tag != "" && strings.Contains(storyText, strings.ToLower(tag)) {
+					s += 3
+				}
+			}
+		}
+		return s
+	}
+
+	ranked := make([]db.Learning, len(all))
+	copy(ranked, all)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		si, sj := score(ranked[i]), score(ranked[j])
+		if si != sj {
+			return si > sj
+		}
+		return ranked[i].ID > ranked[j].ID
+	})
+	if n > 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// Format renders learnings as a Markdown bullet list.
+func Format(learnings []db.Learning) string {
+	var b strings.Builder
+	for _, l := range learnings {
+		b.WriteString("- " + l.Content)
+		if len(l.Tags) > 0 {
+			b.WriteString(" (tags: " + strings.Join(l.Tags, ", ") + ")")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Export writes every learning for prdName to progress.md in prdDir. A
+// progress.md that was not generated by Chief has its Codebase Patterns
+// imported into the store first and is preserved as progress.legacy.md. The
+// Notes section at the end of a generated progress.md is kept as it is.
+func Export(store *db.Store, prdName, prdDir string) error {
+	progressPath := filepath.Join(prdDir, "progress.md")
+	notes := "## Notes\n"
+	if data, err := os.ReadFile(progressPath); err == nil && !strings.HasPrefix(string(data), generatedPrefix) {
+		if err := importLegacy(store, prdName, string(data)); err != nil {
+			return err
+		}
+		legacyPath := filepath.Join(prdDir, "progress.legacy.md")
+		if _, err := os.Stat(legacyPath); os.IsNotExist(err) {
+			if err := os.WriteFile(legacyPath, data, 0644); err != nil {
+				return fmt.Errorf("failed to preserve progress.md: %w", err)
+			}
+		}
+	} else if _, after, found := strings.Cut(string(data), notesMarker+"\n"); found {
+		notes = after
+	}
+
+	all, err := store.GetLearnings(prdName)
+	if err != nil {
+		return fmt.Errorf("failed to load learnings: %w", err)
+	}
+	content := render(prdName, all) + "\n" + notesMarker + "\n" + notes
+	if err := os.WriteFile(progressPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write progress.md: %w", err)
+	}
+	return nil
+}
+
+// render builds the progress.md contents.
+func render(prdName string, all []db.Learning) string {
+	var b strings.Builder
+	b.WriteString(generatedMarker + "\n")
+	b.WriteString("# Progress: " + prdName + "\n\n")
+	b.WriteString("## Codebase Patterns\n\n")
+	if len(all) == 0 {
+		b.WriteString("No learnings recorded yet.\n")
+		return b.String()
+	}
+
+	byFrequency := make([]db.Learning, len(all))
+	copy(byFrequency, all)
+	sort.SliceStable(byFrequency, func(i, j int) bool {
+		return byFrequency[i].Occurrences > byFrequency[j].Occurrences
+	})
+	b.WriteString(Format(byFrequency) + "\n")
+
+	byStory := make(map[string][]db.Learning)
+	var storyIDs []string
+	for _, l := range all {
+		for _, id := range l.StoryIDs {
+			if _, ok := byStory[id]; !ok {
+				storyIDs = append(storyIDs, id)
+			}
+			byStory[id] = append(byStory[id], l)
+		}
+	}
+	if len(storyIDs) == 0 {
+		return b.String()
+	}
+	sort.Strings(storyIDs)
+
+	b.WriteString("\n## Learnings by Story\n")
+	for _, id := range storyIDs {
+		b.WriteString("\n### " + id + "\n\n")
+		b.WriteString(Format(byStory[id]) + "\n")
+	}
+	return b.String()
+}
+
+// importLegacy records the bullets of a hand-written Codebase Patterns section.
+func importLegacy(store *db.Store, prdName, progress string) error {
+	inSection := false
+	for _, line := range strings.Split(progress, "\n") {
+		if strings.HasPrefix(line, "## ") {
+			inSection = strings.TrimSpace(strings.TrimPrefix(line, "## ")) == "Codebase Patterns"
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		item := strings.TrimSpace(line)
+		if !strings.HasPrefix(item, "- ") && !strings.HasPrefix(item, "* ") {
+			continue
+		}
+		item = strings.TrimSpace(item[2:])
+		if item == "" {
+			continue
+		}
+		if _, err := store.AddLearning(prdName, "", item, []string{"imported"}); err != nil {
+			return fmt.Errorf("failed to import learning: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package learnings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/prd"
+)
+
+func newTestStore(t *testing.T) *db.Store {
+	t.Helper()
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "chief.db"))
+	if err != nil {
+		t.Fatalf("NewStore() error: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestAddLearning_Deduplicates(t *testing.T) {
+	store := newTestStore(t)
+
+	added, err := store.AddLearning("auth", "US-1", "Use sqlx for queries.", []string{"database"})
+	if err != nil || !added {
+		t.Fatalf("expected first learning to be added, got %v %v", added, err)
+	}
+	added, err = store.AddLearning("auth", "US-2", "  use SQLX for queries ", []string{"Database", "sql"})
+	if err != nil || added {
+		t.Fatalf("expected duplicate to be merged, got %v %v", added, err)
+	}
+	if _, err := store.AddLearning("other", "", "Use sqlx for queries.", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := store.GetLearnings("auth")
+	if err != nil {
+		t.Fatalf("GetLearnings() error: %v", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("expected 1 learning, got %d", len(all))
+	}
+	l := all[0]
+	if l.Content != "Use sqlx for queries." || l.Occurrences != 2 {
+		t.Errorf("unexpected learning: %+v", l)
+	}
+	if strings.Join(l.Tags, ",") != "database,sql" || strings.Join(l.StoryIDs, ",") != "US-1,US-2" {
+		t.Errorf("expected merged tags and stories, got %v %v", l.Tags, l.StoryIDs)
+	}
+}
+
+func TestTop_RanksByRelevance(t *testing.T) {
+	all := []db.Learning{
+		{ID: 1, Content: "frequent", Occurrences: 3},
+		{ID: 2, Content: "tagged", Occurrences: 1, Tags: []string{"login"}},
+		{ID: 3, Content: "same story", Occurrences: 1, StoryIDs: []string{"US-2"}},
+		{ID: 4, Content: "newest", Occurrences: 1},
+	}
+	story := &prd.UserStory{ID: "US-2", Title: "Login form"}
+
+	top := Top(all, story, 3)
+	var got []string
+	for _, l := range top {
+		got = append(got, l.Content)
+	}
+	if strings.Join(got, ",") != "same story,tagged,frequent" {
+		t.Errorf("unexpected ranking: %v", got)
+	}
+
+	if out := Format(top[1:2]); out != "- tagged (tags: login)" {
+		t.Errorf("unexpected format: %q", out)
+	}
+}
+
+func TestExport_ImportsLegacyProgress(t *testing.T) {
+	store := newTestStore(t)
+	dir := t.TempDir()
+	legacy := "# Progress\n\n## Codebase Patterns\n- Run make test before committing\n- Views live in ui/\n\n## 2024-01-01 - US-1\n- Built the thing\n"
+	if err := os.WriteFile(filepath.Join(dir, "progress.md"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.AddLearning("main", "US-1", "Migrations are idempotent", []string{"db"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Export(store, "main", dir); err != nil {
+		t.Fatalf("Export() error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "progress.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	for _, want := range []string{generatedMarker, "- Run make test before committing (tags: imported)", "- Views live in ui/", "### US-1\n\n- Migrations are idempotent (tags: db)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected progress.md to contain %q, got:\n%s", want, out)
+		}
+	}
+	if saved, err := os.ReadFile(filepath.Join(dir, "progress.legacy.md")); err != nil || string(saved) != legacy {
+		t.Errorf("expected legacy progress.md to be preserved, got %q (%v)", saved, err)
+	}
+
+	// A second export must not import the generated file again
+	if err := Export(store, "main", dir); err != nil {
+		t.Fatal(err)
+	}
+	all, _ := store.GetLearnings("main")
+	if len(all) != 3 {
+		t.Errorf("expected 3 learnings after re-export, got %d", len(all))
+	}
+	for _, l := range all {
+		if l.Occurrences != 1 {
+			t.Errorf("expected no re-imports, got %+v", l)
+		}
+	}
+}
+
+func TestExport_KeepsNotes(t *testing.T) {
+	store := newTestStore(t)
+	dir := t.TempDir()
+	if err := Export(store, "main", dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Notes added by hand survive the next export; edits above them don't
+	path := filepath.Join(dir, "progress.md")
+	data, _ := os.ReadFile(path)
+	edited := strings.Replace(string(data), "No learnings recorded yet.", "Edited.", 1) + "Deploys need a VPN.\n"
+	if err := os.WriteFile(path, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.AddLearning("main", "US-1", "Use the fake clock", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Export(store, "main", dir); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ = os.ReadFile(path)
+	out := string(data)
+	if !strings.HasSuffix(out, notesMarker+"\n## Notes\nDeploys need a VPN.\n") {
+		t.Errorf("notes not kept:\n%s", out)
+	}
+	if strings.Contains(out, "Edited.") || !strings.Contains(out, "- Use the fake clock") {
+		t.Errorf("learnings not regenerated:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "progress.legacy.md")); !os.IsNotExist(err) {
+		t.Error("a generated progress.md was treated as hand-written")
+	}
+}
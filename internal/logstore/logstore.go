@@ -0,0 +1,476 @@
+// Package logstore keeps append-only logs on disk so long runs don't hold
+// every line in memory. A Store writes records to segment files and keeps only
+// the newest records, and a few recently read pages, in memory; older records
+// are read back from disk when a viewer scrolls to them. Old segments are
+// deleted once there are more than the store is allowed to keep.
+package logstore
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Defaults for the zero values of Options.
+const (
+	DefaultSegmentBytes = 4 << 20
+	DefaultWindow       = 1000
+)
+
+// segmentExt is the extension of segment files.
+const segmentExt = ".log"
+
+// pageSize is the number of records read from disk at a time, and pageCache
+// the number of pages kept.
+const (
+	pageSize  = 64
+	pageCache = 8
+)
+
+// ErrGone is returned for records that retention has deleted.
+var ErrGone = errors.New("log record no longer stored")
+
+// Options configures a Store.
+type Options struct {
+	SegmentBytes int64 // Size a segment grows to before the next is started; 0 uses DefaultSegmentBytes
+	MaxSegments  int   // Segments kept on disk, oldest deleted first; 0 keeps all
+	Window       int   // Newest records kept in memory; 0 uses DefaultWindow
+}
+
+// Store is an append-only log of records. Records are single lines, such as
+// JSON documents, addressed by index: the first record appended has index 0.
+// A Store is safe for concurrent use.
+type Store struct {
+	dir  string // "" keeps records in memory only, up to the window
+	temp bool   // Remove dir on Close
+	opts Options
+
+	mu       sync.Mutex
+	segments []*segment // Oldest first; the last is open for appending
+	file     *os.File   // The last segment
+	nextSeq  int        // Number of the next segment file
+	first    int        // Index of the oldest stored record
+	n        int        // Index the next record gets
+	window   [][]byte   // The newest records, window[i] has index n-len(window)+i
+	pages    map[int][][]byte
+	lru      []int // Cached page numbers, least recently used first
+}
+
+// segment is one file of records.
+type segment struct {
+	path    string
+	first   int     // Index of its first record
+	offsets []int64 // Where each record starts
+	size    int64
+}
+
+// Open opens the store in dir, creating it if needed. Records already in dir
+// are kept and numbered from 0.
+func Open(dir string, opts Options) (*Store, error) {
+	s := newStore(dir, opts)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenTemp opens a store in a new temporary directory that Close removes.
+func OpenTemp(opts Options) (*Store, error) {
+	dir, err := os.MkdirTemp("", "chief-log-")
+	if err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	s, err := Open(dir, opts)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	s.temp = true
+	return s, nil
+}
+
+// NewMemory returns a store that keeps only the newest opts.Window records,
+// for when there's nowhere to write.
+func NewMemory(opts Options) *Store {
+	return newStore("", opts)
+}
+
+func newStore(dir string, opts Options) *Store {
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = DefaultSegmentBytes
+	}
+	if opts.Window <= 0 {
+		opts.Window = DefaultWindow
+	}
+	return &Store{dir: dir, opts: opts, pages: make(map[int][][]byte)}
+}
+
+// load indexes the segments already in the directory and fills the window
+// from the newest of them.
+func (s *Store) load() error {
+	names, err := filepath.Glob(filepath.Join(s.dir, "*"+segmentExt))
+	if err != nil {
+		return err
+	}
+	type numbered struct {
+		seq  int
+		path string
+	}
+	var files []numbered
+	for _, name := range names {
+		seq, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(name), segmentExt))
+		if err != nil {
+			continue
+		}
+		files = append(files, numbered{seq, name})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].seq < files[j].seq })
+
+	for _, f := range files {
+		seg := &segment{path: f.path, first: s.n}
+		if err := seg.index(); err != nil {
+			return err
+		}
+		s.segments = append(s.segments, seg)
+		s.n += len(seg.offsets)
+		s.nextSeq = f.seq + 1
+	}
+	s.prune()
+
+	// Refill the window from disk
+	from := max(s.first, s.n-s.opts.Window)
+	return s.scan(from, s.n, func(_ int, data []byte) error {
+		s.window = append(s.window, data)
+		return nil
+	})
+}
+
+// index records where each line of a segment file starts. A last line
+// without a newline was cut short by a crash; it isn't indexed and the next
+// append overwrites it.
+func (seg *segment) index() error {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			seg.size = offset
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		seg.offsets = append(seg.offsets, offset)
+		offset += int64(len(line))
+	}
+}
+
+// Append adds a record and returns its index. Records must not contain
+// newlines.
+func (s *Store) Append(data []byte) (int, error) {
+	if bytes.IndexByte(data, '\n') >= 0 {
+		return 0, fmt.Errorf("log record contains a newline")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dir != "" {
+		if err := s.write(data); err != nil {
+			return 0, err
+		}
+	}
+
+	s.window = append(s.window, append([]byte(nil), data...))
+	if len(s.window) > s.opts.Window {
+		// Copy when the slice has doubled so evicted records can be freed
+		drop := len(s.window) - s.opts.Window
+		if cap(s.window) > 2*s.opts.Window {
+			s.window = append([][]byte(nil), s.window[drop:]...)
+		} else {
+			s.window = s.window[drop:]
+		}
+	}
+	index := s.n
+	s.n++
+	if s.dir == "" {
+		s.first = s.n - len(s.window)
+	}
+	return index, nil
+}
+
+// write appends a record to the last segment, starting a new one when it's
+// full.
+func (s *Store) write(data []byte) error {
+	last := len(s.segments) - 1
+	if s.file == nil || s.segments[last].size >= s.opts.SegmentBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+		last = len(s.segments) - 1
+	}
+	seg := s.segments[last]
+	if _, err := s.file.WriteAt(append(data, '\n'), seg.size); err != nil {
+		return fmt.Errorf("write log: %w", err)
+	}
+	seg.offsets = append(seg.offsets, seg.size)
+	seg.size += int64(len(data)) + 1
+	return nil
+}
+
+// rotate opens the last segment for appending, or starts a new one when it's
+// full, and deletes segments beyond MaxSegments.
+func (s *Store) rotate() error {
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	if n := len(s.segments); n > 0 && s.segments[n-1].size < s.opts.SegmentBytes {
+		f, err := os.OpenFile(s.segments[n-1].path, os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("open log segment: %w", err)
+		}
+		if err := f.Truncate(s.segments[n-1].size); err != nil {
+			f.Close()
+			return fmt.Errorf("open log segment: %w", err)
+		}
+		s.file = f
+		return nil
+	}
+
+	path := filepath.Join(s.dir, fmt.Sprintf("%08d%s", s.nextSeq, segmentExt))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("create log segment: %w", err)
+	}
+	s.nextSeq++
+	s.file = f
+	s.segments = append(s.segments, &segment{path: path, first: s.n})
+	s.prune()
+	return nil
+}
+
+// prune deletes the oldest segments beyond MaxSegments.
+func (s *Store) prune() {
+	for s.opts.MaxSegments > 0 && len(s.segments) > s.opts.MaxSegments {
+		os.Remove(s.segments[0].path)
+		s.segments = s.segments[1:]
+	}
+	if len(s.segments) > 0 {
+		s.first = s.segments[0].first
+	} else {
+		s.first = s.n
+	}
+	s.pages = make(map[int][][]byte)
+	s.lru = nil
+}
+
+// Len returns the index the next record gets: the number of records ever
+// appended, including those retention has deleted.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.n
+}
+
+// First returns the index of the oldest record still stored.
+func (s *Store) First() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.first
+}
+
+// Get returns the record at index i, from memory when it's recent and from
+// disk otherwise.
+func (s *Store) Get(i int) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(i)
+}
+
+func (s *Store) get(i int) ([]byte, error) {
+	if i < s.first || i >= s.n {
+		if i >= 0 && i < s.first {
+			return nil, ErrGone
+		}
+		return nil, fmt.Errorf("log record %d out of range [%d, %d)", i, s.first, s.n)
+	}
+	if w := i - (s.n - len(s.window)); w >= 0 {
+		return s.window[w], nil
+	}
+
+	page := i / pageSize
+	records, ok := s.pages[page]
+	if !ok {
+		from := max(page*pageSize, s.first)
+		to := min((page+1)*pageSize, s.n)
+		records = make([][]byte, 0, to-from)
+		err := s.scan(from, to, func(_ int, data []byte) error {
+			records = append(records, append([]byte(nil), data...))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		s.pages[page] = records
+		if len(s.lru) >= pageCache {
+			delete(s.pages, s.lru[0])
+			s.lru = s.lru[1:]
+		}
+	} else {
+		for j, p := range s.lru {
+			if p == page {
+				s.lru = append(s.lru[:j], s.lru[j+1:]...)
+				break
+			}
+		}
+	}
+	s.lru = append(s.lru, page)
+
+	j := i - max(page*pageSize, s.first)
+	if j >= len(records) {
+		return nil, fmt.Errorf("log record %d missing from its segment", i)
+	}
+	return records[j], nil
+}
+
+// Scan calls fn with every stored record from index from on, oldest first,
+// reading segments sequentially. data is only valid during the call.
+func (s *Store) Scan(from int, fn func(i int, data []byte) error) error {
+	s.mu.Lock()
+	to := s.n
+	s.mu.Unlock()
+
+	// Read a page at a time so appends aren't blocked for a whole scan
+	for i := max(from, 0); i < to; {
+		s.mu.Lock()
+		i = max(i, s.first)
+		end := min(to, i+pageSize*4)
+		var batch [][]byte
+		err := s.scan(i, end, func(_ int, data []byte) error {
+			batch = append(batch, append([]byte(nil), data...))
+			return nil
+		})
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+		for j, data := range batch {
+			if err := fn(i+j, data); err != nil {
+				return err
+			}
+		}
+		i = end
+	}
+	return nil
+}
+
+// scan reads records [from, to) with s.mu held.
+func (s *Store) scan(from, to int, fn func(i int, data []byte) error) error {
+	if from >= to {
+		return nil
+	}
+	// Records in the window need no disk reads
+	if windowStart := s.n - len(s.window); from >= windowStart {
+		for i := from; i < to; i++ {
+			if err := fn(i, s.window[i-windowStart]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if s.dir == "" {
+		return ErrGone
+	}
+
+	i := from
+	for _, seg := range s.segments {
+		end := seg.first + len(seg.offsets)
+		if i >= end || i >= to {
+			continue
+		}
+		if err := seg.read(i, min(to, end), fn); err != nil {
+			return err
+		}
+		i = min(to, end)
+	}
+	return nil
+}
+
+// read reads records [from, to) of a segment.
+func (seg *segment) read(from, to int, fn func(i int, data []byte) error) error {
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return fmt.Errorf("read log segment: %w", err)
+	}
+	defer f.Close()
+
+	start := seg.offsets[from-seg.first]
+	end := seg.size
+	if to-seg.first < len(seg.offsets) {
+		end = seg.offsets[to-seg.first]
+	}
+	r := bufio.NewReader(io.NewSectionReader(f, start, end-start))
+	for i := from; i < to; i++ {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return fmt.Errorf("read log segment %s: %w", filepath.Base(seg.path), err)
+		}
+		if err := fn(i, line[:len(line)-1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset deletes every record.
+func (s *Store) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	for _, seg := range s.segments {
+		os.Remove(seg.path)
+	}
+	s.segments = nil
+	s.first, s.n = 0, 0
+	s.window = nil
+	s.pages = make(map[int][][]byte)
+	s.lru = nil
+	return nil
+}
+
+// Close closes the store, removing its directory if it was temporary.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	if s.file != nil {
+		err = s.file.Close()
+		s.file = nil
+	}
+	if s.temp {
+		if rerr := os.RemoveAll(s.dir); err == nil {
+			err = rerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package logstore
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func record(i int) []byte {
+	return []byte(fmt.Sprintf(`{"n":%d}`, i))
+}
+
+func TestAppendAndGet(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentBytes: 100, Window: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 300; i++ {
+		if n, err := s.Append(record(i)); err != nil || n != i {
+			t.Fatalf("Append(%d) = %d, %v", i, n, err)
+		}
+	}
+	if len(s.window) != 5 {
+		t.Errorf("window holds %d records, want 5", len(s.window))
+	}
+	if len(s.segments) < 2 {
+		t.Errorf("wrote %d segments, want several", len(s.segments))
+	}
+
+	// Read out of order so records come from the window, disk and the cache
+	for _, i := range []int{299, 0, 150, 1, 63, 64, 298, 150} {
+		data, err := s.Get(i)
+		if err != nil || string(data) != string(record(i)) {
+			t.Errorf("Get(%d) = %s, %v", i, data, err)
+		}
+	}
+
+	var scanned int
+	err = s.Scan(100, func(i int, data []byte) error {
+		if string(data) != string(record(i)) {
+			return fmt.Errorf("record %d = %s", i, data)
+		}
+		scanned++
+		return nil
+	})
+	if err != nil || scanned != 200 {
+		t.Errorf("Scan() read %d records, %v", scanned, err)
+	}
+	if _, err := s.Append([]byte("two\nlines")); err == nil {
+		t.Error("Append() accepted a record with a newline")
+	}
+	s.Close()
+
+	// Reopening keeps the records
+	s, err = Open(dir, Options{SegmentBytes: 100, Window: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.Len() != 300 {
+		t.Fatalf("reopened store has %d records, want 300", s.Len())
+	}
+	if data, err := s.Get(299); err != nil || string(data) != string(record(299)) {
+		t.Errorf("Get(299) after reopen = %s, %v", data, err)
+	}
+	if n, err := s.Append(record(300)); err != nil || n != 300 {
+		t.Errorf("Append() after reopen = %d, %v", n, err)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentBytes: 100, MaxSegments: 2, Window: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for i := 0; i < 200; i++ {
+		s.Append(record(i))
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	if len(files) != 2 {
+		t.Errorf("%d segment files on disk, want 2", len(files))
+	}
+	first := s.First()
+	if first == 0 || first >= 200 {
+		t.Fatalf("First() = %d", first)
+	}
+	if _, err := s.Get(first - 1); !errors.Is(err, ErrGone) {
+		t.Errorf("Get(First()-1) error = %v, want ErrGone", err)
+	}
+	if data, err := s.Get(first); err != nil || string(data) != string(record(first)) {
+		t.Errorf("Get(First()) = %s, %v", data, err)
+	}
+}
+
+func TestMemoryAndTemp(t *testing.T) {
+	m := NewMemory(Options{Window: 10})
+	for i := 0; i < 25; i++ {
+		m.Append(record(i))
+	}
+	if m.First() != 15 || m.Len() != 25 {
+		t.Errorf("memory store holds [%d, %d), want [15, 25)", m.First(), m.Len())
+	}
+	if _, err := m.Get(3); !errors.Is(err, ErrGone) {
+		t.Errorf("Get(3) error = %v, want ErrGone", err)
+	}
+
+	s, err := OpenTemp(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Append(record(0))
+	dir := s.dir
+	s.Reset()
+	if s.Len() != 0 {
+		t.Errorf("Len() after Reset() = %d", s.Len())
+	}
+	s.Close()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("temporary directory survived Close(): %v", err)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ollama.log")
+	r, err := OpenRotating(path, 20, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(r, "line %d\n", i) // 7 bytes, so two lines per file
+	}
+	r.Close()
+
+	for name, want := range map[string]string{
+		path:        "line 8\nline 9\n",
+		path + ".1": "line 6\nline 7\n",
+		path + ".2": "line 4\nline 5\n",
+	} {
+		data, err := os.ReadFile(name)
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", filepath.Base(name), data, err, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("kept more than 2 backups")
+	}
+}
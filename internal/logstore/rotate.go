@@ -0,0 +1,117 @@
+package logstore
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Defaults for OpenRotating's zero arguments.
+const (
+	DefaultMaxFileBytes = 10 << 20
+	DefaultMaxFiles     = 3
+)
+
+// RotatingFile is a log file that is renamed to path.1 when it grows past a
+// size, with path.1 moving to path.2 and so on. Files beyond the number of
+// backups are deleted.
+type RotatingFile struct {
+	path     string
+	maxBytes int64
+	backups  int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotating opens path for appending, rotating it first if it's already
+// past maxBytes. maxBytes 0 uses DefaultMaxFileBytes and a negative maxBytes
+// never rotates; backups 0 uses DefaultMaxFiles and a negative backups keeps
+// none.
+func OpenRotating(path string, maxBytes int64, backups int) (*RotatingFile, error) {
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxFileBytes
+	}
+	if backups == 0 {
+		backups = DefaultMaxFiles
+	}
+	r := &RotatingFile{path: path, maxBytes: maxBytes, backups: backups}
+	if info, err := os.Stat(path); err == nil && maxBytes > 0 && info.Size() >= maxBytes {
+		if err := r.shift(); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// shift renames the file and its backups one place along, deleting the
+// oldest.
+func (r *RotatingFile) shift() error {
+	if r.backups <= 0 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.backups))
+	for i := r.backups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Write appends p, rotating first if it would take the file past its size.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.maxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		r.file.Close()
+		r.file = nil
+		if err := r.shift(); err != nil {
+			return 0, fmt.Errorf("rotate %s: %w", r.path, err)
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
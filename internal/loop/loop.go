@@ -7,7 +7,7 @@ package loop
 import (
 	"context"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -15,10 +15,17 @@ import (
 
 	"github.com/izdrail/chief/embed"
 	"github.com/izdrail/chief/internal/agent"
+	"github.com/izdrail/chief/internal/config"
 	"github.com/izdrail/chief/internal/db"
 	"github.com/izdrail/chief/internal/git"
+	"github.com/izdrail/chief/internal/learnings"
+	"github.com/izdrail/chief/internal/logstore"
 	"github.com/izdrail/chief/internal/ollama"
 	"github.com/izdrail/chief/internal/prd"
+	"github.com/izdrail/chief/internal/prompts"
+	"github.com/izdrail/chief/internal/repomap"
+	"github.com/izdrail/chief/internal/tools"
+	"github.com/izdrail/chief/internal/transcript"
 )
 
 // RetryConfig configures automatic retry behavior on Ollama errors.
@@ -45,15 +52,19 @@ type Loop struct {
 	maxIter     int
 	iteration   int
 	events      chan Event
-	logFile     *os.File
+	logFile     io.WriteCloser // ollama.log, rotated at logs.maxFileMB
 	mu          sync.Mutex
 	stopped     bool
 	paused      bool
 	retryConfig RetryConfig
 	ollamaClient *ollama.Client
 	store       *db.Store
+	learnings   *db.Store // store for agent learnings when it differs from store
 	repoURL     string
+	config      *config.Config
+	templated   bool // render the prompt template each iteration instead of using prompt
 	cancelFunc  context.CancelFunc // cancel the current agent run
+	transcript  *transcript.Writer // the current iteration's transcript; only used by Run's goroutine
 }
 
 // NewLoop creates a new Loop instance.
@@ -82,11 +93,19 @@ func NewLoopWithWorkDir(prdPath, workDir string, prompt string, maxIter int) *Lo
 	}
 }
 
-// NewLoopWithEmbeddedPrompt creates a new Loop instance using the embedded agent prompt.
-// The PRD path placeholder in the prompt is automatically substituted.
+// NewLoopWithEmbeddedPrompt creates a new Loop instance using the agent prompt template.
+// The template is re-rendered every iteration; see NewLoopWithTemplate.
 func NewLoopWithEmbeddedPrompt(prdPath string, maxIter int) *Loop {
-	prompt := embed.GetPrompt(prdPath)
-	return NewLoop(prdPath, prompt, maxIter)
+	return NewLoopWithTemplate(prdPath, "", maxIter)
+}
+
+// NewLoopWithTemplate creates a new Loop instance whose prompt is rendered from the
+// agent prompt template before every iteration, so it reflects the current story,
+// learnings and repository map. Project and per-PRD template overrides are honored.
+func NewLoopWithTemplate(prdPath, workDir string, maxIter int) *Loop {
+	l := NewLoopWithWorkDir(prdPath, workDir, embed.GetPrompt(prdPath), maxIter)
+	l.templated = true
+	return l
 }
 
 // SetStore sets the SQLite store for the loop.
@@ -96,6 +115,14 @@ func (l *Loop) SetStore(s *db.Store) {
 	l.store = s
 }
 
+// SetLearningStore sets the store used for agent learnings when the loop has no
+// SQLite store for PRD data. Loops with a store record learnings there.
+func (l *Loop) SetLearningStore(s *db.Store) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.learnings = s
+}
+
 // SetRepoURL sets the repository URL for the loop.
 func (l *Loop) SetRepoURL(url string) {
 	l.mu.Lock()
@@ -103,6 +130,20 @@ func (l *Loop) SetRepoURL(url string) {
 	l.repoURL = url
 }
 
+// SetConfig sets the project config used when building prompts and the
+// fallback models requests switch to when the configured one fails.
+func (l *Loop) SetConfig(cfg *config.Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = cfg
+	if cfg != nil && l.ollamaClient != nil {
+		l.ollamaClient.Fallbacks = nil
+		for _, f := range cfg.LLM.Fallbacks {
+			l.ollamaClient.Fallbacks = append(l.ollamaClient.Fallbacks, ollama.Target{BaseURL: f.URL, Model: f.Model})
+		}
+	}
+}
+
 // Events returns the channel for receiving events from the loop.
 func (l *Loop) Events() <-chan Event {
 	return l.events
@@ -120,14 +161,31 @@ func (l *Loop) Run(ctx context.Context) error {
 	// Open log file in PRD directory
 	prdDir := filepath.Dir(l.prdPath)
 	logPath := filepath.Join(prdDir, "ollama.log")
+	var maxBytes int64
+	var backups int
+	if l.config != nil {
+		maxBytes = int64(l.config.Logs.MaxFileMB) << 20
+		backups = l.config.Logs.MaxFiles
+	}
 	var err error
-	l.logFile, err = os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	l.logFile, err = logstore.OpenRotating(logPath, maxBytes, backups)
 	if err != nil {
 		return fmt.Errorf("failed to open log file: %w", err)
 	}
 	defer l.logFile.Close()
 	defer close(l.events)
 
+	// Refuse to start on a PRD that fails validation. A missing file is not
+	// an error here; the PRD may still be loaded from the store.
+	if diags, err := prd.Lint(l.prdPath); err == nil && prd.HasErrors(diags) {
+		err := &prd.ValidationError{Diagnostics: diags}
+		l.events <- Event{
+			Type: EventError,
+			Err:  err,
+		}
+		return err
+	}
+
 	for {
 		l.mu.Lock()
 		if l.stopped {
@@ -157,23 +215,50 @@ func (l *Loop) Run(ctx context.Context) error {
 			Iteration: currentIter,
 		}
 
+		record := db.IterationRecord{
+			ProjectName: l.prdName(),
+			Iteration:   currentIter,
+			StartedAt:   time.Now(),
+		}
+
 		// Snapshot story states before iteration to detect new completions
 		prePassMap := map[string]bool{}
 		if prePRD, err := l.loadPRD(); err == nil {
 			for _, s := range prePRD.UserStories {
 				prePassMap[s.ID] = s.Passes
 			}
+			if next := prePRD.NextStory(); next != nil {
+				record.StoryID = next.ID
+			}
 		}
 
+		l.openTranscript(&record)
+		dirty := l.startChanges(&record)
+
 		// Run a single iteration with retry logic
-		if err := l.runIterationWithRetry(ctx); err != nil {
+		err := l.runIterationWithRetry(ctx, &record)
+		record.FinishedAt = time.Now()
+		record.Failed = err != nil
+		l.finishChanges(&record, dirty)
+		l.recordIteration(record, prePassMap)
+		l.closeTranscript(record)
+		if err != nil {
+			// RetryCount reaching RetryMax marks an error that exhausted its retries
+			l.mu.Lock()
+			retryMax := l.retryConfig.MaxRetries
+			l.mu.Unlock()
 			l.events <- Event{
-				Type: EventError,
-				Err:  err,
+				Type:       EventError,
+				Iteration:  currentIter,
+				Err:        err,
+				RetryCount: record.Attempts - 1,
+				RetryMax:   retryMax,
 			}
 			return err
 		}
 
+		l.emitStoryCompletions(currentIter, prePassMap)
+
 		// Sync PRD from filesystem back to DB if available
 		l.mu.Lock()
 		store := l.store
@@ -242,7 +327,8 @@ func (l *Loop) Run(ctx context.Context) error {
 }
 
 // runIterationWithRetry wraps runIteration with retry logic for error recovery.
-func (l *Loop) runIterationWithRetry(ctx context.Context) error {
+// Attempts and their errors are counted in record.
+func (l *Loop) runIterationWithRetry(ctx context.Context, record *db.IterationRecord) error {
 	l.mu.Lock()
 	config := l.retryConfig
 	l.mu.Unlock()
@@ -271,7 +357,7 @@ func (l *Loop) runIterationWithRetry(ctx context.Context) error {
 				Iteration:  iter,
 				RetryCount: attempt,
 				RetryMax:   config.MaxRetries,
-				Text:       fmt.Sprintf("Ollama error, retrying (%d/%d)...", attempt, config.MaxRetries),
+				Text:       retryText(lastErr, attempt, config.MaxRetries),
 			}
 
 			// Wait before retry
@@ -293,16 +379,30 @@ func (l *Loop) runIterationWithRetry(ctx context.Context) error {
 		l.mu.Unlock()
 
 		// Run the iteration
-		err := l.runIteration(ctx)
+		record.Attempts++
+		if l.transcript != nil {
+			l.transcript.SetAttempt(record.Attempts)
+		}
+		err := l.runIteration(ctx, record)
 		if err == nil {
 			return nil // Success
 		}
+		record.Errors = append(record.Errors, err.Error())
+		if l.transcript != nil {
+			l.transcript.Error(err)
+		}
 
 		// Check if this is a context cancellation (don't retry)
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
 
+		// A missing model or a rejected request fails the same way every time
+		if class := ollama.Classify(err); !class.Retryable() {
+			l.logLine(fmt.Sprintf("[error] %s error, not retrying: %v", class, err))
+			return err
+		}
+
 		// Check if stopped intentionally
 		l.mu.Lock()
 		stopped := l.stopped
@@ -317,8 +417,21 @@ func (l *Loop) runIterationWithRetry(ctx context.Context) error {
 	return fmt.Errorf("max retries (%d) exceeded: %w", config.MaxRetries, lastErr)
 }
 
-// runIteration runs a single Ollama agent iteration.
-func (l *Loop) runIteration(ctx context.Context) error {
+// retryText describes a retry of an iteration that failed with err.
+func retryText(err error, attempt, max int) string {
+	switch ollama.Classify(err) {
+	case ollama.ErrorStalled:
+		return fmt.Sprintf("Ollama stopped responding, retrying (%d/%d)...", attempt, max)
+	case ollama.ErrorTimeout:
+		return fmt.Sprintf("Ollama timed out, retrying (%d/%d)...", attempt, max)
+	default:
+		return fmt.Sprintf("Ollama error, retrying (%d/%d)...", attempt, max)
+	}
+}
+
+// runIteration runs a single Ollama agent iteration. Its tool calls are
+// counted in record.
+func (l *Loop) runIteration(ctx context.Context, record *db.IterationRecord) error {
 	workDir := l.effectiveWorkDir()
 
 	// Build a cancellable context for this iteration
@@ -333,22 +446,52 @@ func (l *Loop) runIteration(ctx context.Context) error {
 		l.mu.Unlock()
 	}()
 
+	// Refresh progress.md from the learnings store (importing a hand-written one first)
+	learningStore := l.localStore()
+	if learningStore != nil {
+		if err := learnings.Export(learningStore, l.prdName(), filepath.Dir(l.prdPath)); err != nil {
+			l.logLine(fmt.Sprintf("failed to export learnings: %v", err))
+		}
+	}
+
 	// Build the initial messages for this iteration
+	prompt, err := l.buildPrompt(workDir)
+	if err != nil {
+		return err
+	}
 	messages := []ollama.Message{
 		{
 			Role:    "user",
-			Content: l.prompt,
+			Content: prompt,
 		},
 	}
+	for _, m := range messages {
+		l.writeTranscript(m, 0, ollama.Usage{})
+	}
 
 	agentOpts := agent.AgentOptions{
 		WorkDir:       workDir,
 		MaxToolRounds: 50,
 	}
+	if learningStore != nil {
+		agentOpts.Handlers = append(agentOpts.Handlers, tools.RecordLearningHandler(l.recordLearning))
+	}
 
-	stream := agent.RunAgent(iterCtx, l.ollamaClient, messages, agentOpts)
+	// Requests queue for the shared endpoint under the PRD's name, so PRDs
+	// take turns when several loops are running
+	agentCtx := ollama.WithTenant(iterCtx, l.prdName())
 
+	stream := agent.RunAgent(agentCtx, l.ollamaClient, messages, agentOpts)
+
+	// Text of the assistant message being streamed, written to the transcript
+	// if the iteration ends before the message is complete
+	var pending strings.Builder
 	for event := range stream {
+		if event.Message != nil {
+			pending.Reset()
+			l.writeTranscript(*event.Message, event.Elapsed, event.Usage)
+		}
+
 		if event.Error != nil {
 			// Check if we were stopped intentionally
 			l.mu.Lock()
@@ -362,6 +505,7 @@ func (l *Loop) runIteration(ctx context.Context) error {
 
 		if event.TextDelta != "" {
 			l.logLine(event.TextDelta)
+			pending.WriteString(event.TextDelta)
 
 			// Check for <chief-complete/> in the text
 			if strings.Contains(event.TextDelta, "<chief-complete/>") {
@@ -373,6 +517,7 @@ func (l *Loop) runIteration(ctx context.Context) error {
 					Iteration: iter,
 					Text:      event.TextDelta,
 				}
+				l.writeTranscript(ollama.Message{Role: "assistant", Content: pending.String()}, 0, ollama.Usage{})
 				return nil
 			}
 
@@ -399,7 +544,26 @@ func (l *Loop) runIteration(ctx context.Context) error {
 			}
 		}
 
+		if event.QueueWait > 0 {
+			l.reportQueueWait(event.QueueWait)
+		}
+
+		if event.Notice != "" {
+			// The request is retried, and answers from the start
+			pending.Reset()
+			l.logLine(fmt.Sprintf("[recovery] %s", event.Notice))
+			l.mu.Lock()
+			iter := l.iteration
+			l.mu.Unlock()
+			l.events <- Event{
+				Type:      EventRetrying,
+				Iteration: iter,
+				Text:      event.Notice,
+			}
+		}
+
 		if event.ToolName != "" {
+			record.ToolCalls++
 			l.logLine(fmt.Sprintf("[tool] %s %v", event.ToolName, event.ToolInput))
 			l.mu.Lock()
 			iter := l.iteration
@@ -428,6 +592,46 @@ func (l *Loop) runIteration(ctx context.Context) error {
 	return nil
 }
 
+// reportQueueWait emits EventQueueWait after a request waited for a slot on
+// the LLM endpoint. The agent reports the wait in its event stream, so this
+// runs on the loop's goroutine rather than the request's, which still holds
+// the slot.
+func (l *Loop) reportQueueWait(wait time.Duration) {
+	precision := time.Second
+	if wait < time.Second {
+		precision = time.Millisecond
+	}
+	l.logLine(fmt.Sprintf("[queue] waited %s for an LLM request slot", wait.Round(time.Millisecond)))
+	l.mu.Lock()
+	iter := l.iteration
+	l.mu.Unlock()
+	l.events <- Event{
+		Type:      EventQueueWait,
+		Iteration: iter,
+		QueueWait: wait,
+		Text:      fmt.Sprintf("Waited %s for the LLM", wait.Round(precision)),
+	}
+}
+
+// emitStoryCompletions sends EventStoryCompleted for each story that passed
+// during the iteration.
+func (l *Loop) emitStoryCompletions(iteration int, prePassMap map[string]bool) {
+	p, err := l.loadPRD()
+	if err != nil {
+		return
+	}
+	for _, story := range p.UserStories {
+		if story.Passes && !prePassMap[story.ID] {
+			l.events <- Event{
+				Type:      EventStoryCompleted,
+				Iteration: iteration,
+				StoryID:   story.ID,
+				Text:      story.Title,
+			}
+		}
+	}
+}
+
 // autoPushIfStoryCompleted detects newly completed stories and auto-commits+pushes.
 func (l *Loop) autoPushIfStoryCompleted(p *prd.PRD, prePassMap map[string]bool) {
 	workDir := l.effectiveWorkDir()
@@ -438,10 +642,11 @@ func (l *Loop) autoPushIfStoryCompleted(p *prd.PRD, prePassMap map[string]bool)
 	}
 
 	// Find newly completed stories
-	var completedStories []string
+	var completedStories, trailers []string
 	for _, story := range p.UserStories {
 		if story.Passes && !prePassMap[story.ID] {
 			completedStories = append(completedStories, fmt.Sprintf("%s: %s", story.ID, story.Title))
+			trailers = append(trailers, git.StoryTrailer+": "+story.ID)
 		}
 	}
 
@@ -466,7 +671,8 @@ func (l *Loop) autoPushIfStoryCompleted(p *prd.PRD, prePassMap map[string]bool)
 		Text: fmt.Sprintf("Auto-pushing completed stories to %s...", branch),
 	}
 
-	if err := git.CommitAndPush(workDir, branch, commitMsg); err != nil {
+	// Story trailers keep the stories findable when the subject is shortened
+	if err := git.CommitAndPush(workDir, branch, commitMsg+"\n\n"+strings.Join(trailers, "\n")); err != nil {
 		l.events <- Event{
 			Type: EventError,
 			Err:  fmt.Errorf("auto-push failed: %w", err),
@@ -482,7 +688,7 @@ func (l *Loop) autoPushIfStoryCompleted(p *prd.PRD, prePassMap map[string]bool)
 // logLine writes a line to the log file.
 func (l *Loop) logLine(line string) {
 	if l.logFile != nil {
-		l.logFile.WriteString(line + "\n")
+		io.WriteString(l.logFile, line+"\n")
 	}
 }
 
@@ -526,6 +732,214 @@ func (l *Loop) IsStopped() bool {
 	return l.stopped
 }
 
+// buildPrompt returns the prompt for the next iteration. Templated loops render
+// the agent prompt template; otherwise the fixed prompt is used with the
+// repository map appended (skipped when disabled or when it cannot be built).
+func (l *Loop) buildPrompt(workDir string) (string, error) {
+	l.mu.Lock()
+	cfg := l.config
+	templated := l.templated
+	l.mu.Unlock()
+
+	if templated {
+		p, err := l.loadPRD()
+		if err != nil {
+			return "", fmt.Errorf("failed to load PRD: %w", err)
+		}
+		return prompts.Render(prompts.Agent, prompts.Options{
+			PRDPath: l.prdPath,
+			WorkDir: workDir,
+			PRD:     p,
+			Config:  cfg,
+			Store:   l.localStore(),
+		})
+	}
+
+	opts := repomap.Options{}
+	if cfg != nil {
+		if cfg.RepoMap.Disable {
+			return l.prompt, nil
+		}
+		opts.TokenBudget = cfg.RepoMap.TokenBudget
+	}
+	repoMap, err := repomap.Build(workDir, opts)
+	if err != nil || repoMap == "" {
+		return l.prompt, nil
+	}
+	return l.prompt + "\n\n" + repoMap, nil
+}
+
+// localStore returns the store agent learnings and iteration history are recorded in, or nil.
+func (l *Loop) localStore() *db.Store {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.store != nil {
+		return l.store
+	}
+	return l.learnings
+}
+
+// recordIteration saves an iteration to the history used by 'chief report'.
+func (l *Loop) recordIteration(record db.IterationRecord, prePassMap map[string]bool) {
+	store := l.localStore()
+	if store == nil {
+		return
+	}
+	if record.StoryID != "" && !prePassMap[record.StoryID] {
+		if p, err := l.loadPRD(); err == nil {
+			for _, story := range p.UserStories {
+				if story.ID == record.StoryID {
+					record.StoryPassed = story.Passes
+				}
+			}
+		}
+	}
+	if err := store.AddIteration(record); err != nil {
+		l.logLine(fmt.Sprintf("failed to record iteration: %v", err))
+	}
+}
+
+// openTranscript starts the transcript of the iteration described by record
+// and notes its path there. A transcript that can't be created is logged and
+// the iteration runs without one.
+func (l *Loop) openTranscript(record *db.IterationRecord) {
+	path := transcript.Path(filepath.Dir(l.prdPath), record.StartedAt, record.Iteration)
+	w, err := transcript.Create(path)
+	if err != nil {
+		l.logLine(fmt.Sprintf("failed to create transcript: %v", err))
+		l.transcript = nil
+		return
+	}
+	l.transcript = w
+	record.Transcript = path
+}
+
+// startChanges notes the commit an iteration starts from in record, and
+// returns the files already changed then, which finishChanges leaves out.
+// Outside a git repository it does nothing.
+func (l *Loop) startChanges(record *db.IterationRecord) map[string]bool {
+	workDir := l.effectiveWorkDir()
+	head, err := git.HeadCommit(workDir)
+	if err != nil {
+		return nil
+	}
+	record.StartCommit = head
+	dirty := map[string]bool{}
+	files, _ := git.ChangedFiles(workDir, head, "")
+	for _, f := range files {
+		dirty[f] = true
+	}
+	return dirty
+}
+
+// finishChanges records the commit an iteration ended on and the files it
+// changed: those changed by its commits, and those left uncommitted that
+// weren't already changed when it started.
+func (l *Loop) finishChanges(record *db.IterationRecord, dirty map[string]bool) {
+	if record.StartCommit == "" {
+		return
+	}
+	workDir := l.effectiveWorkDir()
+	head, err := git.HeadCommit(workDir)
+	if err != nil {
+		return
+	}
+	record.EndCommit = head
+
+	// Chief's own files, such as transcripts, aren't the agent's changes
+	seen := map[string]bool{}
+	add := func(files []string, skip map[string]bool) {
+		for _, f := range files {
+			if !seen[f] && !skip[f] && !strings.HasPrefix(f, ".chief/") {
+				seen[f] = true
+				record.Files = append(record.Files, f)
+			}
+		}
+	}
+	if head != record.StartCommit {
+		committed, _ := git.ChangedFiles(workDir, record.StartCommit, head)
+		add(committed, nil)
+	}
+	uncommitted, _ := git.ChangedFiles(workDir, head, "")
+	add(uncommitted, dirty)
+}
+
+// writeTranscript appends a message to the current iteration's transcript.
+func (l *Loop) writeTranscript(msg ollama.Message, elapsed time.Duration, usage ollama.Usage) {
+	if l.transcript == nil {
+		return
+	}
+	var model string
+	if msg.Role == "assistant" {
+		model = l.ollamaClient.Model
+	}
+	if err := l.transcript.Message(msg, elapsed, usage, model); err != nil {
+		l.logLine(fmt.Sprintf("failed to write transcript: %v", err))
+	}
+}
+
+// closeTranscript finishes the iteration's transcript and indexes it in the store.
+func (l *Loop) closeTranscript(record db.IterationRecord) {
+	w := l.transcript
+	l.transcript = nil
+	if w == nil {
+		return
+	}
+	w.Close()
+
+	store := l.localStore()
+	if store == nil {
+		return
+	}
+	summary := w.Summary()
+	_, err := store.AddTranscript(db.TranscriptRecord{
+		ProjectName:  record.ProjectName,
+		Iteration:    record.Iteration,
+		StoryID:      record.StoryID,
+		Path:         w.Path(),
+		StartedAt:    record.StartedAt,
+		FinishedAt:   record.FinishedAt,
+		Messages:     summary.Messages,
+		ToolCalls:    summary.ToolCalls,
+		PromptTokens: summary.PromptTokens,
+		OutputTokens: summary.OutputTokens,
+		Failed:       record.Failed,
+	})
+	if err != nil {
+		l.logLine(fmt.Sprintf("failed to index transcript: %v", err))
+	}
+}
+
+// prdName returns the PRD name (its directory name).
+func (l *Loop) prdName() string {
+	return filepath.Base(filepath.Dir(l.prdPath))
+}
+
+// recordLearning stores a learning from the RecordLearning tool against the
+// story currently being worked on, then regenerates progress.md.
+func (l *Loop) recordLearning(content string, tags []string) (bool, error) {
+	store := l.localStore()
+	if store == nil {
+		return false, fmt.Errorf("no learnings store configured")
+	}
+
+	storyID := ""
+	if p, err := l.loadPRD(); err == nil {
+		if story := p.NextStory(); story != nil {
+			storyID = story.ID
+		}
+	}
+
+	added, err := store.AddLearning(l.prdName(), storyID, content, tags)
+	if err != nil {
+		return false, err
+	}
+	if err := learnings.Export(store, l.prdName(), filepath.Dir(l.prdPath)); err != nil {
+		l.logLine(fmt.Sprintf("failed to export learnings: %v", err))
+	}
+	return added, nil
+}
+
 // effectiveWorkDir returns the working directory to use for tool execution.
 // If workDir is set, it is used directly. Otherwise, defaults to the PRD directory.
 func (l *Loop) effectiveWorkDir() string {
@@ -549,7 +963,14 @@ func (l *Loop) SetMaxIterations(maxIter int) {
 	l.maxIter = maxIter
 }
 
-// SetMaxIterations returns the current max iterations limit.
+// MaxIterations returns the current max iterations limit.
+func (l *Loop) MaxIterations() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.maxIter
+}
+
+// loadPRD returns the PRD from the store when it has the project, or from prd.json.
 func (l *Loop) loadPRD() (*prd.PRD, error) {
 	l.mu.Lock()
 	store := l.store
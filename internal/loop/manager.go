@@ -6,7 +6,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/izdrail/chief/embed"
 	"github.com/izdrail/chief/internal/config"
 	"github.com/izdrail/chief/internal/db"
 	"github.com/izdrail/chief/internal/prd"
@@ -74,11 +73,13 @@ type Manager struct {
 	maxIter     int
 	retryConfig RetryConfig
 	store       *db.Store
+	learnings   *db.Store // Store for agent learnings when store is not set
 	config         *config.Config                       // Project config for post-completion actions
 	mu             sync.RWMutex
 	wg             sync.WaitGroup
 	onComplete     func(prdName string)                  // Callback when a PRD completes
 	onPostComplete func(prdName, branch, workDir string) // Callback for post-completion actions (push, PR)
+	onEvent        func(event ManagerEvent)              // Callback for every loop event (notifications)
 }
 
 // NewManager creates a new loop manager.
@@ -112,6 +113,14 @@ func (m *Manager) SetCompletionCallback(fn func(prdName string)) {
 	m.onComplete = fn
 }
 
+// SetEventCallback sets a callback that is called with every event from any
+// loop, in addition to the Events channel.
+func (m *Manager) SetEventCallback(fn func(event ManagerEvent)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onEvent = fn
+}
+
 // SetPostCompleteCallback sets a callback for post-completion actions (push, PR creation).
 // The callback receives the PRD name, branch name, and working directory.
 func (m *Manager) SetPostCompleteCallback(fn func(prdName, branch, workDir string)) {
@@ -133,6 +142,14 @@ func (m *Manager) SetStore(s *db.Store) {
 	m.store = s
 }
 
+// SetLearningStore sets the store loops record agent learnings in when the
+// manager has no SQLite store for PRD data.
+func (m *Manager) SetLearningStore(s *db.Store) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.learnings = s
+}
+
 func (m *Manager) GetStore() *db.Store {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -230,17 +247,14 @@ func (m *Manager) Start(name string) error {
 		return fmt.Errorf("PRD %s is already running", name)
 	}
 
-	// Create a new loop instance, using worktree-aware constructor if WorktreeDir is set
-	if instance.WorktreeDir != "" {
-		prompt := embed.GetPrompt(instance.PRDPath)
-		instance.Loop = NewLoopWithWorkDir(instance.PRDPath, instance.WorktreeDir, prompt, m.maxIter)
-	} else {
-		instance.Loop = NewLoopWithEmbeddedPrompt(instance.PRDPath, m.maxIter)
-	}
+	// Create a new loop instance; an empty WorktreeDir runs in the PRD directory
+	instance.Loop = NewLoopWithTemplate(instance.PRDPath, instance.WorktreeDir, m.maxIter)
 	m.mu.RLock()
 	instance.Loop.SetRetryConfig(m.retryConfig)
 	instance.Loop.SetStore(m.store)
+	instance.Loop.SetLearningStore(m.learnings)
 	instance.Loop.SetRepoURL(instance.RepoURL)
+	instance.Loop.SetConfig(m.config)
 	m.mu.RUnlock()
 	instance.ctx, instance.cancel = context.WithCancel(context.Background())
 	instance.State = LoopStateRunning
@@ -278,11 +292,19 @@ func (m *Manager) runLoop(instance *LoopInstance) {
 				completed := event.Type == EventComplete
 
 				// Forward event to manager channel
-				m.events <- ManagerEvent{
+				managerEvent := ManagerEvent{
 					PRDName:   instance.Name,
 					Event:     event,
 					Completed: completed,
 				}
+				m.events <- managerEvent
+
+				m.mu.RLock()
+				onEvent := m.onEvent
+				m.mu.RUnlock()
+				if onEvent != nil {
+					onEvent(managerEvent)
+				}
 
 				// If completed, trigger callbacks
 				if completed {
@@ -3,11 +3,14 @@ package loop
 import (
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/izdrail/chief/internal/config"
+	"github.com/izdrail/chief/internal/prd"
 )
 
 // createTestPRDWithName creates a minimal test PRD file with a given name and returns its path.
@@ -362,7 +365,7 @@ func TestManagerRegisterWithWorktree(t *testing.T) {
 
 	m := NewManager(10)
 
-	err := m.RegisterWithWorktree("test-prd", prdPath, "/tmp/worktree/test-prd", "chief/test-prd")
+	err := m.RegisterWithWorktree("test-prd", prdPath, "", "/tmp/worktree/test-prd", "chief/test-prd")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -385,7 +388,7 @@ func TestManagerRegisterWithWorktree(t *testing.T) {
 	}
 
 	// Duplicate registration should fail
-	err = m.RegisterWithWorktree("test-prd", prdPath, "/tmp/worktree/test-prd", "chief/test-prd")
+	err = m.RegisterWithWorktree("test-prd", prdPath, "", "/tmp/worktree/test-prd", "chief/test-prd")
 	if err == nil {
 		t.Error("expected error when registering duplicate PRD")
 	}
@@ -398,7 +401,7 @@ func TestManagerRegisterWithWorktreeFieldsInGetAllInstances(t *testing.T) {
 
 	m := NewManager(10)
 	m.Register("prd1", prd1Path)
-	m.RegisterWithWorktree("prd2", prd2Path, "/tmp/wt/prd2", "chief/prd2")
+	m.RegisterWithWorktree("prd2", prd2Path, "", "/tmp/wt/prd2", "chief/prd2")
 
 	instances := m.GetAllInstances()
 	if len(instances) != 2 {
@@ -488,7 +491,7 @@ func TestManagerClearWorktreeInfoAll(t *testing.T) {
 	prdPath := createTestPRDWithName(t, tmpDir, "test-prd")
 
 	m := NewManager(10)
-	m.RegisterWithWorktree("test-prd", prdPath, "/tmp/wt/test", "chief/test")
+	m.RegisterWithWorktree("test-prd", prdPath, "", "/tmp/wt/test", "chief/test")
 
 	// Clear both worktree and branch
 	if err := m.ClearWorktreeInfo("test-prd", true); err != nil {
@@ -509,7 +512,7 @@ func TestManagerClearWorktreeInfoKeepBranch(t *testing.T) {
 	prdPath := createTestPRDWithName(t, tmpDir, "test-prd")
 
 	m := NewManager(10)
-	m.RegisterWithWorktree("test-prd", prdPath, "/tmp/wt/test", "chief/test")
+	m.RegisterWithWorktree("test-prd", prdPath, "", "/tmp/wt/test", "chief/test")
 
 	// Clear worktree only, keep branch
 	if err := m.ClearWorktreeInfo("test-prd", false); err != nil {
@@ -547,7 +550,7 @@ func TestManagerUpdateWorktreeInfo(t *testing.T) {
 	}
 
 	// Update worktree info
-	if err := m.UpdateWorktreeInfo("test-prd", "/tmp/wt/test", "chief/test"); err != nil {
+	if err := m.UpdateWorktreeInfo("test-prd", "", "/tmp/wt/test", "chief/test"); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -562,7 +565,7 @@ func TestManagerUpdateWorktreeInfo(t *testing.T) {
 
 func TestManagerUpdateWorktreeInfoNotFound(t *testing.T) {
 	m := NewManager(10)
-	err := m.UpdateWorktreeInfo("nonexistent", "/tmp", "branch")
+	err := m.UpdateWorktreeInfo("nonexistent", "", "/tmp", "branch")
 	if err == nil {
 		t.Error("expected error for nonexistent PRD")
 	}
@@ -573,10 +576,10 @@ func TestManagerUpdateWorktreeInfoOverwrite(t *testing.T) {
 	prdPath := createTestPRDWithName(t, tmpDir, "test-prd")
 
 	m := NewManager(10)
-	m.RegisterWithWorktree("test-prd", prdPath, "/old/path", "old-branch")
+	m.RegisterWithWorktree("test-prd", prdPath, "", "/old/path", "old-branch")
 
 	// Update with new values
-	if err := m.UpdateWorktreeInfo("test-prd", "/new/path", "new-branch"); err != nil {
+	if err := m.UpdateWorktreeInfo("test-prd", "", "/new/path", "new-branch"); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -594,7 +597,7 @@ func TestManagerConcurrentAccessWithWorktreeFields(t *testing.T) {
 	prdPath := createTestPRDWithName(t, tmpDir, "test-prd")
 
 	m := NewManager(10)
-	m.RegisterWithWorktree("test-prd", prdPath, "/tmp/wt/test", "chief/test")
+	m.RegisterWithWorktree("test-prd", prdPath, "", "/tmp/wt/test", "chief/test")
 	m.SetConfig(&config.Config{})
 
 	var wg sync.WaitGroup
@@ -611,3 +614,80 @@ func TestManagerConcurrentAccessWithWorktreeFields(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+// TestManagerRunsPRDsWithFakeModel runs two PRDs side by side against a fake
+// model until both are complete.
+func TestManagerRunsPRDsWithFakeModel(t *testing.T) {
+	fake, client := fakeClient(t, completeScript)
+	t.Setenv("OLLAMA_HOST", client.BaseURL)
+	t.Setenv("OLLAMA_MODEL", client.Model)
+	tmpDir := t.TempDir()
+	store := newTestStore(t)
+
+	m := NewManager(3)
+	m.SetLearningStore(store)
+	var mu sync.Mutex
+	var completed []string
+	m.SetCompletionCallback(func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		completed = append(completed, name)
+	})
+	go func() {
+		for range m.Events() {
+		}
+	}()
+
+	names := []string{"auth", "billing"}
+	for _, name := range names {
+		dir := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Register(name, createTestPRD(t, dir, false)); err != nil {
+			t.Fatalf("Register(%s) error: %v", name, err)
+		}
+	}
+	for _, name := range names {
+		if err := m.Start(name); err != nil {
+			t.Fatalf("Start(%s) error: %v", name, err)
+		}
+	}
+
+	// Completion callbacks run as events are forwarded, which may be after the
+	// loop's state has changed
+	done := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return !m.IsAnyRunning() && len(completed) == len(names)
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("loops not done after 30s: running %v", m.GetRunningPRDs())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, name := range names {
+		inst := m.GetInstance(name)
+		if inst.State != LoopStateComplete || inst.Error != nil {
+			t.Errorf("%s state = %v, error = %v; want Complete", name, inst.State, inst.Error)
+		}
+		if p, err := prd.LoadPRD(inst.PRDPath); err != nil || !p.AllComplete() {
+			t.Errorf("%s PRD not complete: %v", name, err)
+		}
+		if records, err := store.GetIterations(name); err != nil || len(records) != 1 || !records[0].StoryPassed {
+			t.Errorf("%s iterations = %+v, %v; want 1 passing US-001", name, records, err)
+		}
+	}
+	mu.Lock()
+	sort.Strings(completed)
+	if strings.Join(completed, ",") != "auth,billing" {
+		t.Errorf("completed = %v, want auth and billing", completed)
+	}
+	mu.Unlock()
+	if n := len(fake.Requests()); n != 4 {
+		t.Errorf("server got %d requests, want 4", n)
+	}
+}
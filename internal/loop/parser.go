@@ -1,5 +1,7 @@
 package loop
 
+import "time"
+
 // EventType represents the type of event emitted by the agent loop.
 type EventType int
 
@@ -26,6 +28,9 @@ const (
 	EventError
 	// EventRetrying is emitted when retrying after an error.
 	EventRetrying
+	// EventQueueWait is emitted when a request had to wait for a slot on the
+	// shared LLM endpoint.
+	EventQueueWait
 )
 
 // String returns the string representation of an EventType.
@@ -51,6 +56,8 @@ func (e EventType) String() string {
 		return "Error"
 	case EventRetrying:
 		return "Retrying"
+	case EventQueueWait:
+		return "QueueWait"
 	default:
 		return "Unknown"
 	}
@@ -65,6 +72,7 @@ type Event struct {
 	ToolInput  map[string]interface{}
 	StoryID    string
 	Err        error
-	RetryCount int // Current retry attempt (1-based)
-	RetryMax   int // Maximum retries allowed
+	RetryCount int           // Current retry attempt (1-based)
+	RetryMax   int           // Maximum retries allowed
+	QueueWait  time.Duration // Time spent waiting for an LLM request slot
 }
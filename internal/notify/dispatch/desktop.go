@@ -0,0 +1,29 @@
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// execCommand is replaced in tests.
+var execCommand = exec.CommandContext
+
+// desktopChannel shows a desktop notification with notify-send.
+type desktopChannel struct{}
+
+func (c *desktopChannel) Send(ctx context.Context, n Notification) error {
+	urgency := "normal"
+	if n.Event == EventError || n.Event == EventRetriesExhausted {
+		urgency = "critical"
+	}
+	cmd := execCommand(ctx, "notify-send", "--app-name=Chief", "--urgency="+urgency, n.Title, n.Message)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("notify-send: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+		return fmt.Errorf("notify-send: %w", err)
+	}
+	return nil
+}
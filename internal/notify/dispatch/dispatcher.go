@@ -0,0 +1,259 @@
+// Package dispatch delivers loop events (stories completed, errors, PRDs
+// complete) to webhooks, Slack or Mattermost, desktop notifications and email,
+// routed by the rules in .chief/config.yaml. It is kept apart from the
+// completion sound in package notify, which needs cgo on Linux.
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/izdrail/chief/internal/config"
+	"github.com/izdrail/chief/internal/loop"
+)
+
+// Event names used in notification rules.
+const (
+	EventStoryCompleted   = "storyCompleted"
+	EventError            = "error"
+	EventRetriesExhausted = "retriesExhausted"
+	EventMaxIterations    = "maxIterations"
+	EventComplete         = "complete"
+)
+
+// Events lists every event name a rule can match.
+var Events = []string{EventStoryCompleted, EventError, EventRetriesExhausted, EventMaxIterations, EventComplete}
+
+// DefaultEvents are routed to every channel when no rules are configured.
+var DefaultEvents = []string{EventError, EventRetriesExhausted, EventMaxIterations, EventComplete}
+
+// sendTimeout bounds a single delivery.
+const sendTimeout = 15 * time.Second
+
+// Notification is a message about a loop event.
+type Notification struct {
+	Event     string    `json:"event"`
+	PRD       string    `json:"prd"`
+	StoryID   string    `json:"storyId,omitempty"`
+	Iteration int       `json:"iteration,omitempty"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+// Channel delivers notifications to one destination.
+type Channel interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// Dispatcher routes loop events to channels according to rules.
+type Dispatcher struct {
+	channels map[string]Channel
+	names    []string // Channel names in config order
+	rules    []config.NotifyRule
+	onError  func(channel string, err error)
+	wg       sync.WaitGroup
+}
+
+// New creates a dispatcher from the notify section of the project config.
+// Unknown channel types, duplicate channel names and rules that name unknown
+// channels or events are errors.
+func New(cfg config.NotifyConfig) (*Dispatcher, error) {
+	d := &Dispatcher{
+		channels: make(map[string]Channel),
+		onError: func(channel string, err error) {
+			log.Printf("Warning: notification via %s failed: %v", channel, err)
+		},
+	}
+	for i, c := range cfg.Channels {
+		name := c.Name
+		if name == "" {
+			name = c.Type
+		}
+		if name == "" {
+			return nil, fmt.Errorf("notify channel %d has no name or type", i+1)
+		}
+		if _, ok := d.channels[name]; ok {
+			return nil, fmt.Errorf("duplicate notify channel %q", name)
+		}
+		ch, err := newChannel(c)
+		if err != nil {
+			return nil, fmt.Errorf("notify channel %q: %w", name, err)
+		}
+		d.channels[name] = ch
+		d.names = append(d.names, name)
+	}
+
+	for i, rule := range cfg.Rules {
+		if len(rule.Events) == 0 {
+			return nil, fmt.Errorf("notify rule %d has no events", i+1)
+		}
+		for _, event := range rule.Events {
+			if !isEvent(event) {
+				return nil, fmt.Errorf("notify rule %d: unknown event %q", i+1, event)
+			}
+		}
+		for _, name := range rule.Channels {
+			if _, ok := d.channels[name]; !ok {
+				return nil, fmt.Errorf("notify rule %d: unknown channel %q", i+1, name)
+			}
+		}
+	}
+	d.rules = cfg.Rules
+	if len(d.rules) == 0 {
+		d.rules = []config.NotifyRule{{Events: DefaultEvents}}
+	}
+	return d, nil
+}
+
+// newChannel creates the channel for a config entry.
+func newChannel(c config.NotifyChannel) (Channel, error) {
+	switch c.Type {
+	case "webhook":
+		if c.URL == "" {
+			return nil, fmt.Errorf("url is required")
+		}
+		return &webhookChannel{url: c.URL, headers: c.Headers}, nil
+	case "slack", "mattermost":
+		if c.URL == "" {
+			return nil, fmt.Errorf("url is required")
+		}
+		return &chatChannel{url: c.URL, headers: c.Headers}, nil
+	case "desktop":
+		return &desktopChannel{}, nil
+	case "email":
+		return newEmailChannel(c)
+	}
+	return nil, fmt.Errorf("unknown type %q (use webhook, slack, mattermost, desktop or email)", c.Type)
+}
+
+// SetErrorHandler sets the function called when a delivery fails. By default
+// failures are logged.
+func (d *Dispatcher) SetErrorHandler(fn func(channel string, err error)) {
+	d.onError = fn
+}
+
+// Enabled reports whether any channels are configured.
+func (d *Dispatcher) Enabled() bool {
+	return d != nil && len(d.channels) > 0
+}
+
+// HandleEvent notifies about a loop event if it is one rules can match.
+func (d *Dispatcher) HandleEvent(prdName string, e loop.Event) {
+	if !d.Enabled() {
+		return
+	}
+	if n, ok := FromEvent(prdName, e); ok {
+		d.Notify(n)
+	}
+}
+
+// Notify delivers a notification to every channel a rule routes it to.
+// Deliveries run in the background; use Wait to let them finish.
+func (d *Dispatcher) Notify(n Notification) {
+	if !d.Enabled() {
+		return
+	}
+	if n.Time.IsZero() {
+		n.Time = time.Now()
+	}
+	for _, name := range d.route(n) {
+		ch := d.channels[name]
+		d.wg.Add(1)
+		go func(name string) {
+			defer d.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+			if err := ch.Send(ctx, n); err != nil && d.onError != nil {
+				d.onError(name, err)
+			}
+		}(name)
+	}
+}
+
+// Wait blocks until in-flight deliveries finish.
+func (d *Dispatcher) Wait() {
+	if d != nil {
+		d.wg.Wait()
+	}
+}
+
+// route returns the channels a notification goes to, in config order.
+func (d *Dispatcher) route(n Notification) []string {
+	selected := make(map[string]bool)
+	for _, rule := range d.rules {
+		if !contains(rule.Events, n.Event) {
+			continue
+		}
+		if len(rule.PRDs) > 0 && !contains(rule.PRDs, n.PRD) {
+			continue
+		}
+		if len(rule.Channels) == 0 {
+			for _, name := range d.names {
+				selected[name] = true
+			}
+		}
+		for _, name := range rule.Channels {
+			selected[name] = true
+		}
+	}
+	var names []string
+	for _, name := range d.names {
+		if selected[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// FromEvent builds the notification for a loop event. It returns false for
+// events that are not notified about, such as tool calls and assistant text.
+// An error event whose RetryCount has reached RetryMax is reported as
+// retriesExhausted.
+func FromEvent(prdName string, e loop.Event) (Notification, bool) {
+	n := Notification{PRD: prdName, StoryID: e.StoryID, Iteration: e.Iteration, Time: time.Now()}
+	switch e.Type {
+	case loop.EventStoryCompleted:
+		n.Event = EventStoryCompleted
+		n.Title = fmt.Sprintf("Chief: %s completed %s", prdName, e.StoryID)
+		n.Message = fmt.Sprintf("%s: %s", e.StoryID, e.Text)
+	case loop.EventError:
+		n.Event = EventError
+		n.Title = fmt.Sprintf("Chief: %s failed", prdName)
+		if e.RetryCount > 0 && e.RetryCount >= e.RetryMax {
+			n.Event = EventRetriesExhausted
+			n.Title = fmt.Sprintf("Chief: %s failed after %d retries", prdName, e.RetryCount)
+		}
+		n.Message = "Unknown error"
+		if e.Err != nil {
+			n.Message = e.Err.Error()
+		}
+	case loop.EventMaxIterationsReached:
+		n.Event = EventMaxIterations
+		n.Title = fmt.Sprintf("Chief: %s stopped at max iterations", prdName)
+		n.Message = fmt.Sprintf("Stopped after %d iterations with stories remaining.", e.Iteration)
+	case loop.EventComplete:
+		n.Event = EventComplete
+		n.Title = fmt.Sprintf("Chief: %s complete", prdName)
+		n.Message = "All stories pass."
+	default:
+		return Notification{}, false
+	}
+	return n, true
+}
+
+func isEvent(name string) bool {
+	return contains(Events, name)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
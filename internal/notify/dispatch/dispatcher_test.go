@@ -0,0 +1,244 @@
+package dispatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/izdrail/chief/internal/config"
+	"github.com/izdrail/chief/internal/loop"
+)
+
+func TestFromEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event loop.Event
+		want  string
+	}{
+		{"story completed", loop.Event{Type: loop.EventStoryCompleted, StoryID: "US-001", Text: "Login"}, EventStoryCompleted},
+		{"error", loop.Event{Type: loop.EventError, Err: errors.New("boom"), RetryCount: 1, RetryMax: 3}, EventError},
+		{"retries exhausted", loop.Event{Type: loop.EventError, Err: errors.New("boom"), RetryCount: 3, RetryMax: 3}, EventRetriesExhausted},
+		{"retry disabled", loop.Event{Type: loop.EventError, Err: errors.New("boom"), RetryMax: 3}, EventError},
+		{"max iterations", loop.Event{Type: loop.EventMaxIterationsReached, Iteration: 10}, EventMaxIterations},
+		{"complete", loop.Event{Type: loop.EventComplete}, EventComplete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, ok := FromEvent("auth", tt.event)
+			if !ok || n.Event != tt.want || n.PRD != "auth" || n.Title == "" {
+				t.Errorf("FromEvent() = %+v, %v; want event %q", n, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := FromEvent("auth", loop.Event{Type: loop.EventToolStart}); ok {
+		t.Error("expected tool events not to be notified")
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.NotifyConfig
+		want string
+	}{
+		{"unknown type", config.NotifyConfig{Channels: []config.NotifyChannel{{Name: "x", Type: "pager"}}}, "unknown type"},
+		{"missing url", config.NotifyConfig{Channels: []config.NotifyChannel{{Type: "slack"}}}, "url is required"},
+		{"duplicate", config.NotifyConfig{Channels: []config.NotifyChannel{{Type: "desktop"}, {Type: "desktop"}}}, "duplicate"},
+		{"unknown channel", config.NotifyConfig{Channels: []config.NotifyChannel{{Type: "desktop"}}, Rules: []config.NotifyRule{{Events: []string{"error"}, Channels: []string{"team"}}}}, `unknown channel "team"`},
+		{"unknown event", config.NotifyConfig{Rules: []config.NotifyRule{{Events: []string{"started"}}}}, `unknown event "started"`},
+		{"email", config.NotifyConfig{Channels: []config.NotifyChannel{{Type: "email", SMTPHost: "smtp.example.com"}}}, "from and to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("New() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// recorder is a channel that records what it receives.
+type recorder struct {
+	mu   sync.Mutex
+	got  []Notification
+	fail error
+}
+
+func (r *recorder) Send(ctx context.Context, n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.got = append(r.got, n)
+	return r.fail
+}
+
+func (r *recorder) events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []string
+	for _, n := range r.got {
+		events = append(events, n.PRD+":"+n.Event)
+	}
+	return events
+}
+
+func TestDispatcherRouting(t *testing.T) {
+	d, err := New(config.NotifyConfig{
+		Channels: []config.NotifyChannel{{Name: "team", Type: "desktop"}, {Name: "oncall", Type: "desktop"}},
+		Rules: []config.NotifyRule{
+			{Events: []string{EventComplete, EventStoryCompleted}, Channels: []string{"team"}},
+			{Events: []string{EventRetriesExhausted}, PRDs: []string{"billing"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	team, oncall := &recorder{}, &recorder{}
+	d.channels["team"], d.channels["oncall"] = team, oncall
+
+	d.HandleEvent("auth", loop.Event{Type: loop.EventComplete})
+	d.HandleEvent("auth", loop.Event{Type: loop.EventError, RetryCount: 3, RetryMax: 3})
+	d.HandleEvent("billing", loop.Event{Type: loop.EventError, RetryCount: 3, RetryMax: 3})
+	d.HandleEvent("billing", loop.Event{Type: loop.EventAssistantText, Text: "hi"})
+	d.Wait()
+
+	if got := strings.Join(team.events(), ","); got != "auth:complete,billing:retriesExhausted" && got != "billing:retriesExhausted,auth:complete" {
+		t.Errorf("team got %q", got)
+	}
+	if got := strings.Join(oncall.events(), ","); got != "billing:retriesExhausted" {
+		t.Errorf("oncall got %q", got)
+	}
+}
+
+func TestDispatcherDefaultRulesAndErrors(t *testing.T) {
+	d, err := New(config.NotifyConfig{Channels: []config.NotifyChannel{{Name: "team", Type: "desktop"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	team := &recorder{fail: errors.New("unreachable")}
+	d.channels["team"] = team
+	var failures []string
+	var mu sync.Mutex
+	d.SetErrorHandler(func(channel string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures = append(failures, channel+": "+err.Error())
+	})
+
+	d.HandleEvent("auth", loop.Event{Type: loop.EventStoryCompleted, StoryID: "US-001"})
+	d.HandleEvent("auth", loop.Event{Type: loop.EventError, Err: errors.New("boom")})
+	d.Wait()
+
+	if got := strings.Join(team.events(), ","); got != "auth:error" {
+		t.Errorf("default rules delivered %q, want only the error", got)
+	}
+	if len(failures) != 1 || failures[0] != "team: unreachable" {
+		t.Errorf("unexpected failures: %v", failures)
+	}
+}
+
+func TestWebhookChannels(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string]map[string]interface{}{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid JSON: %v", err)
+		}
+		if r.URL.Path == "/fail" {
+			http.Error(w, "no such hook", http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		bodies[r.URL.Path] = body
+		if r.URL.Path == "/hook" && r.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("missing custom header")
+		}
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	n, _ := FromEvent("auth", loop.Event{Type: loop.EventComplete})
+	hook := &webhookChannel{url: srv.URL + "/hook", headers: map[string]string{"Authorization": "Bearer secret"}}
+	if err := hook.Send(context.Background(), n); err != nil {
+		t.Fatalf("webhook Send() error: %v", err)
+	}
+	chat := &chatChannel{url: srv.URL + "/slack"}
+	if err := chat.Send(context.Background(), n); err != nil {
+		t.Fatalf("chat Send() error: %v", err)
+	}
+
+	if got := bodies["/hook"]; got["event"] != EventComplete || got["prd"] != "auth" {
+		t.Errorf("unexpected webhook payload: %v", got)
+	}
+	if text, _ := bodies["/slack"]["text"].(string); !strings.Contains(text, "*Chief: auth complete*") {
+		t.Errorf("unexpected chat payload: %v", bodies["/slack"])
+	}
+
+	failing := &webhookChannel{url: srv.URL + "/fail"}
+	if err := failing.Send(context.Background(), n); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+}
+
+func TestDesktopChannel(t *testing.T) {
+	var args []string
+	execCommand = func(ctx context.Context, name string, arg ...string) *exec.Cmd {
+		args = append([]string{name}, arg...)
+		return exec.CommandContext(ctx, "true")
+	}
+	defer func() { execCommand = exec.CommandContext }()
+
+	n, _ := FromEvent("auth", loop.Event{Type: loop.EventError, Err: errors.New("boom")})
+	if err := (&desktopChannel{}).Send(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"notify-send", "--app-name=Chief", "--urgency=critical", "Chief: auth failed", "boom"}
+	if strings.Join(args, "|") != strings.Join(want, "|") {
+		t.Errorf("notify-send args = %q, want %q", args, want)
+	}
+}
+
+func TestEmailChannel(t *testing.T) {
+	t.Setenv("CHIEF_TEST_SMTP_PASSWORD", "hunter2")
+	ch, err := newEmailChannel(config.NotifyChannel{
+		Type: "email", SMTPHost: "smtp.example.com", Username: "bot", PasswordEnv: "CHIEF_TEST_SMTP_PASSWORD",
+		From: "chief@example.com", To: []string{"a@example.com", "b@example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch.addr != "smtp.example.com:587" || ch.password != "hunter2" {
+		t.Errorf("unexpected email channel: %+v", ch)
+	}
+
+	var gotAddr string
+	var gotTo []string
+	var gotMsg string
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotTo, gotMsg = addr, to, string(msg)
+		return nil
+	}
+	defer func() { sendMail = smtp.SendMail }()
+
+	n, _ := FromEvent("auth", loop.Event{Type: loop.EventMaxIterationsReached, Iteration: 5})
+	if err := ch.Send(context.Background(), n); err != nil {
+		t.Fatal(err)
+	}
+	if gotAddr != "smtp.example.com:587" || len(gotTo) != 2 {
+		t.Errorf("unexpected delivery to %s %v", gotAddr, gotTo)
+	}
+	for _, want := range []string{"Subject: Chief: auth stopped at max iterations\r\n", "To: a@example.com, b@example.com\r\n", "Stopped after 5 iterations"} {
+		if !strings.Contains(gotMsg, want) {
+			t.Errorf("expected message to contain %q, got:\n%s", want, gotMsg)
+		}
+	}
+}
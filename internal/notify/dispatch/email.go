@@ -0,0 +1,91 @@
+package dispatch
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/izdrail/chief/internal/config"
+)
+
+// sendMail is replaced in tests.
+var sendMail = smtp.SendMail
+
+// emailChannel sends notifications by SMTP.
+type emailChannel struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func newEmailChannel(c config.NotifyChannel) (*emailChannel, error) {
+	if c.SMTPHost == "" {
+		return nil, fmt.Errorf("smtpHost is required")
+	}
+	if c.From == "" || len(c.To) == 0 {
+		return nil, fmt.Errorf("from and to are required")
+	}
+	port := c.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	ch := &emailChannel{
+		addr:     net.JoinHostPort(c.SMTPHost, strconv.Itoa(port)),
+		host:     c.SMTPHost,
+		username: c.Username,
+		from:     c.From,
+		to:       c.To,
+	}
+	if c.PasswordEnv != "" {
+		ch.password = os.Getenv(c.PasswordEnv)
+	}
+	return ch, nil
+}
+
+func (c *emailChannel) Send(ctx context.Context, n Notification) error {
+	var auth smtp.Auth
+	if c.username != "" {
+		auth = smtp.PlainAuth("", c.username, c.password, c.host)
+	}
+
+	// net/smtp has no context support; run it so a hung server cannot block past the deadline
+	done := make(chan error, 1)
+	go func() {
+		done <- sendMail(c.addr, auth, c.from, c.to, c.message(n))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// message formats a plain-text email.
+func (c *emailChannel) message(n Notification) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", c.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(c.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", n.Title)
+	fmt.Fprintf(&b, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(n.Message, "\n", "\r\n"))
+	b.WriteString("\r\n\r\n")
+	fmt.Fprintf(&b, "PRD: %s\r\n", n.PRD)
+	if n.StoryID != "" {
+		fmt.Fprintf(&b, "Story: %s\r\n", n.StoryID)
+	}
+	if n.Iteration > 0 {
+		fmt.Fprintf(&b, "Iteration: %d\r\n", n.Iteration)
+	}
+	return []byte(b.String())
+}
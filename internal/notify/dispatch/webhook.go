@@ -0,0 +1,59 @@
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// webhookChannel posts the notification as JSON to a URL.
+type webhookChannel struct {
+	url     string
+	headers map[string]string
+}
+
+func (c *webhookChannel) Send(ctx context.Context, n Notification) error {
+	return postJSON(ctx, c.url, c.headers, n)
+}
+
+// chatChannel posts to a Slack or Mattermost incoming webhook, which both
+// accept a {"text": "..."} payload.
+type chatChannel struct {
+	url     string
+	headers map[string]string
+}
+
+func (c *chatChannel) Send(ctx context.Context, n Notification) error {
+	payload := map[string]string{"text": fmt.Sprintf("*%s*\n%s", n.Title, n.Message)}
+	return postJSON(ctx, c.url, c.headers, payload)
+}
+
+// postJSON posts v as JSON and fails on non-2xx responses.
+func postJSON(ctx context.Context, url string, headers map[string]string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
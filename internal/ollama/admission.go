@@ -0,0 +1,231 @@
+package ollama
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency is how many requests an endpoint serves at once unless
+// configured otherwise.
+const DefaultConcurrency = 1
+
+// Admission is a weighted semaphore that limits the requests in flight to one
+// endpoint. Callers waiting for slots are grouped by tenant (usually a PRD
+// name) and tenants are served in turn, so a PRD making many requests can't
+// starve the others. Within a tenant, requests are served in FIFO order.
+type Admission struct {
+	mu       sync.Mutex
+	capacity int64
+	inUse    int64
+	queues   map[string][]*waiter // Waiters per tenant, oldest first
+	turns    []string             // Tenants with waiters, next to be served first
+}
+
+type waiter struct {
+	weight int64
+	ready  chan struct{} // Closed once the slots are granted
+}
+
+// NewAdmission creates an admission controller with capacity slots.
+func NewAdmission(capacity int64) *Admission {
+	if capacity <= 0 {
+		capacity = DefaultConcurrency
+	}
+	return &Admission{capacity: capacity, queues: make(map[string][]*waiter)}
+}
+
+// SetCapacity changes the number of slots. Requests already admitted keep
+// their slots.
+func (a *Admission) SetCapacity(capacity int64) {
+	if capacity <= 0 {
+		capacity = DefaultConcurrency
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.capacity = capacity
+	a.grant()
+}
+
+// Capacity returns the number of slots.
+func (a *Admission) Capacity() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.capacity
+}
+
+// Acquire waits until weight slots are free and tenant's turn has come, then
+// takes them. It returns how long it waited and a function that gives the
+// slots back. Weights above the capacity are treated as the whole capacity.
+func (a *Admission) Acquire(ctx context.Context, tenant string, weight int64) (release func(), wait time.Duration, err error) {
+	if weight <= 0 {
+		weight = 1
+	}
+
+	a.mu.Lock()
+	if weight > a.capacity {
+		weight = a.capacity
+	}
+	if len(a.turns) == 0 && a.inUse+weight <= a.capacity {
+		a.inUse += weight
+		a.mu.Unlock()
+		return a.releaser(weight), 0, nil
+	}
+
+	w := &waiter{weight: weight, ready: make(chan struct{})}
+	if len(a.queues[tenant]) == 0 {
+		a.turns = append(a.turns, tenant)
+	}
+	a.queues[tenant] = append(a.queues[tenant], w)
+	a.mu.Unlock()
+
+	start := time.Now()
+	select {
+	case <-w.ready:
+		// grant may have lowered the weight after the capacity shrank
+		return a.releaser(w.weight), time.Since(start), nil
+	case <-ctx.Done():
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	select {
+	case <-w.ready:
+		// Granted while giving up; hand the slots on
+		a.inUse -= w.weight
+	default:
+		a.remove(tenant, w)
+	}
+	a.grant()
+	return nil, time.Since(start), ctx.Err()
+}
+
+// releaser returns a function that gives weight slots back once.
+func (a *Admission) releaser(weight int64) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			a.inUse -= weight
+			a.grant()
+		})
+	}
+}
+
+// grant admits waiters in turn order while their slots fit. A waiter that
+// doesn't fit holds up the ones behind it, so heavy requests aren't starved by
+// light ones. a.mu must be held.
+func (a *Admission) grant() {
+	for len(a.turns) > 0 {
+		tenant := a.turns[0]
+		w := a.queues[tenant][0]
+		if w.weight > a.capacity {
+			w.weight = a.capacity
+		}
+		if a.inUse+w.weight > a.capacity {
+			return
+		}
+		a.inUse += w.weight
+		close(w.ready)
+
+		a.turns = a.turns[1:]
+		if rest := a.queues[tenant][1:]; len(rest) > 0 {
+			a.queues[tenant] = rest
+			a.turns = append(a.turns, tenant)
+		} else {
+			delete(a.queues, tenant)
+		}
+	}
+}
+
+// remove drops a waiter that gave up. a.mu must be held.
+func (a *Admission) remove(tenant string, w *waiter) {
+	queue := a.queues[tenant]
+	for i, q := range queue {
+		if q == w {
+			queue = append(queue[:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) > 0 {
+		a.queues[tenant] = queue
+		return
+	}
+	delete(a.queues, tenant)
+	for i, t := range a.turns {
+		if t == tenant {
+			a.turns = append(a.turns[:i], a.turns[i+1:]...)
+			break
+		}
+	}
+}
+
+var (
+	admissionMu        sync.Mutex
+	admissions         = make(map[string]*Admission)
+	endpointLimits     = make(map[string]int)
+	defaultConcurrency = DefaultConcurrency
+)
+
+// AdmissionFor returns the admission controller shared by every client of the
+// endpoint at baseURL.
+func AdmissionFor(baseURL string) *Admission {
+	key := endpointKey(baseURL)
+	admissionMu.Lock()
+	defer admissionMu.Unlock()
+	a, ok := admissions[key]
+	if !ok {
+		a = NewAdmission(int64(limitFor(key)))
+		admissions[key] = a
+	}
+	return a
+}
+
+// SetConcurrency sets how many requests each endpoint serves at once. Limits
+// in endpoints, keyed by base URL, override the default for those endpoints.
+// Values of 0 or less use DefaultConcurrency.
+func SetConcurrency(defaultLimit int, endpoints map[string]int) {
+	admissionMu.Lock()
+	defer admissionMu.Unlock()
+	defaultConcurrency = defaultLimit
+	endpointLimits = make(map[string]int, len(endpoints))
+	for url, n := range endpoints {
+		endpointLimits[endpointKey(url)] = n
+	}
+	for key, a := range admissions {
+		a.SetCapacity(int64(limitFor(key)))
+	}
+}
+
+// limitFor returns the configured limit for an endpoint. admissionMu must be held.
+func limitFor(key string) int {
+	if n, ok := endpointLimits[key]; ok && n > 0 {
+		return n
+	}
+	if defaultConcurrency > 0 {
+		return defaultConcurrency
+	}
+	return DefaultConcurrency
+}
+
+func endpointKey(baseURL string) string {
+	return strings.TrimRight(strings.ToLower(strings.TrimSpace(baseURL)), "/")
+}
+
+type tenantKey struct{}
+
+type queueWaitKey struct{}
+
+// WithTenant returns a context whose requests queue for an endpoint as tenant.
+// Waiting tenants are served in turn.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// WithQueueWaitHandler returns a context whose requests call fn with the time
+// they spent waiting for a slot, whenever they had to wait.
+func WithQueueWaitHandler(ctx context.Context, fn func(wait time.Duration)) context.Context {
+	return context.WithValue(ctx, queueWaitKey{}, fn)
+}
@@ -0,0 +1,209 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// acquireAsync starts an Acquire and returns a channel that receives its
+// release func once admitted. It waits until the request is queued.
+func acquireAsync(t *testing.T, a *Admission, tenant string, weight int64) <-chan func() {
+	t.Helper()
+	a.mu.Lock()
+	before := len(a.queues[tenant])
+	a.mu.Unlock()
+
+	ch := make(chan func(), 1)
+	go func() {
+		release, _, err := a.Acquire(context.Background(), tenant, weight)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		ch <- release
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		a.mu.Lock()
+		queued := len(a.queues[tenant]) > before
+		a.mu.Unlock()
+		if queued {
+			return ch
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("request for %s was not queued", tenant)
+	return nil
+}
+
+func admitted(ch <-chan func()) (func(), bool) {
+	select {
+	case release := <-ch:
+		return release, true
+	case <-time.After(50 * time.Millisecond):
+		return nil, false
+	}
+}
+
+func TestAdmissionFairness(t *testing.T) {
+	a := NewAdmission(1)
+	release, wait, err := a.Acquire(context.Background(), "busy", 1)
+	if err != nil || wait != 0 {
+		t.Fatalf("Acquire() = %v, %v; want immediate admission", wait, err)
+	}
+
+	// "busy" queues three requests before "quiet" queues one
+	busy1 := acquireAsync(t, a, "busy", 1)
+	busy2 := acquireAsync(t, a, "busy", 1)
+	quiet := acquireAsync(t, a, "quiet", 1)
+
+	var order []string
+	pending := map[string]<-chan func(){"busy1": busy1, "busy2": busy2, "quiet": quiet}
+	for len(pending) > 0 {
+		release()
+		var next string
+		for name, ch := range pending {
+			if r, ok := admitted(ch); ok {
+				next, release = name, r
+				break
+			}
+		}
+		if next == "" {
+			t.Fatalf("nothing admitted after a release; order so far %v", order)
+		}
+		order = append(order, next)
+		delete(pending, next)
+	}
+	release()
+
+	want := []string{"busy1", "quiet", "busy2"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("admission order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestAdmissionWeights(t *testing.T) {
+	a := NewAdmission(3)
+	small, _, _ := a.Acquire(context.Background(), "a", 2)
+
+	heavy := acquireAsync(t, a, "b", 2)
+	light := acquireAsync(t, a, "c", 1)
+	if _, ok := admitted(light); ok {
+		t.Fatal("a light request overtook a waiting heavy one")
+	}
+
+	small()
+	releaseHeavy, ok := admitted(heavy)
+	if !ok {
+		t.Fatal("heavy request not admitted after slots were released")
+	}
+	releaseLight, ok := admitted(light)
+	if !ok {
+		t.Fatal("light request not admitted while a slot was free")
+	}
+	releaseHeavy()
+	releaseLight()
+
+	// Weights above the capacity take the whole endpoint instead of waiting forever
+	release, _, err := a.Acquire(context.Background(), "a", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	release() // Releasing twice is harmless
+	if a.inUse != 0 {
+		t.Errorf("inUse = %d after all releases", a.inUse)
+	}
+}
+
+func TestAdmissionCancel(t *testing.T) {
+	a := NewAdmission(1)
+	release, _, _ := a.Acquire(context.Background(), "a", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, err := a.Acquire(ctx, "b", 1)
+		errCh <- err
+	}()
+	next := acquireAsync(t, a, "c", 1)
+
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Acquire() error = %v, want context.Canceled", err)
+	}
+	release()
+	if _, ok := admitted(next); !ok {
+		t.Fatal("a canceled waiter blocked the queue")
+	}
+}
+
+func TestClientWaitsForSlot(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		w.Write([]byte(`{"message":{"role":"assistant","content":"ok"},"done":true}`))
+	}))
+	defer srv.Close()
+
+	var waited int
+	admission := NewAdmission(1)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := &Client{BaseURL: srv.URL, HTTPClient: srv.Client(), Admission: admission}
+			ctx := WithQueueWaitHandler(WithTenant(context.Background(), "prd"), func(time.Duration) {
+				mu.Lock()
+				waited++
+				mu.Unlock()
+			})
+			if _, err := c.Chat(ctx, ChatRequest{}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxInFlight != 1 {
+		t.Errorf("max requests in flight = %d, want 1", maxInFlight)
+	}
+	if waited != 2 {
+		t.Errorf("queue-wait handler called %d times, want 2", waited)
+	}
+}
+
+func TestSetConcurrency(t *testing.T) {
+	defer SetConcurrency(0, nil)
+
+	a := AdmissionFor("http://gpu-box:11434/")
+	if a != AdmissionFor("http://GPU-box:11434") {
+		t.Fatal("equivalent base URLs should share an admission controller")
+	}
+	SetConcurrency(2, map[string]int{"http://gpu-box:11434": 4})
+	if got := a.Capacity(); got != 4 {
+		t.Errorf("endpoint capacity = %d, want 4", got)
+	}
+	if got := AdmissionFor("http://other:11434").Capacity(); got != 2 {
+		t.Errorf("default capacity = %d, want 2", got)
+	}
+}
@@ -0,0 +1,297 @@
+package ollama
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Cassette is a recording of the requests a session sent to an endpoint and
+// the responses it got back. Cassettes are JSON files, written by a Recorder
+// and served back by a Replayer.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	Status   int             `json:"status"`
+	Response []string        `json:"response"` // Body lines; a streamed response has one chunk per line
+}
+
+// LoadCassette reads a cassette file.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette to path, replacing it atomically.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Recorder is an http.RoundTripper that sends requests through Base and
+// appends each request and its response to the cassette at Path. A response
+// is recorded when its body is closed, so a stream cut short is recorded as
+// far as it was read.
+type Recorder struct {
+	Path string
+	Base http.RoundTripper // nil uses http.DefaultTransport
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder that starts a new cassette at path.
+func NewRecorder(path string, base http.RoundTripper) *Recorder {
+	return &Recorder{Path: path, Base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	base := r.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		// Transport failures aren't recorded; replaying them isn't useful
+		return nil, err
+	}
+
+	interaction := Interaction{Method: req.Method, Path: req.URL.Path, Status: resp.StatusCode}
+	if len(bytes.TrimSpace(reqBody)) > 0 {
+		interaction.Request = json.RawMessage(reqBody)
+	}
+	resp.Body = &recordingBody{ReadCloser: resp.Body, done: func(body []byte) {
+		interaction.Response = splitLines(body)
+		r.add(interaction)
+	}}
+	return resp, nil
+}
+
+// add appends an interaction and saves the cassette.
+func (r *Recorder) add(i Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	// A cassette that can't be saved only costs the recording, not the session
+	r.cassette.Save(r.Path)
+}
+
+// recordingBody copies a response body as it's read and hands the copy to
+// done when the body is closed.
+type recordingBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func(body []byte)
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.once.Do(func() { b.done(b.buf.Bytes()) })
+	return b.ReadCloser.Close()
+}
+
+func splitLines(body []byte) []string {
+	text := strings.TrimSuffix(string(body), "\n")
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(text, "\n")
+}
+
+// Replayer is an http.RoundTripper that answers requests from a cassette
+// without contacting any server. Each recorded interaction is served once. A
+// request gets the first unused interaction with the same method, path and
+// body. Otherwise, unless Strict is set, it gets the first unused interaction
+// with the same method and path, so a session still replays when prompts
+// contain details that change from run to run, such as temporary paths.
+type Replayer struct {
+	Strict bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer returns a Replayer serving the cassette's interactions.
+func NewReplayer(c *Cassette) *Replayer {
+	return &Replayer{interactions: c.Interactions, used: make([]bool, len(c.Interactions))}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	i, err := r.match(req.Method, req.URL.Path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	var body bytes.Buffer
+	for _, line := range i.Response {
+		body.WriteString(line)
+		body.WriteByte('\n')
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+		StatusCode:    i.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/x-ndjson"}},
+		Body:          io.NopCloser(&body),
+		ContentLength: int64(body.Len()),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns how many recorded interactions haven't been served.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// match finds and claims the interaction that answers a request.
+func (r *Replayer) match(method, path string, body []byte) (Interaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	want := canonicalJSON(body)
+	first := -1
+	for i, in := range r.interactions {
+		if r.used[i] || in.Method != method || in.Path != path {
+			continue
+		}
+		if canonicalJSON(in.Request) == want {
+			r.used[i] = true
+			return in, nil
+		}
+		if first < 0 {
+			first = i
+		}
+	}
+	if first < 0 {
+		return Interaction{}, fmt.Errorf("replay: no recorded response left for %s %s", method, path)
+	}
+	if r.Strict {
+		return Interaction{}, fmt.Errorf("replay: %s %s doesn't match the recorded request %d", method, path, first+1)
+	}
+	r.used[first] = true
+	return r.interactions[first], nil
+}
+
+// canonicalJSON returns data re-encoded with sorted keys and no spacing, so
+// requests compare equal regardless of formatting. Data that isn't JSON is
+// returned unchanged.
+func canonicalJSON(data []byte) string {
+	var v interface{}
+	if json.Unmarshal(data, &v) != nil {
+		return string(bytes.TrimSpace(data))
+	}
+	out, _ := json.Marshal(v)
+	return string(out)
+}
+
+var (
+	sessionsMu sync.Mutex
+	sessions   = make(map[string]http.RoundTripper)
+)
+
+// sessionTransport returns the transport for the record or replay session
+// selected by CHIEF_RECORD or CHIEF_REPLAY, or nil if neither is set. Clients
+// in the same process share one session per cassette, so requests from every
+// loop land in, or are served from, the same file.
+func sessionTransport(base http.RoundTripper) http.RoundTripper {
+	path, record := os.Getenv("CHIEF_REPLAY"), false
+	if path == "" {
+		path, record = os.Getenv("CHIEF_RECORD"), true
+	}
+	if path == "" {
+		return nil
+	}
+
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	if t, ok := sessions[path]; ok {
+		return t
+	}
+	var t http.RoundTripper
+	if record {
+		t = NewRecorder(path, base)
+	} else if c, err := LoadCassette(path); err != nil {
+		t = failingTransport{err}
+	} else {
+		replayer := NewReplayer(c)
+		replayer.Strict = os.Getenv("CHIEF_REPLAY_STRICT") != ""
+		t = replayer
+	}
+	sessions[path] = t
+	return t
+}
+
+// failingTransport fails every request with err.
+type failingTransport struct{ err error }
+
+func (t failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
@@ -0,0 +1,114 @@
+package ollama
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// collect runs a chat request and returns its text and tool call names.
+func collect(t *testing.T, c *Client, req ChatRequest) (string, []string) {
+	t.Helper()
+	var text string
+	var calls []string
+	for event := range c.ChatStream(context.Background(), req) {
+		if event.Error != nil {
+			t.Fatalf("ChatStream() error: %v", event.Error)
+		}
+		text += event.TextDelta
+		for _, tc := range event.ToolCalls {
+			calls = append(calls, tc.Function.Name)
+		}
+	}
+	return text, calls
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Reading "}}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":"it","tool_calls":[{"function":{"name":"Read","arguments":{"file_path":"a.go"}}}]},"done":true}` + "\n"))
+	}))
+	path := filepath.Join(t.TempDir(), "session.json")
+	req := ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: "read a.go"}}}
+
+	recorded := &Client{BaseURL: srv.URL, Model: "m", Admission: NewAdmission(1),
+		HTTPClient: &http.Client{Transport: NewRecorder(path, srv.Client().Transport)}}
+	wantText, wantCalls := collect(t, recorded, req)
+	srv.Close()
+
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cassette.Interactions) != 1 || len(cassette.Interactions[0].Response) != 2 {
+		t.Fatalf("unexpected cassette: %+v", cassette)
+	}
+
+	replayer := NewReplayer(cassette)
+	replayed := &Client{BaseURL: srv.URL, Model: "m", Admission: NewAdmission(1), HTTPClient: &http.Client{Transport: replayer}}
+	text, calls := collect(t, replayed, req)
+	if text != wantText || !reflect.DeepEqual(calls, wantCalls) {
+		t.Errorf("replayed %q %v, recorded %q %v", text, calls, wantText, wantCalls)
+	}
+	if replayer.Remaining() != 0 {
+		t.Errorf("%d interactions left", replayer.Remaining())
+	}
+
+	// Each interaction is served once
+	if _, err := replayed.Chat(context.Background(), req); err == nil {
+		t.Error("expected an error once the cassette is used up")
+	}
+}
+
+func TestReplayMatching(t *testing.T) {
+	cassette := &Cassette{Interactions: []Interaction{
+		{Method: "POST", Path: "/api/chat", Request: []byte(`{"model":"m","messages":[{"role":"user","content":"first"}],"stream":false}`), Status: 200,
+			Response: []string{`{"message":{"role":"assistant","content":"one"},"done":true}`}},
+		{Method: "POST", Path: "/api/chat", Request: []byte(`{"model":"m","messages":[{"role":"user","content":"second"}],"stream":false}`), Status: 200,
+			Response: []string{`{"message":{"role":"assistant","content":"two"},"done":true}`}},
+	}}
+	ask := func(r *Replayer, prompt string) (string, error) {
+		c := &Client{BaseURL: "http://replay", Model: "m", Admission: NewAdmission(1), HTTPClient: &http.Client{Transport: r}}
+		msg, err := c.Chat(context.Background(), ChatRequest{Model: "m", Messages: []Message{{Role: "user", Content: prompt}}})
+		if err != nil {
+			return "", err
+		}
+		return msg.Content, nil
+	}
+
+	// Matching requests are served regardless of order
+	r := NewReplayer(cassette)
+	if got, _ := ask(r, "second"); got != "two" {
+		t.Errorf("second = %q", got)
+	}
+	// A changed request gets the next unused interaction
+	if got, _ := ask(r, "changed"); got != "one" {
+		t.Errorf("changed = %q", got)
+	}
+
+	strict := NewReplayer(cassette)
+	strict.Strict = true
+	if _, err := ask(strict, "changed"); err == nil {
+		t.Error("strict replay served a changed request")
+	}
+}
+
+func TestNewClientReplaysFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	cassette := &Cassette{Interactions: []Interaction{{Method: "POST", Path: "/api/chat", Status: 200,
+		Response: []string{`{"message":{"role":"assistant","content":"replayed"},"done":true}`}}}}
+	if err := cassette.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CHIEF_REPLAY", path)
+	t.Setenv("OLLAMA_HOST", "http://127.0.0.1:1") // Nothing listens here
+
+	c := NewClient()
+	c.Admission = NewAdmission(1)
+	if text, _ := collect(t, c, ChatRequest{Model: c.Model}); text != "replayed" {
+		t.Errorf("text = %q", text)
+	}
+}
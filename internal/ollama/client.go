@@ -11,6 +11,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -19,19 +20,53 @@ const (
 	DefaultBaseURL = "https://ai.izdrail.com"
 	// DefaultModel is the default model to use.
 	DefaultModel = "codellama:7b"
-	// DefaultTimeout is the default HTTP client timeout.
-	DefaultTimeout = 10 * time.Minute
 )
 
-// Client is an Ollama API client.
+// Client is an Ollama API client. Requests wait for a slot from the
+// endpoint's shared Admission before they are sent; see WithTenant. A request
+// whose response stalls fails with ErrorStalled; see Timeouts.
 type Client struct {
 	BaseURL    string
 	Model      string
 	HTTPClient *http.Client
+	Admission  *Admission // nil uses AdmissionFor(BaseURL)
+	Weight     int64      // Slots each request takes (default: 1)
+	Fallbacks  []Target   // Models or endpoints to switch to, in order; see NextFallback
+	Timeouts   Timeouts   // Zero fields use the defaults set by SetTimeouts
+	// ToolCallFormat selects the parsers for tool calls written into the
+	// response text; empty uses the format set by SetToolCallFormat
+	ToolCallFormat string
+}
+
+// Target is a model on an endpoint.
+type Target struct {
+	BaseURL string // Empty keeps the client's current endpoint
+	Model   string // Empty keeps the client's current model
+}
+
+// NextFallback switches the client to its next fallback target and removes it
+// from Fallbacks. It returns false when there are no fallbacks left. The
+// switch lasts for the client's remaining requests, so it must not be called
+// while the client has requests in flight.
+func (c *Client) NextFallback() (Target, bool) {
+	if len(c.Fallbacks) == 0 {
+		return Target{}, false
+	}
+	next := c.Fallbacks[0]
+	c.Fallbacks = c.Fallbacks[1:]
+	if next.BaseURL != "" {
+		c.BaseURL = next.BaseURL
+	}
+	if next.Model != "" {
+		c.Model = next.Model
+	}
+	return Target{BaseURL: c.BaseURL, Model: c.Model}, true
 }
 
 // NewClient creates a new Ollama client with defaults.
 // The base URL and model can be overridden via OLLAMA_HOST and OLLAMA_MODEL env vars.
+// CHIEF_RECORD records the client's requests to a cassette file, and
+// CHIEF_REPLAY answers them from one instead of the server; see Cassette.
 func NewClient() *Client {
 	baseURL := DefaultBaseURL
 	if v := os.Getenv("OLLAMA_HOST"); v != "" {
@@ -41,13 +76,16 @@ func NewClient() *Client {
 	if v := os.Getenv("OLLAMA_MODEL"); v != "" {
 		model = v
 	}
-	return &Client{
+	c := &Client{
 		BaseURL: baseURL,
 		Model:   model,
-		HTTPClient: &http.Client{
-			Timeout: DefaultTimeout,
-		},
 	}
+	var transport http.RoundTripper = c.newTransport()
+	if session := sessionTransport(transport); session != nil {
+		transport = session
+	}
+	c.HTTPClient = &http.Client{Transport: transport}
+	return c
 }
 
 // Message represents a chat message.
@@ -106,6 +144,16 @@ type ChatResponse struct {
 	Message   Message `json:"message"`
 	Done      bool    `json:"done"`
 	DoneReason string `json:"done_reason,omitempty"`
+	Error     string  `json:"error,omitempty"` // Set when the server fails mid-stream
+
+	PromptEvalCount int `json:"prompt_eval_count,omitempty"` // Prompt tokens, reported with the last chunk
+	EvalCount       int `json:"eval_count,omitempty"`        // Generated tokens, reported with the last chunk
+}
+
+// Usage is the token count of a response.
+type Usage struct {
+	PromptTokens int `json:"promptTokens,omitempty"`
+	OutputTokens int `json:"outputTokens,omitempty"`
 }
 
 // StreamEvent is emitted during streaming.
@@ -116,6 +164,8 @@ type StreamEvent struct {
 	ToolCalls []ToolCall
 	// Done is true when the stream is complete.
 	Done bool
+	// Usage is set with Done when the server reports token counts.
+	Usage Usage
 	// Error is set if streaming encountered an error.
 	Error error
 }
@@ -135,7 +185,17 @@ func (c *Client) ChatStream(ctx context.Context, req ChatRequest) <-chan StreamE
 			return
 		}
 
-		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		release, err := c.admit(ctx)
+		if err != nil {
+			ch <- StreamEvent{Error: err}
+			return
+		}
+		defer release()
+
+		reqCtx, watchdog := c.watch(ctx)
+		defer watchdog.stop()
+
+		httpReq, err := http.NewRequestWithContext(reqCtx, http.MethodPost,
 			c.BaseURL+"/api/chat", bytes.NewReader(body))
 		if err != nil {
 			ch <- StreamEvent{Error: fmt.Errorf("create request: %w", err)}
@@ -145,23 +205,25 @@ func (c *Client) ChatStream(ctx context.Context, req ChatRequest) <-chan StreamE
 
 		resp, err := c.HTTPClient.Do(httpReq)
 		if err != nil {
-			ch <- StreamEvent{Error: fmt.Errorf("http request: %w", err)}
+			ch <- StreamEvent{Error: c.transportError(req.Model, watchdog, ErrorUnknown, fmt.Errorf("http request: %w", err))}
 			return
 		}
 		defer resp.Body.Close()
 
 		if resp.StatusCode != http.StatusOK {
 			body, _ := io.ReadAll(resp.Body)
-			ch <- StreamEvent{Error: fmt.Errorf("ollama API error %d: %s", resp.StatusCode, string(body))}
+			ch <- StreamEvent{Error: c.statusError(req.Model, resp.StatusCode, body)}
 			return
 		}
 
 		// Accumulate tool calls across chunks (Ollama may split them)
 		var accumulatedToolCalls []ToolCall
+		var content strings.Builder
 
 		scanner := bufio.NewScanner(resp.Body)
 		scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
 		for scanner.Scan() {
+			watchdog.progress()
 			line := scanner.Bytes()
 			if len(line) == 0 {
 				continue
@@ -169,7 +231,16 @@ func (c *Client) ChatStream(ctx context.Context, req ChatRequest) <-chan StreamE
 
 			var chunk ChatResponse
 			if err := json.Unmarshal(line, &chunk); err != nil {
-				ch <- StreamEvent{Error: fmt.Errorf("parse chunk: %w", err)}
+				ch <- StreamEvent{Error: c.requestError(req.Model, ErrorMalformedStream, fmt.Errorf("parse chunk: %w", err))}
+				return
+			}
+			if chunk.Error != "" {
+				ch <- StreamEvent{Error: &Error{
+					Class:    classifyMessage(0, chunk.Error),
+					Endpoint: c.BaseURL,
+					Model:    req.Model,
+					Err:      fmt.Errorf("ollama stream error: %s", chunk.Error),
+				}}
 				return
 			}
 
@@ -180,20 +251,37 @@ func (c *Client) ChatStream(ctx context.Context, req ChatRequest) <-chan StreamE
 
 			// Emit text delta
 			if chunk.Message.Content != "" {
+				content.WriteString(chunk.Message.Content)
 				ch <- StreamEvent{TextDelta: chunk.Message.Content}
 			}
 
 			if chunk.Done {
+				// Models without native tool support write calls into the text
+				if len(accumulatedToolCalls) == 0 {
+					accumulatedToolCalls = c.parseToolCalls(req, content.String())
+				}
 				if len(accumulatedToolCalls) > 0 {
 					ch <- StreamEvent{ToolCalls: accumulatedToolCalls}
 				}
-				ch <- StreamEvent{Done: true}
+				ch <- StreamEvent{Done: true, Usage: Usage{PromptTokens: chunk.PromptEvalCount, OutputTokens: chunk.EvalCount}}
 				return
 			}
 		}
 
 		if err := scanner.Err(); err != nil {
-			ch <- StreamEvent{Error: fmt.Errorf("read stream: %w", err)}
+			class := classifyTransport(err)
+			if class == ErrorUnknown && ctx.Err() == nil {
+				class = ErrorMalformedStream
+			}
+			ch <- StreamEvent{Error: c.transportError(req.Model, watchdog, class, fmt.Errorf("read stream: %w", err))}
+			return
+		}
+		if err := watchdog.stalled(); err != nil {
+			ch <- StreamEvent{Error: c.requestError(req.Model, ErrorStalled, err)}
+			return
+		}
+		if ctx.Err() == nil {
+			ch <- StreamEvent{Error: c.requestError(req.Model, ErrorMalformedStream, fmt.Errorf("stream ended before the response was done"))}
 		}
 	}()
 
@@ -209,7 +297,18 @@ func (c *Client) Chat(ctx context.Context, req ChatRequest) (*Message, error) {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+	release, err := c.admit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// The whole response arrives at once, so it must be ready within the
+	// first-token timeout
+	reqCtx, watchdog := c.watch(ctx)
+	defer watchdog.stop()
+
+	httpReq, err := http.NewRequestWithContext(reqCtx, http.MethodPost,
 		c.BaseURL+"/api/chat", bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
@@ -218,19 +317,38 @@ func (c *Client) Chat(ctx context.Context, req ChatRequest) (*Message, error) {
 
 	resp, err := c.HTTPClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("http request: %w", err)
+		return nil, c.transportError(req.Model, watchdog, ErrorUnknown, fmt.Errorf("http request: %w", err))
 	}
 	defer resp.Body.Close()
+	watchdog.progress()
 
 	if resp.StatusCode != http.StatusOK {
 		b, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("ollama API error %d: %s", resp.StatusCode, string(b))
+		return nil, c.statusError(req.Model, resp.StatusCode, b)
 	}
 
 	var chatResp ChatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
+		return nil, c.transportError(req.Model, watchdog, ErrorMalformedStream, fmt.Errorf("decode response: %w", err))
 	}
 
 	return &chatResp.Message, nil
 }
+
+// admit waits for a request slot on the client's endpoint, reporting the wait
+// to the context's queue-wait handler.
+func (c *Client) admit(ctx context.Context) (release func(), err error) {
+	admission := c.Admission
+	if admission == nil {
+		admission = AdmissionFor(c.BaseURL)
+	}
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	release, wait, err := admission.Acquire(ctx, tenant, c.Weight)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for a request slot: %w", err)
+	}
+	if fn, ok := ctx.Value(queueWaitKey{}).(func(time.Duration)); ok && wait > 0 {
+		fn(wait)
+	}
+	return release, nil
+}
@@ -0,0 +1,229 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// ErrorClass groups request failures by what can be done about them.
+type ErrorClass int
+
+const (
+	// ErrorUnknown is a failure that fits no other class.
+	ErrorUnknown ErrorClass = iota
+	// ErrorConnection means the endpoint couldn't be reached.
+	ErrorConnection
+	// ErrorTimeout means the request or stream took too long.
+	ErrorTimeout
+	// ErrorModelNotFound means the endpoint doesn't have the model.
+	ErrorModelNotFound
+	// ErrorContextOverflow means the conversation no longer fits the model's context.
+	ErrorContextOverflow
+	// ErrorOverloaded means the server is busy or out of memory.
+	ErrorOverloaded
+	// ErrorMalformedStream means the response couldn't be parsed or ended early.
+	ErrorMalformedStream
+	// ErrorInvalidRequest means the server rejected the request itself.
+	ErrorInvalidRequest
+	// ErrorStalled means the response didn't start, or stopped arriving, within
+	// the client's Timeouts.
+	ErrorStalled
+)
+
+// String returns the class name.
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorConnection:
+		return "connection"
+	case ErrorTimeout:
+		return "timeout"
+	case ErrorModelNotFound:
+		return "model-not-found"
+	case ErrorContextOverflow:
+		return "context-overflow"
+	case ErrorOverloaded:
+		return "overloaded"
+	case ErrorMalformedStream:
+		return "malformed-stream"
+	case ErrorInvalidRequest:
+		return "invalid-request"
+	case ErrorStalled:
+		return "stalled"
+	default:
+		return "unknown"
+	}
+}
+
+// Action is what a caller should do about a failed request.
+type Action int
+
+const (
+	// ActionRetry sends the same request again after a delay.
+	ActionRetry Action = iota
+	// ActionFallback sends the request to the next fallback model or endpoint.
+	ActionFallback
+	// ActionCompact shortens the conversation and sends it again.
+	ActionCompact
+	// ActionAbort gives up; sending the request again won't help.
+	ActionAbort
+)
+
+// String returns the action name.
+func (a Action) String() string {
+	switch a {
+	case ActionFallback:
+		return "fallback"
+	case ActionCompact:
+		return "compact"
+	case ActionAbort:
+		return "abort"
+	default:
+		return "retry"
+	}
+}
+
+// Action returns the first thing to try for an error of class c. Callers that
+// can't fall back or compact any further should retry if Retryable says so,
+// and give up otherwise.
+func (c ErrorClass) Action() Action {
+	switch c {
+	case ErrorConnection, ErrorModelNotFound:
+		return ActionFallback
+	case ErrorContextOverflow:
+		return ActionCompact
+	case ErrorInvalidRequest:
+		return ActionAbort
+	default:
+		return ActionRetry
+	}
+}
+
+// Retryable reports whether sending the same request again may succeed.
+func (c ErrorClass) Retryable() bool {
+	return c != ErrorModelNotFound && c != ErrorInvalidRequest
+}
+
+// Error is a classified request failure.
+type Error struct {
+	Class      ErrorClass
+	StatusCode int    // HTTP status, 0 if the server didn't answer
+	Endpoint   string // Base URL the request went to
+	Model      string
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Classify returns the class of an error returned by the client. Errors that
+// didn't come from the client are classified by their cause where possible.
+func Classify(err error) ErrorClass {
+	if err == nil {
+		return ErrorUnknown
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Class
+	}
+	return classifyTransport(err)
+}
+
+// classifyTransport classifies an error from sending a request or reading its
+// response body.
+func classifyTransport(err error) ErrorClass {
+	// A dial that times out is an endpoint that can't be reached
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ErrorConnection
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return ErrorConnection
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorTimeout
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EHOSTUNREACH) {
+		return ErrorConnection
+	}
+	return ErrorUnknown
+}
+
+// classifyMessage classifies an error message reported by the server.
+func classifyMessage(status int, msg string) ErrorClass {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "model") && (strings.Contains(lower, "not found") || strings.Contains(lower, "pull")):
+		return ErrorModelNotFound
+	case strings.Contains(lower, "context") && (strings.Contains(lower, "length") || strings.Contains(lower, "window") ||
+		strings.Contains(lower, "exceed") || strings.Contains(lower, "too long")):
+		return ErrorContextOverflow
+	case strings.Contains(lower, "busy") || strings.Contains(lower, "overloaded") || strings.Contains(lower, "out of memory") ||
+		strings.Contains(lower, "too many requests"):
+		return ErrorOverloaded
+	}
+
+	switch {
+	case status == http.StatusNotFound:
+		return ErrorModelNotFound
+	case status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable || status == http.StatusBadGateway:
+		return ErrorOverloaded
+	case status == http.StatusGatewayTimeout || status == http.StatusRequestTimeout:
+		return ErrorTimeout
+	case status >= 400 && status < 500:
+		return ErrorInvalidRequest
+	default:
+		return ErrorUnknown
+	}
+}
+
+// statusError builds the error for a non-200 response.
+func (c *Client) statusError(model string, status int, body []byte) error {
+	msg := strings.TrimSpace(string(body))
+	// Ollama reports errors as {"error": "..."}
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+		msg = apiErr.Error
+	}
+	return &Error{
+		Class:      classifyMessage(status, msg),
+		StatusCode: status,
+		Endpoint:   c.BaseURL,
+		Model:      model,
+		Err:        fmt.Errorf("ollama API error %d: %s", status, msg),
+	}
+}
+
+// requestError wraps an error from sending a request or reading its response.
+func (c *Client) requestError(model string, class ErrorClass, err error) error {
+	if class == ErrorUnknown {
+		class = classifyTransport(err)
+	}
+	return &Error{Class: class, Endpoint: c.BaseURL, Model: model, Err: err}
+}
+
+// transportError is requestError for a request guarded by a watchdog. When the
+// watchdog canceled the request, the stall is reported instead of err.
+func (c *Client) transportError(model string, w *watchdog, class ErrorClass, err error) error {
+	if stall := w.stalled(); stall != nil {
+		return c.requestError(model, ErrorStalled, stall)
+	}
+	return c.requestError(model, class, err)
+}
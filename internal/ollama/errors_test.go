@@ -0,0 +1,131 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// streamError runs ChatStream against handler and returns the error it reports.
+func streamError(t *testing.T, handler http.HandlerFunc) error {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL, Model: "m", HTTPClient: srv.Client(), Admission: NewAdmission(1)}
+
+	var err error
+	for event := range c.ChatStream(context.Background(), ChatRequest{Model: "m"}) {
+		if event.Error != nil {
+			err = event.Error
+		}
+	}
+	return err
+}
+
+func TestChatStreamErrorClasses(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    ErrorClass
+	}{
+		{"model not found", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model \"m\" not found, try pulling it first"}`))
+		}, ErrorModelNotFound},
+		{"context overflow", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"input length exceeds the context length"}`))
+		}, ErrorContextOverflow},
+		{"overloaded", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"server busy, please try again"}`))
+		}, ErrorOverloaded},
+		{"out of memory", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"model requires more system memory than is available (out of memory)"}`))
+		}, ErrorOverloaded},
+		{"invalid request", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid message format"}`))
+		}, ErrorInvalidRequest},
+		{"error mid-stream", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("{\"message\":{\"role\":\"assistant\",\"content\":\"hi\"}}\n{\"error\":\"context window exceeded\"}\n"))
+		}, ErrorContextOverflow},
+		{"malformed chunk", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("{\"message\":{\"role\":\"assistant\",\"content\":\"hi\"}}\nnot json\n"))
+		}, ErrorMalformedStream},
+		{"truncated stream", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("{\"message\":{\"role\":\"assistant\",\"content\":\"hi\"}}\n"))
+		}, ErrorMalformedStream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := streamError(t, tt.handler)
+			if got := Classify(err); got != tt.want {
+				t.Errorf("Classify(%v) = %v, want %v", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatStreamTransportErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+	c := &Client{BaseURL: url, Model: "m", HTTPClient: http.DefaultClient, Admission: NewAdmission(1)}
+	if _, err := c.Chat(context.Background(), ChatRequest{Model: "m"}); Classify(err) != ErrorConnection {
+		t.Errorf("refused connection classified as %v (%v)", Classify(err), err)
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer slow.Close()
+	c = &Client{BaseURL: slow.URL, Model: "m", HTTPClient: &http.Client{Timeout: 20 * time.Millisecond}, Admission: NewAdmission(1)}
+	_, err := c.Chat(context.Background(), ChatRequest{Model: "m"})
+	if Classify(err) != ErrorTimeout {
+		t.Errorf("timeout classified as %v (%v)", Classify(err), err)
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Endpoint != slow.URL || apiErr.Model != "m" {
+		t.Errorf("expected an *Error naming the endpoint and model, got %#v", err)
+	}
+}
+
+func TestErrorClassActions(t *testing.T) {
+	want := map[ErrorClass]Action{
+		ErrorConnection:      ActionFallback,
+		ErrorModelNotFound:   ActionFallback,
+		ErrorContextOverflow: ActionCompact,
+		ErrorTimeout:         ActionRetry,
+		ErrorOverloaded:      ActionRetry,
+		ErrorMalformedStream: ActionRetry,
+		ErrorInvalidRequest:  ActionAbort,
+		ErrorStalled:         ActionRetry,
+	}
+	for class, action := range want {
+		if got := class.Action(); got != action {
+			t.Errorf("%v.Action() = %v, want %v", class, got, action)
+		}
+	}
+	if ErrorModelNotFound.Retryable() || ErrorInvalidRequest.Retryable() || !ErrorOverloaded.Retryable() {
+		t.Error("unexpected Retryable results")
+	}
+}
+
+func TestNextFallback(t *testing.T) {
+	c := &Client{BaseURL: "http://primary", Model: "big", Fallbacks: []Target{{Model: "small"}, {BaseURL: "http://backup", Model: "big"}}}
+
+	if target, ok := c.NextFallback(); !ok || target != (Target{BaseURL: "http://primary", Model: "small"}) {
+		t.Errorf("first fallback = %+v, %v", target, ok)
+	}
+	if target, ok := c.NextFallback(); !ok || target != (Target{BaseURL: "http://backup", Model: "big"}) {
+		t.Errorf("second fallback = %+v, %v", target, ok)
+	}
+	if _, ok := c.NextFallback(); ok {
+		t.Error("expected fallbacks to run out")
+	}
+}
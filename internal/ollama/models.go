@@ -0,0 +1,120 @@
+package ollama
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ModelInfo describes a model installed on the Ollama server.
+type ModelInfo struct {
+	Name  string `json:"name"`
+	Model string `json:"model"`
+	Size  int64  `json:"size"`
+}
+
+// ModelDetails is the part of /api/show's response that Chief uses.
+type ModelDetails struct {
+	Capabilities []string               `json:"capabilities"` // e.g. "completion", "tools"; empty on older servers
+	ModelInfo    map[string]interface{} `json:"model_info"`
+}
+
+// ListModels returns the models installed on the server (/api/tags).
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	var resp struct {
+		Models []ModelInfo `json:"models"`
+	}
+	if err := c.getJSON(ctx, http.MethodGet, "/api/tags", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Models, nil
+}
+
+// ShowModel returns details of an installed model (/api/show).
+func (c *Client) ShowModel(ctx context.Context, name string) (*ModelDetails, error) {
+	var details ModelDetails
+	if err := c.getJSON(ctx, http.MethodPost, "/api/show", map[string]string{"model": name}, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// SupportsTools reports whether the model supports tool calling. known is
+// false when the server doesn't report capabilities.
+func (d *ModelDetails) SupportsTools() (supported, known bool) {
+	if len(d.Capabilities) == 0 {
+		return false, false
+	}
+	for _, c := range d.Capabilities {
+		if c == "tools" {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// ContextLength returns the longest context the model was trained for, or 0
+// if the server doesn't report it.
+func (d *ModelDetails) ContextLength() int {
+	for key, v := range d.ModelInfo {
+		if !strings.HasSuffix(key, ".context_length") {
+			continue
+		}
+		if n, ok := v.(float64); ok {
+			return int(n)
+		}
+	}
+	return 0
+}
+
+// HasModel reports whether name is among models. A name without a tag matches
+// the model's "latest" tag.
+func HasModel(models []ModelInfo, name string) bool {
+	if !strings.Contains(name, ":") {
+		name += ":latest"
+	}
+	for _, m := range models {
+		if m.Name == name || m.Model == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) getJSON(ctx context.Context, method, path string, reqBody, out interface{}) error {
+	var body io.Reader
+	if reqBody != nil {
+		data, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	if reqBody != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama API error %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package ollama
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultConnectTimeout is how long a client waits to connect to an endpoint.
+	DefaultConnectTimeout = 10 * time.Second
+	// DefaultFirstTokenTimeout is how long a client waits for the first chunk of
+	// a response. It covers loading the model and reading the prompt, which is
+	// slow for big prompts on CPU-only machines.
+	DefaultFirstTokenTimeout = 5 * time.Minute
+	// DefaultIdleTimeout is how long a streaming response may go without a chunk.
+	DefaultIdleTimeout = 2 * time.Minute
+)
+
+// Timeouts limit how long a request waits on its endpoint. Nothing limits the
+// total length of a response, so long generations run as long as they keep
+// streaming. Zero fields use the process defaults set by SetTimeouts;
+// negative fields disable that limit.
+type Timeouts struct {
+	Connect    time.Duration // Connecting to the endpoint
+	FirstToken time.Duration // From sending the request to the first response chunk
+	Idle       time.Duration // Between response chunks
+}
+
+var (
+	timeoutsMu      sync.Mutex
+	defaultTimeouts = Timeouts{
+		Connect:    DefaultConnectTimeout,
+		FirstToken: DefaultFirstTokenTimeout,
+		Idle:       DefaultIdleTimeout,
+	}
+)
+
+// SetTimeouts sets the timeouts used by clients that don't set their own.
+// Zero fields use the package defaults.
+func SetTimeouts(t Timeouts) {
+	timeoutsMu.Lock()
+	defer timeoutsMu.Unlock()
+	defaultTimeouts = Timeouts{
+		Connect:    orDefault(t.Connect, DefaultConnectTimeout),
+		FirstToken: orDefault(t.FirstToken, DefaultFirstTokenTimeout),
+		Idle:       orDefault(t.Idle, DefaultIdleTimeout),
+	}
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+	return d
+}
+
+// timeouts returns the client's timeouts with unset fields filled in.
+func (c *Client) timeouts() Timeouts {
+	timeoutsMu.Lock()
+	defaults := defaultTimeouts
+	timeoutsMu.Unlock()
+	return Timeouts{
+		Connect:    orDefault(c.Timeouts.Connect, defaults.Connect),
+		FirstToken: orDefault(c.Timeouts.FirstToken, defaults.FirstToken),
+		Idle:       orDefault(c.Timeouts.Idle, defaults.Idle),
+	}
+}
+
+// newTransport returns an HTTP transport whose dials are limited by the
+// client's connect timeout at the time of each dial.
+func (c *Client) newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialer := net.Dialer{KeepAlive: 30 * time.Second}
+		if d := c.timeouts().Connect; d > 0 {
+			dialer.Timeout = d
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return transport
+}
+
+// stallError is the cause a watchdog cancels its request with.
+type stallError struct {
+	waitingFor string
+	limit      time.Duration
+}
+
+func (e *stallError) Error() string {
+	return fmt.Sprintf("no %s from the model in %s", e.waitingFor, e.limit)
+}
+
+// watchdog cancels a request when its response doesn't start within the
+// first-token timeout or stops arriving for longer than the idle timeout.
+// Its methods must be called from the goroutine reading the response.
+type watchdog struct {
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	idle    time.Duration
+	timer   *time.Timer
+	started bool
+}
+
+// watch starts a watchdog for a request sent with the returned context.
+func (c *Client) watch(ctx context.Context) (context.Context, *watchdog) {
+	t := c.timeouts()
+	ctx, cancel := context.WithCancelCause(ctx)
+	w := &watchdog{ctx: ctx, cancel: cancel, idle: t.Idle}
+	if t.FirstToken > 0 {
+		w.timer = time.AfterFunc(t.FirstToken, func() {
+			cancel(&stallError{waitingFor: "response", limit: t.FirstToken})
+		})
+	}
+	return ctx, w
+}
+
+// progress records that part of the response arrived.
+func (w *watchdog) progress() {
+	if w.started {
+		if w.timer != nil {
+			w.timer.Reset(w.idle)
+		}
+		return
+	}
+	w.started = true
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.idle > 0 {
+		idle := w.idle
+		w.timer = time.AfterFunc(idle, func() {
+			w.cancel(&stallError{waitingFor: "output", limit: idle})
+		})
+	}
+}
+
+// stop releases the watchdog once the request is finished.
+func (w *watchdog) stop() {
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.cancel(nil)
+}
+
+// stalled returns the error the watchdog canceled the request with, or nil
+// if it didn't.
+func (w *watchdog) stalled() error {
+	var stall *stallError
+	if errors.As(context.Cause(w.ctx), &stall) {
+		return stall
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package ollama
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// chunk is one streamed response line.
+const chunk = "{\"message\":{\"role\":\"assistant\",\"content\":\"x\"}}\n"
+
+// hang blocks until the client gives up on r. The body has to be read for
+// the server to notice the client going away.
+func hang(r *http.Request) {
+	io.Copy(io.Discard, r.Body)
+	<-r.Context().Done()
+}
+
+// timedStream runs ChatStream with timeouts against handler and returns the
+// streamed text and the error it reports.
+func timedStream(t *testing.T, timeouts Timeouts, handler http.HandlerFunc) (string, error) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL, Model: "m", HTTPClient: srv.Client(), Admission: NewAdmission(1), Timeouts: timeouts}
+
+	var text string
+	var err error
+	for event := range c.ChatStream(context.Background(), ChatRequest{Model: "m"}) {
+		text += event.TextDelta
+		if event.Error != nil {
+			err = event.Error
+		}
+	}
+	return text, err
+}
+
+func TestChatStreamStalls(t *testing.T) {
+	timeouts := Timeouts{FirstToken: 100 * time.Millisecond, Idle: 50 * time.Millisecond}
+
+	// Nothing arrives
+	_, err := timedStream(t, timeouts, func(w http.ResponseWriter, r *http.Request) {
+		hang(r)
+	})
+	if Classify(err) != ErrorStalled {
+		t.Errorf("silent server classified as %v (%v)", Classify(err), err)
+	}
+
+	// The stream stops after the first chunk
+	text, err := timedStream(t, timeouts, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(chunk))
+		w.(http.Flusher).Flush()
+		hang(r)
+	})
+	if Classify(err) != ErrorStalled || text != "x" {
+		t.Errorf("stalled stream: text = %q, class = %v (%v)", text, Classify(err), err)
+	}
+}
+
+func TestChatStreamOutlivesTimeouts(t *testing.T) {
+	// A stream that keeps arriving may run far longer than any single timeout
+	text, err := timedStream(t, Timeouts{FirstToken: 50 * time.Millisecond, Idle: 50 * time.Millisecond},
+		func(w http.ResponseWriter, r *http.Request) {
+			for i := 0; i < 10; i++ {
+				w.Write([]byte(chunk))
+				w.(http.Flusher).Flush()
+				time.Sleep(20 * time.Millisecond)
+			}
+			w.Write([]byte(`{"message":{"role":"assistant"},"done":true}` + "\n"))
+		})
+	if err != nil || text != "xxxxxxxxxx" {
+		t.Errorf("text = %q, err = %v", text, err)
+	}
+}
+
+func TestChatFirstTokenTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hang(r)
+	}))
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL, Model: "m", HTTPClient: srv.Client(), Admission: NewAdmission(1),
+		Timeouts: Timeouts{FirstToken: 50 * time.Millisecond}}
+
+	if _, err := c.Chat(context.Background(), ChatRequest{Model: "m"}); Classify(err) != ErrorStalled {
+		t.Errorf("classified as %v (%v)", Classify(err), err)
+	}
+}
+
+func TestConnectTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	c := NewClient()
+	c.BaseURL = srv.URL
+	c.Admission = NewAdmission(1)
+	c.Timeouts = Timeouts{Connect: time.Nanosecond}
+
+	if _, err := c.Chat(context.Background(), ChatRequest{Model: "m"}); Classify(err) != ErrorConnection {
+		t.Errorf("classified as %v (%v)", Classify(err), err)
+	}
+}
+
+func TestClientTimeoutDefaults(t *testing.T) {
+	defer SetTimeouts(Timeouts{})
+
+	SetTimeouts(Timeouts{Idle: time.Minute})
+	c := &Client{Timeouts: Timeouts{FirstToken: -1}}
+	got := c.timeouts()
+	want := Timeouts{Connect: DefaultConnectTimeout, FirstToken: -1, Idle: time.Minute}
+	if got != want {
+		t.Errorf("timeouts() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,319 @@
+package ollama
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ToolCallParser extracts tool calls that a model wrote into its text instead
+// of returning them in message.tool_calls. ChatStream runs the parsers chosen
+// for the model when a response that was offered tools comes back without
+// native tool calls.
+type ToolCallParser interface {
+	// Name is the format name used to select the parser in config.
+	Name() string
+	// Parse returns the tool calls written in content, or nil if there are none.
+	Parse(content string) []ToolCall
+}
+
+const (
+	// ToolCallFormatAuto tries every registered parser, starting with the one
+	// the model's family usually writes.
+	ToolCallFormatAuto = "auto"
+	// ToolCallFormatNone only accepts native tool calls.
+	ToolCallFormatNone = "none"
+)
+
+var (
+	parsersMu         sync.Mutex
+	parsers           = make(map[string]ToolCallParser)
+	parserOrder       []string // Registration order, which auto follows after the model's preferred format
+	defaultCallFormat = ToolCallFormatAuto
+)
+
+// modelFormats maps model family name fragments to the format they usually
+// write tool calls in.
+var modelFormats = []struct {
+	fragment string
+	format   string
+}{
+	{"qwen", "hermes"},
+	{"hermes", "hermes"},
+	{"nous", "hermes"},
+	{"mistral", "mistral"},
+	{"mixtral", "mistral"},
+	{"codestral", "mistral"},
+	{"devstral", "mistral"},
+}
+
+func init() {
+	RegisterToolCallParser(hermesParser{})
+	RegisterToolCallParser(mistralParser{})
+	RegisterToolCallParser(jsonParser{})
+}
+
+// RegisterToolCallParser makes p available by its name, replacing any parser
+// registered under the same name.
+func RegisterToolCallParser(p ToolCallParser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	if _, ok := parsers[p.Name()]; !ok {
+		parserOrder = append(parserOrder, p.Name())
+	}
+	parsers[p.Name()] = p
+}
+
+// ToolCallFormats returns the names of the registered parsers.
+func ToolCallFormats() []string {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	names := append([]string(nil), parserOrder...)
+	sort.Strings(names)
+	return names
+}
+
+// SetToolCallFormat sets the format used by clients that don't set their own:
+// a registered parser name, ToolCallFormatAuto or ToolCallFormatNone. An empty
+// format means ToolCallFormatAuto.
+func SetToolCallFormat(format string) error {
+	if _, err := ToolCallParsersFor(format, ""); err != nil {
+		return err
+	}
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	defaultCallFormat = format
+	return nil
+}
+
+// ToolCallParsersFor returns the parsers to run, in order, for a model's
+// responses in the given format.
+func ToolCallParsersFor(format, model string) ([]ToolCallParser, error) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	switch format {
+	case ToolCallFormatNone:
+		return nil, nil
+	case "", ToolCallFormatAuto:
+		preferred := ""
+		lower := strings.ToLower(model)
+		for _, m := range modelFormats {
+			if strings.Contains(lower, m.fragment) {
+				preferred = m.format
+				break
+			}
+		}
+		var list []ToolCallParser
+		if p, ok := parsers[preferred]; ok {
+			list = append(list, p)
+		}
+		for _, name := range parserOrder {
+			if name != preferred {
+				list = append(list, parsers[name])
+			}
+		}
+		return list, nil
+	}
+	p, ok := parsers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown tool call format %q (want %s, %s or %s)", format,
+			ToolCallFormatAuto, ToolCallFormatNone, strings.Join(parserOrder, ", "))
+	}
+	return []ToolCallParser{p}, nil
+}
+
+// parseToolCalls extracts the tool calls written into a response's content,
+// keeping only calls to the tools the request offered.
+func (c *Client) parseToolCalls(req ChatRequest, content string) []ToolCall {
+	if len(req.Tools) == 0 || strings.TrimSpace(content) == "" {
+		return nil
+	}
+	format := c.ToolCallFormat
+	if format == "" {
+		parsersMu.Lock()
+		format = defaultCallFormat
+		parsersMu.Unlock()
+	}
+	list, err := ToolCallParsersFor(format, req.Model)
+	if err != nil {
+		return nil
+	}
+
+	offered := make(map[string]bool, len(req.Tools))
+	for _, t := range req.Tools {
+		offered[t.Function.Name] = true
+	}
+	for _, p := range list {
+		var calls []ToolCall
+		for _, call := range p.Parse(content) {
+			if offered[call.Function.Name] {
+				calls = append(calls, call)
+			}
+		}
+		if len(calls) > 0 {
+			return calls
+		}
+	}
+	return nil
+}
+
+// hermesParser reads the <tool_call>{...}</tool_call> tags written by Hermes,
+// Qwen and models trained on their chat templates.
+type hermesParser struct{}
+
+func (hermesParser) Name() string { return "hermes" }
+
+func (hermesParser) Parse(content string) []ToolCall {
+	const openTag, closeTag = "<tool_call>", "</tool_call>"
+	var calls []ToolCall
+	for {
+		start := strings.Index(content, openTag)
+		if start < 0 {
+			return calls
+		}
+		content = content[start+len(openTag):]
+		body := content
+		// The closing tag is often missing when the call ends the response
+		if end := strings.Index(content, closeTag); end >= 0 {
+			body = content[:end]
+			content = content[end+len(closeTag):]
+		}
+		calls = append(calls, decodeToolCalls(body)...)
+	}
+}
+
+// mistralParser reads Mistral's [TOOL_CALLS] marker, followed either by a JSON
+// list of calls or, in newer templates, by name[ARGS]{...}.
+type mistralParser struct{}
+
+func (mistralParser) Name() string { return "mistral" }
+
+func (mistralParser) Parse(content string) []ToolCall {
+	const marker, args = "[TOOL_CALLS]", "[ARGS]"
+	segments := strings.Split(content, marker)
+	if len(segments) < 2 {
+		return nil
+	}
+	var calls []ToolCall
+	for _, seg := range segments[1:] {
+		seg = strings.TrimSpace(seg)
+		if strings.HasPrefix(seg, "[") || strings.HasPrefix(seg, "{") {
+			calls = append(calls, decodeToolCalls(seg)...)
+			continue
+		}
+		name, rest, ok := strings.Cut(seg, args)
+		if !ok {
+			continue
+		}
+		var raw json.RawMessage
+		if json.NewDecoder(strings.NewReader(rest)).Decode(&raw) != nil {
+			continue
+		}
+		if call, ok := newToolCall(strings.TrimSpace(name), raw); ok {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// fencePattern matches a fenced code block with an optional language tag.
+var fencePattern = regexp.MustCompile("(?s)```[a-zA-Z_]*[ \t]*\n(.*?)```")
+
+// jsonParser reads calls written as JSON objects with "name" and "arguments"
+// fields, either in fenced code blocks or as the whole response.
+type jsonParser struct{}
+
+func (jsonParser) Name() string { return "json" }
+
+func (jsonParser) Parse(content string) []ToolCall {
+	var calls []ToolCall
+	for _, m := range fencePattern.FindAllStringSubmatch(content, -1) {
+		calls = append(calls, decodeToolCalls(m[1])...)
+	}
+	if len(calls) > 0 {
+		return calls
+	}
+	return decodeToolCalls(content)
+}
+
+// textToolCall is a tool call as models write it in text. Models variously
+// use "arguments" or "parameters", and some wrap the call OpenAI-style in
+// "function".
+type textToolCall struct {
+	Name       string          `json:"name"`
+	Arguments  json.RawMessage `json:"arguments"`
+	Parameters json.RawMessage `json:"parameters"`
+	Function   *struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+// decodeToolCalls decodes the JSON object or list of objects at the start of
+// text. Anything after the first JSON value is ignored.
+func decodeToolCalls(text string) []ToolCall {
+	text = strings.TrimSpace(text)
+	if text == "" || (text[0] != '{' && text[0] != '[') {
+		return nil
+	}
+	var raw json.RawMessage
+	if json.NewDecoder(strings.NewReader(text)).Decode(&raw) != nil {
+		return nil
+	}
+
+	var list []textToolCall
+	if raw[0] == '[' {
+		if json.Unmarshal(raw, &list) != nil {
+			return nil
+		}
+	} else {
+		var one textToolCall
+		if json.Unmarshal(raw, &one) != nil {
+			return nil
+		}
+		list = []textToolCall{one}
+	}
+
+	var calls []ToolCall
+	for _, t := range list {
+		name, args := t.Name, t.Arguments
+		if len(args) == 0 {
+			args = t.Parameters
+		}
+		if t.Function != nil {
+			name, args = t.Function.Name, t.Function.Arguments
+		}
+		if call, ok := newToolCall(name, args); ok {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// newToolCall builds a call from a name and its arguments, which must be a
+// JSON object or a string holding one. Missing arguments are an empty object.
+func newToolCall(name string, args json.RawMessage) (ToolCall, bool) {
+	if name == "" {
+		return ToolCall{}, false
+	}
+	args = bytes.TrimSpace(args)
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+	if args[0] == '"' {
+		var s string
+		if json.Unmarshal(args, &s) != nil {
+			return ToolCall{}, false
+		}
+		args = json.RawMessage(strings.TrimSpace(s))
+	}
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(args, &obj) != nil {
+		return ToolCall{}, false
+	}
+	return ToolCall{Type: "function", Function: FunctionCall{Name: name, Arguments: args}}, true
+}
@@ -0,0 +1,96 @@
+package ollama
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestToolCallParsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		parser  ToolCallParser
+		content string
+		want    []string // name:arguments
+	}{
+		{"hermes", hermesParser{},
+			"Let me look.\n<tool_call>\n{\"name\": \"Read\", \"arguments\": {\"path\": \"a.go\"}}\n</tool_call>\n<tool_call>{\"name\": \"Bash\", \"arguments\": {\"command\": \"ls\"}}",
+			[]string{`Read:{"path": "a.go"}`, `Bash:{"command": "ls"}`}},
+		{"hermes without calls", hermesParser{}, "Nothing to do.", nil},
+		{"mistral list", mistralParser{},
+			`[TOOL_CALLS] [{"name": "Read", "arguments": {"path": "a.go"}}, {"name": "Bash", "arguments": "{\"command\": \"ls\"}"}]`,
+			[]string{`Read:{"path": "a.go"}`, `Bash:{"command": "ls"}`}},
+		{"mistral args", mistralParser{},
+			`[TOOL_CALLS]Read[ARGS]{"path": "a.go"}`,
+			[]string{`Read:{"path": "a.go"}`}},
+		{"fenced json", jsonParser{},
+			"I'll read it:\n```json\n{\"name\": \"Read\", \"parameters\": {\"path\": \"a.go\"}}\n```\n",
+			[]string{`Read:{"path": "a.go"}`}},
+		{"bare json", jsonParser{},
+			`{"function": {"name": "Bash", "arguments": {"command": "ls"}}}`,
+			[]string{`Bash:{"command": "ls"}`}},
+		{"json without a name", jsonParser{}, "```json\n{\"project\": \"P\"}\n```", nil},
+		{"arguments not an object", jsonParser{}, `{"name": "Read", "arguments": 3}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range tt.parser.Parse(tt.content) {
+				got = append(got, c.Function.Name+":"+string(c.Function.Arguments))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Parse() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolCallParsersFor(t *testing.T) {
+	list, err := ToolCallParsersFor("", "qwen2.5-coder:7b")
+	if err != nil || len(list) != len(ToolCallFormats()) || list[0].Name() != "hermes" {
+		t.Errorf("auto for qwen = %v, %v", list, err)
+	}
+	list, _ = ToolCallParsersFor(ToolCallFormatAuto, "mistral-nemo")
+	if list[0].Name() != "mistral" {
+		t.Errorf("auto for mistral starts with %s", list[0].Name())
+	}
+	if list, _ := ToolCallParsersFor(ToolCallFormatNone, "qwen"); len(list) != 0 {
+		t.Errorf("none = %v", list)
+	}
+	if _, err := ToolCallParsersFor("xml", ""); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestChatStreamParsesTextToolCalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"role":"assistant","content":"<tool_call>{\"name\": \"Read\", \"arguments\": {}}</tool_call>"}}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":"<tool_call>{\"name\": \"Delete\", \"arguments\": {}}</tool_call>"},"done":true}` + "\n"))
+	}))
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL, Model: "qwen2.5-coder", HTTPClient: srv.Client(), Admission: NewAdmission(1)}
+	req := ChatRequest{Model: c.Model, Tools: []Tool{{Type: "function", Function: ToolFunction{Name: "Read"}}}}
+
+	toolCalls := func() []ToolCall {
+		var calls []ToolCall
+		for event := range c.ChatStream(context.Background(), req) {
+			if event.Error != nil {
+				t.Fatalf("ChatStream() error: %v", event.Error)
+			}
+			calls = append(calls, event.ToolCalls...)
+		}
+		return calls
+	}
+
+	// Only the offered tool is called
+	if calls := toolCalls(); len(calls) != 1 || calls[0].Function.Name != "Read" {
+		t.Errorf("tool calls = %+v", calls)
+	}
+
+	c.ToolCallFormat = ToolCallFormatNone
+	if calls := toolCalls(); len(calls) != 0 {
+		t.Errorf("tool calls with parsing off = %+v", calls)
+	}
+}
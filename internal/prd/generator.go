@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -34,8 +35,9 @@ const (
 	ChoiceCancel                                  // Cancel conversion
 )
 
-// Convert converts prd.md to prd.json using Ollama one-shot mode.
-// Ollama is responsible for writing the prd.json file directly.
+// Convert converts prd.md to prd.json. A prd.md in the structured format (see
+// ParseMarkdown) is converted deterministically; free-form documents fall back
+// to Ollama one-shot mode, where Ollama writes the prd.json file directly.
 // This function is called:
 // - After chief new (new PRD creation)
 // - After chief edit (PRD modification)
@@ -69,24 +71,13 @@ func Convert(opts ConvertOptions) error {
 		hasProgress = HasProgress(existing)
 	}
 
-	// Run Ollama to convert prd.md and write prd.json directly
-	if err := runOllamaConversion(absPRDDir); err != nil {
-		return err
+	// Parse structured prd.md directly; only free-form documents need Ollama
+	newPRD, err := parseMarkdownFile(prdMdPath)
+	if errors.Is(err, ErrFreeForm) {
+		newPRD, err = convertWithOllama(absPRDDir, prdJsonPath)
 	}
-
-	// Validate that Ollama wrote a valid prd.json
-	newPRD, err := loadAndValidateConvertedPRD(prdJsonPath)
 	if err != nil {
-		// Retry once: ask Ollama to fix the invalid JSON
-		fmt.Println("Conversion produced invalid JSON, retrying...")
-		if retryErr := runOllamaJSONFix(absPRDDir, err); retryErr != nil {
-			return fmt.Errorf("conversion retry failed: %w", retryErr)
-		}
-
-		newPRD, err = loadAndValidateConvertedPRD(prdJsonPath)
-		if err != nil {
-			return fmt.Errorf("conversion produced invalid JSON after retry: %w", err)
-		}
+		return err
 	}
 
 	// Re-save through Go's JSON encoder to guarantee proper escaping and formatting
@@ -156,7 +147,7 @@ func GeneratePRDStream(ctx context.Context, prdDir, name, description string) (<
 		MaxToolRounds: 10,
 	}
 
-	return agent.RunAgent(ctx, client, messages, agentOpts), nil
+	return agent.RunAgent(ollama.WithTenant(ctx, name), client, messages, agentOpts), nil
 }
 
 // GeneratePRD generates a full prd.md from a name and description using Ollama.
@@ -169,7 +160,24 @@ func GeneratePRD(prdDir, name, description string) error {
 }
 
 // ConvertStream returns a stream of events for converting prd.md to prd.json.
+// A structured prd.md is converted immediately and the stream only reports
+// completion; free-form documents are converted by Ollama.
 func ConvertStream(ctx context.Context, absPRDDir string) <-chan agent.AgentEvent {
+	p, err := parseMarkdownFile(filepath.Join(absPRDDir, "prd.md"))
+	if !errors.Is(err, ErrFreeForm) {
+		ch := make(chan agent.AgentEvent, 1)
+		if err == nil {
+			err = p.Save(filepath.Join(absPRDDir, "prd.json"))
+		}
+		if err != nil {
+			ch <- agent.AgentEvent{Error: err}
+		} else {
+			ch <- agent.AgentEvent{Done: true}
+		}
+		close(ch)
+		return ch
+	}
+
 	prompt := embed.GetConvertPrompt(absPRDDir)
 	client := ollama.NewClient()
 
@@ -182,7 +190,41 @@ func ConvertStream(ctx context.Context, absPRDDir string) <-chan agent.AgentEven
 		MaxToolRounds: 20,
 	}
 
-	return agent.RunAgent(ctx, client, messages, agentOpts)
+	return agent.RunAgent(ollama.WithTenant(ctx, filepath.Base(absPRDDir)), client, messages, agentOpts)
+}
+
+// parseMarkdownFile reads and parses a structured prd.md.
+func parseMarkdownFile(path string) (*PRD, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prd.md: %w", err)
+	}
+	return ParseMarkdown(string(data))
+}
+
+// convertWithOllama converts a free-form prd.md with Ollama, retrying once with a
+// JSON fix-up pass if the result is invalid.
+func convertWithOllama(absPRDDir, prdJsonPath string) (*PRD, error) {
+	// Run Ollama to convert prd.md and write prd.json directly
+	if err := runOllamaConversion(absPRDDir); err != nil {
+		return nil, err
+	}
+
+	// Validate that Ollama wrote a valid prd.json
+	newPRD, err := loadAndValidateConvertedPRD(prdJsonPath)
+	if err != nil {
+		// Retry once: ask Ollama to fix the invalid JSON
+		fmt.Println("Conversion produced invalid JSON, retrying...")
+		if retryErr := runOllamaJSONFix(absPRDDir, err); retryErr != nil {
+			return nil, fmt.Errorf("conversion retry failed: %w", retryErr)
+		}
+
+		newPRD, err = loadAndValidateConvertedPRD(prdJsonPath)
+		if err != nil {
+			return nil, fmt.Errorf("conversion produced invalid JSON after retry: %w", err)
+		}
+	}
+	return newPRD, nil
 }
 
 // runOllamaConversion runs Ollama one-shot to convert prd.md and write prd.json.
@@ -328,11 +370,13 @@ func describeToolActivity(tool string, input map[string]interface{}) string {
 			return "Writing " + filepath.Base(path)
 		}
 		return "Writing file"
-	case "Edit":
+	case "Edit", "MultiEdit":
 		if path, ok := input["file_path"].(string); ok {
 			return "Editing " + filepath.Base(path)
 		}
 		return "Editing file"
+	case "ApplyPatch":
+		return "Applying patch"
 	case "Glob":
 		return "Searching files"
 	case "Grep":
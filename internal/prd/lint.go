@@ -0,0 +1,486 @@
+package prd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/izdrail/chief/embed"
+)
+
+// Severity is the severity of a lint diagnostic.
+type Severity int
+
+const (
+	SeverityError   Severity = iota // The PRD cannot be run
+	SeverityWarning                 // The PRD runs but is likely wrong
+)
+
+// String returns the lowercase name of the severity.
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Diagnostic is a problem found in a prd.json file.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Severity Severity
+	Rule     string // Short identifier of the check, e.g. "duplicate-id"
+	Message  string
+}
+
+// String formats the diagnostic as "file:line: severity: message (rule)".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s (%s)", d.File, d.Line, d.Severity, d.Message, d.Rule)
+}
+
+// HasErrors reports whether any diagnostic is an error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidationError is returned when a PRD fails validation.
+type ValidationError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *ValidationError) Error() string {
+	var errs []string
+	for _, d := range e.Diagnostics {
+		if d.Severity == SeverityError {
+			errs = append(errs, d.String())
+		}
+	}
+	return fmt.Sprintf("invalid PRD (%d errors):\n%s", len(errs), strings.Join(errs, "\n"))
+}
+
+// Validate lints the PRD at path and returns a *ValidationError if it has errors.
+func Validate(path string) error {
+	diags, err := Lint(path)
+	if err != nil {
+		return err
+	}
+	if HasErrors(diags) {
+		return &ValidationError{Diagnostics: diags}
+	}
+	return nil
+}
+
+// Lint checks the prd.json at path against the embedded JSON Schema and the
+// semantic rules, returning diagnostics sorted by line.
+func Lint(path string) ([]Diagnostic, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PRD file: %w", err)
+	}
+	return LintData(path, data), nil
+}
+
+// LintData checks prd.json content; file is used in the diagnostics.
+func LintData(file string, data []byte) []Diagnostic {
+	l := &linter{file: file, data: data}
+
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		l.add(l.lineForError(err), SeverityError, "json", fmt.Sprintf("invalid JSON: %v", err))
+		return l.diags
+	}
+	l.lines = locateJSON(data)
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(embed.PRDSchema(), &schema); err != nil {
+		l.add(1, SeverityError, "schema", fmt.Sprintf("embedded schema is invalid: %v", err))
+		return l.diags
+	}
+	l.schema = schema
+	l.validate(doc, schema, "")
+
+	// Semantic checks need the typed PRD; skip them if the shape is wrong
+	var p PRD
+	if err := json.Unmarshal(data, &p); err == nil {
+		l.checkSemantics(&p)
+	}
+
+	sort.SliceStable(l.diags, func(i, j int) bool { return l.diags[i].Line < l.diags[j].Line })
+	return l.diags
+}
+
+// linter accumulates diagnostics for one file.
+type linter struct {
+	file   string
+	data   []byte
+	lines  map[string]int // JSON pointer -> line
+	schema map[string]interface{}
+	diags  []Diagnostic
+}
+
+func (l *linter) add(line int, sev Severity, rule, msg string) {
+	l.diags = append(l.diags, Diagnostic{File: l.file, Line: line, Severity: sev, Rule: rule, Message: msg})
+}
+
+// line returns the line of the value at pointer, falling back to its closest ancestor.
+func (l *linter) line(pointer string) int {
+	for {
+		if line, ok := l.lines[pointer]; ok {
+			return line
+		}
+		if pointer == "" {
+			return 1
+		}
+		pointer = pointer[:strings.LastIndex(pointer, "/")]
+	}
+}
+
+// lineForError returns the line of a JSON decoding error.
+func (l *linter) lineForError(err error) int {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return lineAt(l.data, syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		return lineAt(l.data, typeErr.Offset)
+	}
+	return lineAt(l.data, int64(len(l.data)))
+}
+
+// validate checks value against the subset of JSON Schema used by prd.schema.json:
+// $ref (local), type, required, properties, items, minItems, minLength and minimum.
+func (l *linter) validate(value interface{}, schema map[string]interface{}, pointer string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		if resolved := l.resolveRef(ref); resolved != nil {
+			schema = resolved
+		}
+	}
+
+	if typ, ok := schema["type"].(string); ok && !hasType(value, typ) {
+		l.add(l.line(pointer), SeverityError, "schema", fmt.Sprintf("%s must be %s, got %s", describePointer(pointer), withArticle(typ), jsonTypeName(value)))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if required, ok := schema["required"].([]interface{}); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, present := v[name]; !present {
+					l.add(l.line(pointer), SeverityError, "schema", fmt.Sprintf("%s is missing required field %q", describePointer(pointer), name))
+				}
+			}
+		}
+		if props, ok := schema["properties"].(map[string]interface{}); ok {
+			names := make([]string, 0, len(props))
+			for name := range props {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if child, present := v[name]; present {
+					if subSchema, ok := props[name].(map[string]interface{}); ok {
+						l.validate(child, subSchema, pointer+"/"+escapePointer(name))
+					}
+				}
+			}
+		}
+	case []interface{}:
+		if min, ok := schemaNumber(schema, "minItems"); ok && float64(len(v)) < min {
+			l.add(l.line(pointer), SeverityError, "schema", fmt.Sprintf("%s must have at least %d item(s)", describePointer(pointer), int(min)))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				l.validate(item, items, pointer+"/"+strconv.Itoa(i))
+			}
+		}
+	case string:
+		if min, ok := schemaNumber(schema, "minLength"); ok && float64(len([]rune(v))) < min {
+			l.add(l.line(pointer), SeverityError, "schema", fmt.Sprintf("%s must not be empty", describePointer(pointer)))
+		}
+	case json.Number:
+		if min, ok := schemaNumber(schema, "minimum"); ok {
+			if n, err := v.Float64(); err == nil && n < min {
+				l.add(l.line(pointer), SeverityError, "schema", fmt.Sprintf("%s must be at least %v", describePointer(pointer), min))
+			}
+		}
+	}
+}
+
+// resolveRef resolves a local "#/$defs/name" reference.
+func (l *linter) resolveRef(ref string) map[string]interface{} {
+	var node interface{} = l.schema
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		node = m[part]
+	}
+	resolved, _ := node.(map[string]interface{})
+	return resolved
+}
+
+// checkSemantics runs the checks a schema cannot express.
+func (l *linter) checkSemantics(p *PRD) {
+	ids := make(map[string]int)
+	priorities := make(map[int]string)
+	var inProgress []string
+
+	for i, story := range p.UserStories {
+		base := "/userStories/" + strconv.Itoa(i)
+
+		if story.ID != "" {
+			if first, dup := ids[story.ID]; dup {
+				l.add(l.line(base+"/id"), SeverityError, "duplicate-id", fmt.Sprintf("story ID %q is already used by story %d", story.ID, first+1))
+			} else {
+				ids[story.ID] = i
+			}
+		}
+
+		// Empty titles are reported by the schema; catch whitespace-only ones here
+		if story.Title != "" && strings.TrimSpace(story.Title) == "" {
+			l.add(l.line(base+"/title"), SeverityError, "missing-title", fmt.Sprintf("story %s has a blank title", storyLabel(story, i)))
+		}
+
+		if _, present := l.lines[base+"/acceptanceCriteria"]; present && len(story.AcceptanceCriteria) == 0 {
+			l.add(l.line(base+"/acceptanceCriteria"), SeverityError, "empty-acceptance-criteria", fmt.Sprintf("story %s has no acceptance criteria", storyLabel(story, i)))
+		}
+
+		if other, dup := priorities[story.Priority]; dup {
+			l.add(l.line(base+"/priority"), SeverityWarning, "priority-collision", fmt.Sprintf("story %s has the same priority (%d) as %s; their order is ambiguous", storyLabel(story, i), story.Priority, other))
+		} else {
+			priorities[story.Priority] = storyLabel(story, i)
+		}
+
+		if story.InProgress {
+			inProgress = append(inProgress, storyLabel(story, i))
+			if story.Passes {
+				l.add(l.line(base+"/inProgress"), SeverityWarning, "in-progress-passed", fmt.Sprintf("story %s is marked both passes and inProgress", storyLabel(story, i)))
+			}
+		}
+	}
+
+	if len(inProgress) > 1 {
+		for i, story := range p.UserStories {
+			if story.InProgress {
+				l.add(l.line("/userStories/"+strconv.Itoa(i)+"/inProgress"), SeverityError, "multiple-in-progress",
+					fmt.Sprintf("only one story may be in progress, found %d (%s)", len(inProgress), strings.Join(inProgress, ", ")))
+			}
+		}
+	}
+
+	for i, story := range p.UserStories {
+		for j, dep := range story.DependsOn {
+			pointer := "/userStories/" + strconv.Itoa(i) + "/dependsOn/" + strconv.Itoa(j)
+			switch {
+			case dep == story.ID:
+				l.add(l.line(pointer), SeverityError, "dangling-reference", fmt.Sprintf("story %s depends on itself", storyLabel(story, i)))
+			case !hasKey(ids, dep):
+				l.add(l.line(pointer), SeverityError, "dangling-reference", fmt.Sprintf("story %s depends on unknown story %q", storyLabel(story, i), dep))
+			}
+		}
+	}
+}
+
+// storyLabel identifies a story in messages by ID, or by position when it has none.
+func storyLabel(story UserStory, index int) string {
+	if story.ID != "" {
+		return story.ID
+	}
+	return "#" + strconv.Itoa(index+1)
+}
+
+func hasKey(m map[string]int, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// hasType reports whether a decoded JSON value matches a JSON Schema type.
+func hasType(value interface{}, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	}
+	return true
+}
+
+// jsonTypeName returns the JSON type name of a decoded value.
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	}
+	return "unknown"
+}
+
+func withArticle(typ string) string {
+	if strings.IndexAny(typ[:1], "aeiou") == 0 {
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+func schemaNumber(schema map[string]interface{}, key string) (float64, bool) {
+	n, ok := schema[key].(float64)
+	return n, ok
+}
+
+// describePointer renders a JSON pointer for messages, e.g. "userStories[2].title".
+func describePointer(pointer string) string {
+	if pointer == "" {
+		return "the PRD"
+	}
+	var b strings.Builder
+	for _, part := range strings.Split(pointer[1:], "/") {
+		if _, err := strconv.Atoi(part); err == nil {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~"))
+	}
+	return b.String()
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// lineAt returns the 1-based line containing byte offset in data.
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// locateJSON maps the JSON pointer of every value in data to the line it starts on.
+// Object members map to the line of their key.
+func locateJSON(data []byte) map[string]int {
+	type frame struct {
+		pointer   string
+		isArray   bool
+		index     int
+		key       string
+		expectKey bool
+	}
+
+	lines := map[string]int{"": 1}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []*frame
+
+	childPointer := func() string {
+		top := stack[len(stack)-1]
+		if top.isArray {
+			return top.pointer + "/" + strconv.Itoa(top.index)
+		}
+		return top.pointer + "/" + escapePointer(top.key)
+	}
+	afterValue := func() {
+		if len(stack) == 0 {
+			return
+		}
+		top := stack[len(stack)-1]
+		if top.isArray {
+			top.index++
+		} else {
+			top.expectKey = true
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		line := lineAt(data, dec.InputOffset()-1)
+
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if !top.isArray && top.expectKey {
+				if key, ok := tok.(string); ok {
+					top.key = key
+					top.expectKey = false
+					lines[childPointer()] = line
+					continue
+				}
+			}
+		}
+
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			pointer := ""
+			if len(stack) > 0 {
+				pointer = childPointer()
+				if _, ok := lines[pointer]; !ok {
+					lines[pointer] = line
+				}
+			}
+			stack = append(stack, &frame{pointer: pointer, isArray: tok == json.Delim('['), expectKey: tok == json.Delim('{')})
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			afterValue()
+		default:
+			if len(stack) > 0 {
+				pointer := childPointer()
+				if _, ok := lines[pointer]; !ok {
+					lines[pointer] = line
+				}
+			}
+			afterValue()
+		}
+	}
+	return lines
+}
@@ -0,0 +1,141 @@
+package prd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// findDiagnostic returns the first diagnostic for rule, failing the test if there is none.
+func findDiagnostic(t *testing.T, diags []Diagnostic, rule string) Diagnostic {
+	t.Helper()
+	for _, d := range diags {
+		if d.Rule == rule {
+			return d
+		}
+	}
+	t.Fatalf("expected a %s diagnostic, got %v", rule, diags)
+	return Diagnostic{}
+}
+
+func TestLintData_Valid(t *testing.T) {
+	data := `{
+  "project": "Demo",
+  "userStories": [
+    {"id": "US-001", "title": "First", "acceptanceCriteria": ["works"], "priority": 1, "passes": true},
+    {"id": "US-002", "title": "Second", "acceptanceCriteria": ["works"], "priority": 2, "dependsOn": ["US-001"]}
+  ]
+}`
+	if diags := LintData("prd.json", []byte(data)); len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestLintData_SemanticRules(t *testing.T) {
+	data := `{
+  "project": "Demo",
+  "userStories": [
+    {
+      "id": "US-001",
+      "title": "First",
+      "acceptanceCriteria": [],
+      "priority": 1,
+      "inProgress": true
+    },
+    {
+      "id": "US-001",
+      "title": "   ",
+      "acceptanceCriteria": ["works"],
+      "priority": 1,
+      "inProgress": true,
+      "dependsOn": ["US-404"]
+    }
+  ]
+}`
+	diags := LintData("prd.json", []byte(data))
+
+	tests := []struct {
+		rule     string
+		line     int
+		severity Severity
+	}{
+		{"empty-acceptance-criteria", 7, SeverityError},
+		{"multiple-in-progress", 9, SeverityError},
+		{"duplicate-id", 12, SeverityError},
+		{"missing-title", 13, SeverityError},
+		{"priority-collision", 15, SeverityWarning},
+		{"dangling-reference", 17, SeverityError},
+	}
+	for _, tt := range tests {
+		d := findDiagnostic(t, diags, tt.rule)
+		if d.Line != tt.line || d.Severity != tt.severity {
+			t.Errorf("%s: got line %d %s, want line %d %s", tt.rule, d.Line, d.Severity, tt.line, tt.severity)
+		}
+	}
+	if !HasErrors(diags) {
+		t.Error("expected HasErrors to be true")
+	}
+}
+
+func TestLintData_Schema(t *testing.T) {
+	data := `{
+  "project": "",
+  "userStories": [
+    {
+      "id": "US-001",
+      "acceptanceCriteria": ["works"],
+      "priority": 1.5
+    }
+  ]
+}`
+	diags := LintData("prd.json", []byte(data))
+
+	want := map[string]int{
+		"project must not be empty":                              2,
+		"userStories[0] is missing required field \"title\"":     4,
+		"userStories[0].priority must be an integer, got number": 7,
+	}
+	for msg, line := range want {
+		found := false
+		for _, d := range diags {
+			if d.Message == msg {
+				found = true
+				if d.Line != line {
+					t.Errorf("%q: got line %d, want %d", msg, d.Line, line)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("expected diagnostic %q, got %v", msg, diags)
+		}
+	}
+}
+
+func TestLintData_InvalidJSON(t *testing.T) {
+	diags := LintData("prd.json", []byte("{\n  \"project\": \"Demo\",\n  \"userStories\": [\n}"))
+	d := findDiagnostic(t, diags, "json")
+	if d.Line != 4 {
+		t.Errorf("expected syntax error on line 4, got %d", d.Line)
+	}
+	if !strings.HasPrefix(d.String(), "prd.json:4: error: invalid JSON") {
+		t.Errorf("unexpected format: %s", d)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prd.json")
+	if err := os.WriteFile(path, []byte(`{"project": "Demo", "userStories": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := Validate(path)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "userStories must have at least 1 item(s)") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
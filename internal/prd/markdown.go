@@ -0,0 +1,323 @@
+package prd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Structured prd.md format
+//
+// A prd.md in the structured format converts to prd.json without an LLM:
+//
+//	# Project Name
+//
+//	## Overview
+//	Free text, becomes the PRD description.
+//
+//	## User Stories
+//
+//	### US-001: Story title
+//	**Priority:** 1
+//	**Status:** done
+//	**Depends On:** US-000
+//	**Description:** As a user, I want ...
+//
+//	**Acceptance Criteria:**
+//	- [ ] First criterion
+//	- [ ] Second criterion
+//
+// Priority defaults to the story's position. Status is optional and is one of
+// "todo", "in progress", "done" or "done, in progress". Depends On is an
+// optional comma-separated list of story IDs. Any "## " section other
+// than Overview and User Stories is context for the agent and is ignored.
+// Without an Overview section, the text between the title and the first
+// section is used as the description.
+//
+// A backslash before a line of free text that starts with "#", "**" or a
+// backslash keeps it from being read as a heading or a field; the backslash
+// is dropped from the description.
+
+// ErrFreeForm is returned by ParseMarkdown when a document does not use the
+// structured format and needs LLM conversion.
+var ErrFreeForm = errors.New("prd.md is not in the structured format")
+
+// MarkdownError describes a problem in a structured prd.md.
+type MarkdownError struct {
+	Line int
+	Msg  string
+}
+
+func (e *MarkdownError) Error() string {
+	return fmt.Sprintf("prd.md:%d: %s", e.Line, e.Msg)
+}
+
+var (
+	storyHeadingRe = regexp.MustCompile(`^###\s+([A-Za-z][\w.-]*)\s*[:—–-]\s*(.+?)\s*$`)
+	fieldRe        = regexp.MustCompile(`^\*\*([^*]+?)(?::\*\*|\*\*:)\s*(.*)$`) // **Field:** or **Field**:
+	checkboxRe     = regexp.MustCompile(`^\[[ xX]\]\s*`)
+	numberedRe     = regexp.MustCompile(`^\d+[.)]\s+`)
+)
+
+// ParseMarkdown parses a structured prd.md. It returns ErrFreeForm when the
+// document has no "## User Stories" section with "### ID: Title" stories, and
+// a *MarkdownError for structured documents with invalid content.
+func ParseMarkdown(content string) (*PRD, error) {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+
+	p := &PRD{}
+	section := "" // "" before the first ##, then the lowercased section name
+	var intro, overview []string
+	var story *UserStory
+	var storyLine int
+	var storyField string // field the following lines continue ("description" or "criteria")
+	var description []string
+	seen := make(map[string]int)
+	inFence := false
+
+	finishStory := func() {
+		if story == nil {
+			return
+		}
+		story.Description = strings.TrimSpace(strings.Join(description, "\n"))
+		if story.Priority < 0 {
+			story.Priority = len(p.UserStories) + 1
+		}
+		if story.AcceptanceCriteria == nil {
+			story.AcceptanceCriteria = []string{}
+		}
+		p.UserStories = append(p.UserStories, *story)
+		story, description, storyField = nil, nil, ""
+	}
+
+	for i, raw := range lines {
+		lineNo := i + 1
+		line := strings.TrimRight(raw, " \t")
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+		}
+		if !inFence {
+			switch {
+			case strings.HasPrefix(line, "# ") && p.Project == "" && section == "":
+				p.Project = strings.TrimSpace(line[2:])
+				continue
+			case strings.HasPrefix(line, "## "):
+				finishStory()
+				section = strings.ToLower(strings.TrimSpace(line[3:]))
+				continue
+			case strings.HasPrefix(line, "### ") && section == "user stories":
+				finishStory()
+				m := storyHeadingRe.FindStringSubmatch(line)
+				if m == nil {
+					return nil, &MarkdownError{Line: lineNo, Msg: fmt.Sprintf("story heading must be \"### <ID>: <Title>\", got %q", line)}
+				}
+				if first, dup := seen[m[1]]; dup {
+					return nil, &MarkdownError{Line: lineNo, Msg: fmt.Sprintf("duplicate story ID %s (first defined on line %d)", m[1], first)}
+				}
+				seen[m[1]] = lineNo
+				story = &UserStory{ID: m[1], Title: m[2], Priority: -1} // -1: not set
+				storyLine = lineNo
+				storyField = "description"
+				continue
+			}
+		}
+
+		if !inFence {
+			line = unescapeLine(line)
+		}
+		switch {
+		case section == "":
+			intro = append(intro, line)
+		case section == "overview":
+			overview = append(overview, line)
+		case section == "user stories" && story != nil:
+			if !inFence {
+				if m := fieldRe.FindStringSubmatch(trimmed); m != nil {
+					handled, err := applyField(story, &storyField, &description, m[1], m[2], lineNo)
+					if err != nil {
+						return nil, err
+					}
+					if handled {
+						continue
+					}
+				}
+			}
+			if storyField == "criteria" {
+				if trimmed == "" {
+					continue
+				}
+				item, ok := listItem(trimmed)
+				if !ok {
+					return nil, &MarkdownError{Line: lineNo, Msg: fmt.Sprintf("expected an acceptance criterion list item in story %s (line %d)", story.ID, storyLine)}
+				}
+				story.AcceptanceCriteria = append(story.AcceptanceCriteria, item)
+				continue
+			}
+			description = append(description, line)
+		}
+	}
+	finishStory()
+
+	if len(p.UserStories) == 0 {
+		return nil, ErrFreeForm
+	}
+	if p.Project == "" {
+		return nil, &MarkdownError{Line: 1, Msg: "missing \"# Project Name\" title"}
+	}
+	if overview != nil {
+		p.Description = strings.TrimSpace(strings.Join(overview, "\n"))
+	} else {
+		p.Description = strings.TrimSpace(strings.Join(intro, "\n"))
+	}
+	return p, nil
+}
+
+// applyField applies a "**Field:** value" line to a story. It reports false for
+// unknown fields, which are kept as description text.
+func applyField(story *UserStory, storyField *string, description *[]string, name, value string, lineNo int) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "priority":
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return false, &MarkdownError{Line: lineNo, Msg: fmt.Sprintf("priority must be a non-negative integer, got %q", value)}
+		}
+		story.Priority = n
+	case "status":
+		for _, part := range strings.Split(strings.ToLower(value), ",") {
+			switch strings.TrimSpace(part) {
+			case "done", "passes", "passing", "complete":
+				story.Passes = true
+			case "in progress", "in-progress", "inprogress":
+				story.InProgress = true
+			case "todo", "to do", "pending", "":
+			default:
+				return false, &MarkdownError{Line: lineNo, Msg: fmt.Sprintf("unknown status %q (use todo, in progress or done)", strings.TrimSpace(part))}
+			}
+		}
+	case "depends on":
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				story.DependsOn = append(story.DependsOn, id)
+			}
+		}
+	case "description":
+		*storyField = "description"
+		*description = append(*description, value)
+	case "acceptance criteria":
+		*storyField = "criteria"
+		if item := strings.TrimSpace(value); item != "" {
+			story.AcceptanceCriteria = append(story.AcceptanceCriteria, item)
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// listItem returns the text of a Markdown list item ("- ", "* ", "1. ", with an optional checkbox).
+func listItem(line string) (string, bool) {
+	switch {
+	case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "* "), strings.HasPrefix(line, "+ "):
+		line = line[2:]
+	case numberedRe.MatchString(line):
+		line = numberedRe.ReplaceAllString(line, "")
+	default:
+		return "", false
+	}
+	return strings.TrimSpace(checkboxRe.ReplaceAllString(strings.TrimSpace(line), "")), true
+}
+
+// RenderMarkdown renders a PRD in the structured prd.md format.
+// ParseMarkdown(RenderMarkdown(p)) reproduces p for any PRD whose titles and
+// acceptance criteria are single lines and whose code blocks are closed.
+func RenderMarkdown(p *PRD) string {
+	var b strings.Builder
+	b.WriteString("# " + p.Project + "\n\n")
+	b.WriteString("## Overview\n")
+	if p.Description != "" {
+		b.WriteString(escapeText(p.Description) + "\n")
+	}
+	b.WriteString("\n## User Stories\n")
+
+	for _, story := range p.UserStories {
+		b.WriteString(fmt.Sprintf("\n### %s: %s\n", story.ID, story.Title))
+		b.WriteString(fmt.Sprintf("**Priority:** %d\n", story.Priority))
+		if status := storyStatus(story); status != "" {
+			b.WriteString("**Status:** " + status + "\n")
+		}
+		if len(story.DependsOn) > 0 {
+			b.WriteString("**Depends On:** " + strings.Join(story.DependsOn, ", ") + "\n")
+		}
+		// A first line that needs escaping, or opens a code block, goes on a
+		// line of its own where the parser sees it as such
+		description := escapeText(story.Description)
+		if strings.HasPrefix(description, `\`) || strings.HasPrefix(description, "```") {
+			b.WriteString("**Description:**\n" + description + "\n")
+		} else {
+			b.WriteString("**Description:** " + description + "\n")
+		}
+		b.WriteString("\n**Acceptance Criteria:**\n")
+		check := "[ ]"
+		if story.Passes {
+			check = "[x]"
+		}
+		for _, criterion := range story.AcceptanceCriteria {
+			b.WriteString("- " + check + " " + criterion + "\n")
+		}
+	}
+	return b.String()
+}
+
+// escapeText escapes the lines of free text that ParseMarkdown would read as
+// headings or fields. Code blocks are left as they are, and one left open is
+// closed so that it can't hide the stories after it.
+func escapeText(text string) string {
+	lines := strings.Split(text, "\n")
+	inFence := false
+	for i, line := range lines {
+		rest := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(rest, "```") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence && (strings.HasPrefix(rest, "#") || strings.HasPrefix(rest, "**") || escaped(rest)) {
+			lines[i] = line[:len(line)-len(rest)] + `\` + rest
+		}
+	}
+	if inFence {
+		lines = append(lines, "```")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// unescapeLine drops the backslash escapeText adds to a line.
+func unescapeLine(line string) string {
+	rest := strings.TrimLeft(line, " \t")
+	if !escaped(rest) {
+		return line
+	}
+	return line[:len(line)-len(rest)] + rest[1:]
+}
+
+// escaped reports whether a line starts with an escape: a backslash before
+// "#", "*" or another backslash.
+func escaped(line string) bool {
+	return len(line) > 1 && line[0] == '\\' && strings.ContainsRune(`#*\`, rune(line[1]))
+}
+
+// storyStatus returns the Status field value for a story, or "" for todo.
+func storyStatus(story UserStory) string {
+	switch {
+	case story.Passes && story.InProgress:
+		return "done, in progress"
+	case story.Passes:
+		return "done"
+	case story.InProgress:
+		return "in progress"
+	}
+	return ""
+}
@@ -0,0 +1,188 @@
+package prd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const structuredMarkdown = `# Auth System
+
+## Overview
+Login and registration for the web app.
+
+Uses the existing session middleware.
+
+## Technical Context
+- Go 1.22
+- PostgreSQL
+
+## User Stories
+
+### US-001: User Registration
+**Priority:** 2
+**Description:** As a new user, I want to register so that I can log in.
+Passwords are hashed with bcrypt.
+
+**Acceptance Criteria:**
+- [ ] Form has email and password fields
+- [x] Shows a "Success" message
+
+### US-002 - User Login
+**Status:** done
+As a user, I want to log in.
+
+**Acceptance Criteria**:
+1. Valid credentials create a session
+2. Invalid credentials show an error
+`
+
+func TestParseMarkdown(t *testing.T) {
+	p, err := ParseMarkdown(structuredMarkdown)
+	if err != nil {
+		t.Fatalf("ParseMarkdown() error: %v", err)
+	}
+
+	want := &PRD{
+		Project:     "Auth System",
+		Description: "Login and registration for the web app.\n\nUses the existing session middleware.",
+		UserStories: []UserStory{
+			{
+				ID:                 "US-001",
+				Title:              "User Registration",
+				Description:        "As a new user, I want to register so that I can log in.\nPasswords are hashed with bcrypt.",
+				AcceptanceCriteria: []string{"Form has email and password fields", `Shows a "Success" message`},
+				Priority:           2,
+			},
+			{
+				ID:                 "US-002",
+				Title:              "User Login",
+				Description:        "As a user, I want to log in.",
+				AcceptanceCriteria: []string{"Valid credentials create a session", "Invalid credentials show an error"},
+				Priority:           2,
+				Passes:             true,
+			},
+		},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("ParseMarkdown() =\n%+v\nwant\n%+v", p, want)
+	}
+}
+
+func TestParseMarkdown_IntroAsDescription(t *testing.T) {
+	p, err := ParseMarkdown("# Project\n\nIntro text.\n\n## User Stories\n\n### US-1: Story\n**Acceptance Criteria:**\n- Works\n")
+	if err != nil {
+		t.Fatalf("ParseMarkdown() error: %v", err)
+	}
+	if p.Description != "Intro text." || p.UserStories[0].Priority != 1 {
+		t.Errorf("unexpected PRD: %+v", p)
+	}
+}
+
+func TestParseMarkdown_FreeForm(t *testing.T) {
+	docs := []string{
+		"# Idea\n\nBuild a todo app with tags and due dates.\n",
+		"# Idea\n\n## Features\n\n### Tags\nUsers can tag todos.\n",
+		"# Idea\n\n## User Stories\n\nUsers should be able to add todos.\n",
+	}
+	for _, doc := range docs {
+		if _, err := ParseMarkdown(doc); !errors.Is(err, ErrFreeForm) {
+			t.Errorf("expected ErrFreeForm for %q, got %v", doc, err)
+		}
+	}
+}
+
+func TestParseMarkdown_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		line int
+	}{
+		{"bad heading", "# P\n\n## User Stories\n\n### Just a title\n", 5},
+		{"duplicate ID", "# P\n\n## User Stories\n\n### US-1: A\n\n### US-1: B\n", 7},
+		{"bad priority", "# P\n\n## User Stories\n\n### US-1: A\n**Priority:** high\n", 6},
+		{"bad status", "# P\n\n## User Stories\n\n### US-1: A\n**Status:** blocked\n", 6},
+		{"missing title", "## User Stories\n\n### US-1: A\n", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMarkdown(tt.doc)
+			var mdErr *MarkdownError
+			if !errors.As(err, &mdErr) {
+				t.Fatalf("expected MarkdownError, got %v", err)
+			}
+			if mdErr.Line != tt.line {
+				t.Errorf("expected error on line %d, got %v", tt.line, mdErr)
+			}
+		})
+	}
+}
+
+func TestRenderMarkdown_RoundTrip(t *testing.T) {
+	original := &PRD{
+		Project:     "Round Trip",
+		Description: "First paragraph.\n\nSecond paragraph with `code`.",
+		UserStories: []UserStory{
+			{ID: "US-001", Title: "Todo", Description: "As a user...\nMore detail.", AcceptanceCriteria: []string{`Says "hi"`, "Has **bold** text"}, Priority: 1},
+			{ID: "US-002", Title: "Active", Description: "", AcceptanceCriteria: []string{}, Priority: 3, InProgress: true},
+			{ID: "US-003", Title: "Done", Description: "Finished.", AcceptanceCriteria: []string{"Shipped"}, Priority: 2, Passes: true, DependsOn: []string{"US-001", "US-002"}},
+			{ID: "US-004", Title: "Odd", Description: "Both flags.", AcceptanceCriteria: []string{"x"}, Priority: 0, Passes: true, InProgress: true},
+		},
+	}
+
+	parsed, err := ParseMarkdown(RenderMarkdown(original))
+	if err != nil {
+		t.Fatalf("ParseMarkdown(RenderMarkdown()) error: %v\n%s", err, RenderMarkdown(original))
+	}
+	if !reflect.DeepEqual(parsed, original) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", parsed, original)
+	}
+}
+
+func TestRenderMarkdown_EscapesStructure(t *testing.T) {
+	original := &PRD{
+		Project:     "Escapes",
+		Description: "## Background\nText.",
+		UserStories: []UserStory{
+			{ID: "US-001", Title: "Headings", Description: "### Steps\n1. Run it\n**Priority:** high\n  # indented\n\\# literal", AcceptanceCriteria: []string{"x"}, Priority: 1},
+			{ID: "US-002", Title: "Code", Description: "```sh\n## not a heading\n```\nAfter.", AcceptanceCriteria: []string{"y"}, Priority: 2},
+		},
+	}
+
+	parsed, err := ParseMarkdown(RenderMarkdown(original))
+	if err != nil {
+		t.Fatalf("ParseMarkdown(RenderMarkdown()) error: %v\n%s", err, RenderMarkdown(original))
+	}
+	if !reflect.DeepEqual(parsed, original) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", parsed, original)
+	}
+
+	// A code block left open is closed before the next story
+	original.UserStories[1].Description = "```\n### US-9: swallowed"
+	parsed, err = ParseMarkdown(RenderMarkdown(original))
+	if err != nil || len(parsed.UserStories) != 2 {
+		t.Fatalf("ParseMarkdown() with an open code block = %+v, %v", parsed, err)
+	}
+}
+
+func TestConvert_StructuredMarkdownWithoutOllama(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", "http://127.0.0.1:1") // any Ollama call would fail
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "prd.md"), []byte(structuredMarkdown), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Convert(ConvertOptions{PRDDir: tmpDir}); err != nil {
+		t.Fatalf("Convert() error: %v", err)
+	}
+
+	p, err := LoadPRD(filepath.Join(tmpDir, "prd.json"))
+	if err != nil {
+		t.Fatalf("LoadPRD() error: %v", err)
+	}
+	if p.Project != "Auth System" || len(p.UserStories) != 2 {
+		t.Errorf("unexpected converted PRD: %+v", p)
+	}
+}
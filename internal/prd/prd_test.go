@@ -235,6 +235,32 @@ func TestPRD_NextStory_SkipsCompleted(t *testing.T) {
 	}
 }
 
+func TestPRD_NextStory_WaitsForDependencies(t *testing.T) {
+	p := &PRD{
+		Project: "Test",
+		UserStories: []UserStory{
+			{ID: "US-001", Priority: 1, DependsOn: []string{"US-003"}},
+			{ID: "US-002", Priority: 2, DependsOn: []string{"US-004"}}, // Passes
+			{ID: "US-003", Priority: 3, DependsOn: []string{"US-404"}}, // Unknown IDs are ignored
+			{ID: "US-004", Priority: 4, Passes: true},
+		},
+	}
+
+	if next := p.NextStory(); next == nil || next.ID != "US-002" {
+		t.Errorf("expected US-002 (US-001 waits for US-003), got %v", next)
+	}
+	p.UserStories[1].Passes = true
+	if next := p.NextStory(); next == nil || next.ID != "US-003" {
+		t.Errorf("expected US-003, got %v", next)
+	}
+
+	// A cycle falls back to priority order rather than stalling
+	p.UserStories[2].DependsOn = []string{"US-001"}
+	if next := p.NextStory(); next == nil || next.ID != "US-001" {
+		t.Errorf("expected US-001 for a dependency cycle, got %v", next)
+	}
+}
+
 func TestPRD_NextStory_InterruptedTakesPrecedence(t *testing.T) {
 	// Even if there's a lower priority story, in-progress takes precedence
 	p := &PRD{
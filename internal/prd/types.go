@@ -12,6 +12,7 @@ type UserStory struct {
 	Priority           int      `json:"priority"`
 	Passes             bool     `json:"passes"`
 	InProgress         bool     `json:"inProgress,omitempty"`
+	DependsOn          []string `json:"dependsOn,omitempty"` // IDs of stories that must be complete first
 }
 
 // PRD represents a Product Requirements Document.
@@ -37,7 +38,9 @@ func (p *PRD) AllComplete() bool {
 // NextStory returns the next story to work on.
 // It returns:
 //   - First story with inProgress: true (interrupted story), or
-//   - Lowest priority story with passes: false, or
+//   - Lowest priority story with passes: false whose dependencies pass, or
+//   - Lowest priority story with passes: false, when every one is waiting
+//     on another (a dependency cycle, which chief lint reports), or
 //   - nil if all stories are complete
 func (p *PRD) NextStory() *UserStory {
 	// First, check for any in-progress story (interrupted)
@@ -47,15 +50,36 @@ func (p *PRD) NextStory() *UserStory {
 		}
 	}
 
-	// Find the lowest priority story that hasn't passed
-	var next *UserStory
+	// Find the lowest priority story that hasn't passed, preferring one
+	// that isn't waiting on an unfinished dependency
+	var next, ready *UserStory
 	for i := range p.UserStories {
 		story := &p.UserStories[i]
-		if !story.Passes {
-			if next == nil || story.Priority < next.Priority {
-				next = story
-			}
+		if story.Passes {
+			continue
+		}
+		if next == nil || story.Priority < next.Priority {
+			next = story
 		}
+		if p.dependenciesPass(story) && (ready == nil || story.Priority < ready.Priority) {
+			ready = story
+		}
+	}
+	if ready != nil {
+		return ready
 	}
 	return next
 }
+
+// dependenciesPass reports whether every story a story depends on passes.
+// IDs that name no story are ignored; chief lint reports them.
+func (p *PRD) dependenciesPass(story *UserStory) bool {
+	for _, id := range story.DependsOn {
+		for _, other := range p.UserStories {
+			if other.ID == id && !other.Passes {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,398 @@
+package prd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWorkspaceDebounce is how long the workspace watcher waits for a
+// burst of file changes to settle before emitting events.
+const DefaultWorkspaceDebounce = 250 * time.Millisecond
+
+// workspaceFiles are the files in a PRD directory that produce events. Other
+// files, such as ollama.log, change constantly during a run and are ignored.
+var workspaceFiles = map[string]bool{
+	"prd.json":    true,
+	"prd.md":      true,
+	"progress.md": true,
+}
+
+// WorkspaceEventType is the kind of a workspace event.
+type WorkspaceEventType int
+
+const (
+	WorkspacePRDAdded     WorkspaceEventType = iota // A PRD directory with prd.json or prd.md appeared
+	WorkspacePRDRemoved                             // A PRD directory or its last PRD file disappeared
+	WorkspacePRDChanged                             // prd.json, prd.md or progress.md changed
+	WorkspacePRDConverted                           // prd.md was converted to prd.json automatically
+	WorkspaceError                                  // Watching or conversion failed
+)
+
+// String returns a lowercase name for the event type.
+func (t WorkspaceEventType) String() string {
+	switch t {
+	case WorkspacePRDAdded:
+		return "added"
+	case WorkspacePRDRemoved:
+		return "removed"
+	case WorkspacePRDChanged:
+		return "changed"
+	case WorkspacePRDConverted:
+		return "converted"
+	case WorkspaceError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// MarshalJSON encodes the event type as its name.
+func (t WorkspaceEventType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// WorkspaceEvent describes a change to the PRDs in a workspace.
+type WorkspaceEvent struct {
+	Type  WorkspaceEventType `json:"type"`
+	Name  string             `json:"name"`            // PRD name (its directory name); empty for watcher errors
+	Files []string           `json:"files,omitempty"` // Changed files, e.g. ["prd.md"]
+	PRD   *PRD               `json:"-"`               // prd.json after the change; nil if missing or invalid
+	Err   error              `json:"-"`
+}
+
+// WorkspaceOptions configures a WorkspaceWatcher.
+type WorkspaceOptions struct {
+	Debounce    time.Duration             // Quiet period before emitting events (default: DefaultWorkspaceDebounce)
+	AutoConvert bool                      // Convert prd.md to prd.json when NeedsConversion reports it is newer
+	Convert     func(prdDir string) error // Converter used by AutoConvert (default: ConvertStructured)
+}
+
+// WorkspaceWatcher watches every PRD in a .chief/prds/ directory and emits
+// typed add, remove and change events.
+type WorkspaceWatcher struct {
+	dir     string
+	opts    WorkspaceOptions
+	watcher *fsnotify.Watcher
+	events  chan WorkspaceEvent
+	done    chan struct{}
+	wg      sync.WaitGroup // In-flight conversions
+
+	mu         sync.Mutex
+	running    bool
+	known      map[string]bool // PRDs that currently exist
+	converting map[string]bool
+	held       map[string]bool // PRDs AutoConvert leaves alone, see Hold
+}
+
+// NewWorkspaceWatcher creates a watcher for the PRDs in prdsDir (usually .chief/prds).
+func NewWorkspaceWatcher(prdsDir string, opts WorkspaceOptions) (*WorkspaceWatcher, error) {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultWorkspaceDebounce
+	}
+	if opts.Convert == nil {
+		opts.Convert = ConvertStructured
+	}
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	return &WorkspaceWatcher{
+		dir:        prdsDir,
+		opts:       opts,
+		watcher:    fsWatcher,
+		events:     make(chan WorkspaceEvent, 64),
+		done:       make(chan struct{}),
+		known:      make(map[string]bool),
+		converting: make(map[string]bool),
+		held:       make(map[string]bool),
+	}, nil
+}
+
+// Start begins watching. The directory is created if it does not exist.
+// PRDs present at start are reported by Names, not as added events.
+func (w *WorkspaceWatcher) Start() error {
+	w.mu.Lock()
+	if w.running {
+		w.mu.Unlock()
+		return errors.New("workspace watcher already running")
+	}
+	w.running = true
+	w.mu.Unlock()
+
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create PRDs directory: %w", err)
+	}
+	if err := w.watcher.Add(w.dir); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read PRDs directory: %w", err)
+	}
+	w.mu.Lock()
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		_ = w.watcher.Add(filepath.Join(w.dir, entry.Name()))
+		if w.exists(entry.Name()) {
+			w.known[entry.Name()] = true
+		}
+	}
+	w.mu.Unlock()
+
+	go w.processEvents()
+	return nil
+}
+
+// Stop stops watching and closes the events channel.
+func (w *WorkspaceWatcher) Stop() {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return
+	}
+	w.running = false
+	w.mu.Unlock()
+
+	close(w.done)
+	w.watcher.Close()
+}
+
+// Events returns the channel workspace events are sent on.
+func (w *WorkspaceWatcher) Events() <-chan WorkspaceEvent {
+	return w.events
+}
+
+// Names returns the PRDs currently in the workspace, sorted.
+func (w *WorkspaceWatcher) Names() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	names := make([]string, 0, len(w.known))
+	for name := range w.known {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// processEvents collects filesystem events per PRD and flushes them once the
+// debounce period passes without further changes.
+func (w *WorkspaceWatcher) processEvents() {
+	defer func() {
+		w.wg.Wait()
+		close(w.events)
+	}()
+
+	pending := make(map[string]map[string]bool) // PRD name -> changed files
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			name, file := w.classify(event)
+			if name == "" {
+				continue
+			}
+			if file == "" && event.Op&fsnotify.Create != 0 {
+				// New PRD directory: watch it for file changes
+				_ = w.watcher.Add(event.Name)
+			}
+			if pending[name] == nil {
+				pending[name] = make(map[string]bool)
+			}
+			if file != "" {
+				pending[name][file] = true
+			}
+			timer.Reset(w.opts.Debounce)
+
+		case <-timer.C:
+			for name, files := range pending {
+				w.flush(name, files)
+			}
+			pending = make(map[string]map[string]bool)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.send(WorkspaceEvent{Type: WorkspaceError, Err: err})
+		}
+	}
+}
+
+// classify maps a filesystem event to a PRD name and, for tracked files, the
+// file name. Events that do not concern a PRD return an empty name.
+func (w *WorkspaceWatcher) classify(event fsnotify.Event) (name, file string) {
+	rel, err := filepath.Rel(w.dir, event.Name)
+	if err != nil || rel == "." {
+		return "", ""
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	switch len(parts) {
+	case 1:
+		return parts[0], ""
+	case 2:
+		if workspaceFiles[parts[1]] {
+			return parts[0], parts[1]
+		}
+	}
+	return "", ""
+}
+
+// flush emits the events for one PRD's pending changes.
+func (w *WorkspaceWatcher) flush(name string, files map[string]bool) {
+	changed := make([]string, 0, len(files))
+	for f := range files {
+		changed = append(changed, f)
+	}
+	sort.Strings(changed)
+
+	w.mu.Lock()
+	wasKnown := w.known[name]
+	exists := w.exists(name)
+	if exists {
+		w.known[name] = true
+	} else {
+		delete(w.known, name)
+	}
+	w.mu.Unlock()
+
+	var p *PRD
+	if exists {
+		p, _ = LoadPRD(filepath.Join(w.dir, name, "prd.json"))
+	}
+
+	switch {
+	case !wasKnown && exists:
+		w.send(WorkspaceEvent{Type: WorkspacePRDAdded, Name: name, Files: changed, PRD: p})
+	case wasKnown && !exists:
+		w.send(WorkspaceEvent{Type: WorkspacePRDRemoved, Name: name, Files: changed})
+		return
+	case exists && len(changed) > 0:
+		w.send(WorkspaceEvent{Type: WorkspacePRDChanged, Name: name, Files: changed, PRD: p})
+	default:
+		return
+	}
+
+	if w.opts.AutoConvert && (files["prd.md"] || !wasKnown) {
+		w.maybeConvert(name)
+	}
+}
+
+// Hold stops AutoConvert from converting a PRD until Release is called, for
+// a PRD whose files are still being written, such as one being generated.
+func (w *WorkspaceWatcher) Hold(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.held[name] = true
+}
+
+// Release lets AutoConvert convert a PRD again after Hold.
+func (w *WorkspaceWatcher) Release(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.held, name)
+}
+
+// maybeConvert converts a PRD's prd.md in the background when it is newer than prd.json.
+func (w *WorkspaceWatcher) maybeConvert(name string) {
+	w.mu.Lock()
+	held := w.held[name]
+	w.mu.Unlock()
+	if held {
+		return
+	}
+
+	prdDir := filepath.Join(w.dir, name)
+	needs, err := NeedsConversion(prdDir)
+	if err != nil {
+		w.send(WorkspaceEvent{Type: WorkspaceError, Name: name, Err: err})
+		return
+	}
+	if !needs {
+		return
+	}
+
+	w.mu.Lock()
+	if w.converting[name] {
+		w.mu.Unlock()
+		return
+	}
+	w.converting[name] = true
+	w.mu.Unlock()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		err := w.opts.Convert(prdDir)
+
+		w.mu.Lock()
+		delete(w.converting, name)
+		w.mu.Unlock()
+
+		if err != nil {
+			w.send(WorkspaceEvent{Type: WorkspaceError, Name: name, Files: []string{"prd.md"}, Err: fmt.Errorf("failed to convert prd.md: %w", err)})
+			return
+		}
+		p, _ := LoadPRD(filepath.Join(prdDir, "prd.json"))
+		w.send(WorkspaceEvent{Type: WorkspacePRDConverted, Name: name, Files: []string{"prd.json"}, PRD: p})
+	}()
+}
+
+// exists reports whether a directory in the workspace holds a PRD.
+func (w *WorkspaceWatcher) exists(name string) bool {
+	for _, file := range []string{"prd.json", "prd.md"} {
+		if _, err := os.Stat(filepath.Join(w.dir, name, file)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// send delivers an event unless the watcher is stopping.
+func (w *WorkspaceWatcher) send(event WorkspaceEvent) {
+	select {
+	case w.events <- event:
+	case <-w.done:
+	}
+}
+
+// ConvertStructured converts a structured prd.md to prd.json without an LLM,
+// keeping the progress of stories whose IDs still exist. Free-form documents
+// return ErrFreeForm and are left for an interactive conversion.
+func ConvertStructured(prdDir string) error {
+	newPRD, err := parseMarkdownFile(filepath.Join(prdDir, "prd.md"))
+	if err != nil {
+		return err
+	}
+	prdJsonPath := filepath.Join(prdDir, "prd.json")
+	if existing, err := LoadPRD(prdJsonPath); err == nil && HasProgress(existing) {
+		MergeProgress(existing, newPRD)
+	}
+	data, err := json.MarshalIndent(newPRD, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal PRD: %w", err)
+	}
+	if err := os.WriteFile(prdJsonPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write prd.json: %w", err)
+	}
+	return nil
+}
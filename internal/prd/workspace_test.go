@@ -0,0 +1,200 @@
+package prd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// startWorkspace starts a workspace watcher with a short debounce.
+func startWorkspace(t *testing.T, dir string, opts WorkspaceOptions) *WorkspaceWatcher {
+	t.Helper()
+	opts.Debounce = 20 * time.Millisecond
+	w, err := NewWorkspaceWatcher(dir, opts)
+	if err != nil {
+		t.Fatalf("NewWorkspaceWatcher() error: %v", err)
+	}
+	if err := w.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	t.Cleanup(w.Stop)
+	return w
+}
+
+// nextWorkspaceEvent waits for the next event of the given type, skipping others.
+func nextWorkspaceEvent(t *testing.T, w *WorkspaceWatcher, want WorkspaceEventType) WorkspaceEvent {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case ev := <-w.Events():
+			if ev.Type == want {
+				return ev
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for a %s event", want)
+		}
+	}
+}
+
+func writePRDJSON(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	p := &PRD{Project: "Test", UserStories: []UserStory{{ID: "US-001", Title: "Story", AcceptanceCriteria: []string{"works"}, Priority: 1}}}
+	if err := p.Save(filepath.Join(dir, "prd.json")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWorkspaceWatcherEvents(t *testing.T) {
+	prdsDir := filepath.Join(t.TempDir(), "prds")
+	writePRDJSON(t, filepath.Join(prdsDir, "main"))
+	w := startWorkspace(t, prdsDir, WorkspaceOptions{})
+
+	if names := w.Names(); len(names) != 1 || names[0] != "main" {
+		t.Fatalf("Names() = %v, want [main]", names)
+	}
+
+	writePRDJSON(t, filepath.Join(prdsDir, "auth"))
+	ev := nextWorkspaceEvent(t, w, WorkspacePRDAdded)
+	if ev.Name != "auth" || ev.PRD == nil || ev.PRD.Project != "Test" {
+		t.Errorf("unexpected added event: %+v", ev)
+	}
+
+	// ollama.log changes are ignored; progress.md changes are reported
+	if err := os.WriteFile(filepath.Join(prdsDir, "main", "ollama.log"), []byte("log"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(prdsDir, "main", "progress.md"), []byte("# Progress\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ev = nextWorkspaceEvent(t, w, WorkspacePRDChanged)
+	if ev.Name != "main" || len(ev.Files) != 1 || ev.Files[0] != "progress.md" {
+		t.Errorf("unexpected changed event: %+v", ev)
+	}
+
+	if err := os.RemoveAll(filepath.Join(prdsDir, "auth")); err != nil {
+		t.Fatal(err)
+	}
+	if ev := nextWorkspaceEvent(t, w, WorkspacePRDRemoved); ev.Name != "auth" {
+		t.Errorf("unexpected removed event: %+v", ev)
+	}
+	if names := w.Names(); len(names) != 1 || names[0] != "main" {
+		t.Errorf("Names() after removal = %v, want [main]", names)
+	}
+}
+
+func TestWorkspaceWatcherDebounce(t *testing.T) {
+	prdsDir := t.TempDir()
+	writePRDJSON(t, filepath.Join(prdsDir, "main"))
+	w := startWorkspace(t, prdsDir, WorkspaceOptions{})
+
+	for i := 0; i < 5; i++ {
+		writePRDJSON(t, filepath.Join(prdsDir, "main"))
+	}
+	nextWorkspaceEvent(t, w, WorkspacePRDChanged)
+
+	select {
+	case ev := <-w.Events():
+		t.Errorf("expected a single event for a burst of writes, got another: %+v", ev)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestWorkspaceWatcherAutoConvert(t *testing.T) {
+	prdsDir := t.TempDir()
+	prdDir := filepath.Join(prdsDir, "main")
+	writePRDJSON(t, prdDir)
+
+	// Mark US-001 as passing so conversion has progress to keep
+	p, err := LoadPRD(filepath.Join(prdDir, "prd.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.UserStories[0].Passes = true
+	if err := p.Save(filepath.Join(prdDir, "prd.json")); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(prdDir, "prd.json"), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	w := startWorkspace(t, prdsDir, WorkspaceOptions{AutoConvert: true})
+
+	md := "# Auth\n\n## User Stories\n\n### US-001: Story\n**Acceptance Criteria:**\n- [ ] works\n\n### US-002: Second\n**Acceptance Criteria:**\n- [ ] also works\n"
+	if err := os.WriteFile(filepath.Join(prdDir, "prd.md"), []byte(md), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ev := nextWorkspaceEvent(t, w, WorkspacePRDConverted)
+	if ev.Name != "main" || ev.PRD == nil {
+		t.Fatalf("unexpected converted event: %+v", ev)
+	}
+	if ev.PRD.Project != "Auth" || len(ev.PRD.UserStories) != 2 {
+		t.Errorf("unexpected converted PRD: %+v", ev.PRD)
+	}
+	if !ev.PRD.UserStories[0].Passes {
+		t.Error("expected conversion to keep US-001's progress")
+	}
+}
+
+func TestWorkspaceWatcherAutoConvertFreeForm(t *testing.T) {
+	prdsDir := t.TempDir()
+	w := startWorkspace(t, prdsDir, WorkspaceOptions{AutoConvert: true})
+
+	prdDir := filepath.Join(prdsDir, "notes")
+	if err := os.MkdirAll(prdDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(prdDir, "prd.md"), []byte("We want a login page.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	nextWorkspaceEvent(t, w, WorkspacePRDAdded)
+	ev := nextWorkspaceEvent(t, w, WorkspaceError)
+	if ev.Name != "notes" || ev.Err == nil {
+		t.Errorf("expected a conversion error for a free-form prd.md, got %+v", ev)
+	}
+	if _, err := os.Stat(filepath.Join(prdDir, "prd.json")); !os.IsNotExist(err) {
+		t.Error("expected no prd.json to be written for a free-form prd.md")
+	}
+}
+
+func TestWorkspaceWatcherHold(t *testing.T) {
+	prdsDir := t.TempDir()
+	converted := make(chan string, 4)
+	w := startWorkspace(t, prdsDir, WorkspaceOptions{AutoConvert: true, Convert: func(prdDir string) error {
+		converted <- filepath.Base(prdDir)
+		return nil
+	}})
+
+	// A PRD being generated is left alone until it is released
+	w.Hold("new")
+	prdDir := filepath.Join(prdsDir, "new")
+	if err := os.MkdirAll(prdDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(prdDir, "prd.md"), []byte("Half written\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	nextWorkspaceEvent(t, w, WorkspacePRDAdded)
+	select {
+	case name := <-converted:
+		t.Fatalf("converted held PRD %s", name)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	w.Release("new")
+	if err := os.WriteFile(filepath.Join(prdDir, "prd.md"), []byte("Done\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-converted:
+	case <-time.After(3 * time.Second):
+		t.Fatal("released PRD was not converted")
+	}
+}
@@ -0,0 +1,235 @@
+// Package prompts renders agent prompts from Go text/template sources.
+// Templates can be overridden per project in .chief/prompts/<name>.tmpl and
+// per PRD in .chief/prds/<prd>/prompts/<name>.tmpl; the embedded defaults
+// are used when no override exists.
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/izdrail/chief/embed"
+	"github.com/izdrail/chief/internal/config"
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/learnings"
+	"github.com/izdrail/chief/internal/prd"
+	"github.com/izdrail/chief/internal/repomap"
+)
+
+// Agent is the name of the main loop prompt template.
+const Agent = "agent"
+
+// EmbeddedSource is reported as the source of templates that are not overridden.
+const EmbeddedSource = "embedded"
+
+// maxLearningsLen caps the size of the learnings injected into a prompt.
+const maxLearningsLen = 4000
+
+// Data holds the variables available to prompt templates.
+type Data struct {
+	PRDPath          string          // Path to prd.json
+	PRDName          string          // PRD directory name (e.g. "main")
+	Project          string          // Project name from the PRD
+	Story            *prd.UserStory  // Story the next iteration should work on (nil when all pass)
+	RemainingStories []prd.UserStory // Stories that have not passed yet, including Story
+	CompletedStories []prd.UserStory // Stories that have passed
+	Config           *config.Config  // Project config
+	RepoMap          string          // Repository map, empty when disabled
+	Learnings        string          // Learnings recorded by previous iterations
+	LearningTool     bool            // Whether the RecordLearning tool is available
+}
+
+// Options configures how template data is gathered.
+type Options struct {
+	PRDPath string         // Path to prd.json (required)
+	WorkDir string         // Agent working directory (default: the PRD directory)
+	PRD     *prd.PRD       // Already-loaded PRD (default: loaded from PRDPath)
+	Config  *config.Config // Project config (default: loaded from the project root)
+	Store   *db.Store      // Learnings store (default: learnings are read from progress.md)
+}
+
+// defaultTemplates maps template names to their embedded sources.
+var defaultTemplates = map[string]func() string{
+	Agent: embed.AgentPromptTemplate,
+}
+
+// funcs are the helper functions available to templates.
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"trim":  strings.TrimSpace,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// OverridePaths returns the candidate override files for a template, most specific first.
+func OverridePaths(name, prdPath string) []string {
+	prdDir := filepath.Dir(prdPath)
+	paths := []string{filepath.Join(prdDir, "prompts", name+".tmpl")}
+	if root := ProjectRoot(prdPath); root != "" {
+		paths = append(paths, filepath.Join(root, ".chief", "prompts", name+".tmpl"))
+	}
+	return paths
+}
+
+// ProjectRoot returns the directory containing the .chief directory that holds prdPath,
+// or an empty string if prdPath is not inside a .chief directory.
+func ProjectRoot(prdPath string) string {
+	abs, err := filepath.Abs(prdPath)
+	if err != nil {
+		return ""
+	}
+	for dir := filepath.Dir(abs); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == ".chief" {
+			return filepath.Dir(dir)
+		}
+	}
+	return ""
+}
+
+// Load returns the parsed template for name and the file it was loaded from
+// (EmbeddedSource when no override exists).
+func Load(name, prdPath string) (*template.Template, string, error) {
+	for _, path := range OverridePaths(name, prdPath) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, "", fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		tmpl, err := template.New(name).Funcs(funcs).Parse(string(data))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse prompt template %s: %w", path, err)
+		}
+		return tmpl, path, nil
+	}
+
+	source, ok := defaultTemplates[name]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown prompt template %q", name)
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Parse(source())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse embedded prompt template %s: %w", name, err)
+	}
+	return tmpl, EmbeddedSource, nil
+}
+
+// BuildData gathers the template variables for a PRD.
+func BuildData(opts Options) (Data, error) {
+	if opts.WorkDir == "" {
+		opts.WorkDir = filepath.Dir(opts.PRDPath)
+	}
+	if opts.PRD == nil {
+		p, err := prd.LoadPRD(opts.PRDPath)
+		if err != nil {
+			return Data{}, fmt.Errorf("failed to load PRD: %w", err)
+		}
+		opts.PRD = p
+	}
+	if opts.Config == nil {
+		opts.Config = config.Default()
+		if root := ProjectRoot(opts.PRDPath); root != "" {
+			if cfg, err := config.Load(root); err == nil {
+				opts.Config = cfg
+			}
+		}
+	}
+
+	data := Data{
+		PRDPath: opts.PRDPath,
+		PRDName: filepath.Base(filepath.Dir(opts.PRDPath)),
+		Project: opts.PRD.Project,
+		Story:   opts.PRD.NextStory(),
+		Config:  opts.Config,
+	}
+	for _, story := range opts.PRD.UserStories {
+		if story.Passes {
+			data.CompletedStories = append(data.CompletedStories, story)
+		} else {
+			data.RemainingStories = append(data.RemainingStories, story)
+		}
+	}
+
+	if !opts.Config.RepoMap.Disable {
+		if m, err := repomap.Build(opts.WorkDir, repomap.Options{TokenBudget: opts.Config.RepoMap.TokenBudget}); err == nil {
+			data.RepoMap = m
+		}
+	}
+
+	if opts.Store != nil {
+		all, err := opts.Store.GetLearnings(data.PRDName)
+		if err != nil {
+			return Data{}, fmt.Errorf("failed to load learnings: %w", err)
+		}
+		data.Learnings = learnings.Format(learnings.Top(all, data.Story, learnings.DefaultLimit))
+		data.LearningTool = true
+	} else {
+		data.Learnings = readLearnings(opts.WorkDir, filepath.Dir(opts.PRDPath))
+	}
+	return data, nil
+}
+
+// Render loads the template for name and executes it with data gathered from opts.
+func Render(name string, opts Options) (string, error) {
+	tmpl, _, err := Load(name, opts.PRDPath)
+	if err != nil {
+		return "", err
+	}
+	data, err := BuildData(opts)
+	if err != nil {
+		return "", err
+	}
+	return Execute(tmpl, data)
+}
+
+// Execute runs a prompt template with data.
+func Execute(tmpl *template.Template, data Data) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+// readLearnings returns the "Codebase Patterns" section of progress.md from
+// the first directory that has one.
+func readLearnings(dirs ...string) string {
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "progress.md"))
+		if err != nil {
+			continue
+		}
+		if section := codebasePatterns(string(data)); section != "" {
+			if len(section) > maxLearningsLen {
+				section = section[:maxLearningsLen] + "\n... (truncated)"
+			}
+			return section
+		}
+	}
+	return ""
+}
+
+// codebasePatterns extracts the body of the "## Codebase Patterns" section.
+func codebasePatterns(progress string) string {
+	lines := strings.Split(progress, "\n")
+	var section []string
+	inSection := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "## ") {
+			if inSection {
+				break
+			}
+			inSection = strings.TrimSpace(strings.TrimPrefix(line, "## ")) == "Codebase Patterns"
+			continue
+		}
+		if inSection {
+			section = append(section, line)
+		}
+	}
+	return strings.TrimSpace(strings.Join(section, "\n"))
+}
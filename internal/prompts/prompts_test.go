@@ -0,0 +1,113 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/izdrail/chief/internal/config"
+)
+
+// setupProject creates a project with one PRD and returns the PRD path.
+func setupProject(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	prdDir := filepath.Join(root, ".chief", "prds", "auth")
+	if err := os.MkdirAll(prdDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	prdJSON := `{
+  "project": "Auth",
+  "userStories": [
+    {"id": "US-1", "title": "Sign up", "priority": 1, "passes": true},
+    {"id": "US-2", "title": "Log in", "description": "Users log in", "acceptanceCriteria": ["Form validates"], "priority": 2, "passes": false},
+    {"id": "US-3", "title": "Log out", "priority": 3, "passes": false}
+  ]
+}`
+	prdPath := filepath.Join(prdDir, "prd.json")
+	if err := os.WriteFile(prdPath, []byte(prdJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	progress := "# Progress\n\n## Codebase Patterns\n- Handlers live in api/\n\n## 2024-01-01 - US-1\n- Did things\n"
+	if err := os.WriteFile(filepath.Join(prdDir, "progress.md"), []byte(progress), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return prdPath
+}
+
+func writeTemplate(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRender_EmbeddedDefault(t *testing.T) {
+	prdPath := setupProject(t)
+
+	out, err := Render(Agent, Options{PRDPath: prdPath, Config: &config.Config{RepoMap: config.RepoMapConfig{Disable: true}}})
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	for _, want := range []string{prdPath, "**US-2: Log in**", "- Form validates", "- US-3: Log out", "- Handlers live in api/", "chief-complete"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected prompt to contain %q", want)
+		}
+	}
+	if strings.Contains(out, "Repository Map\n") || strings.Contains(out, "Did things") {
+		t.Error("expected disabled repo map and only the Codebase Patterns section")
+	}
+}
+
+func TestLoad_OverridePrecedence(t *testing.T) {
+	prdPath := setupProject(t)
+	root := ProjectRoot(prdPath)
+
+	_, source, err := Load(Agent, prdPath)
+	if err != nil || source != EmbeddedSource {
+		t.Fatalf("expected embedded template, got %q (err %v)", source, err)
+	}
+
+	projectTmpl := filepath.Join(root, ".chief", "prompts", "agent.tmpl")
+	writeTemplate(t, projectTmpl, "project {{.PRDName}}")
+	_, source, _ = Load(Agent, prdPath)
+	if source != projectTmpl {
+		t.Errorf("expected project override, got %q", source)
+	}
+
+	prdTmpl := filepath.Join(filepath.Dir(prdPath), "prompts", "agent.tmpl")
+	writeTemplate(t, prdTmpl, "prd {{.Story.ID}} of {{len .RemainingStories}} {{join .Story.AcceptanceCriteria \",\"}}")
+	out, err := Render(Agent, Options{PRDPath: prdPath, Config: config.Default()})
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	if out != "prd US-2 of 2 Form validates" {
+		t.Errorf("unexpected render: %q", out)
+	}
+}
+
+func TestLoad_InvalidTemplate(t *testing.T) {
+	prdPath := setupProject(t)
+	writeTemplate(t, filepath.Join(filepath.Dir(prdPath), "prompts", "agent.tmpl"), "{{.Story.ID")
+
+	if _, _, err := Load(Agent, prdPath); err == nil || !strings.Contains(err.Error(), "agent.tmpl") {
+		t.Errorf("expected parse error naming the file, got %v", err)
+	}
+	if _, _, err := Load("missing", prdPath); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
+
+func TestCodebasePatterns(t *testing.T) {
+	if got := codebasePatterns("# Progress\n\n## 2024 - US-1\n- x\n"); got != "" {
+		t.Errorf("expected no section, got %q", got)
+	}
+	got := codebasePatterns("## Codebase Patterns\n- a\n- b\n\n## Next\n- c\n")
+	if got != "- a\n- b" {
+		t.Errorf("unexpected section: %q", got)
+	}
+}
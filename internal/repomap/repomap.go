@@ -0,0 +1,236 @@
+// Package repomap builds a compact, token-budgeted map of a repository
+// (directory tree, key files and exported symbols per package) that is
+// injected into each agent iteration so the model can skip exploratory
+// tool calls.
+package repomap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/izdrail/chief/internal/codenav"
+	"github.com/izdrail/chief/internal/git"
+)
+
+const (
+	// DefaultTokenBudget is the default maximum size of a map, in estimated tokens.
+	DefaultTokenBudget = 2000
+	// maxTreeDepth limits how deep the directory tree is rendered.
+	maxTreeDepth = 3
+	// maxDirEntries limits how many subdirectories are shown per directory.
+	maxDirEntries = 25
+)
+
+// keyFileNames are files that are listed in the map when present at the root.
+var keyFileNames = []string{
+	"README.md", "README", "CLAUDE.md", "AGENTS.md", "CONTRIBUTING.md",
+	"go.mod", "package.json", "Cargo.toml", "pyproject.toml", "requirements.txt",
+	"Gemfile", "pom.xml", "build.gradle", "composer.json",
+	"Makefile", "Dockerfile", "docker-compose.yml", "main.go",
+}
+
+// Options configures map generation.
+type Options struct {
+	TokenBudget int // Maximum map size in estimated tokens (default: DefaultTokenBudget)
+}
+
+// cacheEntry is the last map built for a root.
+type cacheEntry struct {
+	key string // Working tree hash and token budget the map was built for
+	m   string
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]cacheEntry) // By root; only the latest map is kept
+)
+
+// Build returns the repository map for root. The last map for each root is
+// cached by the git working tree hash, so unchanged trees are not re-analyzed
+// between iterations.
+// Outside a git repository the map is rebuilt on every call.
+func Build(root string, opts Options) (string, error) {
+	if opts.TokenBudget <= 0 {
+		opts.TokenBudget = DefaultTokenBudget
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve root: %w", err)
+	}
+
+	key := ""
+	if hash, err := git.WorkingTreeHash(absRoot); err == nil {
+		key = fmt.Sprintf("%s|%d", hash, opts.TokenBudget)
+		cacheMu.Lock()
+		cached, ok := cache[absRoot]
+		cacheMu.Unlock()
+		if ok && cached.key == key {
+			return cached.m, nil
+		}
+	}
+
+	result := render(absRoot, opts.TokenBudget)
+
+	if key != "" {
+		cacheMu.Lock()
+		cache[absRoot] = cacheEntry{key: key, m: result}
+		cacheMu.Unlock()
+	}
+	return result, nil
+}
+
+// render produces the map, adding sections in priority order until the budget is spent.
+func render(root string, budget int) string {
+	var b strings.Builder
+	b.WriteString("## Repository Map\n\n")
+	b.WriteString("A precomputed overview of the project. Use it instead of listing directories; read files for details.\n")
+
+	if files := keyFiles(root); len(files) > 0 {
+		b.WriteString("\n### Key files\n")
+		for _, f := range files {
+			b.WriteString("- " + f + "\n")
+		}
+	}
+
+	b.WriteString("\n### Directory tree\n```\n")
+	// The tree gets at most a third of the budget so symbols still fit.
+	treeLimit := estimateTokens(b.String()) + budget/3
+	for _, line := range treeLines(root) {
+		if estimateTokens(b.String()+line) > treeLimit {
+			b.WriteString("... (tree truncated)\n")
+			break
+		}
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("```\n")
+
+	symbols := packageSymbols(root)
+	if len(symbols) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n### Exported symbols by package\n")
+	dirs := make([]string, 0, len(symbols))
+	for dir := range symbols {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	for i, dir := range dirs {
+		line := fmt.Sprintf("- %s: %s\n", dir, symbolNames(symbols[dir]))
+		if estimateTokens(b.String()+line) > budget {
+			b.WriteString(fmt.Sprintf("- ... (%d more packages omitted)\n", len(dirs)-i))
+			break
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// keyFiles returns the key files present at the root.
+func keyFiles(root string) []string {
+	var found []string
+	for _, name := range keyFileNames {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+			found = append(found, name)
+		}
+	}
+	return found
+}
+
+// treeLines renders the directory tree (directories only, with file counts).
+func treeLines(root string) []string {
+	lines := []string{"./ " + fileCount(root)}
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		if depth > maxTreeDepth {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		shown := 0
+		for _, e := range entries {
+			if !e.IsDir() || skipDir(e.Name()) {
+				continue
+			}
+			if shown == maxDirEntries {
+				lines = append(lines, strings.Repeat("  ", depth)+"...")
+				break
+			}
+			shown++
+			path := filepath.Join(dir, e.Name())
+			lines = append(lines, fmt.Sprintf("%s%s/ %s", strings.Repeat("  ", depth), e.Name(), fileCount(path)))
+			walk(path, depth+1)
+		}
+	}
+	walk(root, 1)
+	return lines
+}
+
+// fileCount returns a "(N files)" label for the files directly inside dir.
+func fileCount(dir string) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	n := 0
+	for _, e := range entries {
+		if !e.IsDir() {
+			n++
+		}
+	}
+	if n == 1 {
+		return "(1 file)"
+	}
+	return fmt.Sprintf("(%d files)", n)
+}
+
+// skipDir reports whether a directory is omitted from the tree.
+func skipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" || name == "dist" || name == "build" || name == "target"
+}
+
+// packageSymbols returns exported symbols per package if the project's language supports it.
+func packageSymbols(root string) map[string][]codenav.Symbol {
+	provider, err := codenav.ProviderFor(root)
+	if err != nil {
+		return nil
+	}
+	lister, ok := provider.(codenav.PackageLister)
+	if !ok {
+		return nil
+	}
+	symbols, err := lister.PackageSymbols(root)
+	if err != nil {
+		return nil
+	}
+	return symbols
+}
+
+// symbolNames formats symbols compactly as a comma-separated list. Functions
+// and methods are suffixed with "()"; struct fields are omitted.
+func symbolNames(symbols []codenav.Symbol) string {
+	names := make([]string, 0, len(symbols))
+	for _, s := range symbols {
+		switch s.Kind {
+		case "field":
+			continue
+		case "func", "method":
+			names = append(names, s.Name+"()")
+		default:
+			names = append(names, s.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// estimateTokens approximates the token count of s (about 4 bytes per token).
+func estimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
@@ -0,0 +1,129 @@
+package repomap
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files relative to root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBuild_GoProject(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":                "module example.com/demo\n\ngo 1.21\n",
+		"README.md":             "# Demo\n",
+		"main.go":               "package main\n\nfunc main() {}\n",
+		"store/store.go":        "package store\n\ntype Store struct{ Name string }\n\nfunc New() *Store { return nil }\n\nfunc (s *Store) Put() {}\n\nfunc helper() {}\n",
+		"store/store_test.go":   "package store\n\nfunc TestHelper() {}\n",
+		"node_modules/x/x.js":   "",
+		".git-like/ignored.txt": "",
+	})
+
+	m, err := Build(root, Options{})
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	for _, want := range []string{"## Repository Map", "- README.md", "- go.mod", "store/ (2 files)", "- store: Store, New(), Store.Put()"} {
+		if !strings.Contains(m, want) {
+			t.Errorf("expected map to contain %q, got:\n%s", want, m)
+		}
+	}
+	for _, unwanted := range []string{"helper", "TestHelper", "node_modules", ".git-like", "Store.Name"} {
+		if strings.Contains(m, unwanted) {
+			t.Errorf("expected map not to contain %q, got:\n%s", unwanted, m)
+		}
+	}
+}
+
+func TestBuild_RespectsTokenBudget(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"go.mod": "module example.com/big\n\ngo 1.21\n"})
+	for i := 0; i < 50; i++ {
+		writeFiles(t, root, map[string]string{
+			fmt.Sprintf("pkg%02d/api.go", i): fmt.Sprintf("package pkg%02d\n\nfunc FirstExportedFunction() {}\n\nfunc SecondExportedFunction() {}\n", i),
+		})
+	}
+
+	budget := 300
+	m, err := Build(root, Options{TokenBudget: budget})
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	if estimateTokens(m) > budget+20 {
+		t.Errorf("map is %d tokens, expected about %d", estimateTokens(m), budget)
+	}
+	if !strings.Contains(m, "more packages omitted") {
+		t.Errorf("expected truncation note, got:\n%s", m)
+	}
+}
+
+func TestBuild_NonGoProject(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"package.json": "{}",
+		"src/index.js": "",
+	})
+
+	m, err := Build(root, Options{})
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	if !strings.Contains(m, "- package.json") || !strings.Contains(m, "src/ (1 file)") {
+		t.Errorf("unexpected map:\n%s", m)
+	}
+	if strings.Contains(m, "Exported symbols") {
+		t.Errorf("expected no symbol section for a non-Go project, got:\n%s", m)
+	}
+}
+
+func TestBuild_CacheFollowsUntrackedEdits(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"go.mod": "module example.com/cached\n\ngo 1.21\n"})
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.email=test@test.com", "-c", "user.name=Test", "add", "."},
+		{"-c", "user.email=test@test.com", "-c", "user.name=Test", "commit", "-qm", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s", args, out)
+		}
+	}
+
+	// An untracked file edited between iterations keeps the same status line
+	writeFiles(t, root, map[string]string{"api/api.go": "package api\n\nfunc First() {}\n"})
+	if m, _ := Build(root, Options{}); !strings.Contains(m, "First") {
+		t.Fatalf("expected First in the map, got:\n%s", m)
+	}
+	writeFiles(t, root, map[string]string{"api/api.go": "package api\n\nfunc First() {}\n\nfunc Second() {}\n"})
+	if m, _ := Build(root, Options{}); !strings.Contains(m, "Second") {
+		t.Errorf("map is stale after editing an untracked file:\n%s", m)
+	}
+
+	// Only the latest map is kept for the root
+	abs, _ := filepath.Abs(root)
+	cacheMu.Lock()
+	entry := cache[abs]
+	cacheMu.Unlock()
+	if !strings.Contains(entry.m, "Second") {
+		t.Errorf("cache holds a stale map:\n%s", entry.m)
+	}
+}
@@ -0,0 +1,200 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// Formats lists the output formats Render accepts.
+var Formats = []string{"md", "html", "json"}
+
+// Render renders the report in the given format ("md", "html" or "json").
+func (r *Report) Render(format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "md", "markdown":
+		return []byte(r.Markdown()), nil
+	case "html":
+		return r.HTML()
+	case "json":
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal report: %w", err)
+		}
+		return append(data, '\n'), nil
+	}
+	return nil, fmt.Errorf("unknown report format %q (use md, html or json)", format)
+}
+
+// Markdown renders the report as GitHub-flavored Markdown.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Chief Report: %s\n\n", r.Project)
+	if r.Description != "" {
+		b.WriteString(r.Description + "\n\n")
+	}
+	fmt.Fprintf(&b, "PRD `%s`", r.PRD)
+	if r.Branch != "" {
+		fmt.Fprintf(&b, " on branch `%s`", r.Branch)
+	}
+	fmt.Fprintf(&b, ", generated %s.\n\n", r.GeneratedAt.Format("2006-01-02 15:04 MST"))
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString("| Stories | Iterations | Time | Errors | Retries | Commits | Lines |\n")
+	b.WriteString("|---|---|---|---|---|---|---|\n")
+	fmt.Fprintf(&b, "| %d/%d done | %s | %s | %d | %d | %d | +%d −%d |\n\n",
+		r.Totals.Completed, r.Totals.Stories, r.iterationsCell(r.Totals.Iterations), formatDuration(r.Totals.Duration()),
+		r.Totals.Errors, r.Totals.Retries, r.Totals.Commits, r.Totals.Insertions, r.Totals.Deletions)
+	if !r.HasHistory {
+		b.WriteString("_No iteration history was recorded for this PRD._\n\n")
+	}
+
+	b.WriteString("## Stories\n\n")
+	b.WriteString("| ID | Story | Status | Iterations | Time | Retries | Commits |\n")
+	b.WriteString("|---|---|---|---|---|---|---|\n")
+	for _, s := range r.Stories {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %d | %d |\n",
+			s.ID, escapeTableCell(s.Title), statusLabel(s.Status), r.iterationsCell(s.Iterations), formatDuration(s.Duration()), s.Retries, len(s.Commits))
+	}
+
+	for _, s := range r.Stories {
+		if len(s.Commits) == 0 && len(s.Errors) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s: %s\n", s.ID, s.Title)
+		if len(s.Commits) > 0 {
+			b.WriteString("\nCommits:\n\n")
+			for _, c := range s.Commits {
+				fmt.Fprintf(&b, "- `%s` %s (+%d −%d)\n", shortHash(c.Hash), c.Subject, c.Insertions, c.Deletions)
+			}
+		}
+		if len(s.Errors) > 0 {
+			b.WriteString("\nErrors:\n\n")
+			for _, e := range s.Errors {
+				fmt.Fprintf(&b, "- %s\n", firstLine(e))
+			}
+		}
+	}
+
+	if len(r.Unattributed) > 0 {
+		b.WriteString("\n## Other Commits\n\n")
+		for _, c := range r.Unattributed {
+			fmt.Fprintf(&b, "- `%s` %s (+%d −%d)\n", shortHash(c.Hash), c.Subject, c.Insertions, c.Deletions)
+		}
+	}
+
+	if r.DiffStats != "" {
+		b.WriteString("\n## Diff Stats\n\n```\n" + r.DiffStats + "\n```\n")
+	}
+	return b.String()
+}
+
+// HTML renders the report as a standalone HTML page.
+func (r *Report) HTML() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, r); err != nil {
+		return nil, fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// iterationsCell shows "-" for iteration counts when no history exists.
+func (r *Report) iterationsCell(n int) string {
+	if !r.HasHistory {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
+
+func statusLabel(status string) string {
+	switch status {
+	case StatusDone:
+		return "✅ done"
+	case StatusInProgress:
+		return "🔄 in progress"
+	}
+	return "⬜ todo"
+}
+
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " …"
+	}
+	return s
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration":   formatDuration,
+	"short":      shortHash,
+	"firstLine":  firstLine,
+	"iterations": func(r *Report, n int) string { return r.iterationsCell(n) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Chief Report: {{.Project}}</title>
+<style>
+  body { font: 14px/1.5 -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; max-width: 960px; margin: 2rem auto; padding: 0 1rem; }
+  h1 { margin-bottom: 0.25rem; }
+  .meta { color: #59636e; margin-top: 0; }
+  table { border-collapse: collapse; width: 100%; margin: 1rem 0; }
+  th, td { border: 1px solid #d1d9e0; padding: 6px 10px; text-align: left; vertical-align: top; }
+  th { background: #f6f8fa; }
+  .summary td { font-size: 1.2em; font-weight: 600; }
+  .done { color: #1a7f37; } .progress { color: #9a6700; } .todo { color: #59636e; }
+  code, pre { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 12px; }
+  pre { background: #f6f8fa; padding: 1rem; overflow-x: auto; }
+  ul { margin: 0.25rem 0; padding-left: 1.25rem; }
+  .error { color: #d1242f; }
+  .add { color: #1a7f37; } .del { color: #d1242f; }
+</style>
+</head>
+<body>
+<h1>{{.Project}}</h1>
+<p class="meta">PRD <code>{{.PRD}}</code>{{if .Branch}} on branch <code>{{.Branch}}</code>{{end}} &middot; generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</p>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+
+<table class="summary">
+<tr><th>Stories</th><th>Iterations</th><th>Time</th><th>Errors</th><th>Retries</th><th>Commits</th><th>Lines</th></tr>
+<tr><td>{{.Totals.Completed}}/{{.Totals.Stories}}</td><td>{{iterations . .Totals.Iterations}}</td><td>{{duration .Totals.Duration}}</td><td>{{.Totals.Errors}}</td><td>{{.Totals.Retries}}</td><td>{{.Totals.Commits}}</td><td><span class="add">+{{.Totals.Insertions}}</span> <span class="del">&minus;{{.Totals.Deletions}}</span></td></tr>
+</table>
+{{if not .HasHistory}}<p class="meta">No iteration history was recorded for this PRD.</p>{{end}}
+
+<h2>Stories</h2>
+<table>
+<tr><th>ID</th><th>Story</th><th>Status</th><th>Iterations</th><th>Time</th><th>Retries</th><th>Commits &amp; errors</th></tr>
+{{- $r := .}}
+{{- range .Stories}}
+<tr>
+  <td><code>{{.ID}}</code></td>
+  <td>{{.Title}}</td>
+  <td class="{{if eq .Status "done"}}done{{else if eq .Status "in progress"}}progress{{else}}todo{{end}}">{{.Status}}</td>
+  <td>{{iterations $r .Iterations}}</td>
+  <td>{{duration .Duration}}</td>
+  <td>{{.Retries}}</td>
+  <td>
+    {{- if .Commits}}<ul>{{range .Commits}}<li><code>{{short .Hash}}</code> {{.Subject}} <span class="add">+{{.Insertions}}</span> <span class="del">&minus;{{.Deletions}}</span></li>{{end}}</ul>{{end}}
+    {{- if .Errors}}<ul>{{range .Errors}}<li class="error">{{firstLine .}}</li>{{end}}</ul>{{end}}
+  </td>
+</tr>
+{{- end}}
+</table>
+
+{{if .Unattributed}}
+<h2>Other Commits</h2>
+<ul>{{range .Unattributed}}<li><code>{{short .Hash}}</code> {{.Subject}} <span class="add">+{{.Insertions}}</span> <span class="del">&minus;{{.Deletions}}</span></li>{{end}}</ul>
+{{end}}
+{{if .DiffStats}}
+<h2>Diff Stats</h2>
+<pre>{{.DiffStats}}</pre>
+{{end}}
+</body>
+</html>
+`))
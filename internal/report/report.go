@@ -0,0 +1,200 @@
+// Package report builds run reports for a PRD: each story's status, the
+// commits that implemented it, iterations and time spent, errors and retries,
+// and the branch's diff stats. Reports render as Markdown, standalone HTML or
+// JSON for attaching to pull requests and status updates.
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/git"
+	"github.com/izdrail/chief/internal/prd"
+)
+
+// Story statuses.
+const (
+	StatusDone       = "done"
+	StatusInProgress = "in progress"
+	StatusTodo       = "todo"
+)
+
+// commitLimit caps the commits read when the branch has no merge base.
+const commitLimit = 200
+
+// Report is a run report for one PRD.
+type Report struct {
+	PRD          string        `json:"prd"`
+	Project      string        `json:"project"`
+	Description  string        `json:"description,omitempty"`
+	Branch       string        `json:"branch,omitempty"`
+	GeneratedAt  time.Time     `json:"generatedAt"`
+	Totals       Totals        `json:"totals"`
+	Stories      []StoryReport `json:"stories"`
+	Unattributed []git.Commit  `json:"unattributedCommits,omitempty"` // Commits that name no story
+	DiffStats    string        `json:"diffStats,omitempty"`
+	HasHistory   bool          `json:"hasHistory"` // Whether iteration history was available
+}
+
+// Totals summarizes a report.
+type Totals struct {
+	Stories    int     `json:"stories"`
+	Completed  int     `json:"completed"`
+	Iterations int     `json:"iterations"`
+	Seconds    float64 `json:"seconds"`
+	Errors     int     `json:"errors"`
+	Retries    int     `json:"retries"`
+	Commits    int     `json:"commits"`
+	Insertions int     `json:"insertions"`
+	Deletions  int     `json:"deletions"`
+}
+
+// StoryReport is the part of a report about one story.
+type StoryReport struct {
+	ID         string       `json:"id"`
+	Title      string       `json:"title"`
+	Priority   int          `json:"priority"`
+	Status     string       `json:"status"`
+	Iterations int          `json:"iterations"`
+	Seconds    float64      `json:"seconds"`
+	Retries    int          `json:"retries"`
+	Errors     []string     `json:"errors,omitempty"`
+	Commits    []git.Commit `json:"commits,omitempty"`
+}
+
+// Duration returns the time spent on the story.
+func (s StoryReport) Duration() time.Duration {
+	return time.Duration(s.Seconds * float64(time.Second))
+}
+
+// Duration returns the total time spent.
+func (t Totals) Duration() time.Duration {
+	return time.Duration(t.Seconds * float64(time.Second))
+}
+
+// Options configures Build.
+type Options struct {
+	PRDPath string    // Path to prd.json (required)
+	RepoDir string    // Git working tree the PRD was implemented in (default: no git data)
+	Store   *db.Store // Store with iteration history (default: no history)
+	Now     time.Time // Report timestamp (default: time.Now())
+}
+
+// Build gathers a report for the PRD at opts.PRDPath.
+func Build(opts Options) (*Report, error) {
+	p, err := prd.LoadPRD(opts.PRDPath)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	r := &Report{
+		PRD:         filepath.Base(filepath.Dir(opts.PRDPath)),
+		Project:     p.Project,
+		Description: p.Description,
+		GeneratedAt: opts.Now,
+	}
+
+	index := make(map[string]int, len(p.UserStories))
+	for i, story := range p.UserStories {
+		status := StatusTodo
+		switch {
+		case story.Passes:
+			status = StatusDone
+			r.Totals.Completed++
+		case story.InProgress:
+			status = StatusInProgress
+		}
+		r.Stories = append(r.Stories, StoryReport{ID: story.ID, Title: story.Title, Priority: story.Priority, Status: status})
+		index[story.ID] = i
+	}
+	r.Totals.Stories = len(r.Stories)
+
+	if opts.Store != nil {
+		records, err := opts.Store.GetIterations(r.PRD)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load iteration history: %w", err)
+		}
+		r.HasHistory = len(records) > 0
+		r.addIterations(records, index)
+	}
+
+	if opts.RepoDir != "" && git.IsGitRepo(opts.RepoDir) {
+		r.Branch, _ = git.GetCurrentBranch(opts.RepoDir)
+		commits, err := git.BranchCommits(opts.RepoDir, commitLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commits: %w", err)
+		}
+		r.addCommits(commits)
+		r.DiffStats, _ = git.GetDiffStats(opts.RepoDir)
+	}
+	return r, nil
+}
+
+// addIterations attributes iteration history to stories.
+func (r *Report) addIterations(records []db.IterationRecord, index map[string]int) {
+	for _, rec := range records {
+		seconds := rec.Duration().Seconds()
+		retries := rec.Attempts - 1
+		if retries < 0 {
+			retries = 0
+		}
+		r.Totals.Iterations++
+		r.Totals.Seconds += seconds
+		r.Totals.Retries += retries
+		r.Totals.Errors += len(rec.Errors)
+
+		i, ok := index[rec.StoryID]
+		if !ok {
+			continue
+		}
+		s := &r.Stories[i]
+		s.Iterations++
+		s.Seconds += seconds
+		s.Retries += retries
+		s.Errors = append(s.Errors, rec.Errors...)
+	}
+}
+
+// addCommits attributes commits to the stories their trailers or subjects
+// name.
+func (r *Report) addCommits(commits []git.Commit) {
+	// Oldest first, so each story lists its commits in the order they were made
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		r.Totals.Commits++
+		r.Totals.Insertions += c.Insertions
+		r.Totals.Deletions += c.Deletions
+
+		attributed := false
+		for j, s := range r.Stories {
+			if c.HasStory(s.ID) {
+				r.Stories[j].Commits = append(r.Stories[j].Commits, c)
+				attributed = true
+			}
+		}
+		if !attributed {
+			r.Unattributed = append(r.Unattributed, c)
+		}
+	}
+}
+
+// formatDuration renders a duration rounded to the second, e.g. "1h2m3s".
+func formatDuration(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	return d.Round(time.Second).String()
+}
+
+// shortHash returns the abbreviated form of a commit hash.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
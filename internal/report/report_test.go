@@ -0,0 +1,158 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/prd"
+)
+
+// setupProject creates a git repository with a PRD on a feature branch and an
+// iteration history, returning the prd.json path and the store.
+func setupProject(t *testing.T) (string, *db.Store) {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s", args, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init")
+	git("config", "user.email", "test@test.com")
+	git("config", "user.name", "Test")
+	git("checkout", "-b", "main")
+	write("README.md", "# Demo\n")
+	git("add", ".")
+	git("commit", "-m", "initial")
+	git("checkout", "-b", "chief/demo")
+	write("a.go", "package a\n")
+	git("add", ".")
+	git("commit", "-m", "feat: US-001 - Add A")
+	write("b.go", "package a\n\nfunc B() {}\n")
+	git("add", ".")
+	git("commit", "-m", "chore: tidy")
+
+	prdDir := filepath.Join(dir, ".chief", "prds", "demo")
+	if err := os.MkdirAll(prdDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	prdPath := filepath.Join(prdDir, "prd.json")
+	p := &prd.PRD{Project: "Demo", UserStories: []prd.UserStory{
+		{ID: "US-001", Title: "Add A", AcceptanceCriteria: []string{"A exists"}, Priority: 1, Passes: true},
+		{ID: "US-0010", Title: "Add B | C", AcceptanceCriteria: []string{"B exists"}, Priority: 2, InProgress: true},
+	}}
+	if err := p.Save(prdPath); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "chief.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	start := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	records := []db.IterationRecord{
+		{ProjectName: "demo", Iteration: 1, StoryID: "US-001", StartedAt: start, FinishedAt: start.Add(90 * time.Second), Attempts: 2, Errors: []string{"connection refused"}, StoryPassed: true},
+		{ProjectName: "demo", Iteration: 2, StoryID: "US-0010", StartedAt: start.Add(2 * time.Minute), FinishedAt: start.Add(3 * time.Minute), Attempts: 1},
+		{ProjectName: "other", Iteration: 1, StoryID: "US-001", StartedAt: start, FinishedAt: start.Add(time.Hour), Attempts: 1},
+	}
+	for _, r := range records {
+		if err := store.AddIteration(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return prdPath, store
+}
+
+func TestBuild(t *testing.T) {
+	prdPath, store := setupProject(t)
+	repoDir := filepath.Dir(filepath.Dir(filepath.Dir(filepath.Dir(prdPath))))
+
+	r, err := Build(Options{PRDPath: prdPath, RepoDir: repoDir, Store: store})
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	if r.PRD != "demo" || r.Branch != "chief/demo" || !r.HasHistory {
+		t.Errorf("unexpected report header: %+v", r)
+	}
+	want := Totals{Stories: 2, Completed: 1, Iterations: 2, Seconds: 150, Errors: 1, Retries: 1, Commits: 2, Insertions: 4}
+	if r.Totals != want {
+		t.Errorf("totals = %+v, want %+v", r.Totals, want)
+	}
+
+	first := r.Stories[0]
+	if first.Status != StatusDone || first.Iterations != 1 || first.Retries != 1 || len(first.Errors) != 1 {
+		t.Errorf("unexpected first story: %+v", first)
+	}
+	if len(first.Commits) != 1 || first.Commits[0].Subject != "feat: US-001 - Add A" {
+		t.Errorf("expected US-001's commit, got %+v", first.Commits)
+	}
+	if second := r.Stories[1]; second.Status != StatusInProgress || len(second.Commits) != 0 || second.Duration() != time.Minute {
+		t.Errorf("unexpected second story: %+v", second)
+	}
+	if len(r.Unattributed) != 1 || r.Unattributed[0].Subject != "chore: tidy" {
+		t.Errorf("unexpected unattributed commits: %+v", r.Unattributed)
+	}
+	if !strings.Contains(r.DiffStats, "2 files changed") {
+		t.Errorf("unexpected diff stats: %q", r.DiffStats)
+	}
+}
+
+func TestRender(t *testing.T) {
+	prdPath, store := setupProject(t)
+	r, err := Build(Options{PRDPath: prdPath, Store: store, Now: time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+
+	md, err := r.Render("md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Chief Report: Demo", "| 1/2 done | 2 | 2m30s | 1 | 1 |", `Add B \| C`, "- connection refused"} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("expected Markdown to contain %q, got:\n%s", want, md)
+		}
+	}
+
+	page, err := r.Render("html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(page), "<!DOCTYPE html>") || !strings.Contains(string(page), "Add B | C") || !strings.Contains(string(page), "connection refused") {
+		t.Errorf("unexpected HTML:\n%s", page)
+	}
+
+	data, err := r.Render("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON report: %v", err)
+	}
+	if decoded.Totals.Seconds != 150 || len(decoded.Stories) != 2 {
+		t.Errorf("unexpected decoded report: %+v", decoded)
+	}
+
+	if _, err := r.Render("pdf"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
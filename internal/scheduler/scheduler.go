@@ -0,0 +1,234 @@
+// Package scheduler runs PRDs from a persistent queue. Runs are queued by hand
+// or by per-PRD cron schedules, start in priority then FIFO order, and are
+// limited by a global concurrency cap so that loops sharing one LLM endpoint
+// don't saturate it. The queue and schedules live in the SQLite store, so they
+// survive restarts of chief serve.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/loop"
+)
+
+// DefaultInterval is how often the scheduler checks schedules and running loops.
+const DefaultInterval = 30 * time.Second
+
+// Options configures a Scheduler.
+type Options struct {
+	Store         *db.Store                                              // Store holding the queue and schedules (required)
+	Start         func(prdName string) error                             // Starts a PRD's loop (required)
+	State         func(prdName string) (state loop.LoopState, err error) // Reports a loop's state and, for failed loops, its error (required)
+	Running       func() []string                                        // Names every running loop, including ones started outside the queue (default: only the queue's runs count)
+	MaxConcurrent int                                                    // Loops allowed to run at once (default: 1)
+	Interval      time.Duration                                          // Polling interval (default: DefaultInterval)
+	Now           func() time.Time                                       // Clock (default: time.Now)
+}
+
+// Scheduler starts queued PRD runs.
+type Scheduler struct {
+	opts    Options
+	kick    chan struct{}
+	mu      sync.Mutex // Serializes Tick
+	started time.Time  // Schedules that never ran count from here
+}
+
+// New creates a scheduler.
+func New(opts Options) *Scheduler {
+	if opts.MaxConcurrent <= 0 {
+		opts.MaxConcurrent = 1
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Scheduler{
+		opts:    opts,
+		kick:    make(chan struct{}, 1),
+		started: opts.Now(),
+	}
+}
+
+// MaxConcurrent returns the global concurrency limit.
+func (s *Scheduler) MaxConcurrent() int {
+	return s.opts.MaxConcurrent
+}
+
+// Run processes the queue until ctx is canceled. Runs left running by a
+// previous process are queued again first.
+func (s *Scheduler) Run(ctx context.Context) error {
+	if err := s.opts.Store.RequeueRunning(); err != nil {
+		return fmt.Errorf("failed to restore run queue: %w", err)
+	}
+
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		// Store errors are usually transient (e.g. a locked database); the next tick retries
+		_ = s.Tick()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-s.kick:
+		}
+	}
+}
+
+// Enqueue queues a run of a PRD and wakes the scheduler.
+func (s *Scheduler) Enqueue(prdName string, priority int) (db.QueueEntry, error) {
+	entry, err := s.opts.Store.Enqueue(prdName, priority, db.SourceManual)
+	if err != nil {
+		return db.QueueEntry{}, err
+	}
+	s.wake()
+	return entry, nil
+}
+
+// Cancel removes a run that has not started from the queue.
+func (s *Scheduler) Cancel(id int64) (bool, error) {
+	return s.opts.Store.CancelQueueEntry(id)
+}
+
+// SetSchedule validates and saves a PRD's schedule.
+func (s *Scheduler) SetSchedule(sched db.Schedule) error {
+	if _, err := ParseCron(sched.Cron); err != nil {
+		return err
+	}
+	return s.opts.Store.SetSchedule(sched)
+}
+
+// NextRun returns when a schedule will next queue a run, or the zero time if
+// it is disabled or never matches.
+func (s *Scheduler) NextRun(sched db.Schedule) time.Time {
+	return NextRun(sched, s.started)
+}
+
+// NextRun returns when a schedule will next queue a run. Schedules that have
+// never run count from since.
+func NextRun(sched db.Schedule, since time.Time) time.Time {
+	if !sched.Enabled {
+		return time.Time{}
+	}
+	c, err := ParseCron(sched.Cron)
+	if err != nil {
+		return time.Time{}
+	}
+	base := sched.LastRun
+	if base.IsZero() {
+		base = since
+	}
+	return c.Next(base.Local())
+}
+
+// Tick queues due scheduled runs, records finished runs and starts queued
+// runs while fewer than MaxConcurrent loops are running, counting loops
+// started outside the queue when Running reports them.
+func (s *Scheduler) Tick() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.opts.Now()
+	if err := s.enqueueDue(now); err != nil {
+		return err
+	}
+
+	entries, err := s.opts.Store.ListQueue()
+	if err != nil {
+		return err
+	}
+
+	running := make(map[string]bool)
+	for _, e := range entries {
+		if e.Status != db.QueueRunning {
+			continue
+		}
+		state, runErr := s.opts.State(e.PRDName)
+		switch state {
+		case loop.LoopStateRunning:
+			running[e.PRDName] = true
+		case loop.LoopStateError:
+			msg := "loop failed"
+			if runErr != nil {
+				msg = runErr.Error()
+			}
+			_ = s.opts.Store.FinishQueueEntry(e.ID, db.QueueFailed, msg)
+		case loop.LoopStateStopped:
+			_ = s.opts.Store.FinishQueueEntry(e.ID, db.QueueCanceled, "stopped")
+		case loop.LoopStateReady:
+			_ = s.opts.Store.FinishQueueEntry(e.ID, db.QueueFailed, "loop is not running")
+		case loop.LoopStatePaused:
+			// Loops pause when they reach max iterations as well as by hand
+			_ = s.opts.Store.FinishQueueEntry(e.ID, db.QueueFailed, "paused with stories remaining (max iterations reached or paused by hand)")
+		case loop.LoopStateComplete:
+			_ = s.opts.Store.FinishQueueEntry(e.ID, db.QueueDone, "")
+		default:
+			_ = s.opts.Store.FinishQueueEntry(e.ID, db.QueueFailed, "unexpected loop state "+state.String())
+		}
+	}
+
+	if s.opts.Running != nil {
+		for _, name := range s.opts.Running() {
+			running[name] = true
+		}
+	}
+
+	for _, e := range entries {
+		if len(running) >= s.opts.MaxConcurrent {
+			break
+		}
+		if e.Status != db.QueueQueued {
+			continue
+		}
+		// A loop started outside the queue (e.g. from the TUI) keeps its queued run waiting
+		if state, _ := s.opts.State(e.PRDName); state == loop.LoopStateRunning {
+			continue
+		}
+		if err := s.opts.Store.StartQueueEntry(e.ID); err != nil {
+			return err
+		}
+		if err := s.opts.Start(e.PRDName); err != nil {
+			_ = s.opts.Store.FinishQueueEntry(e.ID, db.QueueFailed, err.Error())
+			continue
+		}
+		running[e.PRDName] = true
+	}
+	return nil
+}
+
+// enqueueDue queues a run for every schedule whose next run time has passed.
+// A schedule that missed several runs (e.g. while the server was down) queues
+// one run.
+func (s *Scheduler) enqueueDue(now time.Time) error {
+	schedules, err := s.opts.Store.ListSchedules()
+	if err != nil {
+		return err
+	}
+	for _, sched := range schedules {
+		next := NextRun(sched, s.started)
+		if next.IsZero() || next.After(now) {
+			continue
+		}
+		if _, err := s.opts.Store.Enqueue(sched.PRDName, sched.Priority, db.SourceSchedule); err != nil {
+			return err
+		}
+		if err := s.opts.Store.MarkScheduleRun(sched.PRDName, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wake makes Run tick without waiting for the interval.
+func (s *Scheduler) wake() {
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,292 @@
+package scheduler
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/loop"
+)
+
+// fakeLoops records starts and reports states set by the test.
+type fakeLoops struct {
+	mu      sync.Mutex
+	started []string
+	states  map[string]loop.LoopState
+	fail    map[string]error
+}
+
+func newFakeLoops() *fakeLoops {
+	return &fakeLoops{states: map[string]loop.LoopState{}, fail: map[string]error{}}
+}
+
+func (f *fakeLoops) start(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail[name]; err != nil {
+		return err
+	}
+	f.started = append(f.started, name)
+	f.states[name] = loop.LoopStateRunning
+	return nil
+}
+
+func (f *fakeLoops) state(name string) (loop.LoopState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.states[name], nil
+}
+
+func (f *fakeLoops) running() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for name, state := range f.states {
+		if state == loop.LoopStateRunning {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (f *fakeLoops) set(name string, state loop.LoopState) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states[name] = state
+}
+
+func newTestScheduler(t *testing.T, maxConcurrent int, now func() time.Time) (*Scheduler, *db.Store, *fakeLoops) {
+	t.Helper()
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "chief.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	loops := newFakeLoops()
+	s := New(Options{Store: store, Start: loops.start, State: loops.state, Running: loops.running, MaxConcurrent: maxConcurrent, Now: now})
+	return s, store, loops
+}
+
+func queueNames(t *testing.T, store *db.Store, status string) []string {
+	t.Helper()
+	entries, err := store.ListQueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Status == status {
+			names = append(names, e.PRDName)
+		}
+	}
+	return names
+}
+
+func TestSchedulerConcurrencyAndPriority(t *testing.T) {
+	s, store, loops := newTestScheduler(t, 1, nil)
+
+	for _, q := range []struct {
+		name     string
+		priority int
+	}{{"a", 0}, {"b", 0}, {"urgent", 5}} {
+		if _, err := s.Enqueue(q.name, q.priority); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Queuing a PRD twice keeps one entry
+	if _, err := s.Enqueue("a", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	if got := queueNames(t, store, db.QueueRunning); len(got) != 1 || got[0] != "urgent" {
+		t.Fatalf("running = %v, want [urgent]", got)
+	}
+	if got := queueNames(t, store, db.QueueQueued); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("queued = %v, want [a b]", got)
+	}
+
+	// Nothing else starts while the first loop runs
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	if len(loops.started) != 1 {
+		t.Fatalf("started = %v, want only urgent", loops.started)
+	}
+
+	loops.set("urgent", loop.LoopStateComplete)
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	if got := queueNames(t, store, db.QueueRunning); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("running = %v, want [a]", got)
+	}
+
+	history, err := store.QueueHistory(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].PRDName != "urgent" || history[0].Status != db.QueueDone {
+		t.Errorf("unexpected history: %+v", history)
+	}
+}
+
+func TestSchedulerCountsLoopsOutsideTheQueue(t *testing.T) {
+	s, store, loops := newTestScheduler(t, 2, nil)
+
+	// Started from the TUI or the API, bypassing the queue
+	loops.set("direct", loop.LoopStateRunning)
+	for _, name := range []string{"a", "b"} {
+		if _, err := s.Enqueue(name, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	if got := queueNames(t, store, db.QueueRunning); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("running = %v, want [a] next to the direct loop", got)
+	}
+
+	loops.set("direct", loop.LoopStateComplete)
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	if got := queueNames(t, store, db.QueueRunning); len(got) != 2 {
+		t.Fatalf("running = %v, want [a b] once the direct loop finished", got)
+	}
+}
+
+func TestSchedulerFailuresAndCancel(t *testing.T) {
+	s, store, loops := newTestScheduler(t, 2, nil)
+	loops.fail["broken"] = errors.New("no prd.json")
+
+	broken, _ := s.Enqueue("broken", 0)
+	ok, _ := s.Enqueue("ok", 0)
+	waiting, _ := s.Enqueue("waiting", 0)
+	if canceled, err := s.Cancel(waiting.ID); err != nil || !canceled {
+		t.Fatalf("Cancel() = %v, %v", canceled, err)
+	}
+
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	loops.set("ok", loop.LoopStateError)
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := store.QueueHistory(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := map[int64]string{}
+	for _, e := range history {
+		status[e.ID] = e.Status
+	}
+	if status[broken.ID] != db.QueueFailed || status[ok.ID] != db.QueueFailed || status[waiting.ID] != db.QueueCanceled {
+		t.Errorf("unexpected statuses: %+v", history)
+	}
+	if canceled, _ := s.Cancel(ok.ID); canceled {
+		t.Error("expected finished entries not to be cancelable")
+	}
+}
+
+func TestSchedulerPausedRunIsNotDone(t *testing.T) {
+	s, store, loops := newTestScheduler(t, 1, nil)
+	paused, _ := s.Enqueue("paused", 0)
+	s.Enqueue("next", 0)
+
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	// Stopped at max iterations with stories left
+	loops.set("paused", loop.LoopStatePaused)
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := store.QueueHistory(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range history {
+		if e.ID == paused.ID && (e.Status != db.QueueFailed || !strings.Contains(e.Error, "max iterations")) {
+			t.Errorf("paused run recorded as %s (%q)", e.Status, e.Error)
+		}
+	}
+	if running := queueNames(t, store, db.QueueRunning); len(running) != 1 || running[0] != "next" {
+		t.Errorf("expected the next run to take the slot, running: %v", running)
+	}
+}
+
+func TestSchedulerCron(t *testing.T) {
+	now := time.Date(2026, 3, 4, 1, 0, 0, 0, time.Local)
+	clock := func() time.Time { return now }
+	s, store, loops := newTestScheduler(t, 1, clock)
+
+	if err := s.SetSchedule(db.Schedule{PRDName: "nightly", Cron: "not a cron", Enabled: true}); err == nil {
+		t.Fatal("expected an invalid cron expression to be rejected")
+	}
+	if err := s.SetSchedule(db.Schedule{PRDName: "nightly", Cron: "0 2 * * *", Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetSchedule(db.Schedule{PRDName: "off", Cron: "* * * * *", Enabled: false}); err != nil {
+		t.Fatal(err)
+	}
+
+	schedules, _ := store.ListSchedules()
+	if next := s.NextRun(schedules[0]); !next.Equal(time.Date(2026, 3, 4, 2, 0, 0, 0, time.Local)) {
+		t.Errorf("NextRun() = %v", next)
+	}
+
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	if len(loops.started) != 0 {
+		t.Fatalf("started %v before the schedule was due", loops.started)
+	}
+
+	now = now.Add(90 * time.Minute)
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	if len(loops.started) != 1 || loops.started[0] != "nightly" {
+		t.Fatalf("started = %v, want [nightly]", loops.started)
+	}
+
+	// The schedule waits for its next time once it has run
+	loops.set("nightly", loop.LoopStateComplete)
+	now = now.Add(time.Hour)
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	if len(loops.started) != 1 {
+		t.Errorf("schedule ran again before it was due: %v", loops.started)
+	}
+}
+
+func TestSchedulerRequeuesInterruptedRuns(t *testing.T) {
+	s, store, loops := newTestScheduler(t, 1, nil)
+	entry, _ := s.Enqueue("a", 0)
+	if err := store.StartQueueEntry(entry.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.RequeueRunning(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	if len(loops.started) != 1 || loops.started[0] != "a" {
+		t.Errorf("expected the interrupted run to start again, started %v", loops.started)
+	}
+}
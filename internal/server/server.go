@@ -1,8 +1,11 @@
 package server
 
 import (
+	"context"
+	"database/sql"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/fs"
 	"net/http"
@@ -17,9 +20,13 @@ import (
 	"github.com/izdrail/chief/internal/db"
 	"github.com/izdrail/chief/internal/git"
 	"github.com/izdrail/chief/internal/git/api"
+	"github.com/izdrail/chief/internal/logstore"
 	"github.com/izdrail/chief/internal/loop"
+	"github.com/izdrail/chief/internal/notify/dispatch"
 	"github.com/izdrail/chief/internal/ollama"
 	"github.com/izdrail/chief/internal/prd"
+	"github.com/izdrail/chief/internal/scheduler"
+	"github.com/izdrail/chief/internal/transcript"
 )
 
 //go:embed static
@@ -40,10 +47,13 @@ type Server struct {
 	ollama         *ollama.Client
 	loopManager    *loop.Manager
 	mux            *http.ServeMux
-	logBuffer      []string
-	logMu          sync.Mutex
+	logs           *logstore.Store   // chief serve's own log, in .chief/logs/serve/
+	logLimits      config.LogsConfig // Retention for agent logs in the store
 	creationStatus map[string]*CreationStatus
 	statusMu       sync.Mutex
+	prdSubscribers map[chan prd.WorkspaceEvent]struct{} // Clients of /api/prd/events
+	subscribersMu  sync.Mutex
+	scheduler      *scheduler.Scheduler // Run queue and cron schedules; nil without a store
 }
 
 func NewServer(addr, baseDir string, gitToken string) *Server {
@@ -64,24 +74,92 @@ func NewServer(addr, baseDir string, gitToken string) *Server {
 		loopManager:    loop.NewManager(10),
 		mux:            http.NewServeMux(),
 		creationStatus: make(map[string]*CreationStatus),
+		prdSubscribers: make(map[chan prd.WorkspaceEvent]struct{}),
 	}
 	if store != nil {
 		srv.loopManager.SetStore(store)
 	}
+
+	cfg, err := config.Load(baseDir)
+	if err != nil {
+		fmt.Printf("Warning: failed to load config: %v\n", err)
+		cfg = config.Default()
+	}
+
+	// Keep the server log on disk, holding only the newest lines in memory
+	srv.logLimits = cfg.Logs
+	srv.logs, err = logstore.Open(filepath.Join(baseDir, ".chief", "logs", "serve"), logstore.Options{
+		SegmentBytes: int64(cfg.Logs.MaxFileMB) << 20,
+		MaxSegments:  max(cfg.Logs.MaxFiles, logstore.DefaultMaxFiles) + 1,
+		Window:       cfg.Logs.MemoryEntries,
+	})
+	if err != nil {
+		fmt.Printf("Warning: server log kept in memory only: %v\n", err)
+		srv.logs = logstore.NewMemory(logstore.Options{Window: cfg.Logs.MemoryEntries})
+	}
+
+	// Loops, PRD generation and suggest-fix share the LLM endpoint's request slots
+	ollama.SetConcurrency(cfg.LLM.MaxConcurrent, cfg.LLM.Endpoints)
+	ollama.SetTimeouts(ollama.Timeouts{
+		Connect:    cfg.LLM.ConnectTimeout,
+		FirstToken: cfg.LLM.FirstTokenTimeout,
+		Idle:       cfg.LLM.IdleTimeout,
+	})
+	if err := ollama.SetToolCallFormat(cfg.LLM.ToolCallFormat); err != nil {
+		fmt.Printf("Warning: llm.toolCallFormat: %v\n", err)
+	}
+
+	// Queue runs so only scheduler.maxConcurrent loops share the model at once
+	if store != nil {
+		srv.scheduler = scheduler.New(scheduler.Options{
+			Store:         store,
+			Start:         srv.startAgent,
+			State:         srv.agentState,
+			Running:       srv.loopManager.GetRunningPRDs,
+			MaxConcurrent: cfg.Scheduler.MaxConcurrent,
+		})
+	}
+
+	// Route loop events to the notification channels in .chief/config.yaml
+	if len(cfg.Notify.Channels) > 0 {
+		if dispatcher, err := dispatch.New(cfg.Notify); err != nil {
+			fmt.Printf("Warning: notifications disabled: %v\n", err)
+		} else {
+			dispatcher.SetErrorHandler(func(channel string, err error) {
+				srv.log(fmt.Sprintf("Notification via %s failed: %v", channel, err))
+			})
+			srv.loopManager.SetEventCallback(func(event loop.ManagerEvent) {
+				dispatcher.HandleEvent(event.PRDName, event.Event)
+			})
+		}
+	}
 	return srv
 }
 
 func (s *Server) Start() error {
+	if err := s.routes(); err != nil {
+		return err
+	}
+	s.startWorkers(context.Background())
+
+	fmt.Printf("Starting Chief server on %s\n", s.addr)
+	return http.ListenAndServe(s.addr, s.mux)
+}
+
+// routes registers the API handlers and the static frontend on the server's mux.
+func (s *Server) routes() error {
 	s.mux.HandleFunc("/api/prd/list", s.handlePRDList)
 	s.mux.HandleFunc("/api/prd/get", s.handlePRDGet)
 	s.mux.HandleFunc("/api/prd/create", s.handlePRDCreate)
 	s.mux.HandleFunc("/api/prd/create/status", s.handlePRDCreateStatus)
 	s.mux.HandleFunc("/api/prd/delete", s.handlePRDDelete)
+	s.mux.HandleFunc("/api/prd/events", s.handlePRDEvents)
 	s.mux.HandleFunc("/api/agent/start", s.handleAgentStart)
 	s.mux.HandleFunc("/api/agent/stop", s.handleAgentStop)
 	s.mux.HandleFunc("/api/agent/status", s.handleAgentStatus)
 	s.mux.HandleFunc("/api/agent/log", s.handleAgentLog)
 	s.mux.HandleFunc("/api/agent/iterations", s.handleAgentIterations)
+	s.mux.HandleFunc("/api/transcripts", s.handleTranscripts)
 	s.mux.HandleFunc("/api/git/repos", s.handleListRepos)
 	s.mux.HandleFunc("/api/git/repos/", s.handleGitRepoAction)
 	s.mux.HandleFunc("/api/git/diff", s.handleGitDiff)
@@ -91,6 +169,8 @@ func (s *Server) Start() error {
 	s.mux.HandleFunc("/api/git/clean", s.handleGitClean)
 	s.mux.HandleFunc("/api/story/delete", s.handleStoryDelete)
 	s.mux.HandleFunc("/api/config", s.handleConfig)
+	s.mux.HandleFunc("/api/queue", s.handleQueue)
+	s.mux.HandleFunc("/api/schedule", s.handleSchedule)
 	
 	// Serve static frontend
 	subFS, err := fs.Sub(staticFiles, "static")
@@ -98,7 +178,13 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to create static file system: %w", err)
 	}
 	s.mux.Handle("/", http.FileServer(http.FS(subFS)))
+	return nil
+}
 
+// startWorkers starts the background work of a running server: logging loop
+// events, watching the workspace, pruning agent logs and running the
+// scheduler until ctx is done.
+func (s *Server) startWorkers(ctx context.Context) {
 	// Start event listener
 	go func() {
 		for event := range s.loopManager.Events() {
@@ -120,34 +206,140 @@ func (s *Server) Start() error {
 		}
 	}()
 
-	fmt.Printf("Starting Chief server on %s\n", s.addr)
-	return http.ListenAndServe(s.addr, s.mux)
+	s.watchWorkspace()
+
+	if s.store != nil {
+		go s.pruneLogs()
+	}
+
+	if s.scheduler != nil {
+		go func() {
+			if err := s.scheduler.Run(ctx); err != nil && ctx.Err() == nil {
+				s.log(fmt.Sprintf("Scheduler stopped: %v", err))
+			}
+		}()
+	}
 }
 
+// handlePRDList lists the PRDs in .chief/prds/. The directory is the source of
+// truth for which PRDs exist; the store supplies titles and recency order.
 func (s *Server) handlePRDList(w http.ResponseWriter, r *http.Request) {
+	prdsDir := filepath.Join(s.baseDir, ".chief", "prds")
+	names, err := scanPRDs(prdsDir)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	onDisk := make(map[string]bool, len(names))
+	for _, name := range names {
+		onDisk[name] = true
+	}
+
+	prds := make([]db.ProjectInfo, 0, len(names))
+	listed := make(map[string]bool, len(names))
 	if s.store != nil {
-		prds, err := s.store.ListProjects()
-		if err == nil && len(prds) > 0 {
-			json.NewEncoder(w).Encode(prds)
-			return
+		if projects, err := s.store.ListProjects(); err == nil {
+			for _, p := range projects {
+				if onDisk[p.Name] {
+					prds = append(prds, p)
+					listed[p.Name] = true
+				}
+			}
 		}
 	}
 
-	// Fallback to directory scan
-	names, err := scanPRDs(filepath.Join(s.baseDir, ".chief", "prds"))
+	// PRDs not synced to the store yet, e.g. created by the CLI or TUI
+	for _, name := range names {
+		if listed[name] {
+			continue
+		}
+		info := db.ProjectInfo{Name: name, Title: name}
+		if p, err := prd.LoadPRD(filepath.Join(prdsDir, name, "prd.json")); err == nil && p.Project != "" {
+			info.Title = p.Project
+			info.Description = p.Description
+		}
+		prds = append(prds, info)
+	}
+	json.NewEncoder(w).Encode(prds)
+}
+
+// watchWorkspace starts a watcher over .chief/prds/ that pushes PRD changes to
+// /api/prd/events subscribers. The server runs without live updates if the
+// watcher cannot start.
+func (s *Server) watchWorkspace() {
+	watcher, err := prd.NewWorkspaceWatcher(filepath.Join(s.baseDir, ".chief", "prds"), prd.WorkspaceOptions{AutoConvert: true})
+	if err == nil {
+		err = watcher.Start()
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		fmt.Printf("Warning: failed to watch PRD directory: %v\n", err)
 		return
 	}
-	
-	prds := make([]db.ProjectInfo, len(names))
-	for i, name := range names {
-		prds[i] = db.ProjectInfo{
-			Name:  name,
-			Title: name,
+
+	go func() {
+		for event := range watcher.Events() {
+			switch event.Type {
+			case prd.WorkspacePRDAdded, prd.WorkspacePRDRemoved, prd.WorkspacePRDConverted:
+				s.log(fmt.Sprintf("[%s] PRD %s", event.Name, event.Type))
+			case prd.WorkspaceError:
+				if event.Err != nil && !errors.Is(event.Err, prd.ErrFreeForm) {
+					s.log(fmt.Sprintf("[%s] PRD watcher error: %v", event.Name, event.Err))
+				}
+			}
+			s.broadcastPRDEvent(event)
+		}
+	}()
+}
+
+// broadcastPRDEvent sends an event to every /api/prd/events subscriber,
+// dropping it for subscribers that are not keeping up.
+func (s *Server) broadcastPRDEvent(event prd.WorkspaceEvent) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	for ch := range s.prdSubscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// handlePRDEvents streams PRD add, remove and change events as server-sent events.
+func (s *Server) handlePRDEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan prd.WorkspaceEvent, 16)
+	s.subscribersMu.Lock()
+	s.prdSubscribers[ch] = struct{}{}
+	s.subscribersMu.Unlock()
+	defer func() {
+		s.subscribersMu.Lock()
+		delete(s.prdSubscribers, ch)
+		s.subscribersMu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-ch:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: prd\ndata: %s\n\n", data)
+			flusher.Flush()
 		}
 	}
-	json.NewEncoder(w).Encode(prds)
 }
 
 func (s *Server) handlePRDGet(w http.ResponseWriter, r *http.Request) {
@@ -199,8 +391,9 @@ func (s *Server) handleAgentStart(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	var req struct {
-		Name string `json:"name"`
-		Path string `json:"path"`
+		Name     string `json:"name"`
+		Path     string `json:"path"`
+		Priority int    `json:"priority"` // Queue priority; higher runs first
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -211,16 +404,75 @@ func (s *Server) handleAgentStart(w http.ResponseWriter, r *http.Request) {
 		req.Path = filepath.Join(s.baseDir, ".chief", "prds", req.Name, "prd.json")
 	}
 
+	// Without a store there is no queue; start immediately
+	if s.scheduler == nil {
+		if err := s.startAgentAt(req.Name, req.Path); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "Agent started for %s", req.Name)
+		return
+	}
+
+	// Register now so a custom path is used when the queued run starts
+	if err := s.prepareAgent(req.Name, req.Path); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	entry, err := s.scheduler.Enqueue(req.Name, req.Priority)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.log(fmt.Sprintf("[%s] Queued run #%d (priority %d)", req.Name, entry.ID, entry.Priority))
+	fmt.Fprintf(w, "Agent queued for %s", req.Name)
+}
+
+// startAgent prepares and starts a PRD's loop. It is the scheduler's way of
+// starting queued runs.
+func (s *Server) startAgent(name string) error {
+	path := filepath.Join(s.baseDir, ".chief", "prds", name, "prd.json")
+	if instance := s.loopManager.GetInstance(name); instance != nil {
+		path = instance.PRDPath
+	}
+	return s.startAgentAt(name, path)
+}
+
+// startAgentAt prepares and starts a PRD's loop from the given prd.json.
+func (s *Server) startAgentAt(name, path string) error {
+	if err := s.prepareAgent(name, path); err != nil {
+		return err
+	}
+	return s.loopManager.Start(name)
+}
+
+// agentState reports a loop's state for the scheduler, with the error a failed
+// loop stopped with.
+func (s *Server) agentState(name string) (loop.LoopState, error) {
+	instance := s.loopManager.GetInstance(name)
+	if instance == nil {
+		return loop.LoopStateReady, nil
+	}
+	return instance.State, instance.Error
+}
+
+// prepareAgent syncs a PRD to the store, ensures its worktree and registers it
+// with the loop manager.
+func (s *Server) prepareAgent(name, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("PRD %s not found: %w", name, err)
+	}
+
 	// Sync to DB if available
 	var repoURL string
 	if s.store != nil {
-		p, err := prd.LoadPRD(req.Path)
+		p, err := prd.LoadPRD(path)
 		if err == nil {
 			// Try to get existing repoURL
-			_, _, _, rURL, _ := s.store.GetProject(req.Name)
+			_, _, _, rURL, _ := s.store.GetProject(name)
 			repoURL = rURL
-			
-			projectID, err := s.store.SaveProject(req.Name, p.Project, p.Description, repoURL)
+
+			projectID, err := s.store.SaveProject(name, p.Project, p.Description, repoURL)
 			if err == nil {
 				for _, story := range p.UserStories {
 					s.store.SaveStory(projectID, db.StoryDB{
@@ -238,16 +490,16 @@ func (s *Server) handleAgentStart(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Automatic branch/worktree management
-	branchName := fmt.Sprintf("chief/%s", req.Name)
-	worktreePath := git.WorktreePathForPRD(s.baseDir, req.Name)
-	
+	branchName := fmt.Sprintf("chief/%s", name)
+	worktreePath := git.WorktreePathForPRD(s.baseDir, name)
+
 	repoBaseDir := s.baseDir
 	if repoURL != "" {
-		repoBaseDir = filepath.Join(s.baseDir, ".chief", "repos", req.Name)
+		repoBaseDir = filepath.Join(s.baseDir, ".chief", "repos", name)
 	}
 
 	if git.IsGitRepo(repoBaseDir) {
-		s.log(fmt.Sprintf("Ensuring worktree for %s on branch %s", req.Name, branchName))
+		s.log(fmt.Sprintf("Ensuring worktree for %s on branch %s", name, branchName))
 		if err := git.CreateWorktree(repoBaseDir, worktreePath, branchName); err != nil {
 			s.log(fmt.Sprintf("Warning: failed to create worktree: %v. Running in base dir.", err))
 			// Fallback to base dir if worktree creation fails
@@ -260,27 +512,15 @@ func (s *Server) handleAgentStart(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Register or update with worktree info
-	if instance := s.loopManager.GetInstance(req.Name); instance == nil {
+	if instance := s.loopManager.GetInstance(name); instance == nil {
 		if worktreePath != "" {
-			s.loopManager.RegisterWithWorktree(req.Name, req.Path, repoURL, worktreePath, branchName)
-		} else {
-			s.loopManager.Register(req.Name, req.Path)
+			return s.loopManager.RegisterWithWorktree(name, path, repoURL, worktreePath, branchName)
 		}
+		return s.loopManager.Register(name, path)
 	} else if worktreePath != "" {
-		s.loopManager.UpdateWorktreeInfo(req.Name, repoURL, worktreePath, branchName)
+		return s.loopManager.UpdateWorktreeInfo(name, repoURL, worktreePath, branchName)
 	}
-	
-	// Start via manager
-	if err := s.loopManager.Start(req.Name); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// We can't easily stream logs back in this request structure without a websocket or long poll
-	// The manager runs the loop in a goroutine and sends events to its central channel.
-	// We need to tap into that central channel in the server struct.
-
-	fmt.Fprintf(w, "Agent started for %s", req.Name)
+	return nil
 }
 
 func (s *Server) handleAgentStop(w http.ResponseWriter, r *http.Request) {
@@ -319,6 +559,147 @@ func (s *Server) handleAgentStatus(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// handleQueue lists the run queue (GET), queues a run (POST {"name", "priority"})
+// or cancels a queued run (DELETE ?id=).
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	if s.scheduler == nil {
+		http.Error(w, "run queue requires the SQLite store", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		entries, err := s.store.ListQueue()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		history, err := s.store.QueueHistory(20)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if entries == nil {
+			entries = []db.QueueEntry{}
+		}
+		if history == nil {
+			history = []db.QueueEntry{}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"maxConcurrent": s.scheduler.MaxConcurrent(),
+			"entries":       entries,
+			"history":       history,
+		})
+
+	case http.MethodPost:
+		var req struct {
+			Name     string `json:"name"`
+			Priority int    `json:"priority"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Name == "" {
+			http.Error(w, "name required", http.StatusBadRequest)
+			return
+		}
+		if err := s.prepareAgent(req.Name, filepath.Join(s.baseDir, ".chief", "prds", req.Name, "prd.json")); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		entry, err := s.scheduler.Enqueue(req.Name, req.Priority)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(entry)
+
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "id required", http.StatusBadRequest)
+			return
+		}
+		canceled, err := s.scheduler.Cancel(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !canceled {
+			http.Error(w, "no queued run with that id", http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "Run #%d canceled", id)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSchedule lists cron schedules (GET), creates or replaces one (POST
+// {"prd", "cron", "priority", "enabled"}) or removes one (DELETE ?name=).
+func (s *Server) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	if s.scheduler == nil {
+		http.Error(w, "schedules require the SQLite store", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		schedules, err := s.store.ListSchedules()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		type scheduleInfo struct {
+			db.Schedule
+			NextRun *time.Time `json:"nextRun,omitempty"`
+		}
+		resp := make([]scheduleInfo, 0, len(schedules))
+		for _, sched := range schedules {
+			info := scheduleInfo{Schedule: sched}
+			if next := s.scheduler.NextRun(sched); !next.IsZero() {
+				info.NextRun = &next
+			}
+			resp = append(resp, info)
+		}
+		json.NewEncoder(w).Encode(resp)
+
+	case http.MethodPost:
+		sched := db.Schedule{Enabled: true}
+		if err := json.NewDecoder(r.Body).Decode(&sched); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if sched.PRDName == "" {
+			http.Error(w, "prd required", http.StatusBadRequest)
+			return
+		}
+		if err := s.scheduler.SetSchedule(sched); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.log(fmt.Sprintf("[%s] Scheduled %q", sched.PRDName, sched.Cron))
+		fmt.Fprintf(w, "Schedule saved for %s", sched.PRDName)
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "name required", http.StatusBadRequest)
+			return
+		}
+		if err := s.store.DeleteSchedule(name); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "Schedule removed for %s", name)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 func (s *Server) handlePRDCreate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "POST required", http.StatusMethodNotAllowed)
@@ -548,24 +929,162 @@ func (s *Server) handleAgentIterations(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 }
 
+// handleTranscripts lists a PRD's iteration transcripts (?name=), or returns
+// the entries of one (?id=).
+func (s *Server) handleTranscripts(w http.ResponseWriter, r *http.Request) {
+	if s.store == nil {
+		http.Error(w, "transcripts require the SQLite store", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if idParam := r.URL.Query().Get("id"); idParam != "" {
+		id, err := strconv.ParseInt(idParam, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid id", http.StatusBadRequest)
+			return
+		}
+		record, err := s.store.GetTranscript(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "transcript not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		entries, err := transcript.Read(record.Path)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("read transcript: %v", err), http.StatusNotFound)
+			return
+		}
+		if entries == nil {
+			entries = []transcript.Entry{}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"transcript": record,
+			"entries":    entries,
+		})
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "name or id is required", http.StatusBadRequest)
+		return
+	}
+	records, err := s.store.GetTranscripts(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if records == nil {
+		records = []db.TranscriptRecord{}
+	}
+	json.NewEncoder(w).Encode(records)
+}
+
+// Defaults for the agent log retention in logs.retainDays and
+// logs.maxEntries, and how often it's applied.
+const (
+	defaultLogRetainDays = 30
+	defaultLogMaxEntries = 10000
+	logPruneInterval     = time.Hour
+)
+
+// pruneLogs deletes agent logs past their retention, now and then every
+// logPruneInterval.
+func (s *Server) pruneLogs() {
+	retainDays := s.logLimits.RetainDays
+	if retainDays <= 0 {
+		retainDays = defaultLogRetainDays
+	}
+	maxEntries := s.logLimits.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultLogMaxEntries
+	}
+
+	ticker := time.NewTicker(logPruneInterval)
+	defer ticker.Stop()
+	for {
+		n, err := s.store.PruneLogs(time.Duration(retainDays)*24*time.Hour, maxEntries)
+		if err != nil {
+			s.log(fmt.Sprintf("Pruning agent logs failed: %v", err))
+		} else if n > 0 {
+			s.log(fmt.Sprintf("Pruned %d agent log entries", n))
+		}
+		<-ticker.C
+	}
+}
+
+// Limits for a page of /api/agent/log.
+const (
+	defaultLogPage = 100
+	maxLogPage     = 1000
+)
+
+// handleAgentLog returns a page of log lines, oldest first: a PRD's agent log
+// with ?name=, or the server's own log. ?limit= sets the page size and
+// ?before= pages back from the cursor the previous page returned in the
+// X-Log-Before header, which is absent on the oldest page.
 func (s *Server) handleAgentLog(w http.ResponseWriter, r *http.Request) {
+	limit := defaultLogPage
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxLogPage)
+	}
+	var before int64
+	if v := r.URL.Query().Get("before"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			http.Error(w, "before must be a cursor from X-Log-Before", http.StatusBadRequest)
+			return
+		}
+		before = n
+	}
+
 	name := r.URL.Query().Get("name")
 	if s.store != nil && name != "" {
-		logs, err := s.store.GetLogs(name, 100)
+		logs, next, err := s.store.GetLogPage(name, before, limit)
 		if err == nil {
+			if logs == nil {
+				logs = []string{}
+			}
+			if next > 0 {
+				w.Header().Set("X-Log-Before", strconv.FormatInt(next, 10))
+			}
 			json.NewEncoder(w).Encode(logs)
 			return
 		}
 	}
 
-	s.logMu.Lock()
-	defer s.logMu.Unlock()
-	// Return last 100 log lines
-	start := 0
-	if len(s.logBuffer) > 100 {
-		start = len(s.logBuffer) - 100
+	// The server log's cursor is a record index plus one, so 0 means none
+	end := s.logs.Len()
+	if before > 0 {
+		end = min(end, int(before)-1)
+	}
+	start := max(s.logs.First(), end-limit)
+	logs := []string{}
+	for i := start; i < end; i++ {
+		data, err := s.logs.Get(i)
+		if err != nil {
+			continue
+		}
+		var line string
+		if json.Unmarshal(data, &line) == nil {
+			logs = append(logs, line)
+		}
+	}
+	if start > s.logs.First() {
+		w.Header().Set("X-Log-Before", strconv.Itoa(start+1))
 	}
-	json.NewEncoder(w).Encode(s.logBuffer[start:])
+	json.NewEncoder(w).Encode(logs)
 }
 
 func (s *Server) handleListRepos(w http.ResponseWriter, r *http.Request) {
@@ -701,7 +1220,8 @@ func (s *Server) handleSuggestFix(w http.ResponseWriter, r *http.Request, owner,
 	prompt += "\nPlease suggest a fix or implementation plan."
 
 	// Use local Ollama
-	resp, err := s.ollama.Chat(r.Context(), ollama.ChatRequest{
+	ctx := ollama.WithTenant(r.Context(), fmt.Sprintf("suggest-fix %s/%s", owner, repo))
+	resp, err := s.ollama.Chat(ctx, ollama.ChatRequest{
 		Model: "codellama:7b", // Default or configurable
 		Messages: []ollama.Message{
 			{Role: "user", Content: prompt},
@@ -740,9 +1260,9 @@ func (s *Server) handleGetPullRequest(w http.ResponseWriter, r *http.Request, ow
 }
 
 func (s *Server) log(msg string) {
-	s.logMu.Lock()
-	defer s.logMu.Unlock()
-	s.logBuffer = append(s.logBuffer, fmt.Sprintf("%s: %s", time.Now().Format(time.RFC3339), msg))
+	// Lines are stored as JSON strings, which escape any newlines in msg
+	data, _ := json.Marshal(fmt.Sprintf("%s: %s", time.Now().Format(time.RFC3339), msg))
+	s.logs.Append(data)
 }
 
 // scanPRDs is a helper logic from main
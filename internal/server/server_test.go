@@ -0,0 +1,463 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/fakellm"
+	"github.com/izdrail/chief/internal/loop"
+	"github.com/izdrail/chief/internal/ollama"
+	"github.com/izdrail/chief/internal/prd"
+	"github.com/izdrail/chief/internal/transcript"
+)
+
+// newTestServer creates a server for a new project directory with its routes
+// registered. Its background workers aren't started.
+func newTestServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	s := NewServer("localhost:0", dir, "")
+	if s.store == nil {
+		t.Fatal("NewServer() has no store")
+	}
+	t.Cleanup(func() {
+		s.store.Close()
+		s.logs.Close()
+	})
+	if err := s.routes(); err != nil {
+		t.Fatalf("routes() error: %v", err)
+	}
+	return s, dir
+}
+
+// writePRD writes a PRD with one story to the project's .chief/prds/name/.
+func writePRD(t *testing.T, dir, name string) string {
+	t.Helper()
+	p := prd.PRD{
+		Project:     name,
+		Description: "A test PRD",
+		UserStories: []prd.UserStory{
+			{ID: "US-001", Title: "Test Story", Description: "A test story", AcceptanceCriteria: []string{"It works"}, Priority: 1},
+		},
+	}
+	path := filepath.Join(dir, ".chief", "prds", name, "prd.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := json.MarshalIndent(p, "", "  ")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// request sends a request to the server's handlers.
+func request(s *Server, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode decodes a JSON response into v.
+func decode(t *testing.T, rec *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", rec.Code, rec.Body.String())
+	}
+	if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+}
+
+// waitFor polls cond until it holds, failing the test after 30s.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestServeRunsQueuedPRDWithFakeModel queues a PRD through the API and runs
+// it against a fake model until it is complete.
+func TestServeRunsQueuedPRDWithFakeModel(t *testing.T) {
+	script, err := fakellm.ParseScript([]byte(`
+rules:
+  - when: {role: user}
+    reply:
+      text: "<ralph-status>US-001</ralph-status>"
+      toolCalls:
+        - name: Edit
+          arguments: {file_path: prd.json, old_string: '"passes": false', new_string: '"passes": true'}
+  - when: {afterTool: Edit}
+    reply: {text: "US-001 is done."}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := fakellm.NewServer(script)
+	llm := httptest.NewServer(fake)
+	defer llm.Close()
+	t.Setenv("OLLAMA_HOST", llm.URL)
+	t.Setenv("OLLAMA_MODEL", script.Model)
+
+	s, dir := newTestServer(t)
+	writePRD(t, dir, "auth")
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	s.startWorkers(ctx)
+
+	if rec := request(s, http.MethodPost, "/api/agent/start", `{"name": "auth"}`); rec.Code != http.StatusOK || rec.Body.String() != "Agent queued for auth" {
+		t.Fatalf("start = %d %q", rec.Code, rec.Body.String())
+	}
+	waitFor(t, "the loop to complete", func() bool {
+		var status struct {
+			State loop.LoopState `json:"state"`
+			Error string         `json:"error"`
+		}
+		decode(t, request(s, http.MethodGet, "/api/agent/status?name=auth", ""), &status)
+		if status.State == loop.LoopStateError {
+			t.Fatalf("loop failed: %s", status.Error)
+		}
+		return status.State == loop.LoopStateComplete
+	})
+
+	var p prd.PRD
+	decode(t, request(s, http.MethodGet, "/api/prd/get?name=auth", ""), &p)
+	if !p.AllComplete() {
+		t.Errorf("PRD = %+v, want US-001 passing", p)
+	}
+	if n := len(fake.Requests()); n != 2 {
+		t.Errorf("fake model got %d requests, want 2", n)
+	}
+
+	// Loop events reach the agent log as they're forwarded
+	waitFor(t, "the tool call in the agent log", func() bool {
+		var logs []string
+		decode(t, request(s, http.MethodGet, "/api/agent/log?name=auth", ""), &logs)
+		return strings.Contains(strings.Join(logs, "\n"), "Tool: Edit")
+	})
+
+	var records []db.TranscriptRecord
+	decode(t, request(s, http.MethodGet, "/api/transcripts?name=auth", ""), &records)
+	if len(records) != 1 || records[0].StoryID != "US-001" || records[0].ToolCalls != 1 {
+		t.Fatalf("transcripts = %+v, want one for US-001 with 1 tool call", records)
+	}
+	var resp struct {
+		Entries []transcript.Entry `json:"entries"`
+	}
+	decode(t, request(s, http.MethodGet, fmt.Sprintf("/api/transcripts?id=%d", records[0].ID), ""), &resp)
+	var roles []string
+	for _, e := range resp.Entries {
+		roles = append(roles, e.Role)
+	}
+	if got := strings.Join(roles, ","); got != "user,assistant,tool,assistant" {
+		t.Errorf("transcript roles = %s, want user,assistant,tool,assistant", got)
+	}
+}
+
+// TestQueueEnqueueAndCancel queues a run through /api/queue and cancels it.
+// The scheduler isn't running, so the run stays queued until canceled.
+func TestQueueEnqueueAndCancel(t *testing.T) {
+	s, dir := newTestServer(t)
+	writePRD(t, dir, "auth")
+
+	var entry db.QueueEntry
+	decode(t, request(s, http.MethodPost, "/api/queue", `{"name": "auth", "priority": 2}`), &entry)
+	if entry.ID == 0 || entry.PRDName != "auth" || entry.Priority != 2 || entry.Status != db.QueueQueued {
+		t.Fatalf("queued entry = %+v", entry)
+	}
+
+	var queue struct {
+		MaxConcurrent int             `json:"maxConcurrent"`
+		Entries       []db.QueueEntry `json:"entries"`
+		History       []db.QueueEntry `json:"history"`
+	}
+	decode(t, request(s, http.MethodGet, "/api/queue", ""), &queue)
+	if len(queue.Entries) != 1 || queue.Entries[0].ID != entry.ID || len(queue.History) != 0 || queue.MaxConcurrent < 1 {
+		t.Errorf("queue = %+v, want the entry queued", queue)
+	}
+
+	target := fmt.Sprintf("/api/queue?id=%d", entry.ID)
+	if rec := request(s, http.MethodDelete, target, ""); rec.Code != http.StatusOK {
+		t.Fatalf("cancel = %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := request(s, http.MethodDelete, target, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("second cancel = %d, want 404", rec.Code)
+	}
+	decode(t, request(s, http.MethodGet, "/api/queue", ""), &queue)
+	if len(queue.Entries) != 0 || len(queue.History) != 1 || queue.History[0].Status != db.QueueCanceled {
+		t.Errorf("queue = %+v, want the entry canceled", queue)
+	}
+}
+
+func TestQueueRejectsBadRequests(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	tests := []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodPost, "/api/queue", `{"name": "missing"}`, http.StatusNotFound},
+		{http.MethodPost, "/api/queue", `{}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/queue", `not json`, http.StatusBadRequest},
+		{http.MethodDelete, "/api/queue", "", http.StatusBadRequest},
+		{http.MethodPut, "/api/queue", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if rec := request(s, tt.method, tt.target, tt.body); rec.Code != tt.want {
+			t.Errorf("%s %s %s = %d, want %d", tt.method, tt.target, tt.body, rec.Code, tt.want)
+		}
+	}
+}
+
+// TestSchedule saves, lists and removes a cron schedule through /api/schedule.
+func TestSchedule(t *testing.T) {
+	s, dir := newTestServer(t)
+	writePRD(t, dir, "auth")
+
+	if rec := request(s, http.MethodPost, "/api/schedule", `{"prd": "auth", "cron": "not a cron"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid cron = %d, want 400", rec.Code)
+	}
+	if rec := request(s, http.MethodPost, "/api/schedule", `{"cron": "0 2 * * *"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing prd = %d, want 400", rec.Code)
+	}
+	if rec := request(s, http.MethodPost, "/api/schedule", `{"prd": "auth", "cron": "0 2 * * *", "priority": 1}`); rec.Code != http.StatusOK {
+		t.Fatalf("save = %d %q", rec.Code, rec.Body.String())
+	}
+
+	var schedules []struct {
+		db.Schedule
+		NextRun *time.Time `json:"nextRun"`
+	}
+	decode(t, request(s, http.MethodGet, "/api/schedule", ""), &schedules)
+	if len(schedules) != 1 {
+		t.Fatalf("schedules = %+v, want 1", schedules)
+	}
+	sched := schedules[0]
+	if sched.PRDName != "auth" || sched.Cron != "0 2 * * *" || !sched.Enabled || sched.Priority != 1 {
+		t.Errorf("schedule = %+v", sched.Schedule)
+	}
+	if sched.NextRun == nil || sched.NextRun.Hour() != 2 || sched.NextRun.Minute() != 0 {
+		t.Errorf("next run = %v, want 02:00", sched.NextRun)
+	}
+
+	if rec := request(s, http.MethodDelete, "/api/schedule?name=auth", ""); rec.Code != http.StatusOK {
+		t.Fatalf("delete = %d %q", rec.Code, rec.Body.String())
+	}
+	decode(t, request(s, http.MethodGet, "/api/schedule", ""), &schedules)
+	if len(schedules) != 0 {
+		t.Errorf("schedules = %+v, want none", schedules)
+	}
+}
+
+// readLogPages follows /api/agent/log's X-Log-Before cursor from the newest
+// page of query to the oldest, returning the pages' lines with their
+// timestamps trimmed.
+func readLogPages(t *testing.T, s *Server, query string) [][]string {
+	t.Helper()
+	var pages [][]string
+	target := "/api/agent/log?" + query
+	for {
+		rec := request(s, http.MethodGet, target, "")
+		var logs []string
+		decode(t, rec, &logs)
+		for i, line := range logs {
+			logs[i] = line[strings.LastIndex(line, ": ")+2:]
+		}
+		pages = append(pages, logs)
+
+		cursor := rec.Header().Get("X-Log-Before")
+		if cursor == "" {
+			return pages
+		}
+		if len(pages) > 10 {
+			t.Fatalf("cursor never ran out: %v", pages)
+		}
+		target = "/api/agent/log?" + query + "&before=" + cursor
+	}
+}
+
+func TestAgentLogPaging(t *testing.T) {
+	s, _ := newTestServer(t)
+	for i := 1; i <= 5; i++ {
+		if err := s.store.AddLog("auth", fmt.Sprintf("line %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.store.AddLog("billing", "not auth")
+
+	pages := readLogPages(t, s, "name=auth&limit=2")
+	want := `[[line 4 line 5] [line 2 line 3] [line 1]]`
+	if got := fmt.Sprint(pages); got != want {
+		t.Errorf("pages = %s, want %s", got, want)
+	}
+}
+
+func TestServerLogPaging(t *testing.T) {
+	s, _ := newTestServer(t)
+	for i := 1; i <= 5; i++ {
+		s.log(fmt.Sprintf("line %d", i))
+	}
+
+	pages := readLogPages(t, s, "limit=2")
+	want := `[[line 4 line 5] [line 2 line 3] [line 1]]`
+	if got := fmt.Sprint(pages); got != want {
+		t.Errorf("pages = %s, want %s", got, want)
+	}
+}
+
+func TestAgentLogRejectsBadParameters(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	for _, query := range []string{"limit=0", "limit=x", "before=0", "before=x"} {
+		if rec := request(s, http.MethodGet, "/api/agent/log?name=auth&"+query, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestTranscripts(t *testing.T) {
+	s, dir := newTestServer(t)
+	started := time.Now()
+	path := transcript.Path(filepath.Join(dir, ".chief", "prds", "auth"), started, 1)
+	w, err := transcript.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Message(ollama.Message{Role: "user", Content: "Implement US-001"}, 0, ollama.Usage{}, "")
+	w.Message(ollama.Message{Role: "assistant", Content: "Done."}, time.Second, ollama.Usage{PromptTokens: 10, OutputTokens: 2}, "fake")
+	w.Close()
+	id, err := s.store.AddTranscript(db.TranscriptRecord{
+		ProjectName: "auth",
+		Iteration:   1,
+		StoryID:     "US-001",
+		Path:        path,
+		StartedAt:   started,
+		FinishedAt:  started.Add(time.Second),
+		Messages:    2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var records []db.TranscriptRecord
+	decode(t, request(s, http.MethodGet, "/api/transcripts?name=auth", ""), &records)
+	if len(records) != 1 || records[0].ID != id || records[0].Path != path {
+		t.Errorf("transcripts = %+v, want the one added", records)
+	}
+	decode(t, request(s, http.MethodGet, "/api/transcripts?name=billing", ""), &records)
+	if records == nil || len(records) != 0 {
+		t.Errorf("transcripts of a PRD without any = %#v, want []", records)
+	}
+
+	var resp struct {
+		Transcript db.TranscriptRecord `json:"transcript"`
+		Entries    []transcript.Entry  `json:"entries"`
+	}
+	decode(t, request(s, http.MethodGet, fmt.Sprintf("/api/transcripts?id=%d", id), ""), &resp)
+	if resp.Transcript.StoryID != "US-001" || len(resp.Entries) != 2 {
+		t.Fatalf("transcript = %+v", resp)
+	}
+	if e := resp.Entries[1]; e.Role != "assistant" || e.Content != "Done." || e.Model != "fake" || e.DurationMs != 1000 || e.OutputTokens != 2 {
+		t.Errorf("assistant entry = %+v", e)
+	}
+}
+
+func TestTranscriptsRejectsBadRequests(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	tests := []struct {
+		method, target string
+		want           int
+	}{
+		{http.MethodGet, "/api/transcripts", http.StatusBadRequest},
+		{http.MethodGet, "/api/transcripts?id=x", http.StatusBadRequest},
+		{http.MethodGet, "/api/transcripts?id=999", http.StatusNotFound},
+		{http.MethodPost, "/api/transcripts?name=auth", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if rec := request(s, tt.method, tt.target, ""); rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.target, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestPRDEvents(t *testing.T) {
+	s, dir := newTestServer(t)
+	authPath := writePRD(t, dir, "auth")
+	s.watchWorkspace()
+	srv := httptest.NewServer(s.mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/prd/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	type event struct {
+		Type  string   `json:"type"`
+		Name  string   `json:"name"`
+		Files []string `json:"files"`
+	}
+	events := make(chan event, 16)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var e event
+			if json.Unmarshal([]byte(data), &e) == nil {
+				events <- e
+			}
+		}
+		close(events)
+	}()
+	next := func() event {
+		t.Helper()
+		select {
+		case e, ok := <-events:
+			if !ok {
+				t.Fatal("event stream ended")
+			}
+			return e
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for a PRD event")
+		}
+		return event{}
+	}
+
+	writePRD(t, dir, "billing")
+	if e := next(); e.Type != "added" || e.Name != "billing" {
+		t.Errorf("event = %+v, want billing added", e)
+	}
+
+	data, _ := os.ReadFile(authPath)
+	os.WriteFile(authPath, []byte(strings.Replace(string(data), "A test PRD", "An edited PRD", 1)), 0644)
+	if e := next(); e.Type != "changed" || e.Name != "auth" || strings.Join(e.Files, ",") != "prd.json" {
+		t.Errorf("event = %+v, want auth's prd.json changed", e)
+	}
+}
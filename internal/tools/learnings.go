@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/izdrail/chief/internal/ollama"
+)
+
+// Handler is a tool whose implementation is supplied by the caller, for tools
+// that need state the static tools do not have (such as the learnings store).
+type Handler struct {
+	Definition ollama.Tool
+	Execute    func(argsJSON json.RawMessage) (string, error)
+}
+
+// Name returns the tool name from the handler's definition.
+func (h Handler) Name() string {
+	return h.Definition.Function.Name
+}
+
+// LearningRecorder persists a learning and reports whether it was new
+// (false means it merged into an existing, equivalent learning).
+type LearningRecorder func(content string, tags []string) (bool, error)
+
+// RecordLearningHandler returns the RecordLearning tool backed by record.
+func RecordLearningHandler(record LearningRecorder) Handler {
+	return Handler{
+		Definition: ollama.Tool{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        "RecordLearning",
+				Description: "Record a reusable learning about this codebase (a pattern, convention or gotcha) for future iterations. Record one fact per call. Duplicates are merged automatically.",
+				Parameters: mustJSON(map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"learning": map[string]interface{}{
+							"type":        "string",
+							"description": "The learning, as one short self-contained sentence.",
+						},
+						"tags": map[string]interface{}{
+							"type":        "array",
+							"description": "Optional topic tags, e.g. [\"testing\", \"database\"].",
+							"items":       map[string]interface{}{"type": "string"},
+						},
+					},
+					"required": []string{"learning"},
+				}),
+			},
+		},
+		Execute: func(argsJSON json.RawMessage) (string, error) {
+			var args struct {
+				Learning string   `json:"learning"`
+				Tags     []string `json:"tags"`
+			}
+			if err := json.Unmarshal(argsJSON, &args); err != nil {
+				return "", fmt.Errorf("parse RecordLearning args: %w", err)
+			}
+			if strings.TrimSpace(args.Learning) == "" {
+				return "Error: learning is required", nil
+			}
+
+			added, err := record(args.Learning, args.Tags)
+			if err != nil {
+				return fmt.Sprintf("Error recording learning: %v", err), nil
+			}
+			if !added {
+				return "Learning already known; merged with the existing entry.", nil
+			}
+			return "Learning recorded.", nil
+		},
+	}
+}
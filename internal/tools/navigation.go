@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/izdrail/chief/internal/codenav"
+)
+
+// maxNavResults caps the number of lines returned by the navigation tools.
+const maxNavResults = 200
+
+// executeSymbols lists declarations in a file or directory.
+func executeSymbols(argsJSON json.RawMessage, workDir string) (string, error) {
+	var args struct {
+		Path  string `json:"path"`
+		Query string `json:"query"`
+	}
+	if err := json.Unmarshal(argsJSON, &args); err != nil {
+		return "", fmt.Errorf("parse Symbols args: %w", err)
+	}
+
+	provider, err := codenav.ProviderFor(workDir)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	symbols, err := provider.Symbols(workDir, args.Path, args.Query)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if len(symbols) == 0 {
+		return "No symbols found.", nil
+	}
+
+	lines := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		lines = append(lines, formatSymbol(sym))
+	}
+	return capLines(lines), nil
+}
+
+// executeDefinition finds where a symbol is declared.
+func executeDefinition(argsJSON json.RawMessage, workDir string) (string, error) {
+	var args struct {
+		Symbol string `json:"symbol"`
+	}
+	if err := json.Unmarshal(argsJSON, &args); err != nil {
+		return "", fmt.Errorf("parse Definition args: %w", err)
+	}
+	if strings.TrimSpace(args.Symbol) == "" {
+		return "Error: symbol is required", nil
+	}
+
+	provider, err := codenav.ProviderFor(workDir)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	symbols, err := provider.Definition(workDir, args.Symbol)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if len(symbols) == 0 {
+		return fmt.Sprintf("No definition found for %s.", args.Symbol), nil
+	}
+
+	lines := make([]string, 0, len(symbols))
+	for _, sym := range symbols {
+		lines = append(lines, formatSymbol(sym))
+	}
+	return capLines(lines), nil
+}
+
+// executeReferences finds every usage of a symbol.
+func executeReferences(argsJSON json.RawMessage, workDir string) (string, error) {
+	var args struct {
+		Symbol string `json:"symbol"`
+	}
+	if err := json.Unmarshal(argsJSON, &args); err != nil {
+		return "", fmt.Errorf("parse References args: %w", err)
+	}
+	if strings.TrimSpace(args.Symbol) == "" {
+		return "Error: symbol is required", nil
+	}
+
+	provider, err := codenav.ProviderFor(workDir)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	refs, err := provider.References(workDir, args.Symbol)
+	if err != nil {
+		return fmt.Sprintf("Error: %v", err), nil
+	}
+	if len(refs) == 0 {
+		return fmt.Sprintf("No references found for %s.", args.Symbol), nil
+	}
+
+	lines := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		lines = append(lines, fmt.Sprintf("%s: %s", ref.Location, ref.Line))
+	}
+	return capLines(lines), nil
+}
+
+// formatSymbol renders a symbol as "file:line: kind signature".
+func formatSymbol(sym codenav.Symbol) string {
+	return fmt.Sprintf("%s: %s %s", sym.Location, sym.Kind, sym.Signature)
+}
+
+// capLines joins lines, truncating to maxNavResults.
+func capLines(lines []string) string {
+	if len(lines) > maxNavResults {
+		extra := len(lines) - maxNavResults
+		lines = append(lines[:maxNavResults], fmt.Sprintf("... (%d more results)", extra))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,583 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxPatchFuzz is the number of leading/trailing context lines that may be
+// dropped from a hunk when it cannot be located with its full context.
+const maxPatchFuzz = 2
+
+// filePatch is the set of hunks that apply to a single file in a unified diff.
+type filePatch struct {
+	OldPath string
+	NewPath string
+	Hunks   []patchHunk
+}
+
+// patchHunk is a single @@ hunk of a unified diff.
+type patchHunk struct {
+	Header   string
+	OldStart int      // 1-indexed start line in the original file (0 for new files)
+	Lines    []string // Hunk body lines, each prefixed with ' ', '-' or '+'
+}
+
+// contextBounds returns the number of unchanged context lines at the start and end of the hunk.
+func (h patchHunk) contextBounds() (int, int) {
+	lead := 0
+	for lead < len(h.Lines) && h.Lines[lead][0] == ' ' {
+		lead++
+	}
+	trail := 0
+	for trail < len(h.Lines)-lead && h.Lines[len(h.Lines)-1-trail][0] == ' ' {
+		trail++
+	}
+	return lead, trail
+}
+
+// oldLines returns the lines the hunk expects to find in the original file.
+func (h patchHunk) oldLines() []string {
+	var out []string
+	for _, l := range h.Lines {
+		if l[0] == ' ' || l[0] == '-' {
+			out = append(out, l[1:])
+		}
+	}
+	return out
+}
+
+// newLines returns the lines the hunk produces in the patched file.
+func (h patchHunk) newLines() []string {
+	var out []string
+	for _, l := range h.Lines {
+		if l[0] == ' ' || l[0] == '+' {
+			out = append(out, l[1:])
+		}
+	}
+	return out
+}
+
+// parseUnifiedDiff parses a unified diff into per-file patches.
+// If the diff has no ---/+++ headers, defaultPath is used as the target file.
+func parseUnifiedDiff(diff, defaultPath string) ([]filePatch, error) {
+	diff = strings.ReplaceAll(diff, "\r\n", "\n")
+	lines := strings.Split(diff, "\n")
+
+	var patches []filePatch
+	var current *filePatch
+	var hunk *patchHunk
+	oldLeft, newLeft := 0, 0 // Lines the hunk header says are still to come
+
+	flushHunk := func() {
+		if hunk != nil && current != nil {
+			current.Hunks = append(current.Hunks, *hunk)
+		}
+		hunk = nil
+		oldLeft, newLeft = 0, 0
+	}
+	flushFile := func() {
+		flushHunk()
+		if current != nil {
+			patches = append(patches, *current)
+		}
+		current = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// While the header's line counts last, every line is body, even one
+		// that looks like a file header, such as a removed "-- comment"
+		if hunk != nil && (oldLeft > 0 || newLeft > 0) {
+			if strings.HasPrefix(line, `\ No newline`) {
+				continue
+			}
+			if line == "" && moreLines(lines, i+1) {
+				line = " "
+			}
+			if line != "" && (line[0] == ' ' || line[0] == '-' || line[0] == '+') {
+				hunk.Lines = append(hunk.Lines, line)
+				if line[0] != '+' {
+					oldLeft--
+				}
+				if line[0] != '-' {
+					newLeft--
+				}
+				continue
+			}
+			// The header overstated its counts; the hunk ends here
+			oldLeft, newLeft = 0, 0
+		}
+
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			flushFile()
+			current = &filePatch{
+				OldPath: parseDiffPath(strings.TrimPrefix(line, "--- ")),
+				NewPath: parseDiffPath(strings.TrimPrefix(lines[i+1], "+++ ")),
+			}
+			i++
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			if current == nil {
+				if defaultPath == "" {
+					return nil, fmt.Errorf("hunk %q has no file header and no file_path was given", line)
+				}
+				current = &filePatch{OldPath: defaultPath, NewPath: defaultPath}
+			}
+			r, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			hunk = &patchHunk{Header: line, OldStart: r.oldStart}
+			oldLeft, newLeft = r.oldCount, r.newCount
+		case hunk != nil && line != "" && (line[0] == ' ' || line[0] == '-' || line[0] == '+'):
+			// Lines past the header's counts, or in a hunk without counts
+			hunk.Lines = append(hunk.Lines, line)
+		case hunk != nil && line == "" && continuesHunk(lines, i+1):
+			// Some models strip the leading space from blank context lines
+			hunk.Lines = append(hunk.Lines, " ")
+		case strings.HasPrefix(line, `\ No newline`):
+			// Ignore end-of-file markers
+		default:
+			// diff --git, index lines and free text between files are ignored
+		}
+	}
+	flushFile()
+
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no hunks found in patch")
+	}
+	return patches, nil
+}
+
+// moreLines reports whether any non-blank line follows index i.
+func moreLines(lines []string, i int) bool {
+	for ; i < len(lines); i++ {
+		if lines[i] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// continuesHunk reports whether the line at i still belongs to a hunk body,
+// so that a blank line before it can be treated as blank context.
+func continuesHunk(lines []string, i int) bool {
+	if i >= len(lines) {
+		return false
+	}
+	next := lines[i]
+	if next == "" {
+		return continuesHunk(lines, i+1)
+	}
+	if strings.HasPrefix(next, "--- ") || strings.HasPrefix(next, "+++ ") {
+		return false
+	}
+	return next[0] == ' ' || next[0] == '-' || next[0] == '+'
+}
+
+// parseDiffPath strips the a/ or b/ prefix and any trailing timestamp from a diff header path.
+func parseDiffPath(p string) string {
+	if idx := strings.Index(p, "\t"); idx != -1 {
+		p = p[:idx]
+	}
+	p = strings.TrimSpace(p)
+	if p == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+		p = p[2:]
+	}
+	return p
+}
+
+// hunkRange is the position and size of a hunk, from its "@@ -a,b +c,d @@" header.
+type hunkRange struct {
+	oldStart int
+	oldCount int
+	newCount int
+}
+
+// parseHunkHeader parses an "@@ -a,b +c,d @@" header; an omitted count is 1.
+// A bare "@@" header (no ranges) yields zeros, meaning "search the whole file"
+// with the hunk's length unknown.
+func parseHunkHeader(header string) (hunkRange, error) {
+	var r hunkRange
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return r, nil
+	}
+	var err error
+	if r.oldStart, r.oldCount, err = parseRange(fields[1][1:]); err != nil {
+		return r, fmt.Errorf("invalid hunk header %q", header)
+	}
+	if _, r.newCount, err = parseRange(fields[2][1:]); err != nil {
+		return r, fmt.Errorf("invalid hunk header %q", header)
+	}
+	return r, nil
+}
+
+// parseRange parses the "start,count" half of a hunk header.
+func parseRange(spec string) (int, int, error) {
+	count := 1
+	if idx := strings.Index(spec, ","); idx != -1 {
+		n, err := strconv.Atoi(spec[idx+1:])
+		if err != nil {
+			return 0, 0, err
+		}
+		spec, count = spec[:idx], n
+	}
+	start, err := strconv.Atoi(spec)
+	return start, count, err
+}
+
+// applyHunks applies hunks to content in order. Hunks that cannot be located
+// are skipped and reported; the returned content contains every hunk that applied.
+func applyHunks(content string, hunks []patchHunk) (string, []string) {
+	lines := splitLines(content)
+	var failures []string
+
+	offset := 0 // Net line shift from previously applied hunks
+	for i, h := range hunks {
+		old := h.oldLines()
+		repl := h.newLines()
+
+		lead, trail := h.contextBounds()
+		maxFuzz := maxPatchFuzz
+		if lead < maxFuzz {
+			maxFuzz = lead
+		}
+		if trail < maxFuzz {
+			maxFuzz = trail
+		}
+
+		hint := h.OldStart - 1 + offset
+		if len(old) == 0 {
+			// "-N,0" means insert after line N
+			hint = h.OldStart + offset
+		}
+
+		pos, fuzz := locateHunk(lines, old, hint, maxFuzz)
+		if pos < 0 {
+			failures = append(failures, fmt.Sprintf("hunk %d (%s): context not found", i+1, strings.TrimSpace(h.Header)))
+			continue
+		}
+
+		// With fuzz, the leading/trailing context lines were not matched and must be kept as-is
+		body := h.Lines[fuzz : len(h.Lines)-fuzz]
+		removed := len(old) - 2*fuzz
+		added := len(repl) - 2*fuzz
+
+		// Context keeps the file's own lines, which may only have matched
+		// loosely; only removed and added lines come from the hunk
+		updated := make([]string, 0, len(lines)-removed+added)
+		updated = append(updated, lines[:pos]...)
+		at := pos
+		for _, l := range body {
+			switch l[0] {
+			case ' ':
+				updated = append(updated, lines[at])
+				at++
+			case '-':
+				at++
+			case '+':
+				updated = append(updated, l[1:])
+			}
+		}
+		updated = append(updated, lines[at:]...)
+		lines = updated
+		offset += added - removed
+	}
+
+	return joinLines(lines, content), failures
+}
+
+// locateHunk finds the position of old in lines, preferring the position closest to hint.
+// It first tries an exact match, then a whitespace-insensitive match, then drops up to
+// maxFuzz context lines from each end. Returns the index and fuzz used, or -1.
+func locateHunk(lines, old []string, hint, maxFuzz int) (int, int) {
+	if len(old) == 0 {
+		// Pure insertion: trust the header position
+		if hint < 0 {
+			hint = 0
+		}
+		if hint > len(lines) {
+			hint = len(lines)
+		}
+		return hint, 0
+	}
+
+	for fuzz := 0; fuzz <= maxFuzz; fuzz++ {
+		if len(old)-2*fuzz <= 0 {
+			break
+		}
+		trimmed := old[fuzz : len(old)-fuzz]
+		if pos := searchLines(lines, trimmed, hint+fuzz, linesEqual); pos >= 0 {
+			return pos, fuzz
+		}
+		if pos := searchLines(lines, trimmed, hint+fuzz, linesEqualLoose); pos >= 0 {
+			return pos, fuzz
+		}
+	}
+	return -1, 0
+}
+
+// searchLines looks for needle in haystack, scanning outward from hint.
+func searchLines(haystack, needle []string, hint int, eq func(a, b string) bool) int {
+	last := len(haystack) - len(needle)
+	if last < 0 {
+		return -1
+	}
+	if hint < 0 {
+		hint = 0
+	}
+	if hint > last {
+		hint = last
+	}
+	for delta := 0; delta <= last; delta++ {
+		for _, pos := range []int{hint - delta, hint + delta} {
+			if pos < 0 || pos > last || (delta == 0 && pos != hint) {
+				continue
+			}
+			if matchAt(haystack, needle, pos, eq) {
+				return pos
+			}
+		}
+		if hint-delta < 0 && hint+delta > last {
+			break
+		}
+	}
+	return -1
+}
+
+func matchAt(haystack, needle []string, pos int, eq func(a, b string) bool) bool {
+	for i, n := range needle {
+		if !eq(haystack[pos+i], n) {
+			return false
+		}
+	}
+	return true
+}
+
+func linesEqual(a, b string) bool { return a == b }
+
+// linesEqualLoose compares lines ignoring leading/trailing whitespace and internal runs of spaces.
+func linesEqualLoose(a, b string) bool {
+	return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+}
+
+// splitLines splits content into lines without the trailing newline.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// joinLines joins lines, preserving whether the original content ended with a newline.
+func joinLines(lines []string, original string) string {
+	out := strings.Join(lines, "\n")
+	if original == "" || strings.HasSuffix(original, "\n") {
+		if len(lines) > 0 {
+			out += "\n"
+		}
+	}
+	return out
+}
+
+// executeApplyPatch applies a unified diff to one or more files. Either every
+// file applies cleanly and all are written, or nothing is written and the
+// failing hunks are reported.
+func executeApplyPatch(argsJSON json.RawMessage, workDir string) (string, error) {
+	var args struct {
+		Patch    string `json:"patch"`
+		FilePath string `json:"file_path"`
+	}
+	if err := json.Unmarshal(argsJSON, &args); err != nil {
+		return "", fmt.Errorf("parse ApplyPatch args: %w", err)
+	}
+
+	patches, err := parseUnifiedDiff(args.Patch, args.FilePath)
+	if err != nil {
+		return fmt.Sprintf("Error parsing patch: %v", err), nil
+	}
+
+	// Each file's content as the patch leaves it, so that a later section for
+	// the same file applies on top of an earlier one
+	type fileState struct {
+		content string
+		exists  bool
+	}
+	state := make(map[string]*fileState)
+	originals := make(map[string]fileState)
+	var order []string
+	load := func(path string) (*fileState, error) {
+		if s, ok := state[path]; ok {
+			return s, nil
+		}
+		s := &fileState{}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			s.content, s.exists = string(data), true
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+		state[path], originals[path] = s, *s
+		order = append(order, path)
+		return s, nil
+	}
+
+	var report []string
+	failed := false
+
+	for _, fp := range patches {
+		name := fp.NewPath
+		switch {
+		case fp.OldPath == "" && fp.NewPath == "":
+			return "Error: patch file header has no path", nil
+		case fp.NewPath == "":
+			name = fp.OldPath
+		case fp.OldPath != "" && fp.OldPath != fp.NewPath:
+			name = fp.OldPath + " -> " + fp.NewPath
+		}
+
+		var src *fileState
+		original := ""
+		if fp.OldPath != "" {
+			s, err := load(resolvePath(fp.OldPath, workDir))
+			if err == nil && !s.exists {
+				err = os.ErrNotExist
+			}
+			if err != nil {
+				report = append(report, fmt.Sprintf("%s: error reading file: %v", name, err))
+				failed = true
+				continue
+			}
+			src, original = s, s.content
+		}
+
+		updated, failures := applyHunks(original, fp.Hunks)
+		if len(failures) > 0 {
+			failed = true
+			for _, f := range failures {
+				report = append(report, fmt.Sprintf("%s: %s", name, f))
+			}
+			continue
+		}
+
+		// A deleted or renamed file is removed from its old path
+		if src != nil && fp.NewPath != fp.OldPath {
+			src.content, src.exists = "", false
+		}
+		if fp.NewPath != "" {
+			dst, err := load(resolvePath(fp.NewPath, workDir))
+			if err != nil {
+				report = append(report, fmt.Sprintf("%s: error reading file: %v", name, err))
+				failed = true
+				continue
+			}
+			dst.content, dst.exists = updated, true
+		}
+		report = append(report, fmt.Sprintf("%s: %d hunk(s) applied", name, len(fp.Hunks)))
+	}
+
+	if failed {
+		return "Patch not applied (no files were changed):\n" + strings.Join(report, "\n"), nil
+	}
+
+	// Write every file, putting back the ones already written if one fails
+	var written []string
+	restore := func() {
+		for _, path := range written {
+			if o := originals[path]; o.exists {
+				os.WriteFile(path, []byte(o.content), 0644)
+			} else {
+				os.Remove(path)
+			}
+		}
+	}
+	for _, path := range order {
+		s, o := state[path], originals[path]
+		if *s == o {
+			continue
+		}
+		var err error
+		if !s.exists {
+			err = os.Remove(path)
+		} else if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = os.WriteFile(path, []byte(s.content), 0644)
+		}
+		if err != nil {
+			restore()
+			return fmt.Sprintf("Error writing patched files (no files were changed): %v", err), nil
+		}
+		written = append(written, path)
+	}
+
+	return "Patch applied successfully:\n" + strings.Join(report, "\n"), nil
+}
+
+// executeMultiEdit applies several exact-string replacements to a single file.
+// Edits are applied in order against the in-memory result of the previous edit;
+// if any edit fails, the file is left untouched.
+func executeMultiEdit(argsJSON json.RawMessage, workDir string) (string, error) {
+	var args struct {
+		FilePath string `json:"file_path"`
+		Edits    []struct {
+			OldString  string `json:"old_string"`
+			NewString  string `json:"new_string"`
+			ReplaceAll bool   `json:"replace_all"`
+		} `json:"edits"`
+	}
+	if err := json.Unmarshal(argsJSON, &args); err != nil {
+		return "", fmt.Errorf("parse MultiEdit args: %w", err)
+	}
+	if len(args.Edits) == 0 {
+		return "Error: edits must contain at least one edit", nil
+	}
+
+	path := resolvePath(args.FilePath, workDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("Error reading file for edit: %v", err), nil
+	}
+	content := string(data)
+
+	var failures []string
+	for i, e := range args.Edits {
+		if e.OldString == "" {
+			failures = append(failures, fmt.Sprintf("edit %d: old_string is empty", i+1))
+			continue
+		}
+		if e.OldString == e.NewString {
+			failures = append(failures, fmt.Sprintf("edit %d: old_string and new_string are identical", i+1))
+			continue
+		}
+		count := strings.Count(content, e.OldString)
+		switch {
+		case count == 0:
+			failures = append(failures, fmt.Sprintf("edit %d: old_string not found", i+1))
+		case count > 1 && !e.ReplaceAll:
+			failures = append(failures, fmt.Sprintf("edit %d: old_string matches %d times; add more context or set replace_all", i+1, count))
+		case e.ReplaceAll:
+			content = strings.ReplaceAll(content, e.OldString, e.NewString)
+		default:
+			content = strings.Replace(content, e.OldString, e.NewString, 1)
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Sprintf("MultiEdit not applied to %s (file unchanged):\n%s", args.FilePath, strings.Join(failures, "\n")), nil
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Sprintf("Error writing edited file: %v", err), nil
+	}
+	return fmt.Sprintf("File edited successfully: %s (%d edits applied)", args.FilePath, len(args.Edits)), nil
+}
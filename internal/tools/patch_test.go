@@ -0,0 +1,276 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func runTool(t *testing.T, name string, args interface{}, dir string) string {
+	t.Helper()
+	raw, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Execute(name, raw, dir)
+	if err != nil {
+		t.Fatalf("Execute(%s) error: %v", name, err)
+	}
+	return out
+}
+
+func TestApplyPatch_ExactMatch(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "a.txt", "one\ntwo\nthree\nfour\n")
+
+	patch := `--- a/a.txt
++++ b/a.txt
+@@ -1,4 +1,4 @@
+ one
+-two
++TWO
+ three
+ four
+`
+	out := runTool(t, "ApplyPatch", map[string]string{"patch": patch}, dir)
+	if !strings.Contains(out, "Patch applied successfully") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if got := readTestFile(t, path); got != "one\nTWO\nthree\nfour\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestApplyPatch_ShiftedLineNumbers(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "a.txt", "x\ny\nz\none\ntwo\nthree\n")
+
+	// Header claims line 1, but the context lives at line 4
+	patch := "@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n"
+	out := runTool(t, "ApplyPatch", map[string]string{"patch": patch, "file_path": "a.txt"}, dir)
+	if !strings.Contains(out, "Patch applied successfully") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if got := readTestFile(t, path); got != "x\ny\nz\none\n2\nthree\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestApplyPatch_WhitespaceAndStaleContext(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "a.go", "func a() {\n\treturn 1\n}\n\nfunc b() {\n\treturn 2\n}\n")
+
+	// Indentation differs and the first context line is stale
+	patch := "@@ -5,3 +5,3 @@\n func B() {\n-    return 2\n+    return 3\n }\n"
+	out := runTool(t, "ApplyPatch", map[string]string{"patch": patch, "file_path": "a.go"}, dir)
+	if !strings.Contains(out, "Patch applied successfully") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if got := readTestFile(t, path); !strings.Contains(got, "    return 3") || !strings.Contains(got, "func b() {") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestApplyPatch_MultiFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.txt", "alpha\n")
+	b := writeTestFile(t, dir, "b.txt", "beta\n")
+
+	patch := `--- a/a.txt
++++ b/a.txt
+@@ -1 +1 @@
+-alpha
++ALPHA
+--- a/b.txt
++++ b/b.txt
+@@ -1 +1 @@
+-missing
++BETA
+`
+	out := runTool(t, "ApplyPatch", map[string]string{"patch": patch}, dir)
+	if !strings.Contains(out, "no files were changed") || !strings.Contains(out, "b.txt: hunk 1") {
+		t.Fatalf("expected failure report, got: %s", out)
+	}
+	if got := readTestFile(t, a); got != "alpha\n" {
+		t.Errorf("a.txt should be untouched, got %q", got)
+	}
+	if got := readTestFile(t, b); got != "beta\n" {
+		t.Errorf("b.txt should be untouched, got %q", got)
+	}
+}
+
+func TestApplyPatch_CreateFile(t *testing.T) {
+	dir := t.TempDir()
+	patch := `--- /dev/null
++++ b/sub/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+`
+	out := runTool(t, "ApplyPatch", map[string]string{"patch": patch}, dir)
+	if !strings.Contains(out, "Patch applied successfully") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if got := readTestFile(t, filepath.Join(dir, "sub", "new.txt")); got != "hello\nworld\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestApplyPatch_LooseMatchKeepsFileContext(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "a.go", "func a() {\n\tx := 1\n\ty := 2\n\treturn x + y\n}\n")
+
+	// The model re-indented the context with spaces
+	patch := "@@ -1,5 +1,5 @@\n func a() {\n     x := 1\n-    y := 2\n+\ty := 3\n     return x + y\n }\n"
+	out := runTool(t, "ApplyPatch", map[string]string{"patch": patch, "file_path": "a.go"}, dir)
+	if !strings.Contains(out, "Patch applied successfully") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if got := readTestFile(t, path); got != "func a() {\n\tx := 1\n\ty := 3\n\treturn x + y\n}\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestApplyPatch_HunkCountsBoundBody(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "q.sql", "-- old\nselect 1;\n")
+
+	// "--- old" and "+++ new" remove "-- old" and add "++ new"; they are not a file header
+	patch := "--- a/q.sql\n+++ b/q.sql\n@@ -1,2 +1,2 @@\n--- old\n+++ new\n select 1;\n"
+	out := runTool(t, "ApplyPatch", map[string]string{"patch": patch}, dir)
+	if !strings.Contains(out, "Patch applied successfully") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if got := readTestFile(t, path); got != "++ new\nselect 1;\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestApplyPatch_RenameAndRepeatedFile(t *testing.T) {
+	dir := t.TempDir()
+	old := writeTestFile(t, dir, "old.txt", "one\ntwo\n")
+	path := writeTestFile(t, dir, "b.txt", "1\n2\n3\n4\n5\n6\n")
+
+	patch := `--- a/old.txt
++++ b/new.txt
+@@ -1,2 +1,2 @@
+ one
+-two
++TWO
+--- a/b.txt
++++ b/b.txt
+@@ -1,2 +1,2 @@
+-1
++one
+ 2
+--- a/b.txt
++++ b/b.txt
+@@ -5,2 +5,2 @@
+ 5
+-6
++six
+`
+	out := runTool(t, "ApplyPatch", map[string]string{"patch": patch}, dir)
+	if !strings.Contains(out, "Patch applied successfully") || !strings.Contains(out, "old.txt -> new.txt") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("old.txt still exists after the rename")
+	}
+	if got := readTestFile(t, filepath.Join(dir, "new.txt")); got != "one\nTWO\n" {
+		t.Errorf("new.txt = %q", got)
+	}
+	if got := readTestFile(t, path); got != "one\n2\n3\n4\n5\nsix\n" {
+		t.Errorf("b.txt = %q", got)
+	}
+}
+
+func TestApplyPatch_WriteFailureRestoresFiles(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestFile(t, dir, "a.txt", "alpha\n")
+	writeTestFile(t, dir, "blocker", "a file, not a directory\n")
+
+	patch := `--- a/a.txt
++++ b/a.txt
+@@ -1 +1 @@
+-alpha
++ALPHA
+--- /dev/null
++++ b/blocker/new.txt
+@@ -0,0 +1 @@
++new
+`
+	out := runTool(t, "ApplyPatch", map[string]string{"patch": patch}, dir)
+	if !strings.Contains(out, "no files were changed") {
+		t.Fatalf("expected a write failure, got: %s", out)
+	}
+	if got := readTestFile(t, a); got != "alpha\n" {
+		t.Errorf("a.txt should be restored, got %q", got)
+	}
+}
+
+func TestMultiEdit_AppliesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "f.txt", "foo bar baz\nfoo\n")
+
+	out := runTool(t, "MultiEdit", map[string]interface{}{
+		"file_path": "f.txt",
+		"edits": []map[string]interface{}{
+			{"old_string": "bar", "new_string": "BAR"},
+			{"old_string": "foo", "new_string": "qux", "replace_all": true},
+		},
+	}, dir)
+	if !strings.Contains(out, "2 edits applied") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	if got := readTestFile(t, path); got != "qux BAR baz\nqux\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestMultiEdit_FailureLeavesFileUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	original := "foo\nfoo\nbar\n"
+	path := writeTestFile(t, dir, "f.txt", original)
+
+	out := runTool(t, "MultiEdit", map[string]interface{}{
+		"file_path": "f.txt",
+		"edits": []map[string]interface{}{
+			{"old_string": "bar", "new_string": "BAR"},
+			{"old_string": "foo", "new_string": "FOO"},
+			{"old_string": "nope", "new_string": "x"},
+		},
+	}, dir)
+	if !strings.Contains(out, "edit 2: old_string matches 2 times") {
+		t.Errorf("expected uniqueness failure, got: %s", out)
+	}
+	if !strings.Contains(out, "edit 3: old_string not found") {
+		t.Errorf("expected not-found failure, got: %s", out)
+	}
+	if got := readTestFile(t, path); got != original {
+		t.Errorf("file should be unchanged, got %q", got)
+	}
+}
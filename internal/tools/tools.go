@@ -1,6 +1,7 @@
 // Package tools provides file system and shell tools that the Ollama agent
 // can invoke during its agentic loop. These match the tool names referenced
-// in the embedded agent prompt (Read, Write, Edit, Bash, Glob, Grep, List).
+// in the embedded agent prompt (Read, Write, Edit, MultiEdit, ApplyPatch, Bash,
+// Glob, Grep, List, Symbols, Definition, References).
 package tools
 
 import (
@@ -90,6 +91,66 @@ func Definitions() []ollama.Tool {
 				}),
 			},
 		},
+		{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        "MultiEdit",
+				Description: "Apply several exact-string replacements to one file atomically. Edits run in order; each old_string must match exactly once unless replace_all is set. If any edit fails, the file is left unchanged and the failing edits are reported.",
+				Parameters: mustJSON(map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"file_path": map[string]interface{}{
+							"type":        "string",
+							"description": "Path to the file to edit. Relative to the project root.",
+						},
+						"edits": map[string]interface{}{
+							"type":        "array",
+							"description": "Replacements to apply in order.",
+							"items": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"old_string": map[string]interface{}{
+										"type":        "string",
+										"description": "The exact string to find (must be unique unless replace_all is true).",
+									},
+									"new_string": map[string]interface{}{
+										"type":        "string",
+										"description": "The replacement string.",
+									},
+									"replace_all": map[string]interface{}{
+										"type":        "boolean",
+										"description": "Optional. Replace every occurrence instead of requiring a unique match.",
+									},
+								},
+								"required": []string{"old_string", "new_string"},
+							},
+						},
+					},
+					"required": []string{"file_path", "edits"},
+				}),
+			},
+		},
+		{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        "ApplyPatch",
+				Description: "Apply a unified diff (as produced by `diff -u` or `git diff`) to one or more files. Hunks are matched fuzzily, tolerating shifted line numbers, whitespace differences and slightly stale context. Either all files are patched or none are.",
+				Parameters: mustJSON(map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"patch": map[string]interface{}{
+							"type":        "string",
+							"description": "The unified diff. Use ---/+++ headers for multiple files; use /dev/null to create or delete a file.",
+						},
+						"file_path": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional. Target file when the patch has only @@ hunks and no ---/+++ headers.",
+						},
+					},
+					"required": []string{"patch"},
+				}),
+			},
+		},
 		{
 			Type: "function",
 			Function: ollama.ToolFunction{
@@ -162,6 +223,60 @@ func Definitions() []ollama.Tool {
 				}),
 			},
 		},
+		{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        "Symbols",
+				Description: "List declarations (functions, methods, types, variables, constants) with signatures and file:line locations. Faster and more precise than Grep for finding what a file or package defines.",
+				Parameters: mustJSON(map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional. File or directory to list symbols for. Defaults to the whole project.",
+						},
+						"query": map[string]interface{}{
+							"type":        "string",
+							"description": "Optional. Case-insensitive substring to filter symbol names.",
+						},
+					},
+				}),
+			},
+		},
+		{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        "Definition",
+				Description: "Find where a symbol is declared and show its signature. Accepts Name, pkg.Name or Type.Method.",
+				Parameters: mustJSON(map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"symbol": map[string]interface{}{
+							"type":        "string",
+							"description": "Symbol to look up, e.g. RunAgent, agent.RunAgent or Loop.Run.",
+						},
+					},
+					"required": []string{"symbol"},
+				}),
+			},
+		},
+		{
+			Type: "function",
+			Function: ollama.ToolFunction{
+				Name:        "References",
+				Description: "Find every usage of a symbol with file:line locations and the source line. Accepts Name, pkg.Name or Type.Method.",
+				Parameters: mustJSON(map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"symbol": map[string]interface{}{
+							"type":        "string",
+							"description": "Symbol to find usages of, e.g. NewLoop or Manager.Start.",
+						},
+					},
+					"required": []string{"symbol"},
+				}),
+			},
+		},
 	}
 }
 
@@ -175,6 +290,10 @@ func Execute(name string, argsJSON json.RawMessage, workDir string) (string, err
 		return executeWrite(argsJSON, workDir)
 	case "Edit":
 		return executeEdit(argsJSON, workDir)
+	case "MultiEdit":
+		return executeMultiEdit(argsJSON, workDir)
+	case "ApplyPatch":
+		return executeApplyPatch(argsJSON, workDir)
 	case "Bash":
 		return executeBash(argsJSON, workDir)
 	case "Glob":
@@ -183,6 +302,12 @@ func Execute(name string, argsJSON json.RawMessage, workDir string) (string, err
 		return executeGrep(argsJSON, workDir)
 	case "List":
 		return executeList(argsJSON, workDir)
+	case "Symbols":
+		return executeSymbols(argsJSON, workDir)
+	case "Definition":
+		return executeDefinition(argsJSON, workDir)
+	case "References":
+		return executeReferences(argsJSON, workDir)
 	default:
 		return "", fmt.Errorf("unknown tool: %s", name)
 	}
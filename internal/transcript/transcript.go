@@ -0,0 +1,182 @@
+// Package transcript records the complete conversation of a loop iteration:
+// the prompt, every assistant message with its tool calls, every tool result,
+// and how long and how many tokens each step took. Transcripts are JSONL
+// files, one entry per line, kept next to the PRD so a run can be replayed
+// step by step when a model goes off the rails.
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/izdrail/chief/internal/ollama"
+)
+
+// Dir is the directory, relative to the PRD directory, transcripts are written to.
+const Dir = "transcripts"
+
+// Entry is one line of a transcript: a message, or an error that ended an
+// attempt.
+type Entry struct {
+	Time       time.Time         `json:"time"`
+	Attempt    int               `json:"attempt,omitempty"` // 1 for the first attempt, 2 for the first retry, ...
+	Role       string            `json:"role"`              // system, user, assistant, tool or error
+	Content    string            `json:"content,omitempty"`
+	ToolCalls  []ollama.ToolCall `json:"toolCalls,omitempty"`
+	ToolCallID string            `json:"toolCallId,omitempty"`
+	ToolName   string            `json:"toolName,omitempty"`
+	DurationMs int64             `json:"durationMs,omitempty"` // Time the model took to answer, or the tool to run
+	ollama.Usage
+	Model string `json:"model,omitempty"`
+}
+
+// Duration returns the entry's duration.
+func (e Entry) Duration() time.Duration {
+	return time.Duration(e.DurationMs) * time.Millisecond
+}
+
+// Summary counts what a transcript holds.
+type Summary struct {
+	Messages  int
+	ToolCalls int
+	Errors    int
+	ollama.Usage
+}
+
+// Writer appends entries to a transcript file.
+type Writer struct {
+	path string
+
+	mu      sync.Mutex
+	file    *os.File
+	attempt int
+	summary Summary
+}
+
+// Path returns the transcript file for an iteration that started at started
+// in the PRD directory prdDir.
+func Path(prdDir string, started time.Time, iteration int) string {
+	name := fmt.Sprintf("%s-iter-%d.jsonl", started.UTC().Format("20060102T150405Z"), iteration)
+	return filepath.Join(prdDir, Dir, name)
+}
+
+// Create creates the transcript file at path, and its directory.
+func Create(path string) (*Writer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("create transcript directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("create transcript: %w", err)
+	}
+	return &Writer{path: path, file: f, attempt: 1}, nil
+}
+
+// Path returns the file the writer writes to.
+func (w *Writer) Path() string {
+	return w.path
+}
+
+// SetAttempt sets the attempt later entries belong to.
+func (w *Writer) SetAttempt(attempt int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.attempt = attempt
+}
+
+// Message writes a message, with how long it took and the tokens it used.
+func (w *Writer) Message(msg ollama.Message, elapsed time.Duration, usage ollama.Usage, model string) error {
+	return w.Write(Entry{
+		Role:       msg.Role,
+		Content:    msg.Content,
+		ToolCalls:  msg.ToolCalls,
+		ToolCallID: msg.ToolCallID,
+		ToolName:   msg.Name,
+		DurationMs: elapsed.Milliseconds(),
+		Usage:      usage,
+		Model:      model,
+	})
+}
+
+// Error writes the error that ended an attempt.
+func (w *Writer) Error(err error) error {
+	return w.Write(Entry{Role: "error", Content: err.Error()})
+}
+
+// Write appends an entry, filling in its time and attempt when unset.
+func (w *Writer) Write(e Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return fmt.Errorf("transcript %s is closed", w.path)
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Attempt == 0 {
+		e.Attempt = w.attempt
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := w.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	if e.Role == "error" {
+		w.summary.Errors++
+	} else {
+		w.summary.Messages++
+	}
+	w.summary.ToolCalls += len(e.ToolCalls)
+	w.summary.PromptTokens += e.PromptTokens
+	w.summary.OutputTokens += e.OutputTokens
+	return nil
+}
+
+// Summary returns the counts of what has been written so far.
+func (w *Writer) Summary() Summary {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.summary
+}
+
+// Close closes the file. Entries written after Close fail.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// Read returns the entries of a transcript file. Lines that don't parse, such
+// as a last line cut short by a run killed mid-write, are skipped.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,79 @@
+package transcript
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/izdrail/chief/internal/ollama"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	started := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	path := Path(t.TempDir(), started, 3)
+	if filepath.Base(path) != "20260301T093000Z-iter-3.jsonl" {
+		t.Errorf("Path() = %s", path)
+	}
+
+	w, err := Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Message(ollama.Message{Role: "user", Content: "Implement US-001"}, 0, ollama.Usage{}, "")
+	w.Message(ollama.Message{Role: "assistant", Content: "Reading.", ToolCalls: []ollama.ToolCall{{
+		ID:       "call_1",
+		Function: ollama.FunctionCall{Name: "Read", Arguments: json.RawMessage(`{"file_path":"main.go"}`)},
+	}}}, 2*time.Second, ollama.Usage{PromptTokens: 1200, OutputTokens: 40}, "qwen3")
+	w.Message(ollama.Message{Role: "tool", Content: "package main", ToolCallID: "call_1", Name: "Read"}, 5*time.Millisecond, ollama.Usage{}, "")
+	w.Error(errors.New("stream error: connection reset"))
+	w.SetAttempt(2)
+	w.Message(ollama.Message{Role: "user", Content: "Implement US-001"}, 0, ollama.Usage{}, "")
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(Entry{Role: "user"}); err == nil {
+		t.Error("Write() after Close() succeeded")
+	}
+
+	summary := w.Summary()
+	want := Summary{Messages: 4, ToolCalls: 1, Errors: 1, Usage: ollama.Usage{PromptTokens: 1200, OutputTokens: 40}}
+	if summary != want {
+		t.Errorf("Summary() = %+v, want %+v", summary, want)
+	}
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 5 {
+		t.Fatalf("got %d entries, want 5", len(entries))
+	}
+	assistant := entries[1]
+	if assistant.Model != "qwen3" || assistant.Duration() != 2*time.Second || assistant.PromptTokens != 1200 {
+		t.Errorf("assistant entry = %+v", assistant)
+	}
+	if len(assistant.ToolCalls) != 1 || string(assistant.ToolCalls[0].Function.Arguments) != `{"file_path":"main.go"}` {
+		t.Errorf("tool calls = %+v", assistant.ToolCalls)
+	}
+	if tool := entries[2]; tool.ToolCallID != "call_1" || tool.ToolName != "Read" {
+		t.Errorf("tool entry = %+v", tool)
+	}
+	if entries[3].Role != "error" || entries[3].Attempt != 1 || entries[4].Attempt != 2 {
+		t.Errorf("attempts = %d, %d", entries[3].Attempt, entries[4].Attempt)
+	}
+}
+
+func TestReadSkipsTruncatedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "t.jsonl")
+	data := `{"role":"user","content":"hi"}` + "\n" + `{"role":"assistant","cont`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Read(path)
+	if err != nil || len(entries) != 1 || entries[0].Content != "hi" {
+		t.Errorf("Read() = %+v, %v", entries, err)
+	}
+}
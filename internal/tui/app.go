@@ -2,6 +2,7 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -11,8 +12,11 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/izdrail/chief/embed"
+	"github.com/izdrail/chief/internal/agent"
 	"github.com/izdrail/chief/internal/config"
+	"github.com/izdrail/chief/internal/db"
 	"github.com/izdrail/chief/internal/git"
+	"github.com/izdrail/chief/internal/logstore"
 	"github.com/izdrail/chief/internal/loop"
 	"github.com/izdrail/chief/internal/ollama"
 	"github.com/izdrail/chief/internal/prd"
@@ -24,6 +28,11 @@ type PRDUpdateMsg struct {
 	Error error
 }
 
+// WorkspaceUpdateMsg is sent when a PRD is added to, removed from or changed in .chief/prds/.
+type WorkspaceUpdateMsg struct {
+	Event prd.WorkspaceEvent
+}
+
 // AppState represents the current state of the application.
 type AppState int
 
@@ -74,10 +83,18 @@ type PRDCompletedMsg struct {
 
 // mergeResultMsg is sent when a merge operation completes.
 type mergeResultMsg struct {
-	branch    string
-	conflicts []string
-	output    string
-	err       error
+	branch     string
+	conflicts  []string
+	output     string
+	err        error
+	inProgress bool // The merge was left in progress to resolve its conflicts
+}
+
+// conflictAgentResultMsg is sent when the agent has finished working on a
+// merge's conflicts.
+type conflictAgentResultMsg struct {
+	summary string
+	err     error
 }
 
 // cleanResultMsg is sent when a clean operation completes.
@@ -139,6 +156,10 @@ type PRDCreationStatusMsg struct {
 	Error   string
 }
 
+// activityMsg reports something to show as the last activity, from code that
+// can't set it directly, such as Init.
+type activityMsg string
+
 // ViewMode represents which view is currently active.
 type ViewMode int
 
@@ -152,6 +173,9 @@ const (
 	ViewWorktreeSpinner
 	ViewCompletion
 	ViewSettings
+	ViewTranscript
+	ViewTimeline
+	ViewConflicts
 )
 
 // App is the main Bubble Tea model for the Chief TUI.
@@ -171,11 +195,15 @@ type App struct {
 	manager *loop.Manager
 	maxIter int
 
+	// Project SQLite store for learnings and transcripts, nil if it can't be opened
+	store *db.Store
+
 	// Activity tracking
 	lastActivity string
 
 	// File watching
-	watcher *prd.Watcher
+	watcher   *prd.Watcher
+	workspace *prd.WorkspaceWatcher // Watches every PRD so the picker and tabs update live
 
 	// View mode
 	viewMode  ViewMode
@@ -194,6 +222,13 @@ type App struct {
 	// Diff viewer
 	diffViewer *DiffViewer
 
+	// Iteration transcript viewer
+	transcriptViewer *TranscriptViewer
+
+	// Iteration timeline
+	timelineViewer *TimelineViewer
+	conflictViewer *ConflictViewer
+
 	// Help overlay
 	helpOverlay      *HelpOverlay
 	previousViewMode ViewMode // View to return to when closing help
@@ -286,6 +321,16 @@ func NewAppWithOptions(prdPath string, maxIter int) (*App, error) {
 		cfg = config.Default()
 	}
 
+	// Loops running in parallel share the LLM endpoint's request slots
+	ollama.SetConcurrency(cfg.LLM.MaxConcurrent, cfg.LLM.Endpoints)
+	ollama.SetTimeouts(ollama.Timeouts{
+		Connect:    cfg.LLM.ConnectTimeout,
+		FirstToken: cfg.LLM.FirstTokenTimeout,
+		Idle:       cfg.LLM.IdleTimeout,
+	})
+	// An unknown format keeps the default; chief doctor reports it
+	ollama.SetToolCallFormat(cfg.LLM.ToolCallFormat)
+
 	// Prune stale worktrees on startup (clean git's internal tracking)
 	if git.IsGitRepo(baseDir) {
 		_ = git.PruneWorktrees(baseDir)
@@ -295,14 +340,29 @@ func NewAppWithOptions(prdPath string, maxIter int) (*App, error) {
 	manager := loop.NewManager(maxIter)
 	manager.SetConfig(cfg)
 
+	// Record agent learnings in the project's SQLite store when it can be opened
+	var store *db.Store
+	if learningStore, err := db.NewStore(filepath.Join(baseDir, ".chief", "chief.db")); err == nil {
+		store = learningStore
+		manager.SetLearningStore(learningStore)
+	}
+
 	// Register the initial PRD with the manager
 	manager.Register(prdName, prdPath)
 
+	// Watch .chief/prds/ for new, removed and edited PRDs, converting edited
+	// structured prd.md files as they are saved
+	workspace, err := prd.NewWorkspaceWatcher(filepath.Join(baseDir, ".chief", "prds"), prd.WorkspaceOptions{AutoConvert: true})
+	if err != nil {
+		workspace = nil
+	}
+
 	// Create tab bar for always-visible PRD tabs
 	tabBar := NewTabBar(baseDir, prdName, manager)
 
 	// Create picker with manager reference (for creating new PRDs)
 	picker := NewPRDPicker(baseDir, prdName, manager)
+	picker.SetStore(store)
 
 	return &App{
 		prd:           p,
@@ -313,10 +373,15 @@ func NewAppWithOptions(prdPath string, maxIter int) (*App, error) {
 		selectedIndex: 0,
 		maxIter:       maxIter,
 		manager:       manager,
+		store:         store,
 		watcher:       watcher,
+		workspace:     workspace,
 		viewMode:      ViewDashboard,
-		logViewer:     NewLogViewer(),
+		logViewer:     NewLogViewerWithOptions(logstore.Options{Window: cfg.Logs.MemoryEntries}),
 		diffViewer:    NewDiffViewer(baseDir),
+		transcriptViewer: NewTranscriptViewer(store),
+		timelineViewer:   NewTimelineViewer(store),
+		conflictViewer:   NewConflictViewer(),
 		tabBar:        tabBar,
 		picker:        picker,
 		baseDir:       baseDir,
@@ -329,6 +394,15 @@ func NewAppWithOptions(prdPath string, maxIter int) (*App, error) {
 	}, nil
 }
 
+// Close releases what the app holds after the program exits, such as the
+// log viewer's entries on disk and the project store.
+func (a App) Close() {
+	a.logViewer.Close()
+	if a.store != nil {
+		a.store.Close()
+	}
+}
+
 // SetCompletionCallback sets a callback that is called when any PRD completes.
 func (a *App) SetCompletionCallback(fn func(prdName string)) {
 	a.onCompletion = fn
@@ -337,6 +411,13 @@ func (a *App) SetCompletionCallback(fn func(prdName string)) {
 	}
 }
 
+// SetEventCallback sets a callback that is called with every loop event.
+func (a *App) SetEventCallback(fn func(event loop.ManagerEvent)) {
+	if a.manager != nil {
+		a.manager.SetEventCallback(fn)
+	}
+}
+
 // SetVerbose enables or disables verbose mode (raw agent output in log).
 func (a *App) SetVerbose(v bool) {
 	a.verbose = v
@@ -351,19 +432,32 @@ func (a *App) DisableRetry() {
 
 // Init initializes the App.
 func (a App) Init() tea.Cmd {
+	// Init can't change the app, so warnings are sent as messages
+	var warnings []tea.Cmd
+	warn := func(text string) {
+		warnings = append(warnings, func() tea.Msg { return activityMsg(text) })
+	}
+
 	// Start the file watcher
 	if a.watcher != nil {
 		if err := a.watcher.Start(); err != nil {
 			// Log error but don't fail - watcher is not critical
-			a.lastActivity = "Warning: file watcher failed to start"
+			warn("Warning: file watcher failed to start")
 		}
 	}
 
-	return tea.Batch(
+	if a.workspace != nil {
+		if err := a.workspace.Start(); err != nil {
+			warn("Warning: PRD directory watcher failed to start")
+		}
+	}
+
+	return tea.Batch(append([]tea.Cmd{
 		tea.EnterAltScreen,
 		a.listenForPRDChanges(),
+		a.listenForWorkspaceChanges(),
 		a.listenForManagerEvents(),
-	)
+	}, warnings...)...)
 }
 
 // listenForManagerEvents listens for events from all managed loops.
@@ -387,7 +481,7 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.width = msg.Width
 		a.height = msg.Height
 		// Update log viewer size
-		a.logViewer.SetSize(a.width, a.height-a.effectiveHeaderHeight()-footerHeight-2)
+		a.logViewer.SetSize(a.width-4, a.height-a.effectiveHeaderHeight()-footerHeight-2)
 		return a, nil
 
 	case LoopEventMsg:
@@ -411,6 +505,9 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case mergeResultMsg:
 		return a.handleMergeResult(msg)
 
+	case conflictAgentResultMsg:
+		return a.handleConflictAgentResult(msg)
+
 	case cleanResultMsg:
 		return a.handleCleanResult(msg)
 
@@ -446,11 +543,18 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case PRDUpdateMsg:
 		return a.handlePRDUpdate(msg)
 
+	case WorkspaceUpdateMsg:
+		return a.handleWorkspaceUpdate(msg)
+
 	case LaunchInitMsg:
 		a.PostExitAction = PostExitInit
 		a.PostExitPRD = msg.Name
 		return a, tea.Quit
 
+	case activityMsg:
+		a.lastActivity = string(msg)
+		return a, nil
+
 	case PRDCreationStatusMsg:
 		a.picker.SetCreationTask(&CreationStatus{
 			PRDName: msg.Name,
@@ -531,17 +635,33 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a.handleCompletionKeys(msg)
 		}
 
+		// Handle iteration timeline view
+		if a.viewMode == ViewTimeline {
+			return a.handleTimelineKeys(msg)
+		}
+
+		// Handle merge conflict resolution
+		if a.viewMode == ViewConflicts {
+			return a.handleConflictKeys(msg)
+		}
+
+		// Handle the log view's search and filter prompt
+		if a.viewMode == ViewLog && a.logViewer.IsInputting() {
+			return a.handleLogInputKeys(msg)
+		}
+
 		switch msg.String() {
 		case "q", "ctrl+c":
 			a.stopAllLoops()
 			a.stopWatcher()
+			a.stopWorkspaceWatcher()
 			return a, tea.Quit
 
 		// View switching
 		case "t":
 			if a.viewMode == ViewDashboard || a.viewMode == ViewDiff {
 				a.viewMode = ViewLog
-				a.logViewer.SetSize(a.width, a.height-a.effectiveHeaderHeight()-footerHeight-2)
+				a.logViewer.SetSize(a.width-4, a.height-a.effectiveHeaderHeight()-footerHeight-2)
 			} else {
 				a.viewMode = ViewDashboard
 			}
@@ -551,16 +671,119 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "d":
 			if a.viewMode == ViewDashboard || a.viewMode == ViewLog {
 				a.diffViewer.SetSize(a.width-4, a.height-headerHeight-footerHeight-2)
-				a.diffViewer.Load()
+				a.diffViewer.LoadBranch(a.workDir(a.prdName))
 				a.viewMode = ViewDiff
 			} else if a.viewMode == ViewDiff {
 				a.viewMode = ViewDashboard
 			}
 			return a, nil
 
-		// New PRD (opens picker in input mode)
-		case "n":
+		// Iteration timeline
+		case "i":
+			if a.viewMode == ViewDashboard || a.viewMode == ViewLog || a.viewMode == ViewDiff || a.viewMode == ViewTranscript {
+				a.timelineViewer.SetSize(a.width-4, a.height-headerHeight-footerHeight-2)
+				a.timelineViewer.Load(a.prdName)
+				a.viewMode = ViewTimeline
+			}
+			return a, nil
+
+		// Transcript view
+		case "v":
 			if a.viewMode == ViewDashboard || a.viewMode == ViewLog || a.viewMode == ViewDiff {
+				a.transcriptViewer.SetSize(a.width-4, a.height-headerHeight-footerHeight-2)
+				a.transcriptViewer.Load(a.prdName)
+				a.viewMode = ViewTranscript
+			} else if a.viewMode == ViewTranscript {
+				a.viewMode = ViewDashboard
+			}
+			return a, nil
+		case "enter":
+			if a.viewMode == ViewTranscript {
+				a.transcriptViewer.Open()
+			} else if a.viewMode == ViewDiff && a.diffViewer.FilesFocused() {
+				a.diffViewer.ToggleFiles()
+			}
+			return a, nil
+		case "esc":
+			if a.viewMode == ViewTranscript {
+				if a.transcriptViewer.IsOpen() {
+					a.transcriptViewer.Close()
+				} else {
+					a.viewMode = ViewDashboard
+				}
+			} else if a.viewMode == ViewLog {
+				a.logViewer.ClearSearchAndFilter()
+			} else if a.viewMode == ViewDiff && a.diffViewer.FilesFocused() {
+				a.diffViewer.ToggleFiles()
+			}
+			return a, nil
+
+		// Diff browsing: file list, files, hunks, layout and scope
+		case "tab":
+			if a.viewMode == ViewDiff {
+				a.diffViewer.ToggleFiles()
+			}
+			return a, nil
+		case "]":
+			if a.viewMode == ViewDiff {
+				a.diffViewer.NextFile()
+			}
+			return a, nil
+		case "[":
+			if a.viewMode == ViewDiff {
+				a.diffViewer.PrevFile()
+			}
+			return a, nil
+		case "}":
+			if a.viewMode == ViewDiff {
+				a.diffViewer.NextHunk()
+			}
+			return a, nil
+		case "{":
+			if a.viewMode == ViewDiff {
+				a.diffViewer.PrevHunk()
+			}
+			return a, nil
+		case "|":
+			if a.viewMode == ViewDiff {
+				a.diffViewer.ToggleSideBySide()
+			}
+			return a, nil
+		case "c":
+			if a.viewMode == ViewDiff {
+				a.cycleDiffScope()
+			}
+			return a, nil
+
+		// Log search, filter and export
+		case "/":
+			if a.viewMode == ViewLog {
+				a.logViewer.StartSearch()
+			}
+			return a, nil
+		case "f":
+			if a.viewMode == ViewLog {
+				a.logViewer.StartFilter()
+			}
+			return a, nil
+		case "N":
+			if a.viewMode == ViewLog {
+				a.logViewer.PrevMatch()
+			}
+			return a, nil
+		case "w":
+			if a.viewMode == ViewLog {
+				a.exportLog()
+			}
+			return a, nil
+
+		// New PRD (opens picker in input mode); next search match in the log
+		case "n":
+			if a.viewMode == ViewLog {
+				a.logViewer.NextMatch()
+				return a, nil
+			}
+			if a.viewMode == ViewDashboard || a.viewMode == ViewDiff {
 				a.picker.Refresh()
 				a.picker.SetSize(a.width, a.height)
 				a.picker.StartInputMode()
@@ -582,6 +805,7 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if a.viewMode == ViewDashboard || a.viewMode == ViewLog || a.viewMode == ViewDiff {
 				a.stopAllLoops()
 				a.stopWatcher()
+				a.stopWorkspaceWatcher()
 				return a, func() tea.Msg {
 					return LaunchEditMsg{Name: a.prdName}
 				}
@@ -625,6 +849,8 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				a.logViewer.ScrollUp()
 			} else if a.viewMode == ViewDiff {
 				a.diffViewer.ScrollUp()
+			} else if a.viewMode == ViewTranscript {
+				a.transcriptViewer.ScrollUp()
 			} else {
 				if a.selectedIndex > 0 {
 					a.selectedIndex--
@@ -635,6 +861,8 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				a.logViewer.ScrollDown()
 			} else if a.viewMode == ViewDiff {
 				a.diffViewer.ScrollDown()
+			} else if a.viewMode == ViewTranscript {
+				a.transcriptViewer.ScrollDown()
 			} else {
 				if a.selectedIndex < len(a.prd.UserStories)-1 {
 					a.selectedIndex++
@@ -647,24 +875,32 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				a.logViewer.PageDown()
 			} else if a.viewMode == ViewDiff {
 				a.diffViewer.PageDown()
+			} else if a.viewMode == ViewTranscript {
+				a.transcriptViewer.PageDown()
 			}
 		case "ctrl+u":
 			if a.viewMode == ViewLog {
 				a.logViewer.PageUp()
 			} else if a.viewMode == ViewDiff {
 				a.diffViewer.PageUp()
+			} else if a.viewMode == ViewTranscript {
+				a.transcriptViewer.PageUp()
 			}
 		case "g":
 			if a.viewMode == ViewLog {
 				a.logViewer.ScrollToTop()
 			} else if a.viewMode == ViewDiff {
 				a.diffViewer.ScrollToTop()
+			} else if a.viewMode == ViewTranscript {
+				a.transcriptViewer.ScrollToTop()
 			}
 		case "G":
 			if a.viewMode == ViewLog {
 				a.logViewer.ScrollToBottom()
 			} else if a.viewMode == ViewDiff {
 				a.diffViewer.ScrollToBottom()
+			} else if a.viewMode == ViewTranscript {
+				a.transcriptViewer.ScrollToBottom()
 			}
 
 		// Max iterations control
@@ -678,6 +914,284 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// handleLogInputKeys handles typing into the log view's search or filter
+// prompt.
+func (a App) handleLogInputKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		a.stopAllLoops()
+		a.stopWatcher()
+		a.stopWorkspaceWatcher()
+		return a, tea.Quit
+	case "esc":
+		a.logViewer.CancelInput()
+	case "enter":
+		a.logViewer.SubmitInput()
+	case "backspace":
+		a.logViewer.DeleteInputChar()
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			for _, r := range msg.Runes {
+				a.logViewer.AddInputChar(r)
+			}
+		}
+	}
+	return a, nil
+}
+
+// cycleDiffScope switches the diff view between the whole branch and the
+// story selected on the dashboard.
+func (a *App) cycleDiffScope() {
+	dir := a.workDir(a.prdName)
+	story := a.GetSelectedStory()
+	if a.diffViewer.Scope() == DiffScopeStory || story == nil {
+		a.diffViewer.LoadBranch(dir)
+		return
+	}
+	a.diffViewer.LoadStory(dir, story.ID)
+}
+
+// exportLog writes the log, as filtered, to a file in the PRD directory.
+func (a *App) exportLog() {
+	name := "log-" + time.Now().Format("20060102-150405") + ".txt"
+	path := filepath.Join(a.baseDir, ".chief", "prds", a.prdName, "exports", name)
+	n, err := a.logViewer.Export(path)
+	if err != nil {
+		a.lastActivity = "Error exporting log: " + err.Error()
+		return
+	}
+	if rel, err := filepath.Rel(a.baseDir, path); err == nil {
+		path = rel
+	}
+	a.lastActivity = fmt.Sprintf("Exported %d log entries to %s", n, path)
+}
+
+// handleTimelineKeys handles keyboard input for the iteration timeline.
+func (a App) handleTimelineKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		a.stopAllLoops()
+		a.stopWatcher()
+		a.stopWorkspaceWatcher()
+		return a, tea.Quit
+	case "esc", "i":
+		a.viewMode = ViewDashboard
+	case "up", "k":
+		a.timelineViewer.ScrollUp()
+	case "down", "j":
+		a.timelineViewer.ScrollDown()
+	case "ctrl+u":
+		a.timelineViewer.PageUp()
+	case "ctrl+d":
+		a.timelineViewer.PageDown()
+	case "g":
+		a.timelineViewer.ScrollToTop()
+	case "G":
+		a.timelineViewer.ScrollToBottom()
+
+	// Open the selected iteration's diff, log or transcript
+	case "enter", "d":
+		if r := a.timelineViewer.Selected(); r != nil {
+			a.diffViewer.SetSize(a.width-4, a.height-headerHeight-footerHeight-2)
+			a.diffViewer.LoadIteration(a.workDir(a.prdName), *r)
+			a.viewMode = ViewDiff
+		}
+	case "t":
+		if r := a.timelineViewer.Selected(); r != nil {
+			a.logViewer.SetSize(a.width-4, a.height-a.effectiveHeaderHeight()-footerHeight-2)
+			if a.logViewer.JumpToIteration(r.Iteration, r.StartedAt) {
+				a.viewMode = ViewLog
+			} else {
+				a.lastActivity = fmt.Sprintf("Iteration %d isn't in this session's log; press v for its transcript", r.Iteration)
+			}
+		}
+	case "v":
+		if r := a.timelineViewer.Selected(); r != nil {
+			if r.Transcript == "" {
+				a.lastActivity = fmt.Sprintf("Iteration %d has no transcript", r.Iteration)
+				return a, nil
+			}
+			a.transcriptViewer.SetSize(a.width-4, a.height-headerHeight-footerHeight-2)
+			a.transcriptViewer.OpenPath(a.prdName, r.Transcript)
+			a.viewMode = ViewTranscript
+		}
+	}
+	return a, nil
+}
+
+// handleConflictKeys handles keys in the merge conflict view.
+func (a App) handleConflictKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	c := a.conflictViewer
+	if c.IsConfirmingAbort() {
+		switch msg.String() {
+		case "y", "Y":
+			c.CancelAbortConfirmation()
+			c.StopResolving()
+			if err := git.AbortMerge(c.RepoDir()); err != nil {
+				a.lastActivity = "Error: " + err.Error()
+				return a, nil
+			}
+			a.lastActivity = fmt.Sprintf("Aborted the merge of %s", c.Branch())
+			a.picker.Refresh()
+			a.picker.SetSize(a.width, a.height)
+			a.viewMode = ViewPicker
+		case "ctrl+c":
+			c.StopResolving()
+			a.stopAllLoops()
+			a.stopWatcher()
+			a.stopWorkspaceWatcher()
+			return a, tea.Quit
+		default:
+			c.CancelAbortConfirmation()
+		}
+		return a, nil
+	}
+
+	var err error
+	switch msg.String() {
+	case "q", "ctrl+c":
+		c.StopResolving()
+		a.stopAllLoops()
+		a.stopWatcher()
+		a.stopWorkspaceWatcher()
+		return a, tea.Quit
+	case "esc":
+		// The merge stays in progress; merging again resumes it, but the
+		// agent doesn't keep editing files no one is looking at
+		c.StopResolving()
+		a.lastActivity = "The merge is still in progress. Press m on the PRD to come back to it."
+		a.picker.Refresh()
+		a.picker.SetSize(a.width, a.height)
+		a.viewMode = ViewPicker
+		return a, nil
+
+	// Navigation
+	case "down", "j":
+		c.NextHunk()
+	case "up", "k":
+		c.PrevHunk()
+	case "]":
+		c.NextFile()
+	case "[":
+		c.PrevFile()
+	case "ctrl+d":
+		c.PageDown()
+	case "ctrl+u":
+		c.PageUp()
+	}
+	if c.IsResolving() {
+		switch msg.String() {
+		case "o", "t", "b", "O", "T", "a", "u", "r", "f":
+			a.lastActivity = "The agent is still working on the conflicts"
+		case "x":
+			// Aborting stops the agent too
+			c.StartAbortConfirmation()
+		}
+		return a, nil
+	}
+
+	switch msg.String() {
+	// Resolving the selected hunk or file
+	case "o":
+		err = c.Resolve(git.ResolveOurs)
+	case "t":
+		err = c.Resolve(git.ResolveTheirs)
+	case "b":
+		err = c.Resolve(git.ResolveBoth)
+	case "O":
+		err = c.TakeFile(git.ResolveOurs)
+	case "T":
+		err = c.TakeFile(git.ResolveTheirs)
+	case "a":
+		err = c.MarkResolved()
+	case "u":
+		err = c.Undo()
+
+	// Handing the conflicts to the agent
+	case "r":
+		if files := c.Remaining(); len(files) > 0 {
+			ctx, cancel := context.WithCancel(context.Background())
+			c.StartResolving(cancel)
+			a.lastActivity = fmt.Sprintf("Asking the agent to resolve %d files...", len(files))
+			return a, resolveConflictsWithAgent(ctx, c.RepoDir(), c.Branch(), files)
+		}
+
+	// Finishing or aborting the merge
+	case "f":
+		if err = git.FinishMerge(c.RepoDir()); err == nil {
+			return a.handleMergeResult(mergeResultMsg{branch: c.Branch(), output: parseMergeSuccessMessage(c.RepoDir(), c.Branch())})
+		}
+	case "x":
+		c.StartAbortConfirmation()
+	}
+	if err != nil {
+		a.lastActivity = "Error: " + err.Error()
+	}
+	return a, nil
+}
+
+// resolveConflictsWithAgent returns a command that asks the agent to resolve
+// the conflicts in files, left by merging branch in repoDir, until ctx is
+// canceled.
+func resolveConflictsWithAgent(ctx context.Context, repoDir, branch string, files []string) tea.Cmd {
+	return func() tea.Msg {
+		messages := []ollama.Message{
+			{Role: "user", Content: embed.GetResolveConflictsPrompt(branch, files)},
+		}
+		stream := agent.RunAgent(ctx, ollama.NewClient(), messages, agent.AgentOptions{WorkDir: repoDir})
+
+		var summary string
+		for event := range stream {
+			if ctx.Err() != nil {
+				return conflictAgentResultMsg{err: ctx.Err()}
+			}
+			if event.Error != nil {
+				return conflictAgentResultMsg{err: event.Error}
+			}
+			if event.Message != nil && event.Message.Role == "assistant" && strings.TrimSpace(event.Message.Content) != "" {
+				summary = strings.TrimSpace(event.Message.Content)
+			}
+		}
+		if ctx.Err() != nil {
+			return conflictAgentResultMsg{err: ctx.Err()}
+		}
+		return conflictAgentResultMsg{summary: summary}
+	}
+}
+
+// handleConflictAgentResult shows what the agent resolved.
+func (a App) handleConflictAgentResult(msg conflictAgentResultMsg) (tea.Model, tea.Cmd) {
+	if errors.Is(msg.err, context.Canceled) {
+		// Stopped by leaving the view or aborting the merge
+		return a, nil
+	}
+	c := a.conflictViewer
+	c.StopResolving()
+	c.Reload()
+	if msg.err != nil {
+		a.lastActivity = "The agent couldn't resolve the conflicts: " + msg.err.Error()
+		return a, nil
+	}
+	remaining := len(c.Remaining())
+	a.lastActivity = fmt.Sprintf("The agent finished; %d files still have conflicts", remaining)
+	if remaining == 0 {
+		a.lastActivity = "The agent resolved every conflict. Review the files, then press f to commit the merge."
+	}
+	if line, _, _ := strings.Cut(msg.summary, "\n"); line != "" {
+		a.lastActivity += " (" + line + ")"
+	}
+	return a, nil
+}
+
+// workDir returns the directory a PRD's loop works in: its worktree, or the
+// project root.
+func (a *App) workDir(prdName string) string {
+	if instance := a.manager.GetInstance(prdName); instance != nil && instance.WorktreeDir != "" {
+		return instance.WorktreeDir
+	}
+	return a.baseDir
+}
+
 // startLoop starts the agent loop for the current PRD.
 func (a App) startLoop() (tea.Model, tea.Cmd) {
 	return a.startLoopForPRD(a.prdName)
@@ -833,6 +1347,11 @@ func (a App) handleLoopEvent(prdName string, event loop.Event) (tea.Model, tea.C
 		a.iteration = event.Iteration
 		// Add event to log viewer
 		a.logViewer.AddEvent(event)
+
+		// These events follow an iteration being recorded
+		if a.viewMode == ViewTimeline && (event.Type == loop.EventIterationStart || event.Type == loop.EventError || event.Type == loop.EventMaxIterationsReached) {
+			a.timelineViewer.Load(a.prdName)
+		}
 	}
 
 	var autoActionCmd tea.Cmd
@@ -863,6 +1382,10 @@ func (a App) handleLoopEvent(prdName string, event loop.Event) (tea.Model, tea.C
 		if isCurrentPRD {
 			a.lastActivity = "Working on: " + event.StoryID
 		}
+	case loop.EventStoryCompleted:
+		if isCurrentPRD {
+			a.lastActivity = "Completed: " + event.StoryID
+		}
 	case loop.EventComplete:
 		if isCurrentPRD {
 			a.state = StateComplete
@@ -889,7 +1412,7 @@ func (a App) handleLoopEvent(prdName string, event loop.Event) (tea.Model, tea.C
 				a.lastActivity = "Error: " + event.Err.Error()
 			}
 		}
-	case loop.EventRetrying:
+	case loop.EventRetrying, loop.EventQueueWait:
 		if isCurrentPRD {
 			a.lastActivity = event.Text
 		}
@@ -955,6 +1478,12 @@ func (a App) View() string {
 		return a.renderLogView()
 	case ViewDiff:
 		return a.renderDiffView()
+	case ViewTranscript:
+		return a.renderTranscriptView()
+	case ViewTimeline:
+		return a.renderTimelineView()
+	case ViewConflicts:
+		return a.renderConflictView()
 	case ViewPicker:
 		return a.renderPickerView()
 	case ViewHelp:
@@ -1310,6 +1839,12 @@ func (a *App) runBackgroundPRDCreation(name, description string) tea.Cmd {
 
 func (a *App) executePRDCreation(name, description string) tea.Msg {
 	prdDir := filepath.Join(a.baseDir, ".chief", "prds", name)
+
+	// The workspace watcher would convert prd.md while it's being generated
+	if a.workspace != nil {
+		a.workspace.Hold(name)
+		defer a.workspace.Release(name)
+	}
 	
 	// Create directory
 	if err := os.MkdirAll(prdDir, 0755); err != nil {
@@ -1401,6 +1936,7 @@ func (a App) handleSettingsKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "q", "ctrl+c":
 		a.stopAllLoops()
 		a.stopWatcher()
+		a.stopWorkspaceWatcher()
 		return a, tea.Quit
 	case "up", "k":
 		a.settingsOverlay.MoveUp()
@@ -1467,6 +2003,7 @@ func (a App) handleCompletionKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "q", "ctrl+c":
 		a.stopAllLoops()
 		a.stopWatcher()
+		a.stopWorkspaceWatcher()
 		return a, tea.Quit
 
 	case "l":
@@ -1480,16 +2017,8 @@ func (a App) handleCompletionKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Merge the completed PRD's branch
 		if a.completionScreen.HasBranch() {
 			branch := a.completionScreen.Branch()
-			baseDir := a.baseDir
 			a.viewMode = ViewDashboard
-			return a, func() tea.Msg {
-				conflicts, err := git.MergeBranch(baseDir, branch)
-				if err != nil {
-					return mergeResultMsg{branch: branch, conflicts: conflicts, err: err}
-				}
-				output := parseMergeSuccessMessage(baseDir, branch)
-				return mergeResultMsg{branch: branch, output: output}
-			}
+			return a, a.mergeBranch(branch)
 		}
 		return a, nil
 
@@ -1659,8 +2188,38 @@ func (a App) finishWorktreeSetup() (tea.Model, tea.Cmd) {
 	return a.doStartLoop(prdName, prdDir)
 }
 
+// mergeBranch returns a command that merges branch into the project root's
+// current branch. A conflicted merge is left in progress for the conflict
+// view, and a merge already in progress is resumed rather than started.
+func (a *App) mergeBranch(branch string) tea.Cmd {
+	baseDir := a.baseDir
+	return func() tea.Msg {
+		if git.MergeInProgress(baseDir) {
+			return mergeResultMsg{branch: branch, conflicts: git.ConflictedFiles(baseDir), inProgress: true}
+		}
+		conflicts, err := git.StartMerge(baseDir, branch)
+		if err != nil {
+			return mergeResultMsg{branch: branch, conflicts: conflicts, err: err, inProgress: len(conflicts) > 0}
+		}
+		// Build success message with merge details
+		output := parseMergeSuccessMessage(baseDir, branch)
+		return mergeResultMsg{branch: branch, output: output}
+	}
+}
+
 // handleMergeResult handles the result of an async merge operation.
 func (a App) handleMergeResult(msg mergeResultMsg) (tea.Model, tea.Cmd) {
+	if msg.inProgress {
+		a.conflictViewer.Load(a.baseDir, msg.branch)
+		a.conflictViewer.SetSize(a.width-4, a.height-headerHeight-footerHeight-2)
+		a.viewMode = ViewConflicts
+		if msg.err == nil {
+			a.lastActivity = fmt.Sprintf("Resuming the merge in progress: %d files have conflicts", len(msg.conflicts))
+		} else {
+			a.lastActivity = fmt.Sprintf("Merging %s: %d files have conflicts", msg.branch, len(msg.conflicts))
+		}
+		return a, nil
+	}
 	if msg.err != nil {
 		a.picker.SetMergeResult(&MergeResult{
 			Success:   false,
@@ -1840,6 +2399,7 @@ func (a App) handlePickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "q", "ctrl+c":
 		a.stopAllLoops()
 		a.stopWatcher()
+		a.stopWorkspaceWatcher()
 		return a, tea.Quit
 	case "up", "k":
 		a.picker.MoveUp()
@@ -1864,6 +2424,7 @@ func (a App) handlePickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if entry != nil && entry.LoadError == nil {
 			a.stopAllLoops()
 			a.stopWatcher()
+			a.stopWorkspaceWatcher()
 			return a, func() tea.Msg {
 				return LaunchEditMsg{Name: entry.Name}
 			}
@@ -1907,17 +2468,7 @@ func (a App) handlePickerKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Merge completed PRD's branch
 		if a.picker.CanMerge() {
 			entry := a.picker.GetSelectedEntry()
-			branch := entry.Branch
-			baseDir := a.baseDir
-			return a, func() tea.Msg {
-				conflicts, err := git.MergeBranch(baseDir, branch)
-				if err != nil {
-					return mergeResultMsg{branch: branch, conflicts: conflicts, err: err}
-				}
-				// Build success message with merge details
-				output := parseMergeSuccessMessage(baseDir, branch)
-				return mergeResultMsg{branch: branch, output: output}
-			}
+			return a, a.mergeBranch(entry.Branch)
 		}
 		return a, nil
 
@@ -2174,9 +2725,56 @@ func (a App) handlePRDUpdate(msg PRDUpdateMsg) (tea.Model, tea.Cmd) {
 	return a, a.listenForPRDChanges()
 }
 
+// listenForWorkspaceChanges listens for PRDs being added, removed or changed.
+func (a *App) listenForWorkspaceChanges() tea.Cmd {
+	if a.workspace == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		event, ok := <-a.workspace.Events()
+		if !ok {
+			return nil
+		}
+		return WorkspaceUpdateMsg{Event: event}
+	}
+}
+
+// handleWorkspaceUpdate refreshes the picker and tab bar when the set of PRDs
+// or their files change.
+func (a App) handleWorkspaceUpdate(msg WorkspaceUpdateMsg) (tea.Model, tea.Cmd) {
+	ev := msg.Event
+	switch ev.Type {
+	case prd.WorkspacePRDAdded:
+		a.lastActivity = "New PRD: " + ev.Name
+	case prd.WorkspacePRDRemoved:
+		a.lastActivity = "PRD removed: " + ev.Name
+	case prd.WorkspacePRDConverted:
+		a.lastActivity = "Converted prd.md for " + ev.Name
+	case prd.WorkspaceError:
+		if errors.Is(ev.Err, prd.ErrFreeForm) {
+			a.lastActivity = fmt.Sprintf("%s/prd.md is free-form; run chief edit %s to convert it", ev.Name, ev.Name)
+		} else if ev.Err != nil {
+			a.lastActivity = "PRD watcher error: " + ev.Err.Error()
+		}
+	}
+
+	a.picker.Refresh()
+	if a.tabBar != nil {
+		a.tabBar.Refresh()
+	}
+	return a, a.listenForWorkspaceChanges()
+}
+
 // stopWatcher stops the file watcher.
 func (a *App) stopWatcher() {
 	if a.watcher != nil {
 		a.watcher.Stop()
 	}
 }
+
+// stopWorkspaceWatcher stops the PRD directory watcher.
+func (a *App) stopWorkspaceWatcher() {
+	if a.workspace != nil {
+		a.workspace.Stop()
+	}
+}
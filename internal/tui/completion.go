@@ -167,7 +167,8 @@ func (c *CompletionScreen) Render() string {
 		content.WriteString(infoStyle.Render(fmt.Sprintf("Commits: %d %s on branch", c.commitCount, commitLabel)))
 		content.WriteString("\n")
 	}
-	content.WriteString("\n")
+	content.WriteString(infoStyle.Render(fmt.Sprintf("Report: chief report %s --format html", c.prdName)))
+	content.WriteString("\n\n")
 
 	// Auto-actions progress or hint
 	if c.pushState != AutoActionIdle || c.prState != AutoActionIdle {
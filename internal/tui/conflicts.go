@@ -0,0 +1,480 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/izdrail/chief/internal/git"
+)
+
+// conflictContext is how many lines around a hunk are shown for context.
+const conflictContext = 3
+
+// conflictEntry is a conflicted file and whether it has been resolved.
+type conflictEntry struct {
+	file     *git.ConflictFile
+	resolved bool   // Staged, so no longer conflicted
+	how      string // How it was resolved, for the file list
+}
+
+// ConflictViewer walks through the conflicts a merge left, hunk by hunk,
+// resolving each to ours, theirs or both, and tracks which files are done.
+// The merge stays in progress in the repository until it's finished or
+// aborted.
+type ConflictViewer struct {
+	repoDir string
+	branch  string
+	entries []*conflictEntry
+	file    int
+	hunk    int
+	offset  int
+	width   int
+	height  int
+	err     error
+
+	stopAgent    context.CancelFunc // Stops the agent working on the conflicts; nil when there's none
+	confirmAbort bool
+}
+
+// NewConflictViewer creates an empty conflict viewer.
+func NewConflictViewer() *ConflictViewer {
+	return &ConflictViewer{}
+}
+
+// SetSize sets the viewport dimensions.
+func (c *ConflictViewer) SetSize(width, height int) {
+	c.width = width
+	c.height = height
+}
+
+// Load reads the conflicts left in repoDir by merging branch.
+func (c *ConflictViewer) Load(repoDir, branch string) {
+	c.repoDir = repoDir
+	c.branch = branch
+	c.entries = nil
+	c.file, c.hunk, c.offset = 0, 0, 0
+	c.StopResolving()
+	c.confirmAbort = false
+	c.Reload()
+}
+
+// Reload re-reads the conflicted files, after the agent or something outside
+// the viewer has worked on them. Files no longer conflicted are resolved.
+func (c *ConflictViewer) Reload() {
+	files, err := git.ReadConflicts(c.repoDir)
+	c.err = err
+	if err != nil {
+		return
+	}
+
+	open := make(map[string]*git.ConflictFile, len(files))
+	for _, f := range files {
+		open[f.Path] = f
+	}
+	for _, e := range c.entries {
+		if f, ok := open[e.file.Path]; ok {
+			e.file, e.resolved, e.how = f, false, ""
+			delete(open, f.Path)
+		} else if !e.resolved {
+			e.resolved, e.how = true, "resolved"
+		}
+	}
+	for _, f := range files {
+		if open[f.Path] != nil {
+			c.entries = append(c.entries, &conflictEntry{file: f})
+		}
+	}
+	c.file = min(c.file, max(0, len(c.entries)-1))
+	c.hunk = 0
+	c.offset = 0
+}
+
+// Branch returns the branch being merged.
+func (c *ConflictViewer) Branch() string {
+	return c.branch
+}
+
+// RepoDir returns the repository the merge is in.
+func (c *ConflictViewer) RepoDir() string {
+	return c.repoDir
+}
+
+// Remaining returns the conflicted files that are still unresolved.
+func (c *ConflictViewer) Remaining() []string {
+	var paths []string
+	for _, e := range c.entries {
+		if !e.resolved {
+			paths = append(paths, e.file.Path)
+		}
+	}
+	return paths
+}
+
+// StartResolving records that the agent is working on the conflicts, until
+// StopResolving calls stop.
+func (c *ConflictViewer) StartResolving(stop context.CancelFunc) {
+	c.StopResolving()
+	c.stopAgent = stop
+}
+
+// StopResolving stops the agent working on the conflicts, if there is one.
+func (c *ConflictViewer) StopResolving() {
+	if c.stopAgent != nil {
+		c.stopAgent()
+		c.stopAgent = nil
+	}
+}
+
+// IsResolving reports whether the agent is working on the conflicts.
+func (c *ConflictViewer) IsResolving() bool {
+	return c.stopAgent != nil
+}
+
+// StartAbortConfirmation asks whether to abort the merge.
+func (c *ConflictViewer) StartAbortConfirmation() {
+	c.confirmAbort = true
+}
+
+// CancelAbortConfirmation dismisses the abort question.
+func (c *ConflictViewer) CancelAbortConfirmation() {
+	c.confirmAbort = false
+}
+
+// IsConfirmingAbort reports whether the abort question is showing.
+func (c *ConflictViewer) IsConfirmingAbort() bool {
+	return c.confirmAbort
+}
+
+// current returns the selected file, or nil when there are none.
+func (c *ConflictViewer) current() *conflictEntry {
+	if c.file >= len(c.entries) {
+		return nil
+	}
+	return c.entries[c.file]
+}
+
+// Resolve resolves the selected hunk and moves on to the next unresolved
+// one. The file is written as it goes, and staged once every hunk in it is
+// resolved.
+func (c *ConflictViewer) Resolve(r git.Resolution) error {
+	e := c.current()
+	if e == nil || e.resolved || c.hunk >= len(e.file.Hunks) {
+		return nil
+	}
+	e.file.Hunks[c.hunk].Resolution = r
+	if err := git.SaveResolution(c.repoDir, e.file); err != nil {
+		return err
+	}
+	if e.file.Resolved() {
+		e.resolved, e.how = true, "merged"
+	}
+	c.nextUnresolved()
+	return nil
+}
+
+// TakeFile resolves the whole selected file to one side.
+func (c *ConflictViewer) TakeFile(side git.Resolution) error {
+	e := c.current()
+	if e == nil || e.resolved {
+		return nil
+	}
+	if err := git.ResolveFile(c.repoDir, e.file.Path, side); err != nil {
+		return err
+	}
+	e.resolved, e.how = true, resolutionName(side)
+	c.nextUnresolved()
+	return nil
+}
+
+// MarkResolved stages the selected file as it is on disk, for files whose
+// markers are gone: edited outside the viewer, or conflicted without markers.
+func (c *ConflictViewer) MarkResolved() error {
+	e := c.current()
+	if e == nil || e.resolved {
+		return nil
+	}
+	if n := unresolvedHunks(e.file); n > 0 {
+		return fmt.Errorf("%s still has %d conflicts", e.file.Path, n)
+	}
+	if err := git.MarkResolved(c.repoDir, e.file.Path); err != nil {
+		return err
+	}
+	e.resolved, e.how = true, "as is"
+	c.nextUnresolved()
+	return nil
+}
+
+// Undo clears the selected hunk's resolution, or restores the conflicts of a
+// resolved file.
+func (c *ConflictViewer) Undo() error {
+	e := c.current()
+	if e == nil {
+		return nil
+	}
+	if !e.resolved {
+		if c.hunk < len(e.file.Hunks) && e.file.Hunks[c.hunk].Resolution != git.Unresolved {
+			e.file.Hunks[c.hunk].Resolution = git.Unresolved
+			return git.SaveResolution(c.repoDir, e.file)
+		}
+		return nil
+	}
+	if err := git.UnresolveFile(c.repoDir, e.file.Path); err != nil {
+		return err
+	}
+	c.Reload()
+	return c.err
+}
+
+// nextUnresolved selects the next unresolved hunk, in this file or after it.
+func (c *ConflictViewer) nextUnresolved() {
+	c.offset = 0
+	for n := 0; n < len(c.entries); n++ {
+		i := (c.file + n) % len(c.entries)
+		e := c.entries[i]
+		if e.resolved {
+			continue
+		}
+		from := 0
+		if n == 0 {
+			from = c.hunk
+		}
+		for h := from; h < len(e.file.Hunks); h++ {
+			if e.file.Hunks[h].Resolution == git.Unresolved {
+				c.file, c.hunk = i, h
+				return
+			}
+		}
+		if len(e.file.Hunks) == 0 {
+			c.file, c.hunk = i, 0
+			return
+		}
+	}
+}
+
+// NextHunk selects the next hunk, moving on to the next file after the last.
+func (c *ConflictViewer) NextHunk() {
+	e := c.current()
+	if e == nil {
+		return
+	}
+	c.offset = 0
+	if c.hunk < len(e.file.Hunks)-1 {
+		c.hunk++
+		return
+	}
+	c.NextFile()
+}
+
+// PrevHunk selects the previous hunk, moving back to the previous file's
+// last hunk before the first.
+func (c *ConflictViewer) PrevHunk() {
+	c.offset = 0
+	if c.hunk > 0 {
+		c.hunk--
+		return
+	}
+	if c.file > 0 {
+		c.file--
+		c.hunk = max(0, len(c.entries[c.file].file.Hunks)-1)
+	}
+}
+
+// NextFile selects the next file.
+func (c *ConflictViewer) NextFile() {
+	if c.file < len(c.entries)-1 {
+		c.file++
+		c.hunk, c.offset = 0, 0
+	}
+}
+
+// PrevFile selects the previous file.
+func (c *ConflictViewer) PrevFile() {
+	if c.file > 0 {
+		c.file--
+		c.hunk, c.offset = 0, 0
+	}
+}
+
+// PageDown scrolls a long hunk down half a page.
+func (c *ConflictViewer) PageDown() {
+	c.offset += c.height / 2
+}
+
+// PageUp scrolls a long hunk up half a page.
+func (c *ConflictViewer) PageUp() {
+	c.offset = max(0, c.offset-c.height/2)
+}
+
+// Summary describes the merge's progress, for the header.
+func (c *ConflictViewer) Summary() string {
+	return fmt.Sprintf("%s  %d/%d files resolved", c.branch, len(c.entries)-len(c.Remaining()), len(c.entries))
+}
+
+// unresolvedHunks counts the hunks of f without a resolution.
+func unresolvedHunks(f *git.ConflictFile) int {
+	n := 0
+	for _, h := range f.Hunks {
+		if h.Resolution == git.Unresolved {
+			n++
+		}
+	}
+	return n
+}
+
+// resolutionName names a resolution for display.
+func resolutionName(r git.Resolution) string {
+	switch r {
+	case git.ResolveOurs:
+		return "ours"
+	case git.ResolveTheirs:
+		return "theirs"
+	case git.ResolveBoth:
+		return "both"
+	}
+	return ""
+}
+
+// Render renders the file list and the selected hunk.
+func (c *ConflictViewer) Render() string {
+	if c.err != nil {
+		return lipgloss.NewStyle().Foreground(ErrorColor).Render("Error reading conflicts: " + c.err.Error())
+	}
+	if len(c.entries) == 0 {
+		return lipgloss.NewStyle().Foreground(MutedColor).Render("No conflicts left. Press f to finish the merge or x to abort it.")
+	}
+
+	listWidth := 0
+	if c.width >= fileListMinWidth {
+		listWidth = min(48, max(28, c.width/4))
+	}
+	body := c.renderHunk(c.width - listWidth)
+	if listWidth == 0 {
+		return strings.Join(body, "\n")
+	}
+
+	list := c.renderFileList(listWidth - 2)
+	border := lipgloss.NewStyle().Foreground(BorderColor).Render("│")
+	var content strings.Builder
+	for i := 0; i < c.height; i++ {
+		var left, right string
+		if i < len(list) {
+			left = list[i]
+		}
+		if i < len(body) {
+			right = body[i]
+		}
+		content.WriteString(left + strings.Repeat(" ", max(0, listWidth-2-lipgloss.Width(left))) + " " + border + right)
+		if i < c.height-1 {
+			content.WriteString("\n")
+		}
+	}
+	return content.String()
+}
+
+// renderFileList renders one line per conflicted file, width columns wide.
+func (c *ConflictViewer) renderFileList(width int) []string {
+	start := 0
+	if c.height > 0 && c.file >= c.height {
+		start = c.file - c.height + 1
+	}
+	end := min(len(c.entries), start+max(c.height, 1))
+
+	var lines []string
+	for i := start; i < end; i++ {
+		e := c.entries[i]
+		mark, state := lipgloss.NewStyle().Foreground(WarningColor).Render("!"), ""
+		if e.resolved {
+			mark, state = lipgloss.NewStyle().Foreground(SuccessColor).Render("✓"), " "+e.how
+		} else if n := unresolvedHunks(e.file); n > 0 {
+			state = fmt.Sprintf(" %d left", n)
+		}
+		path := tailWidth(e.file.Path, max(1, width-2-len(state)))
+		gap := strings.Repeat(" ", max(0, width-2-len([]rune(path))-len(state)))
+		if i == c.file {
+			style := lipgloss.NewStyle().Foreground(TextBrightColor).Bold(true).Background(BgSelectedColor)
+			lines = append(lines, mark+" "+style.Render(path+gap+state))
+			continue
+		}
+		lines = append(lines, mark+" "+path+gap+lipgloss.NewStyle().Foreground(MutedColor).Render(state))
+	}
+	return lines
+}
+
+// renderHunk renders the selected hunk with the lines around it, scrolled
+// by offset, in width columns.
+func (c *ConflictViewer) renderHunk(width int) []string {
+	e := c.current()
+	muted := lipgloss.NewStyle().Foreground(MutedColor)
+	title := lipgloss.NewStyle().Foreground(TextBrightColor).Bold(true)
+
+	var lines []string
+	if c.confirmAbort {
+		lines = append(lines, lipgloss.NewStyle().Foreground(WarningColor).Bold(true).Render(fitWidth("Abort the merge and discard every resolution? y/n", width)), "")
+	} else if c.IsResolving() {
+		lines = append(lines, lipgloss.NewStyle().Foreground(PrimaryColor).Bold(true).Render(fitWidth(fmt.Sprintf("The agent is resolving %d files...", len(c.Remaining())), width)), "")
+	} else if len(c.Remaining()) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Foreground(SuccessColor).Bold(true).Render(fitWidth("Every conflict is resolved. Press f to commit the merge.", width)), "")
+	}
+
+	hunks := len(e.file.Hunks)
+	switch {
+	case e.resolved:
+		lines = append(lines, title.Render(fitWidth(e.file.Path, width)), "",
+			lipgloss.NewStyle().Foreground(SuccessColor).Render(fitWidth("Resolved ("+e.how+") and staged.", width)),
+			muted.Render(fitWidth("Press u to restore its conflicts.", width)))
+		return lines
+	case hunks == 0:
+		lines = append(lines, title.Render(fitWidth(e.file.Path, width)), "",
+			fitWidth("No conflict markers in this file: one side deleted it, or it was edited.", width),
+			muted.Render(fitWidth("O keeps ours, T takes theirs, a stages the file as it is.", width)))
+		return lines
+	}
+
+	h := e.file.Hunks[c.hunk]
+	head := fmt.Sprintf("%s  conflict %d of %d  line %d", e.file.Path, c.hunk+1, hunks, h.Line)
+	if h.Resolution != git.Unresolved {
+		head += "  → " + resolutionName(h.Resolution)
+	}
+	lines = append(lines, title.Render(fitWidth(head, width)))
+
+	// The body scrolls; the title stays
+	var body []string
+	before := e.file.Before(c.hunk)
+	for _, l := range before[max(0, len(before)-conflictContext):] {
+		body = append(body, muted.Render(fitWidth("  "+l, width)))
+	}
+	side := func(name, label string, chosen bool, color lipgloss.Color, content []string) {
+		heading := "── " + name
+		if label != "" {
+			heading += " (" + label + ")"
+		}
+		if chosen {
+			heading += " ✓"
+		}
+		heading += " " + strings.Repeat("─", max(0, width-len([]rune(heading))-1))
+		style := lipgloss.NewStyle().Foreground(color)
+		body = append(body, style.Bold(true).Render(fitWidth(heading, width)))
+		if len(content) == 0 {
+			body = append(body, muted.Render("  (nothing)"))
+		}
+		for _, l := range content {
+			body = append(body, style.Render(fitWidth("  "+l, width)))
+		}
+	}
+	r := h.Resolution
+	side("ours", h.OursLabel, r == git.ResolveOurs || r == git.ResolveBoth, PrimaryColor, h.Ours)
+	if h.HasBase {
+		side("base", h.BaseLabel, false, MutedColor, h.Base)
+	}
+	side("theirs", h.TheirsLabel, r == git.ResolveTheirs || r == git.ResolveBoth, WarningColor, h.Theirs)
+	after := e.file.After(c.hunk)
+	for _, l := range after[:min(len(after), conflictContext)] {
+		body = append(body, muted.Render(fitWidth("  "+l, width)))
+	}
+
+	room := max(1, c.height-len(lines))
+	c.offset = max(0, min(c.offset, len(body)-room))
+	return append(lines, body[c.offset:min(len(body), c.offset+room)]...)
+}
@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/izdrail/chief/internal/git"
+)
+
+// conflictRepo returns a repository in the middle of merging "feature", with
+// a.txt conflicted in two places and b.txt in one.
+func conflictRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s", args, out)
+		}
+	}
+	write := func(a, b string) {
+		t.Helper()
+		os.WriteFile(filepath.Join(dir, "a.txt"), []byte(a), 0644)
+		os.WriteFile(filepath.Join(dir, "b.txt"), []byte(b), 0644)
+	}
+
+	run("init", "-q", "-b", "main")
+	run("config", "user.email", "test@test.com")
+	run("config", "user.name", "Test")
+	write("1\n2\n3\n4\n5\n6\n7\n", "x\n")
+	run("add", ".")
+	run("commit", "-qm", "base")
+	run("checkout", "-qb", "feature")
+	write("1 feature\n2\n3\n4\n5\n6\n7 feature\n", "x feature\n")
+	run("commit", "-qam", "feature")
+	run("checkout", "-q", "main")
+	write("1 main\n2\n3\n4\n5\n6\n7 main\n", "x main\n")
+	run("commit", "-qam", "main")
+
+	if conflicts, _ := git.StartMerge(dir, "feature"); len(conflicts) != 2 {
+		t.Fatalf("expected 2 conflicted files, got %v", conflicts)
+	}
+	return dir
+}
+
+func TestConflictViewer_ResolveHunks(t *testing.T) {
+	dir := conflictRepo(t)
+	c := NewConflictViewer()
+	c.SetSize(120, 30)
+	c.Load(dir, "feature")
+	if len(c.entries) != 2 || len(c.entries[0].file.Hunks) != 2 {
+		t.Fatalf("loaded %d files", len(c.entries))
+	}
+
+	out := c.Render()
+	for _, want := range []string{"a.txt  conflict 1 of 2", "── ours (HEAD)", "1 main", "── theirs (feature)", "1 feature"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Render() is missing %q:\n%s", want, out)
+		}
+	}
+
+	// Each choice moves on to the next conflict, and the file is staged once
+	// it has none left
+	if err := c.Resolve(git.ResolveOurs); err != nil {
+		t.Fatal(err)
+	}
+	if c.file != 0 || c.hunk != 1 {
+		t.Errorf("after resolving the first hunk at %d/%d", c.file, c.hunk)
+	}
+	if err := c.Resolve(git.ResolveBoth); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if string(data) != "1 main\n2\n3\n4\n5\n6\n7 main\n7 feature\n" {
+		t.Errorf("a.txt = %q", data)
+	}
+	if remaining := c.Remaining(); len(remaining) != 1 || remaining[0] != "b.txt" || c.file != 1 {
+		t.Errorf("Remaining() = %v with file %d selected", remaining, c.file)
+	}
+
+	// Undo restores a resolved file's conflicts
+	c.PrevFile()
+	if err := c.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Remaining()) != 2 || unresolvedHunks(c.entries[0].file) != 2 {
+		t.Errorf("after Undo() %v remain", c.Remaining())
+	}
+}
+
+func TestConflictViewer_FinishAfterWholeFiles(t *testing.T) {
+	dir := conflictRepo(t)
+	c := NewConflictViewer()
+	c.SetSize(80, 20)
+	c.Load(dir, "feature")
+
+	if err := c.MarkResolved(); err == nil {
+		t.Error("MarkResolved() staged a file with conflict markers")
+	}
+	if err := c.TakeFile(git.ResolveTheirs); err != nil {
+		t.Fatal(err)
+	}
+
+	// Resolved outside the viewer, as the agent does
+	os.WriteFile(filepath.Join(dir, "b.txt"), []byte("x both\n"), 0644)
+	cmd := exec.Command("git", "add", "b.txt")
+	cmd.Dir = dir
+	cmd.Run()
+	c.Reload()
+	if len(c.Remaining()) != 0 || c.entries[1].how != "resolved" {
+		t.Fatalf("after Reload() %v remain", c.Remaining())
+	}
+	if !strings.Contains(c.Render(), "Every conflict is resolved") {
+		t.Error("Render() doesn't say the merge can be finished")
+	}
+
+	if err := git.FinishMerge(dir); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if !strings.HasPrefix(string(data), "1 feature") {
+		t.Errorf("a.txt = %q", data)
+	}
+}
+
+func TestConflictViewer_LeavingStopsTheAgent(t *testing.T) {
+	dir := conflictRepo(t)
+	app := App{
+		baseDir:        dir,
+		viewMode:       ViewConflicts,
+		conflictViewer: NewConflictViewer(),
+		picker:         NewPRDPicker(dir, "", nil),
+	}
+	c := app.conflictViewer
+	c.Load(dir, "feature")
+	key := func(s string) tea.KeyMsg {
+		if s == "esc" {
+			return tea.KeyMsg{Type: tea.KeyEsc}
+		}
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+	}
+
+	for _, keys := range [][]string{{"esc"}, {"x", "y"}} {
+		app.viewMode = ViewConflicts
+		ctx, cancel := context.WithCancel(context.Background())
+		c.StartResolving(cancel)
+
+		var model tea.Model = app
+		for _, k := range keys {
+			model, _ = model.(App).handleConflictKeys(key(k))
+		}
+		if ctx.Err() == nil || c.IsResolving() {
+			t.Errorf("%v left the agent running", keys)
+		}
+		if model.(App).viewMode != ViewPicker {
+			t.Errorf("%v left the view in mode %v", keys, model.(App).viewMode)
+		}
+	}
+	if git.MergeInProgress(dir) {
+		t.Error("the merge is still in progress after aborting it")
+	}
+
+	// A canceled agent's result doesn't touch the next one
+	_, cancel := context.WithCancel(context.Background())
+	c.StartResolving(cancel)
+	app.handleConflictAgentResult(conflictAgentResultMsg{err: context.Canceled})
+	if !c.IsResolving() {
+		t.Error("a canceled agent's result stopped the next agent")
+	}
+	c.StopResolving()
+}
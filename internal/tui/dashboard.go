@@ -224,10 +224,31 @@ func (a *App) renderFooter() string {
 
 	if a.viewMode == ViewLog {
 		// Log view shortcuts
-		shortcuts = []string{"t: dashboard", "d: diff", "e: edit", "n: new", "l: list", "1-9: switch", "?: help", "j/k: scroll", "q: quit"}
+		shortcuts = []string{"t: dashboard", "d: diff", "/: search", "f: filter", "w: export", "e: edit", "l: list", "1-9: switch", "?: help", "j/k: scroll", "q: quit"}
 	} else if a.viewMode == ViewDiff {
 		// Diff view shortcuts
-		shortcuts = []string{"d: dashboard", "t: log", "e: edit", "n: new", "l: list", "?: help", "j/k: scroll", "q: quit"}
+		if a.diffViewer.FilesFocused() {
+			shortcuts = []string{"j/k: select file", "enter: diff", "tab: diff", "d: dashboard", "?: help", "q: quit"}
+		} else {
+			shortcuts = []string{"d: dashboard", "tab: files", "[/]: file", "{/}: hunk", "|: side by side", "c: story/branch", "?: help", "j/k: scroll", "q: quit"}
+		}
+	} else if a.viewMode == ViewConflicts {
+		// Merge conflict view shortcuts
+		if a.conflictViewer.IsConfirmingAbort() {
+			shortcuts = []string{"y: abort the merge", "n: keep going"}
+		} else {
+			shortcuts = []string{"o: ours", "t: theirs", "b: both", "O/T: whole file", "u: undo", "r: ask agent", "f: finish", "x: abort", "j/k: hunk", "[/]: file", "esc: back"}
+		}
+	} else if a.viewMode == ViewTimeline {
+		// Timeline view shortcuts
+		shortcuts = []string{"enter: diff", "t: log", "v: transcript", "j/k: select", "esc: dashboard", "?: help", "q: quit"}
+	} else if a.viewMode == ViewTranscript {
+		// Transcript view shortcuts
+		if a.transcriptViewer.IsOpen() {
+			shortcuts = []string{"esc: back", "v: dashboard", "j/k: scroll", "g/G: top/bottom", "?: help", "q: quit"}
+		} else {
+			shortcuts = []string{"enter: open", "v: dashboard", "j/k: select", "?: help", "q: quit"}
+		}
 	} else {
 		// Dashboard view shortcuts
 		switch a.state {
@@ -266,7 +287,13 @@ func (a *App) renderNarrowFooter() string {
 
 	if a.viewMode == ViewLog {
 		// Log view shortcuts - condensed
-		shortcuts = []string{"t", "e", "n", "1-9", "?", "q"}
+		shortcuts = []string{"t", "/", "f", "e", "1-9", "?", "q"}
+	} else if a.viewMode == ViewConflicts {
+		// Merge conflict view shortcuts - condensed
+		shortcuts = []string{"o", "t", "b", "u", "r", "f", "x", "?"}
+	} else if a.viewMode == ViewDiff {
+		// Diff view shortcuts - condensed
+		shortcuts = []string{"d", "tab", "[ ]", "{ }", "c", "?", "q"}
 	} else {
 		// Dashboard view shortcuts - condensed
 		switch a.state {
@@ -741,23 +768,25 @@ func (a *App) renderDiffHeader() string {
 		Foreground(PrimaryColor).
 		Bold(true).
 		Render("[Diff View]")
+	scope := SubtitleStyle.Render(a.diffViewer.Title())
 
 	// State indicator
 	stateStyle := GetStateStyle(a.state)
 	state := stateStyle.Render(fmt.Sprintf("[%s]", a.state.String()))
 
-	// Scroll position
+	// File and scroll position
 	var scrollInfo string
 	if len(a.diffViewer.lines) > 0 {
 		pct := 0
 		if a.diffViewer.maxOffset() > 0 {
-			pct = a.diffViewer.offset * 100 / a.diffViewer.maxOffset()
+			pct = min(100, a.diffViewer.offset*100/a.diffViewer.maxOffset())
 		}
-		scrollInfo = SubtitleStyle.Render(fmt.Sprintf("%d lines  %d%%", len(a.diffViewer.lines), pct))
+		file, files := a.diffViewer.CurrentFile()
+		scrollInfo = SubtitleStyle.Render(fmt.Sprintf("file %d/%d  %d lines  %d%%", file+1, files, len(a.diffViewer.lines), pct))
 	}
 
 	// Combine elements
-	leftPart := lipgloss.JoinHorizontal(lipgloss.Center, brand, "  ", viewIndicator, "  ", state)
+	leftPart := lipgloss.JoinHorizontal(lipgloss.Center, brand, "  ", viewIndicator, "  ", scope, "  ", state)
 	rightPart := scrollInfo
 
 	// Create the full header line with proper spacing
@@ -799,7 +828,8 @@ func (a *App) renderNarrowDiffHeader() string {
 
 	var rightPart string
 	if len(a.diffViewer.lines) > 0 {
-		rightPart = SubtitleStyle.Render(fmt.Sprintf("%d lines", len(a.diffViewer.lines)))
+		file, files := a.diffViewer.CurrentFile()
+		rightPart = SubtitleStyle.Render(fmt.Sprintf("%d/%d files", file+1, files))
 	}
 
 	spacing := strings.Repeat(" ", max(0, a.width-lipgloss.Width(leftPart)-lipgloss.Width(rightPart)-2))
@@ -810,6 +840,63 @@ func (a *App) renderNarrowDiffHeader() string {
 	return lipgloss.JoinVertical(lipgloss.Left, headerLine, border)
 }
 
+// renderTranscriptView renders the full-screen transcript view.
+func (a *App) renderTranscriptView() string {
+	if a.width == 0 || a.height == 0 {
+		return "Loading..."
+	}
+	contentHeight := a.height - headerHeight - footerHeight - 2
+	a.transcriptViewer.SetSize(a.width-4, contentHeight)
+	return a.renderPanelView("[Transcripts]", a.transcriptViewer.Title(), a.transcriptViewer.Render())
+}
+
+// renderTimelineView renders the full-screen iteration timeline.
+func (a *App) renderTimelineView() string {
+	if a.width == 0 || a.height == 0 {
+		return "Loading..."
+	}
+	contentHeight := a.height - headerHeight - footerHeight - 2
+	a.timelineViewer.SetSize(a.width-4, contentHeight)
+	return a.renderPanelView("[Timeline]", fmt.Sprintf("%d iterations", len(a.timelineViewer.records)), a.timelineViewer.Render())
+}
+
+// renderConflictView renders the full-screen merge conflict view.
+func (a *App) renderConflictView() string {
+	if a.width == 0 || a.height == 0 {
+		return "Loading..."
+	}
+	contentHeight := a.height - headerHeight - footerHeight - 2
+	a.conflictViewer.SetSize(a.width-4, contentHeight)
+	return a.renderPanelView("[Merge Conflicts]", a.conflictViewer.Summary(), a.conflictViewer.Render())
+}
+
+// renderPanelView renders a full-screen view: a header with the view's name
+// and a summary on the right, the content in a panel, and the footer.
+func (a *App) renderPanelView(name, summary, content string) string {
+	var footer string
+	if a.isNarrowMode() {
+		footer = a.renderNarrowFooter()
+	} else {
+		footer = a.renderFooter()
+	}
+
+	brand := headerStyle.Render("chief")
+	viewIndicator := lipgloss.NewStyle().
+		Foreground(PrimaryColor).
+		Bold(true).
+		Render(name)
+	leftPart := lipgloss.JoinHorizontal(lipgloss.Center, brand, "  ", viewIndicator)
+	rightPart := SubtitleStyle.Render(summary)
+	spacing := strings.Repeat(" ", max(0, a.width-lipgloss.Width(leftPart)-lipgloss.Width(rightPart)-2))
+	headerLine := lipgloss.JoinHorizontal(lipgloss.Center, leftPart, spacing, rightPart)
+	header := lipgloss.JoinVertical(lipgloss.Left, headerLine, DividerStyle.Render(strings.Repeat("─", a.width)))
+
+	contentHeight := a.height - headerHeight - footerHeight - 2
+	panel := panelStyle.Width(a.width - 2).Height(contentHeight).Render(content)
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, panel, footer)
+}
+
 // renderLogView renders the full-screen log view.
 func (a *App) renderLogView() string {
 	if a.width == 0 || a.height == 0 {
@@ -1,22 +1,60 @@
 package tui
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/izdrail/chief/internal/db"
 	"github.com/izdrail/chief/internal/git"
 )
 
-// DiffViewer displays git diffs with syntax highlighting and scrolling.
+// DiffScope is the set of changes a DiffViewer shows.
+type DiffScope int
+
+const (
+	DiffScopeBranch    DiffScope = iota // The branch since it left the default branch
+	DiffScopeStory                      // The commits belonging to one story
+	DiffScopeIteration                  // The changes one iteration made
+)
+
+// storyCommitLimit bounds the commits searched for a story's commits on a
+// protected branch, where there is no merge base to stop at.
+const storyCommitLimit = 200
+
+// Layout thresholds, in columns of the viewer's width.
+const (
+	fileListMinWidth   = 100 // Show the file list beside the diff
+	sideBySideMinWidth = 120 // Allow the side-by-side layout for the diff pane
+)
+
+// DiffViewer browses git diffs: a file list beside the diff, per-file and
+// per-hunk navigation, and a unified or side-by-side layout.
 type DiffViewer struct {
-	lines      []string
+	lines   []string     // The raw unified diff
+	files   []diffFile   // The files in lines, in order
+	hunks   []int        // Indexes in lines of every hunk header
+	commits []git.Commit // The commits a story diff is made of, oldest first
+
+	rows       []diffRow // lines laid out for the current mode and width
+	rowsWidth  int
+	rowsSplit  bool
 	offset     int
-	width      int
-	height     int
-	stats      string
-	baseDir    string
-	err        error
-	loaded     bool
+	fileCursor int
+	focusFiles bool
+	sideBySide bool
+
+	width   int
+	height  int
+	stats   string
+	baseDir string
+	err     error
+	loaded  bool
+
+	scope     DiffScope
+	storyID   string
+	iteration int
 }
 
 // NewDiffViewer creates a new diff viewer.
@@ -32,48 +70,331 @@ func (d *DiffViewer) SetSize(width, height int) {
 	d.height = height
 }
 
-// Load fetches the latest git diff.
-func (d *DiffViewer) Load() {
+// Scope returns what the viewer is showing.
+func (d *DiffViewer) Scope() DiffScope {
+	return d.scope
+}
+
+// Title describes what the viewer is showing, for the header.
+func (d *DiffViewer) Title() string {
+	var title string
+	switch d.scope {
+	case DiffScopeStory:
+		title = "story " + d.storyID
+	case DiffScopeIteration:
+		title = fmt.Sprintf("iteration %d", d.iteration)
+	default:
+		title = "branch"
+	}
+	if d.SideBySide() {
+		title += " · side by side"
+	}
+	return title
+}
+
+// reset clears the viewer before a load.
+func (d *DiffViewer) reset(scope DiffScope) {
+	d.lines = nil
+	d.files = nil
+	d.hunks = nil
+	d.commits = nil
+	d.rows = nil
 	d.offset = 0
+	d.fileCursor = 0
+	d.focusFiles = false
+	d.stats = ""
+	d.err = nil
 	d.loaded = true
+	d.scope = scope
+	d.storyID = ""
+	d.iteration = 0
+}
+
+// Load fetches the latest git diff of the project root's branch.
+func (d *DiffViewer) Load() {
+	d.LoadBranch(d.baseDir)
+}
 
-	diff, err := git.GetDiff(d.baseDir)
+// LoadBranch shows the diff of the branch checked out in dir against its
+// merge base with the default branch.
+func (d *DiffViewer) LoadBranch(dir string) {
+	d.reset(DiffScopeBranch)
+
+	diff, err := git.GetDiff(dir)
 	if err != nil {
 		d.err = err
-		d.lines = nil
-		d.stats = ""
 		return
 	}
+	d.appendDiff(diff, -1)
 
-	d.err = nil
+	stats, err := git.GetDiffStats(dir)
+	if err == nil {
+		d.stats = stats
+	}
+}
 
+// LoadStory shows the changes of the commits on the branch checked out in
+// dir that belong to a story, commit by commit.
+func (d *DiffViewer) LoadStory(dir, storyID string) {
+	d.reset(DiffScopeStory)
+	d.storyID = storyID
+
+	commits, err := git.StoryCommits(dir, storyID, storyCommitLimit)
+	if err != nil {
+		d.err = err
+		return
+	}
+	var adds, dels int
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		diff, err := git.CommitDiff(dir, c.Hash)
+		if err != nil {
+			d.err = err
+			return
+		}
+		d.commits = append(d.commits, c)
+		d.appendDiff(diff, len(d.commits)-1)
+		adds += c.Insertions
+		dels += c.Deletions
+	}
+	if len(commits) == 0 {
+		d.stats = fmt.Sprintf("Story %s: no commits found", storyID)
+		return
+	}
+	d.stats = fmt.Sprintf("Story %s: %d commits, %d files changed, +%d -%d", storyID, len(commits), len(d.files), adds, dels)
+}
+
+// LoadIteration shows the changes an iteration made to its files, read from
+// the repository at dir. An iteration that committed its work shows its
+// commits' diff; one that didn't shows how those files differ from where it
+// started, as they are in the working tree now.
+func (d *DiffViewer) LoadIteration(dir string, r db.IterationRecord) {
+	d.reset(DiffScopeIteration)
+	d.iteration = r.Iteration
+
+	if r.StartCommit == "" {
+		d.err = fmt.Errorf("no git history was recorded for iteration %d", r.Iteration)
+		return
+	}
+	if len(r.Files) == 0 {
+		return
+	}
+
+	to, source := r.EndCommit, "commits "+shortHash(r.StartCommit)+".."+shortHash(r.EndCommit)
+	if to == r.StartCommit {
+		to, source = "", "uncommitted, as in the working tree now"
+	}
+	diff, err := git.DiffRange(dir, r.StartCommit, to, r.Files...)
+	if err != nil {
+		d.err = err
+		return
+	}
+	d.appendDiff(diff, -1)
+	d.stats = fmt.Sprintf("Iteration %d: %d files changed (%s)", r.Iteration, len(r.Files), source)
+}
+
+// appendDiff adds a unified diff, made by commits[commit] when commit isn't
+// -1, to the lines and files being shown.
+func (d *DiffViewer) appendDiff(diff string, commit int) {
 	if strings.TrimSpace(diff) == "" {
-		d.lines = nil
-		d.stats = ""
 		return
 	}
+	base := len(d.lines)
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	for _, f := range parseDiffFiles(lines) {
+		f.start += base
+		for i := range f.hunks {
+			f.hunks[i] += base
+		}
+		f.commit = commit
+		d.files = append(d.files, f)
+		d.hunks = append(d.hunks, f.hunks...)
+	}
+	d.lines = append(d.lines, lines...)
+	d.rows = nil
+}
 
-	d.lines = strings.Split(diff, "\n")
+// ToggleSideBySide switches between the unified and side-by-side layouts,
+// keeping the same place in the diff.
+func (d *DiffViewer) ToggleSideBySide() {
+	line := d.topLine()
+	d.sideBySide = !d.sideBySide
+	d.offset = d.rowOf(line)
+}
 
-	stats, err := git.GetDiffStats(d.baseDir)
-	if err == nil {
-		d.stats = stats
+// SideBySide reports whether the diff is laid out side by side, which needs
+// a wide enough pane.
+func (d *DiffViewer) SideBySide() bool {
+	return d.sideBySide && d.diffWidth() >= sideBySideMinWidth
+}
+
+// ToggleFiles moves the focus between the file list and the diff.
+func (d *DiffViewer) ToggleFiles() {
+	if len(d.files) > 0 {
+		d.focusFiles = !d.focusFiles
+	}
+}
+
+// FilesFocused reports whether the file list has the focus.
+func (d *DiffViewer) FilesFocused() bool {
+	return d.focusFiles
+}
+
+// showFileList reports whether the file list is drawn beside the diff.
+func (d *DiffViewer) showFileList() bool {
+	return len(d.files) > 0 && d.width >= fileListMinWidth
+}
+
+// fileListWidth is the width of the file list pane, including its border.
+func (d *DiffViewer) fileListWidth() int {
+	if !d.showFileList() {
+		return 0
+	}
+	return min(48, max(28, d.width/4))
+}
+
+// diffWidth is the width the diff itself is laid out in.
+func (d *DiffViewer) diffWidth() int {
+	return d.width - d.fileListWidth()
+}
+
+// layout lays lines out as rows for the current mode and width.
+func (d *DiffViewer) layout() {
+	width, split := d.diffWidth(), d.SideBySide()
+	if d.rows != nil && width == d.rowsWidth && split == d.rowsSplit {
+		return
+	}
+	line := -1
+	if d.rows != nil {
+		line = d.topLine()
+	}
+	if split {
+		d.rows = sideBySideRows(d.lines)
+	} else {
+		d.rows = make([]diffRow, len(d.lines))
+		for i, l := range d.lines {
+			d.rows[i] = diffRow{line: i, left: l, full: true}
+		}
+	}
+	d.rowsWidth, d.rowsSplit = width, split
+	if line >= 0 {
+		d.offset = d.rowOf(line)
+	}
+}
+
+// topLine returns the index in lines of the row at the top of the view.
+func (d *DiffViewer) topLine() int {
+	if d.offset < len(d.rows) {
+		return d.rows[d.offset].line
+	}
+	return 0
+}
+
+// rowOf returns the first row showing line.
+func (d *DiffViewer) rowOf(line int) int {
+	d.layout()
+	return sort.Search(len(d.rows), func(i int) bool { return d.rows[i].line >= line })
+}
+
+// fileAt returns the index of the file line belongs to.
+func (d *DiffViewer) fileAt(line int) int {
+	return max(0, sort.Search(len(d.files), func(i int) bool { return d.files[i].start > line })-1)
+}
+
+// CurrentFile returns the index of the selected file, and the number of files.
+func (d *DiffViewer) CurrentFile() (int, int) {
+	return d.fileCursor, len(d.files)
+}
+
+// jumpTo scrolls so that line is at the top of the view. Jumps may scroll
+// past the last full page so that the last file and hunk can be reached.
+func (d *DiffViewer) jumpTo(line int) {
+	d.offset = min(d.rowOf(line), max(0, len(d.rows)-1))
+}
+
+// scrolled updates the file cursor after the diff has scrolled.
+func (d *DiffViewer) scrolled() {
+	d.layout()
+	if len(d.files) > 0 {
+		d.fileCursor = d.fileAt(d.topLine())
 	}
 }
 
-// ScrollUp scrolls up one line.
+// SelectFile shows file i.
+func (d *DiffViewer) SelectFile(i int) {
+	if len(d.files) == 0 {
+		return
+	}
+	d.fileCursor = max(0, min(len(d.files)-1, i))
+	d.jumpTo(d.files[d.fileCursor].start)
+}
+
+// NextFile shows the next file.
+func (d *DiffViewer) NextFile() {
+	d.SelectFile(d.fileCursor + 1)
+}
+
+// PrevFile shows the previous file, or the start of the current one when the
+// view is part way through it.
+func (d *DiffViewer) PrevFile() {
+	if len(d.files) == 0 {
+		return
+	}
+	d.layout()
+	if d.topLine() > d.files[d.fileCursor].start {
+		d.SelectFile(d.fileCursor)
+		return
+	}
+	d.SelectFile(d.fileCursor - 1)
+}
+
+// NextHunk scrolls to the next hunk.
+func (d *DiffViewer) NextHunk() {
+	d.layout()
+	top := d.topLine()
+	i := sort.SearchInts(d.hunks, top+1)
+	if i < len(d.hunks) {
+		d.jumpTo(d.hunks[i])
+		d.fileCursor = d.fileAt(d.hunks[i])
+	}
+}
+
+// PrevHunk scrolls to the previous hunk.
+func (d *DiffViewer) PrevHunk() {
+	d.layout()
+	top := d.topLine()
+	i := sort.SearchInts(d.hunks, top) - 1
+	if i >= 0 {
+		d.jumpTo(d.hunks[i])
+		d.fileCursor = d.fileAt(d.hunks[i])
+	}
+}
+
+// ScrollUp scrolls up one line, or selects the previous file when the file
+// list has the focus.
 func (d *DiffViewer) ScrollUp() {
+	if d.focusFiles {
+		d.SelectFile(d.fileCursor - 1)
+		return
+	}
 	if d.offset > 0 {
 		d.offset--
 	}
+	d.scrolled()
 }
 
-// ScrollDown scrolls down one line.
+// ScrollDown scrolls down one line, or selects the next file when the file
+// list has the focus.
 func (d *DiffViewer) ScrollDown() {
+	if d.focusFiles {
+		d.SelectFile(d.fileCursor + 1)
+		return
+	}
 	maxOffset := d.maxOffset()
 	if d.offset < maxOffset {
 		d.offset++
 	}
+	d.scrolled()
 }
 
 // PageUp scrolls up half a page.
@@ -82,6 +403,7 @@ func (d *DiffViewer) PageUp() {
 	if d.offset < 0 {
 		d.offset = 0
 	}
+	d.scrolled()
 }
 
 // PageDown scrolls down half a page.
@@ -89,25 +411,30 @@ func (d *DiffViewer) PageDown() {
 	d.offset += d.height / 2
 	maxOffset := d.maxOffset()
 	if d.offset > maxOffset {
-		d.offset = maxOffset
+		// Past the last full page after a jump: stay put rather than scroll back
+		d.offset = max(maxOffset, d.offset-d.height/2)
 	}
+	d.scrolled()
 }
 
 // ScrollToTop scrolls to the top.
 func (d *DiffViewer) ScrollToTop() {
 	d.offset = 0
+	d.scrolled()
 }
 
 // ScrollToBottom scrolls to the bottom.
 func (d *DiffViewer) ScrollToBottom() {
 	d.offset = d.maxOffset()
+	d.scrolled()
 }
 
 func (d *DiffViewer) maxOffset() int {
-	if len(d.lines) <= d.height {
+	d.layout()
+	if len(d.rows) <= d.height {
 		return 0
 	}
-	return len(d.lines) - d.height
+	return len(d.rows) - d.height
 }
 
 // Render renders the diff view.
@@ -121,39 +448,60 @@ func (d *DiffViewer) Render() string {
 	}
 
 	if len(d.lines) == 0 {
+		if d.scope == DiffScopeStory {
+			return lipgloss.NewStyle().Foreground(MutedColor).Render(fmt.Sprintf("No commits found for %s. Commits are matched by a \"%s: %s\" trailer or the story ID in their subject.", d.storyID, git.StoryTrailer, d.storyID))
+		}
 		return lipgloss.NewStyle().Foreground(MutedColor).Render("No changes detected")
 	}
 
-	var content strings.Builder
+	d.layout()
 
-	// Render visible lines with syntax highlighting
-	visibleEnd := d.offset + d.height
-	if visibleEnd > len(d.lines) {
-		visibleEnd = len(d.lines)
+	// A narrow viewer shows the file list in place of the diff
+	if d.focusFiles && !d.showFileList() {
+		return strings.Join(d.renderFileList(d.width), "\n")
 	}
 
-	for i := d.offset; i < visibleEnd; i++ {
-		line := d.lines[i]
-		styled := d.styleLine(line)
+	diff := d.renderRows()
+	if !d.showFileList() {
+		return strings.Join(diff, "\n")
+	}
 
-		// Truncate to width
-		if lipgloss.Width(styled) > d.width {
-			// Re-style the truncated raw line
-			if len(line) > d.width-3 {
-				line = line[:d.width-3] + "..."
-			}
-			styled = d.styleLine(line)
+	listWidth := d.fileListWidth()
+	list := d.renderFileList(listWidth - 2)
+	border := lipgloss.NewStyle().Foreground(BorderColor).Render("│")
+	var content strings.Builder
+	for i := 0; i < d.height; i++ {
+		var left, right string
+		if i < len(list) {
+			left = list[i]
 		}
-
-		content.WriteString(styled)
-		if i < visibleEnd-1 {
+		if i < len(diff) {
+			right = diff[i]
+		}
+		content.WriteString(left + strings.Repeat(" ", max(0, listWidth-2-lipgloss.Width(left))) + " " + border + right)
+		if i < d.height-1 {
 			content.WriteString("\n")
 		}
 	}
-
 	return content.String()
 }
 
+// renderRows renders the rows on screen.
+func (d *DiffViewer) renderRows() []string {
+	width := d.diffWidth()
+	end := min(d.offset+d.height, len(d.rows))
+	var lines []string
+	for i := d.offset; i < end; i++ {
+		row := d.rows[i]
+		if row.full {
+			lines = append(lines, d.styleLine(fitWidth(row.left, width)))
+			continue
+		}
+		lines = append(lines, d.renderSplitRow(row, width))
+	}
+	return lines
+}
+
 // styleLine applies diff syntax highlighting to a single line.
 func (d *DiffViewer) styleLine(line string) string {
 	addStyle := lipgloss.NewStyle().Foreground(SuccessColor)
@@ -179,4 +527,3 @@ func (d *DiffViewer) styleLine(line string) string {
 		return line
 	}
 }
-
@@ -0,0 +1,258 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// diffFile is one file's section of a unified diff.
+type diffFile struct {
+	path   string
+	status byte // 'A'dded, 'D'eleted, 'R'enamed or 'M'odified
+	adds   int
+	dels   int
+	start  int   // Index of the file's "diff" line
+	hunks  []int // Indexes of its "@@" lines
+	commit int   // Index in DiffViewer.commits, or -1
+}
+
+// diffRow is one screen row of a laid out diff: a whole line, or an old and
+// a new line side by side.
+type diffRow struct {
+	line   int    // Index in the diff of the row's first line
+	left   string // The line, or the old side's
+	right  string // The new side's line
+	full   bool   // The row spans both sides
+	oldNum int    // Line numbers of the sides, 0 for none
+	newNum int
+}
+
+// parseDiffFiles finds the files and hunks in the lines of a unified diff.
+func parseDiffFiles(lines []string) []diffFile {
+	var files []diffFile
+	var f *diffFile
+	inHunk := false
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			files = append(files, diffFile{path: diffHeaderPath(line), status: 'M', start: i, commit: -1})
+			f = &files[len(files)-1]
+			inHunk = false
+		case f == nil:
+		case strings.HasPrefix(line, "@@"):
+			f.hunks = append(f.hunks, i)
+			inHunk = true
+		case inHunk:
+			if strings.HasPrefix(line, "+") {
+				f.adds++
+			} else if strings.HasPrefix(line, "-") {
+				f.dels++
+			}
+		case strings.HasPrefix(line, "new file"):
+			f.status = 'A'
+		case strings.HasPrefix(line, "deleted file"):
+			f.status = 'D'
+		case strings.HasPrefix(line, "rename to "):
+			f.status = 'R'
+			f.path = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "+++ ") && line != "+++ /dev/null":
+			f.path = diffSidePath(line[4:], "b/")
+		}
+	}
+	return files
+}
+
+// diffHeaderPath returns the path named by a "diff --git a/x b/x" or
+// "diff --cc x" line.
+func diffHeaderPath(line string) string {
+	if i := strings.LastIndex(line, " b/"); i >= 0 {
+		return strings.Trim(line[i+3:], `"`)
+	}
+	fields := strings.Fields(line)
+	return fields[len(fields)-1]
+}
+
+// diffSidePath returns the path of a "---" or "+++" line, without the
+// side's prefix.
+func diffSidePath(path, prefix string) string {
+	path = strings.Trim(strings.TrimRight(path, "\t"), `"`)
+	return strings.TrimPrefix(path, prefix)
+}
+
+// parseHunkHeader returns the old and new starting line numbers of a
+// "@@ -12,5 +12,7 @@" line.
+func parseHunkHeader(line string) (int, int) {
+	var oldStart, newStart int
+	for _, field := range strings.Fields(line)[1:] {
+		n, _ := strconv.Atoi(strings.SplitN(field[1:], ",", 2)[0])
+		switch field[0] {
+		case '-':
+			oldStart = n
+		case '+':
+			newStart = n
+			return oldStart, newStart
+		}
+	}
+	return oldStart, newStart
+}
+
+// sideBySideRows lays a unified diff out with the old lines on the left and
+// the new on the right. A run of removals and the additions after it are
+// paired up line by line; headers span both sides.
+func sideBySideRows(lines []string) []diffRow {
+	var rows []diffRow
+	var oldNum, newNum int
+	inHunk := false
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			inHunk = false
+		case strings.HasPrefix(line, "@@"):
+			oldNum, newNum = parseHunkHeader(line)
+			inHunk = true
+		case inHunk && (strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+")):
+			j := i
+			for j < len(lines) && strings.HasPrefix(lines[j], "-") {
+				j++
+			}
+			k := j
+			for k < len(lines) && strings.HasPrefix(lines[k], "+") {
+				k++
+			}
+			removed, added := lines[i:j], lines[j:k]
+			for n := 0; n < max(len(removed), len(added)); n++ {
+				row := diffRow{line: j + n}
+				if n < len(removed) {
+					row.line, row.left, row.oldNum = i+n, removed[n], oldNum
+					oldNum++
+				}
+				if n < len(added) {
+					row.right, row.newNum = added[n], newNum
+					newNum++
+				}
+				rows = append(rows, row)
+			}
+			i = k
+			continue
+		case inHunk && strings.HasPrefix(line, " "):
+			rows = append(rows, diffRow{line: i, left: line, right: line, oldNum: oldNum, newNum: newNum})
+			oldNum++
+			newNum++
+			i++
+			continue
+		}
+		rows = append(rows, diffRow{line: i, left: line, full: true})
+		i++
+	}
+	return rows
+}
+
+// renderSplitRow renders a side-by-side row in width columns.
+func (d *DiffViewer) renderSplitRow(row diffRow, width int) string {
+	half := (width - 1) / 2
+	numStyle := lipgloss.NewStyle().Foreground(MutedColor)
+	side := func(line string, num int) string {
+		gutter := "     "
+		if num > 0 {
+			gutter = fmt.Sprintf("%4d ", num)
+		}
+		if line == "" {
+			return numStyle.Render(gutter) + strings.Repeat(" ", max(0, half-len(gutter)))
+		}
+		text := padWidth(line[1:], half-len(gutter))
+		switch line[0] {
+		case '-':
+			text = lipgloss.NewStyle().Foreground(ErrorColor).Render(text)
+		case '+':
+			text = lipgloss.NewStyle().Foreground(SuccessColor).Render(text)
+		}
+		return numStyle.Render(gutter) + text
+	}
+	return side(row.left, row.oldNum) + lipgloss.NewStyle().Foreground(BorderColor).Render("│") + side(row.right, row.newNum)
+}
+
+// renderFileList renders the file list, width columns wide, scrolled to keep
+// the selected file on screen. Story diffs group the files by commit.
+func (d *DiffViewer) renderFileList(width int) []string {
+	type item struct {
+		text string
+		file int // -1 for a commit heading
+	}
+	var items []item
+	selected := 0
+	for i, f := range d.files {
+		if f.commit >= 0 && (i == 0 || d.files[i-1].commit != f.commit) {
+			c := d.commits[f.commit]
+			items = append(items, item{shortHash(c.Hash) + " " + c.Subject, -1})
+		}
+		if i == d.fileCursor {
+			selected = len(items)
+		}
+		items = append(items, item{"", i})
+	}
+
+	start := 0
+	if d.height > 0 && selected >= d.height {
+		start = selected - d.height + 1
+	}
+	end := min(len(items), start+max(d.height, 1))
+
+	statusColors := map[byte]lipgloss.Color{'A': SuccessColor, 'D': ErrorColor, 'R': WarningColor, 'M': PrimaryColor}
+	headingStyle := lipgloss.NewStyle().Foreground(MutedColor).Bold(true)
+	statStyle := lipgloss.NewStyle().Foreground(MutedColor)
+	var lines []string
+	for _, it := range items[start:end] {
+		if it.file < 0 {
+			lines = append(lines, headingStyle.Render(fitWidth(it.text, width)))
+			continue
+		}
+		f := d.files[it.file]
+		stat := fmt.Sprintf(" +%d -%d", f.adds, f.dels)
+		path := tailWidth(f.path, max(1, width-2-len(stat)))
+		line := string(f.status) + " " + path
+		gap := strings.Repeat(" ", max(0, width-len([]rune(line))-len(stat)))
+		if it.file == d.fileCursor {
+			style := lipgloss.NewStyle().Foreground(TextBrightColor).Bold(true)
+			if d.focusFiles {
+				style = style.Background(BgSelectedColor)
+			}
+			lines = append(lines, style.Render(line+gap+stat))
+			continue
+		}
+		status := lipgloss.NewStyle().Foreground(statusColors[f.status]).Render(string(f.status))
+		lines = append(lines, status+" "+path+gap+statStyle.Render(stat))
+	}
+	return lines
+}
+
+// fitWidth expands tabs and truncates text to width runes.
+func fitWidth(text string, width int) string {
+	runes := []rune(strings.ReplaceAll(text, "\t", "    "))
+	if len(runes) <= width {
+		return string(runes)
+	}
+	if width <= 3 {
+		return string(runes[:max(0, width)])
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+// padWidth fits text to exactly width runes.
+func padWidth(text string, width int) string {
+	text = fitWidth(text, width)
+	return text + strings.Repeat(" ", max(0, width-len([]rune(text))))
+}
+
+// tailWidth shortens a path to width runes by dropping its start, which
+// keeps the file name readable.
+func tailWidth(path string, width int) string {
+	runes := []rune(path)
+	if len(runes) <= width {
+		return path
+	}
+	return "…" + string(runes[len(runes)-width+1:])
+}
@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,4 @@
+ package main
+-func old() {}
++func new() {}
++func extra() {}
+ // end
+@@ -20,2 +20,2 @@
+-	a := 1
++	a := 2
+diff --git a/docs/new file.md b/docs/new file.md
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/docs/new file.md
+@@ -0,0 +1 @@
++# New
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+
+func TestParseDiffFiles(t *testing.T) {
+	files := parseDiffFiles(strings.Split(strings.TrimRight(testDiff, "\n"), "\n"))
+	want := []struct {
+		path       string
+		status     byte
+		adds, dels int
+		hunks      int
+	}{
+		{"main.go", 'M', 3, 2, 2},
+		{"docs/new file.md", 'A', 1, 0, 1},
+		{"gone.txt", 'D', 0, 1, 1},
+	}
+	if len(files) != len(want) {
+		t.Fatalf("parsed %d files, want %d: %+v", len(files), len(want), files)
+	}
+	for i, w := range want {
+		f := files[i]
+		if f.path != w.path || f.status != w.status || f.adds != w.adds || f.dels != w.dels || len(f.hunks) != w.hunks {
+			t.Errorf("file %d = %+v, want %+v", i, f, w)
+		}
+	}
+}
+
+func TestDiffViewer_Navigation(t *testing.T) {
+	d := NewDiffViewer("")
+	d.reset(DiffScopeBranch)
+	d.appendDiff(testDiff, -1)
+	d.SetSize(80, 5)
+
+	d.NextFile()
+	if file, files := d.CurrentFile(); file != 1 || files != 3 || d.topLine() != d.files[1].start {
+		t.Errorf("after NextFile() file %d/%d at line %d", file, files, d.topLine())
+	}
+	d.NextFile()
+	d.NextFile()
+	if file, _ := d.CurrentFile(); file != 2 || d.topLine() != d.files[2].start {
+		t.Errorf("NextFile() past the end: file %d at line %d", file, d.topLine())
+	}
+
+	d.ScrollToTop()
+	for _, want := range []int{d.hunks[0], d.hunks[1], d.hunks[2]} {
+		d.NextHunk()
+		if d.topLine() != want {
+			t.Errorf("NextHunk() at line %d, want %d", d.topLine(), want)
+		}
+	}
+	if file, _ := d.CurrentFile(); file != 1 {
+		t.Errorf("hunk in file %d, want 1", file)
+	}
+	d.PrevHunk()
+	if d.topLine() != d.hunks[1] {
+		t.Errorf("PrevHunk() at line %d, want %d", d.topLine(), d.hunks[1])
+	}
+
+	// PrevFile goes to the start of the current file first
+	d.PrevFile()
+	if d.topLine() != d.files[0].start {
+		t.Errorf("PrevFile() part way through at line %d, want %d", d.topLine(), d.files[0].start)
+	}
+}
+
+func TestDiffViewer_SideBySide(t *testing.T) {
+	d := NewDiffViewer("")
+	d.reset(DiffScopeBranch)
+	d.appendDiff(testDiff, -1)
+	d.SetSize(100, 40)
+
+	d.ToggleSideBySide()
+	if d.SideBySide() {
+		t.Error("side by side in a pane too narrow for it")
+	}
+	d.SetSize(200, 40)
+	if !d.SideBySide() {
+		t.Fatal("side by side not used on a wide terminal")
+	}
+	d.layout()
+
+	// "-func old" pairs with "+func new"; "+func extra" has no old side
+	var paired, added *diffRow
+	for i := range d.rows {
+		r := &d.rows[i]
+		switch r.right {
+		case "+func new() {}":
+			paired = r
+		case "+func extra() {}":
+			added = r
+		}
+	}
+	if paired == nil || paired.left != "-func old() {}" || paired.oldNum != 2 || paired.newNum != 2 {
+		t.Errorf("paired row = %+v", paired)
+	}
+	if added == nil || added.left != "" || added.newNum != 3 {
+		t.Errorf("added row = %+v", added)
+	}
+
+	// Toggling back keeps the place in the diff
+	d.NextFile()
+	d.ToggleSideBySide()
+	if d.topLine() != d.files[1].start {
+		t.Errorf("unified layout at line %d, want %d", d.topLine(), d.files[1].start)
+	}
+
+	out := d.Render()
+	if !strings.Contains(out, "A docs/new file.md") || !strings.Contains(out, "│") {
+		t.Errorf("Render() has no file list:\n%s", out)
+	}
+}
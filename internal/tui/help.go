@@ -59,6 +59,8 @@ func (h *HelpOverlay) GetCategories() []ShortcutCategory {
 		Shortcuts: []Shortcut{
 			{Key: "t", Description: "Toggle log view"},
 			{Key: "d", Description: "Toggle diff view"},
+			{Key: "v", Description: "Toggle transcript view"},
+			{Key: "i", Description: "Iteration timeline"},
 			{Key: "?", Description: "Help overlay"},
 		},
 	}
@@ -96,8 +98,83 @@ func (h *HelpOverlay) GetCategories() []ShortcutCategory {
 				{Key: "G", Description: "Go to bottom"},
 			},
 		}
+		if h.viewMode == ViewLog {
+			search := ShortcutCategory{
+				Name: "Search & Filter",
+				Shortcuts: []Shortcut{
+					{Key: "/", Description: "Search the log"},
+					{Key: "n / N", Description: "Next/previous match"},
+					{Key: "f", Description: "Filter (type: tool: story:)"},
+					{Key: "Esc", Description: "Clear search and filter"},
+					{Key: "w", Description: "Export the filtered log"},
+				},
+			}
+			return []ShortcutCategory{loopControl, views, scrolling, search, general}
+		}
+		if h.viewMode == ViewDiff {
+			browse := ShortcutCategory{
+				Name: "Diff Browser",
+				Shortcuts: []Shortcut{
+					{Key: "Tab", Description: "Focus the file list"},
+					{Key: "[ / ]", Description: "Previous/next file"},
+					{Key: "{ / }", Description: "Previous/next hunk"},
+					{Key: "|", Description: "Side by side (wide terminals)"},
+					{Key: "c", Description: "Selected story / whole branch"},
+				},
+			}
+			return []ShortcutCategory{prdControl, views, scrolling, browse, general}
+		}
 		return []ShortcutCategory{loopControl, prdControl, views, scrolling, general}
 
+	case ViewTimeline:
+		timeline := ShortcutCategory{
+			Name: "Timeline",
+			Shortcuts: []Shortcut{
+				{Key: "j / k", Description: "Select iteration"},
+				{Key: "Enter / d", Description: "Show the iteration's diff"},
+				{Key: "t", Description: "Jump to its log"},
+				{Key: "v", Description: "Open its transcript"},
+				{Key: "Esc / i", Description: "Back to the dashboard"},
+			},
+		}
+		return []ShortcutCategory{loopControl, views, timeline, general}
+
+	case ViewConflicts:
+		resolve := ShortcutCategory{
+			Name: "Resolve",
+			Shortcuts: []Shortcut{
+				{Key: "o / t / b", Description: "Take ours, theirs or both"},
+				{Key: "O / T", Description: "Whole file: ours or theirs"},
+				{Key: "a", Description: "Stage the file as it is"},
+				{Key: "u", Description: "Undo the hunk or file"},
+				{Key: "r", Description: "Ask the agent to resolve"},
+			},
+		}
+		merge := ShortcutCategory{
+			Name: "Merge",
+			Shortcuts: []Shortcut{
+				{Key: "j / k", Description: "Next/previous conflict"},
+				{Key: "[ / ]", Description: "Previous/next file"},
+				{Key: "f", Description: "Finish (commit) the merge"},
+				{Key: "x", Description: "Abort the merge"},
+				{Key: "Esc", Description: "Leave it in progress"},
+			},
+		}
+		return []ShortcutCategory{resolve, merge, general}
+
+	case ViewTranscript:
+		transcripts := ShortcutCategory{
+			Name: "Transcripts",
+			Shortcuts: []Shortcut{
+				{Key: "j / k", Description: "Select iteration / scroll"},
+				{Key: "Enter", Description: "Open transcript"},
+				{Key: "Esc", Description: "Back to the list"},
+				{Key: "Ctrl+D/U", Description: "Page down/up"},
+				{Key: "g / G", Description: "Go to top/bottom"},
+			},
+		}
+		return []ShortcutCategory{loopControl, views, transcripts, general}
+
 	case ViewPicker:
 		navigation := ShortcutCategory{
 			Name: "Navigation",
@@ -218,7 +218,7 @@ func TestGetWorktreeInfo_NoBranch(t *testing.T) {
 
 func TestGetWorktreeInfo_WithBranch(t *testing.T) {
 	mgr := loop.NewManager(10)
-	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "/tmp/.chief/worktrees/auth", "chief/auth")
+	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "", "/tmp/.chief/worktrees/auth", "chief/auth")
 
 	app := &App{prdName: "auth", manager: mgr}
 	branch, dir := app.getWorktreeInfo()
@@ -233,7 +233,7 @@ func TestGetWorktreeInfo_WithBranch(t *testing.T) {
 func TestGetWorktreeInfo_WithBranchNoWorktree(t *testing.T) {
 	// Branch set but no worktree dir (branch-only mode)
 	mgr := loop.NewManager(10)
-	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "", "chief/auth")
+	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "", "", "chief/auth")
 
 	app := &App{prdName: "auth", manager: mgr}
 	branch, dir := app.getWorktreeInfo()
@@ -266,7 +266,7 @@ func TestHasWorktreeInfo(t *testing.T) {
 
 	// With branch
 	mgr := loop.NewManager(10)
-	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "/tmp/.chief/worktrees/auth", "chief/auth")
+	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "", "/tmp/.chief/worktrees/auth", "chief/auth")
 	app.manager = mgr
 	if !app.hasWorktreeInfo() {
 		t.Error("expected hasWorktreeInfo=true with branch set")
@@ -282,7 +282,7 @@ func TestEffectiveHeaderHeight_NoBranch(t *testing.T) {
 
 func TestEffectiveHeaderHeight_WithBranch(t *testing.T) {
 	mgr := loop.NewManager(10)
-	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "/tmp/.chief/worktrees/auth", "chief/auth")
+	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "", "/tmp/.chief/worktrees/auth", "chief/auth")
 
 	app := &App{prdName: "auth", manager: mgr}
 	if got := app.effectiveHeaderHeight(); got != headerHeight+1 {
@@ -299,7 +299,7 @@ func TestRenderWorktreeInfoLine_NoBranch(t *testing.T) {
 
 func TestRenderWorktreeInfoLine_WithBranch(t *testing.T) {
 	mgr := loop.NewManager(10)
-	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "/tmp/.chief/worktrees/auth", "chief/auth")
+	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "", "/tmp/.chief/worktrees/auth", "chief/auth")
 
 	app := &App{prdName: "auth", manager: mgr}
 	got := app.renderWorktreeInfoLine()
@@ -322,7 +322,7 @@ func TestRenderWorktreeInfoLine_WithBranch(t *testing.T) {
 
 func TestRenderWorktreeInfoLine_BranchNoWorktree(t *testing.T) {
 	mgr := loop.NewManager(10)
-	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "", "chief/auth")
+	mgr.RegisterWithWorktree("auth", "/tmp/prd.json", "", "", "chief/auth")
 
 	app := &App{prdName: "auth", manager: mgr}
 	got := app.renderWorktreeInfoLine()
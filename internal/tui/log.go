@@ -2,47 +2,104 @@ package tui
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/formatters"
 	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/izdrail/chief/internal/logstore"
 	"github.com/izdrail/chief/internal/loop"
 )
 
 // LogEntry represents a single entry in the log viewer.
 type LogEntry struct {
 	Type      loop.EventType
-	Text      string
+	Text      string                 `json:",omitempty"`
+	Tool      string                 `json:",omitempty"`
+	ToolInput map[string]interface{} `json:",omitempty"`
+	StoryID   string                 `json:",omitempty"`
+	FilePath  string                 `json:",omitempty"` // For Read tool results, stores the file path for syntax highlighting
+	Iteration int                    `json:",omitempty"`
+	Time      time.Time              // When the event arrived
+}
+
+// logMeta is what the viewer keeps in memory for each entry: enough to filter,
+// lay out and find entries without reading them back from the log store.
+type logMeta struct {
+	Type      loop.EventType
 	Tool      string
-	ToolInput map[string]interface{}
 	StoryID   string
-	FilePath  string // For Read tool results, stores the file path for syntax highlighting
+	Iteration int
+	Time      time.Time
+	lines     int // Rendered height at the layout width
 }
 
-// LogViewer manages the log viewport state.
+// entry returns the entry's header fields, for filtering.
+func (m logMeta) entry() LogEntry {
+	return LogEntry{Type: m.Type, Tool: m.Tool, StoryID: m.StoryID, Iteration: m.Iteration, Time: m.Time}
+}
+
+// LogViewer manages the log viewport state. Entries are written to a
+// logstore.Store, which keeps only the newest in memory; the entries on
+// screen are read back from it as the log is scrolled.
 type LogViewer struct {
-	entries          []LogEntry
-	scrollPos        int    // Current scroll position (top line index)
-	height           int    // Viewport height (lines)
-	width            int    // Viewport width
-	autoScroll       bool   // Auto-scroll to bottom when new content arrives
-	lastReadFilePath string // Track the last Read tool's file path for syntax highlighting
+	store            *logstore.Store
+	meta             []logMeta // One per stored entry, oldest first
+	base             int       // Store index of meta[0]
+	layoutWidth      int       // Width the line counts in meta were computed at
+	scrollPos        int       // Current scroll position (top line index)
+	height           int       // Viewport height (lines)
+	width            int       // Viewport width
+	autoScroll       bool      // Auto-scroll to bottom when new content arrives
+	lastReadFilePath string    // Track the last Read tool's file path for syntax highlighting
+	lastTool         string    // Tool of the last tool call, attached to its result
+	currentStory     string    // Story being worked on, attached to every entry
+
+	// Search and filter, see log_search.go
+	filter       LogFilter
+	query        string
+	matchLine    int // Rendered line of the selected match, -1 for none
+	matchCache   logMatches
+	input        logInputMode
+	inputText    string
+	inputErr     string
+	searchOrigin int // Scroll position the search started from
 }
 
-// NewLogViewer creates a new log viewer.
+// NewLogViewer creates a new log viewer keeping the default number of
+// entries in memory.
 func NewLogViewer() *LogViewer {
+	return NewLogViewerWithOptions(logstore.Options{})
+}
+
+// NewLogViewerWithOptions creates a log viewer whose entries are stored in a
+// temporary directory with the given options. When the directory can't be
+// created, only the newest opts.Window entries are kept.
+func NewLogViewerWithOptions(opts logstore.Options) *LogViewer {
+	store, err := logstore.OpenTemp(opts)
+	if err != nil {
+		store = logstore.NewMemory(opts)
+	}
 	return &LogViewer{
-		entries:    make([]LogEntry, 0),
+		store:      store,
 		scrollPos:  0,
 		autoScroll: true,
+		matchLine:  -1,
 	}
 }
 
+// Close deletes the viewer's stored entries.
+func (l *LogViewer) Close() error {
+	return l.store.Close()
+}
+
 // AddEvent adds a loop event to the log.
 func (l *LogViewer) AddEvent(event loop.Event) {
 	entry := LogEntry{
@@ -51,6 +108,26 @@ func (l *LogViewer) AddEvent(event loop.Event) {
 		Tool:      event.Tool,
 		ToolInput: event.ToolInput,
 		StoryID:   event.StoryID,
+		Iteration: event.Iteration,
+		Time:      time.Now(),
+	}
+
+	// Tag entries with the story being worked on and results with their
+	// tool, so the log can be filtered by both
+	switch event.Type {
+	case loop.EventIterationStart:
+		l.currentStory = ""
+	case loop.EventStoryStarted:
+		l.currentStory = event.StoryID
+	case loop.EventToolStart:
+		l.lastTool = event.Tool
+	case loop.EventToolResult:
+		if entry.Tool == "" {
+			entry.Tool = l.lastTool
+		}
+	}
+	if entry.StoryID == "" {
+		entry.StoryID = l.currentStory
 	}
 
 	// Track Read tool file paths for syntax highlighting
@@ -68,20 +145,115 @@ func (l *LogViewer) AddEvent(event loop.Event) {
 
 	// Filter out events we don't want to display
 	switch event.Type {
-	case loop.EventAssistantText, loop.EventToolStart, loop.EventToolResult,
-		loop.EventStoryStarted, loop.EventComplete, loop.EventError, loop.EventRetrying:
-		l.entries = append(l.entries, entry)
+	case loop.EventIterationStart, loop.EventAssistantText, loop.EventToolStart, loop.EventToolResult,
+		loop.EventStoryStarted, loop.EventComplete, loop.EventError, loop.EventRetrying,
+		loop.EventQueueWait:
+		l.store.Append(encodeLogEntry(entry))
+		l.meta = append(l.meta, logMeta{
+			Type:      entry.Type,
+			Tool:      entry.Tool,
+			StoryID:   entry.StoryID,
+			Iteration: entry.Iteration,
+			Time:      entry.Time,
+			lines:     l.entryHeight(entry),
+		})
+		l.trim()
 	default:
-		// Skip iteration start, unknown events, etc.
+		// Skip unknown events, etc.
 		return
 	}
 
 	// Auto-scroll to bottom if enabled
-	if l.autoScroll && l.height > 0 {
+	if l.autoScroll && l.height > 0 && l.filter.Matches(entry) {
 		l.scrollToBottom()
 	}
 }
 
+// encodeLogEntry encodes an entry as a single-line log store record.
+func encodeLogEntry(entry LogEntry) []byte {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		// Tool input that doesn't encode; keep the rest of the entry
+		entry.ToolInput = nil
+		data, _ = json.Marshal(entry)
+	}
+	return data
+}
+
+// trim drops the entries the log store no longer keeps, keeping the view on
+// the same lines.
+func (l *LogViewer) trim() {
+	drop := min(l.store.First()-l.base, len(l.meta))
+	if drop <= 0 {
+		return
+	}
+	removed := 0
+	for _, m := range l.meta[:drop] {
+		if l.filter.Matches(m.entry()) {
+			removed += m.lines
+		}
+	}
+	l.meta = append([]logMeta(nil), l.meta[drop:]...)
+	l.base += drop
+	l.scrollPos = max(0, l.scrollPos-removed)
+	if l.matchLine >= 0 {
+		l.matchLine = max(-1, l.matchLine-removed)
+	}
+}
+
+// entry reads an entry back from the log store. i indexes meta.
+func (l *LogViewer) entry(i int) LogEntry {
+	data, err := l.store.Get(l.base + i)
+	if err != nil {
+		return LogEntry{Type: loop.EventError, Text: "log entry unavailable: " + err.Error(), Time: l.meta[i].Time}
+	}
+	return decodeLogEntry(data, l.meta[i])
+}
+
+// decodeLogEntry decodes a log store record, falling back to what's known of
+// the entry from m.
+func decodeLogEntry(data []byte, m logMeta) LogEntry {
+	var entry LogEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return LogEntry{Type: loop.EventError, Text: "log entry unreadable: " + err.Error(), Time: m.Time}
+	}
+	return entry
+}
+
+// eachEntry calls fn with every entry that passes the filter, oldest first,
+// reading them from the log store in order. i indexes meta.
+func (l *LogViewer) eachEntry(fn func(i int, entry LogEntry)) {
+	l.store.Scan(l.base, func(index int, data []byte) error {
+		i := index - l.base
+		if i >= len(l.meta) {
+			return errStopScan
+		}
+		if l.filter.Matches(l.meta[i].entry()) {
+			fn(i, decodeLogEntry(data, l.meta[i]))
+		}
+		return nil
+	})
+}
+
+// errStopScan ends a log store scan early.
+var errStopScan = errors.New("stop scan")
+
+// layout recomputes every entry's height when the width has changed since
+// they were computed.
+func (l *LogViewer) layout() {
+	if l.layoutWidth == l.width {
+		return
+	}
+	l.layoutWidth = l.width
+	filter := l.filter
+	l.filter = LogFilter{}
+	l.eachEntry(func(i int, entry LogEntry) {
+		l.meta[i].lines = l.entryHeight(entry)
+	})
+	l.filter = filter
+	l.matchCache = logMatches{}
+}
+
 // SetSize sets the viewport dimensions.
 func (l *LogViewer) SetSize(width, height int) {
 	l.width = width
@@ -155,10 +327,30 @@ func (l *LogViewer) ScrollToBottom() {
 	l.scrollToBottom()
 }
 
+// viewHeight returns the number of log lines shown, leaving room for the
+// search and filter status line.
+func (l *LogViewer) viewHeight() int {
+	if l.hasStatus() {
+		return max(1, l.height-1)
+	}
+	return l.height
+}
+
+// visible returns the positions in meta of the entries that pass the filter.
+func (l *LogViewer) visible() []int {
+	visible := make([]int, 0, len(l.meta))
+	for i, m := range l.meta {
+		if l.filter.Matches(m.entry()) {
+			visible = append(visible, i)
+		}
+	}
+	return visible
+}
+
 // maxScrollPos returns the maximum scroll position.
 func (l *LogViewer) maxScrollPos() int {
 	totalLines := l.totalLines()
-	maxPos := totalLines - l.height
+	maxPos := totalLines - l.viewHeight()
 	if maxPos < 0 {
 		return 0
 	}
@@ -167,34 +359,20 @@ func (l *LogViewer) maxScrollPos() int {
 
 // totalLines calculates the total number of rendered lines.
 func (l *LogViewer) totalLines() int {
-	if l.width <= 0 {
-		return len(l.entries)
-	}
-
+	l.layout()
 	total := 0
-	for _, entry := range l.entries {
-		total += l.entryHeight(entry)
+	for _, i := range l.visible() {
+		total += l.meta[i].lines
 	}
 	return total
 }
 
 // entryHeight calculates how many lines an entry takes.
 func (l *LogViewer) entryHeight(entry LogEntry) int {
-	switch entry.Type {
-	case loop.EventToolStart:
-		// Tool display is now a single line
-		return 1
-	case loop.EventToolResult:
-		// Tool result is typically compact
+	if l.width <= 0 {
 		return 1
-	default:
-		// Text entries: count wrapped lines
-		if entry.Text == "" {
-			return 1
-		}
-		wrapped := wrapText(entry.Text, l.width-4)
-		return strings.Count(wrapped, "\n") + 1
 	}
+	return len(l.renderEntry(entry))
 }
 
 // getToolIcon returns an emoji icon for a tool name.
@@ -202,8 +380,10 @@ func getToolIcon(toolName string) string {
 	switch toolName {
 	case "Read":
 		return "📖"
-	case "Edit":
+	case "Edit", "MultiEdit":
 		return "✏️"
+	case "ApplyPatch":
+		return "🩹"
 	case "Write":
 		return "📝"
 	case "Bash":
@@ -212,6 +392,10 @@ func getToolIcon(toolName string) string {
 		return "🔍"
 	case "Grep":
 		return "🔎"
+	case "Symbols", "Definition", "References":
+		return "🧭"
+	case "RecordLearning":
+		return "💡"
 	case "Task":
 		return "🤖"
 	case "WebFetch":
@@ -230,10 +414,25 @@ func getToolArgument(toolName string, input map[string]interface{}) string {
 	}
 
 	switch toolName {
-	case "Read", "Edit", "Write":
+	case "Read", "Edit", "MultiEdit", "Write":
 		if path, ok := input["file_path"].(string); ok {
 			return path
 		}
+	case "RecordLearning":
+		if learning, ok := input["learning"].(string); ok {
+			return learning
+		}
+	case "ApplyPatch":
+		if path, ok := input["file_path"].(string); ok && path != "" {
+			return path
+		}
+		if patch, ok := input["patch"].(string); ok {
+			for _, line := range strings.Split(patch, "\n") {
+				if strings.HasPrefix(line, "+++ ") {
+					return strings.TrimPrefix(strings.TrimPrefix(line, "+++ "), "b/")
+				}
+			}
+		}
 	case "Bash":
 		if cmd, ok := input["command"].(string); ok {
 			// Truncate long commands
@@ -250,6 +449,14 @@ func getToolArgument(toolName string, input map[string]interface{}) string {
 		if pattern, ok := input["pattern"].(string); ok {
 			return pattern
 		}
+	case "Symbols":
+		if path, ok := input["path"].(string); ok {
+			return path
+		}
+	case "Definition", "References":
+		if symbol, ok := input["symbol"].(string); ok {
+			return symbol
+		}
 	case "WebFetch", "WebSearch":
 		if url, ok := input["url"].(string); ok {
 			return url
@@ -266,6 +473,48 @@ func getToolArgument(toolName string, input map[string]interface{}) string {
 	return ""
 }
 
+// iterationMatchWindow is how far apart an iteration's recorded start and the
+// arrival of its start event can be for JumpToIteration to match them.
+const iterationMatchWindow = time.Minute
+
+// JumpToIteration scrolls to the start of an iteration and reports whether
+// it's in the log. Iteration numbers restart with each run, so the iteration
+// is matched by its number and the time it started. Jumping clears the filter,
+// which could hide the iteration.
+func (l *LogViewer) JumpToIteration(iteration int, started time.Time) bool {
+	best := -1
+	var bestGap time.Duration
+	for i, m := range l.meta {
+		if m.Type != loop.EventIterationStart || m.Iteration != iteration {
+			continue
+		}
+		gap := m.Time.Sub(started)
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap <= iterationMatchWindow && (best < 0 || gap < bestGap) {
+			best, bestGap = i, gap
+		}
+	}
+	if best < 0 {
+		return false
+	}
+	l.filter = LogFilter{}
+	l.layout()
+
+	// Count rendered lines, as Render scrolls by them
+	line, total := 0, 0
+	for i, m := range l.meta {
+		if i == best {
+			line = total
+		}
+		total += m.lines
+	}
+	l.scrollPos = max(0, min(line, total-l.viewHeight()))
+	l.autoScroll = false
+	return true
+}
+
 // IsAutoScrolling returns whether auto-scroll is enabled.
 func (l *LogViewer) IsAutoScrolling() bool {
 	return l.autoScroll
@@ -273,25 +522,39 @@ func (l *LogViewer) IsAutoScrolling() bool {
 
 // Clear clears all log entries.
 func (l *LogViewer) Clear() {
-	l.entries = make([]LogEntry, 0)
+	l.store.Reset()
+	l.meta = nil
+	l.base = 0
 	l.scrollPos = 0
 	l.autoScroll = true
+	l.lastTool = ""
+	l.currentStory = ""
+	l.matchLine = -1
+	l.matchCache = logMatches{}
 }
 
-// Render renders the log viewer content.
+// Render renders the log viewer content. Only the entries on screen are read
+// from the log store.
 func (l *LogViewer) Render() string {
-	if len(l.entries) == 0 {
+	if len(l.meta) == 0 {
 		emptyStyle := lipgloss.NewStyle().
 			Foreground(MutedColor).
 			Padding(1, 2)
 		return emptyStyle.Render("No log entries yet. Start the loop to see the agent's activity.")
 	}
 
-	// Build all lines
-	var allLines []string
-	for _, entry := range l.entries {
-		lines := l.renderEntry(entry)
-		allLines = append(allLines, lines...)
+	l.layout()
+	visible := l.visible()
+	status := l.statusLine()
+	height := l.viewHeight()
+	if len(visible) == 0 {
+		emptyStyle := lipgloss.NewStyle().Foreground(MutedColor)
+		return emptyStyle.Render("No log entries match the filter.") + "\n" + status
+	}
+
+	total := 0
+	for _, i := range visible {
+		total += l.meta[i].lines
 	}
 
 	// Apply scrolling
@@ -299,36 +562,71 @@ func (l *LogViewer) Render() string {
 	if startLine < 0 {
 		startLine = 0
 	}
-	if startLine >= len(allLines) {
-		startLine = len(allLines) - 1
-		if startLine < 0 {
-			startLine = 0
-		}
+	if startLine >= total {
+		startLine = max(0, total-1)
 	}
 
-	endLine := startLine + l.height
-	if endLine > len(allLines) {
-		endLine = len(allLines)
+	// Find the entry holding the first line, then render entries until the
+	// view is full
+	var visibleLines []string
+	line := 0
+	for _, i := range visible {
+		if len(visibleLines) >= height {
+			break
+		}
+		lines := l.meta[i].lines
+		if line+lines <= startLine {
+			line += lines
+			continue
+		}
+		rendered := l.renderEntry(l.entry(i))
+		if skip := startLine - line; skip > 0 && skip < len(rendered) {
+			rendered = rendered[skip:]
+		} else if skip >= len(rendered) {
+			rendered = nil
+		}
+		visibleLines = append(visibleLines, rendered...)
+		line += lines
+	}
+	if len(visibleLines) > height {
+		visibleLines = visibleLines[:height]
 	}
 
-	visibleLines := allLines[startLine:endLine]
+	if l.query != "" {
+		query := strings.ToLower(l.query)
+		for i, line := range visibleLines {
+			if strings.Contains(strings.ToLower(stripANSI(line)), query) {
+				visibleLines[i] = l.highlightMatches(line, startLine+i == l.matchLine)
+			}
+		}
+	}
 
 	// Add cursor indicator at bottom if streaming
 	content := strings.Join(visibleLines, "\n")
-	if l.autoScroll && len(l.entries) > 0 {
-		lastEntry := l.entries[len(l.entries)-1]
-		if lastEntry.Type == loop.EventAssistantText || lastEntry.Type == loop.EventToolStart {
+	if l.autoScroll && status == "" {
+		last := l.meta[len(l.meta)-1]
+		if last.Type == loop.EventAssistantText || last.Type == loop.EventToolStart {
 			cursorStyle := lipgloss.NewStyle().Foreground(PrimaryColor).Blink(true)
 			content += "\n" + cursorStyle.Render("▌")
 		}
 	}
 
+	// Pin the search and filter status to the bottom of the view
+	if status != "" {
+		if pad := height - len(visibleLines); pad > 0 {
+			content += strings.Repeat("\n", pad)
+		}
+		content += "\n" + status
+	}
+
 	return content
 }
 
 // renderEntry renders a single log entry as lines.
 func (l *LogViewer) renderEntry(entry LogEntry) []string {
 	switch entry.Type {
+	case loop.EventIterationStart:
+		return l.renderIterationStart(entry)
 	case loop.EventToolStart:
 		return l.renderToolCard(entry)
 	case loop.EventToolResult:
@@ -341,6 +639,8 @@ func (l *LogViewer) renderEntry(entry LogEntry) []string {
 		return l.renderError(entry)
 	case loop.EventRetrying:
 		return l.renderRetrying(entry)
+	case loop.EventQueueWait:
+		return l.renderQueueWait(entry)
 	default:
 		return l.renderText(entry)
 	}
@@ -534,6 +834,13 @@ func stripLineNumbers(code string) string {
 	return strings.Join(result, "\n")
 }
 
+// renderIterationStart renders the divider that starts an iteration.
+func (l *LogViewer) renderIterationStart(entry LogEntry) []string {
+	label := fmt.Sprintf("── Iteration %d · %s ", entry.Iteration, entry.Time.Format("15:04:05"))
+	fill := max(0, l.width-4-lipgloss.Width(label))
+	return []string{lipgloss.NewStyle().Foreground(MutedColor).Render(label + strings.Repeat("─", fill))}
+}
+
 // renderStoryStarted renders a story started marker.
 func (l *LogViewer) renderStoryStarted(entry LogEntry) []string {
 	storyStyle := lipgloss.NewStyle().
@@ -598,3 +905,9 @@ func (l *LogViewer) renderRetrying(entry LogEntry) []string {
 
 	return []string{retryStyle.Render("🔄 " + text)}
 }
+
+// renderQueueWait renders the time a request waited for the shared LLM endpoint.
+func (l *LogViewer) renderQueueWait(entry LogEntry) []string {
+	waitStyle := lipgloss.NewStyle().Foreground(MutedColor)
+	return []string{waitStyle.Render("⏳ " + entry.Text)}
+}
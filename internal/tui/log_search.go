@@ -0,0 +1,479 @@
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/izdrail/chief/internal/loop"
+)
+
+// logInputMode is what the log viewer's prompt is reading.
+type logInputMode int
+
+const (
+	logInputNone logInputMode = iota
+	logInputSearch
+	logInputFilter
+)
+
+// logTypeNames maps the event type names used in filters to event types.
+var logTypeNames = map[string]loop.EventType{
+	"iteration": loop.EventIterationStart,
+	"text":      loop.EventAssistantText,
+	"tool":      loop.EventToolStart,
+	"result":    loop.EventToolResult,
+	"story":     loop.EventStoryStarted,
+	"complete":  loop.EventComplete,
+	"error":     loop.EventError,
+	"retry":     loop.EventRetrying,
+	"wait":      loop.EventQueueWait,
+}
+
+// logTypeName returns the filter name of an event type.
+func logTypeName(t loop.EventType) string {
+	for name, et := range logTypeNames {
+		if et == t {
+			return name
+		}
+	}
+	return strings.ToLower(t.String())
+}
+
+// LogFilter selects log entries by event type, tool and story. An empty field
+// matches every entry; a filter matches an entry when all its fields do.
+type LogFilter struct {
+	Types []loop.EventType
+	Tools []string
+	Story string
+}
+
+// ParseLogFilter parses a filter such as "type:tool,result tool:Bash
+// story:US-002". Type names are iteration, text, tool, result, story,
+// complete, error, retry and wait.
+func ParseLogFilter(s string) (LogFilter, error) {
+	var f LogFilter
+	for _, field := range strings.Fields(s) {
+		key, value, ok := strings.Cut(field, ":")
+		if !ok || value == "" {
+			return LogFilter{}, fmt.Errorf("%q isn't key:value (type:, tool: or story:)", field)
+		}
+		switch strings.ToLower(key) {
+		case "type":
+			for _, name := range strings.Split(value, ",") {
+				t, ok := logTypeNames[strings.ToLower(name)]
+				if !ok {
+					return LogFilter{}, fmt.Errorf("unknown event type %q", name)
+				}
+				f.Types = append(f.Types, t)
+			}
+		case "tool":
+			f.Tools = append(f.Tools, strings.Split(value, ",")...)
+		case "story":
+			f.Story = value
+		default:
+			return LogFilter{}, fmt.Errorf("unknown filter %q (type:, tool: or story:)", key)
+		}
+	}
+	return f, nil
+}
+
+// IsEmpty reports whether the filter matches every entry.
+func (f LogFilter) IsEmpty() bool {
+	return len(f.Types) == 0 && len(f.Tools) == 0 && f.Story == ""
+}
+
+// Matches reports whether an entry passes the filter. A tool filter matches
+// tool calls and their results.
+func (f LogFilter) Matches(e LogEntry) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == e.Type {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Tools) > 0 {
+		found := false
+		for _, tool := range f.Tools {
+			if strings.EqualFold(tool, e.Tool) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return f.Story == "" || strings.EqualFold(f.Story, e.StoryID)
+}
+
+// String formats the filter the way ParseLogFilter reads it.
+func (f LogFilter) String() string {
+	var parts []string
+	if len(f.Types) > 0 {
+		names := make([]string, len(f.Types))
+		for i, t := range f.Types {
+			names[i] = logTypeName(t)
+		}
+		parts = append(parts, "type:"+strings.Join(names, ","))
+	}
+	if len(f.Tools) > 0 {
+		parts = append(parts, "tool:"+strings.Join(f.Tools, ","))
+	}
+	if f.Story != "" {
+		parts = append(parts, "story:"+f.Story)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ansiPattern matches the SGR escape sequences lipgloss styles text with.
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// stripANSI removes styling from a rendered line.
+func stripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// IsInputting reports whether the search or filter prompt is open.
+func (l *LogViewer) IsInputting() bool {
+	return l.input != logInputNone
+}
+
+// StartSearch opens the search prompt. The search runs as the query is typed.
+func (l *LogViewer) StartSearch() {
+	l.input = logInputSearch
+	l.inputText = ""
+	l.inputErr = ""
+	l.searchOrigin = l.scrollPos
+}
+
+// StartFilter opens the filter prompt with the current filter.
+func (l *LogViewer) StartFilter() {
+	l.input = logInputFilter
+	l.inputText = l.filter.String()
+	l.inputErr = ""
+}
+
+// AddInputChar types a character into the prompt.
+func (l *LogViewer) AddInputChar(r rune) {
+	l.inputText += string(r)
+	if l.input == logInputSearch {
+		l.search(l.inputText)
+	}
+}
+
+// DeleteInputChar deletes the last character of the prompt.
+func (l *LogViewer) DeleteInputChar() {
+	if l.inputText == "" {
+		return
+	}
+	runes := []rune(l.inputText)
+	l.inputText = string(runes[:len(runes)-1])
+	if l.input == logInputSearch {
+		l.search(l.inputText)
+	}
+}
+
+// SubmitInput closes the prompt, keeping the search or applying the filter.
+// A filter that doesn't parse leaves the prompt open with the error.
+func (l *LogViewer) SubmitInput() {
+	switch l.input {
+	case logInputSearch:
+		if l.inputText == "" {
+			l.ClearSearch()
+		}
+	case logInputFilter:
+		f, err := ParseLogFilter(l.inputText)
+		if err != nil {
+			l.inputErr = err.Error()
+			return
+		}
+		l.SetFilter(f)
+	}
+	l.input = logInputNone
+	l.inputText = ""
+	l.inputErr = ""
+}
+
+// CancelInput closes the prompt. A cancelled search scrolls back to where it
+// started.
+func (l *LogViewer) CancelInput() {
+	if l.input == logInputSearch {
+		l.ClearSearch()
+		l.scrollPos = min(l.searchOrigin, l.maxScrollPos())
+	}
+	l.input = logInputNone
+	l.inputText = ""
+	l.inputErr = ""
+}
+
+// SetFilter shows only the entries that pass f.
+func (l *LogViewer) SetFilter(f LogFilter) {
+	l.filter = f
+	l.matchLine = -1
+	if l.query != "" {
+		l.search(l.query)
+	} else {
+		l.scrollToBottom()
+	}
+}
+
+// Filter returns the active filter.
+func (l *LogViewer) Filter() LogFilter {
+	return l.filter
+}
+
+// ClearSearch drops the search query and its highlights.
+func (l *LogViewer) ClearSearch() {
+	l.query = ""
+	l.matchLine = -1
+}
+
+// ClearSearchAndFilter drops the search and the filter, and reports whether
+// there was either.
+func (l *LogViewer) ClearSearchAndFilter() bool {
+	if l.query == "" && l.filter.IsEmpty() {
+		return false
+	}
+	l.ClearSearch()
+	l.SetFilter(LogFilter{})
+	return true
+}
+
+// logMatches caches the lines matching a search, which are found by reading
+// and rendering the whole log.
+type logMatches struct {
+	query   string
+	filter  string
+	entries int // Entries stored when the search ran
+	base    int
+	lines   []int
+}
+
+// matches returns the rendered lines containing the query, case-insensitively.
+func (l *LogViewer) matches() []int {
+	if l.query == "" {
+		return nil
+	}
+	l.layout()
+	key := logMatches{query: l.query, filter: l.filter.String(), entries: len(l.meta), base: l.base}
+	if c := l.matchCache; c.query == key.query && c.filter == key.filter && c.entries == key.entries && c.base == key.base {
+		return c.lines
+	}
+
+	query := strings.ToLower(l.query)
+	line := 0
+	l.eachEntry(func(_ int, entry LogEntry) {
+		for _, text := range l.renderEntry(entry) {
+			if strings.Contains(strings.ToLower(stripANSI(text)), query) {
+				key.lines = append(key.lines, line)
+			}
+			line++
+		}
+	})
+	l.matchCache = key
+	return key.lines
+}
+
+// search sets the query and moves to its first match at or below where the
+// search started.
+func (l *LogViewer) search(query string) {
+	l.query = query
+	l.matchLine = -1
+	found := l.matches()
+	if len(found) == 0 {
+		return
+	}
+	i := sort.SearchInts(found, l.searchOrigin)
+	if i == len(found) {
+		i = 0
+	}
+	l.showMatch(found[i])
+}
+
+// NextMatch moves to the next match, wrapping to the first.
+func (l *LogViewer) NextMatch() {
+	found := l.matches()
+	if len(found) == 0 {
+		return
+	}
+	i := sort.SearchInts(found, l.matchLine+1)
+	if i == len(found) {
+		i = 0
+	}
+	l.showMatch(found[i])
+}
+
+// PrevMatch moves to the previous match, wrapping to the last.
+func (l *LogViewer) PrevMatch() {
+	found := l.matches()
+	if len(found) == 0 {
+		return
+	}
+	i := sort.SearchInts(found, l.matchLine) - 1
+	if i < 0 || l.matchLine < 0 {
+		i = len(found) - 1
+	}
+	l.showMatch(found[i])
+}
+
+// showMatch selects a matching line and scrolls it into the upper part of the
+// view.
+func (l *LogViewer) showMatch(line int) {
+	l.matchLine = line
+	l.scrollPos = max(0, min(line-l.viewHeight()/3, l.maxScrollPos()))
+	l.autoScroll = false
+}
+
+// highlightMatches re-renders a matching line plainly with the query
+// highlighted; the selected match stands out from the others.
+func (l *LogViewer) highlightMatches(line string, selected bool) string {
+	plain := stripANSI(line)
+	lower := strings.ToLower(plain)
+	query := strings.ToLower(l.query)
+
+	matchStyle := lipgloss.NewStyle().Background(WarningColor).Foreground(BgColor)
+	if selected {
+		matchStyle = lipgloss.NewStyle().Background(PrimaryColor).Foreground(BgColor).Bold(true)
+	}
+	textStyle := lipgloss.NewStyle().Foreground(TextColor)
+
+	// Lowercasing can change byte lengths outside ASCII; fall back to
+	// marking the whole line rather than cutting a rune in half.
+	if len(lower) != len(plain) {
+		return matchStyle.Render(plain)
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.Index(lower, query)
+		if i < 0 {
+			b.WriteString(textStyle.Render(plain))
+			break
+		}
+		b.WriteString(textStyle.Render(plain[:i]))
+		b.WriteString(matchStyle.Render(plain[i : i+len(query)]))
+		plain, lower = plain[i+len(query):], lower[i+len(query):]
+	}
+	return b.String()
+}
+
+// hasStatus reports whether the view has a status line.
+func (l *LogViewer) hasStatus() bool {
+	return l.input != logInputNone || l.query != "" || !l.filter.IsEmpty()
+}
+
+// statusLine renders the prompt, or the active search and filter, or "" when
+// there's neither.
+func (l *LogViewer) statusLine() string {
+	if !l.hasStatus() {
+		return ""
+	}
+	promptStyle := lipgloss.NewStyle().Foreground(PrimaryColor).Bold(true)
+	muted := lipgloss.NewStyle().Foreground(MutedColor)
+
+	switch l.input {
+	case logInputSearch:
+		return promptStyle.Render("/") + l.inputText + "▌" + "  " + muted.Render(l.matchCount())
+	case logInputFilter:
+		line := promptStyle.Render("filter: ") + l.inputText + "▌"
+		if l.inputErr != "" {
+			return line + "  " + lipgloss.NewStyle().Foreground(ErrorColor).Render(l.inputErr)
+		}
+		return line + "  " + muted.Render("type:text,tool,result,story,error,retry,wait,iteration,complete tool:NAME story:ID")
+	}
+
+	var parts []string
+	if l.query != "" {
+		parts = append(parts, fmt.Sprintf("/%s  %s  n/N: next/prev", l.query, l.matchCount()))
+	}
+	if !l.filter.IsEmpty() {
+		parts = append(parts, fmt.Sprintf("filter: %s  (%d of %d entries)", l.filter, len(l.visible()), len(l.meta)))
+	}
+	return muted.Render(strings.Join(parts, "  │  ") + "  │  esc: clear")
+}
+
+// matchCount describes where the selected match is among all matches.
+func (l *LogViewer) matchCount() string {
+	found := l.matches()
+	if len(found) == 0 {
+		if l.query == "" {
+			return ""
+		}
+		return "no matches"
+	}
+	current := sort.SearchInts(found, l.matchLine)
+	if current == len(found) || found[current] != l.matchLine {
+		return fmt.Sprintf("%d matches", len(found))
+	}
+	return fmt.Sprintf("%d/%d", current+1, len(found))
+}
+
+// Export writes the entries that pass the filter to path as plain text, with
+// full tool results rather than the one-line summaries shown on screen.
+func (l *LogViewer) Export(path string) (int, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if !l.filter.IsEmpty() {
+		fmt.Fprintf(w, "# filter: %s\n", l.filter)
+	}
+	n := 0
+	l.eachEntry(func(_ int, entry LogEntry) {
+		w.WriteString(exportEntry(entry))
+		n++
+	})
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	return n, f.Close()
+}
+
+// exportEntry formats an entry as a header line, then its text indented.
+func exportEntry(e LogEntry) string {
+	head := []string{e.Time.Format("2006-01-02 15:04:05"), fmt.Sprintf("%-9s", logTypeName(e.Type))}
+	if e.Iteration > 0 {
+		head = append(head, fmt.Sprintf("#%d", e.Iteration))
+	}
+	if e.StoryID != "" {
+		head = append(head, e.StoryID)
+	}
+	if e.Tool != "" {
+		head = append(head, e.Tool)
+		if e.Type == loop.EventToolStart {
+			if arg := getToolArgument(e.Tool, e.ToolInput); arg != "" {
+				head = append(head, arg)
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Join(head, "  "))
+	b.WriteString("\n")
+	if text := strings.TrimRight(e.Text, "\n"); text != "" {
+		for _, line := range strings.Split(text, "\n") {
+			b.WriteString("    ")
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
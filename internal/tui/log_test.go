@@ -1,6 +1,13 @@
 package tui
 
-import "testing"
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/izdrail/chief/internal/logstore"
+	"github.com/izdrail/chief/internal/loop"
+)
 
 func TestGetToolIcon(t *testing.T) {
 	tests := []struct {
@@ -123,20 +130,20 @@ func TestNewLogViewer(t *testing.T) {
 	if !lv.autoScroll {
 		t.Error("Expected autoScroll to be true by default")
 	}
-	if len(lv.entries) != 0 {
+	if len(lv.meta) != 0 {
 		t.Error("Expected entries to be empty")
 	}
 }
 
 func TestLogViewer_Clear(t *testing.T) {
 	lv := NewLogViewer()
-	lv.entries = []LogEntry{{Text: "test"}}
+	lv.AddEvent(loop.Event{Type: loop.EventAssistantText, Text: "test"})
 	lv.scrollPos = 5
 	lv.autoScroll = false
 
 	lv.Clear()
 
-	if len(lv.entries) != 0 {
+	if len(lv.meta) != 0 || lv.store.Len() != 0 {
 		t.Error("Expected entries to be empty after Clear")
 	}
 	if lv.scrollPos != 0 {
@@ -147,6 +154,29 @@ func TestLogViewer_Clear(t *testing.T) {
 	}
 }
 
+func TestLogViewer_PagesFromStore(t *testing.T) {
+	lv := NewLogViewerWithOptions(logstore.Options{Window: 10})
+	defer lv.Close()
+	lv.SetSize(80, 5)
+	for i := 0; i < 200; i++ {
+		lv.AddEvent(loop.Event{Type: loop.EventAssistantText, Text: fmt.Sprintf("message %d", i)})
+	}
+
+	if !strings.Contains(lv.Render(), "message 199") {
+		t.Error("Expected the newest entry at the bottom")
+	}
+	lv.ScrollToTop()
+	if out := lv.Render(); !strings.Contains(out, "message 0") || strings.Contains(out, "message 5") {
+		t.Errorf("Expected the oldest entries, read back from disk, got:\n%s", out)
+	}
+	for i := 0; i < 100; i++ {
+		lv.ScrollDown()
+	}
+	if out := lv.Render(); !strings.Contains(out, "message 100") {
+		t.Errorf("Expected entry 100 at the top, got:\n%s", out)
+	}
+}
+
 func TestLogViewer_SetSize(t *testing.T) {
 	lv := NewLogViewer()
 	lv.SetSize(100, 50)
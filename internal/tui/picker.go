@@ -5,11 +5,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/izdrail/chief/internal/db"
 	"github.com/izdrail/chief/internal/git"
 	"github.com/izdrail/chief/internal/loop"
 	"github.com/izdrail/chief/internal/prd"
+	"github.com/izdrail/chief/internal/scheduler"
 )
 
 // PRDEntry represents a PRD in the picker list.
@@ -26,6 +29,8 @@ type PRDEntry struct {
 	Branch      string         // Git branch for this PRD (empty = no branch)
 	WorktreeDir string         // Worktree directory (empty = current directory)
 	Orphaned    bool           // True if worktree exists on disk but no running PRD tracks it
+	QueuePos    int            // Position in the chief serve run queue (0 = not queued)
+	NextRun     time.Time      // Next scheduled run (zero = not scheduled)
 }
 
 // MergeResult holds the result of a merge operation for display.
@@ -92,6 +97,7 @@ type PRDPicker struct {
 	cleanConfirmation  *CleanConfirmation // Active clean confirmation dialog (nil = none)
 	cleanResult        *CleanResult       // Result of the last clean operation (nil = none)
 	creationTasks      map[string]*CreationStatus // Map of active creation tasks
+	store              *db.Store                  // Store holding the chief serve run queue and schedules (nil = none)
 }
 
 // NewPRDPicker creates a new PRD picker.
@@ -116,6 +122,12 @@ func (p *PRDPicker) SetManager(manager *loop.Manager) {
 	p.manager = manager
 }
 
+// SetStore sets the store used to show queued and scheduled runs.
+func (p *PRDPicker) SetStore(store *db.Store) {
+	p.store = store
+	p.Refresh()
+}
+
 // Refresh reloads the list of PRDs from the .chief/prds/ directory.
 func (p *PRDPicker) Refresh() {
 	p.entries = make([]PRDEntry, 0)
@@ -209,6 +221,8 @@ func (p *PRDPicker) Refresh() {
 		}
 	}
 
+	p.annotateQueue()
+
 	// Ensure selected index is valid
 	if p.selectedIndex >= len(p.entries) {
 		p.selectedIndex = len(p.entries) - 1
@@ -258,6 +272,34 @@ func (p *PRDPicker) loadPRDEntry(name, prdPath string) PRDEntry {
 	return prdEntry
 }
 
+// annotateQueue marks entries with their place in the run queue and their
+// next scheduled run.
+func (p *PRDPicker) annotateQueue() {
+	if p.store == nil {
+		return
+	}
+	queued, _ := p.store.ListQueue()
+	schedules, _ := p.store.ListSchedules()
+
+	positions := make(map[string]int)
+	pos := 0
+	for _, e := range queued {
+		if e.Status == db.QueueQueued {
+			pos++
+			positions[e.PRDName] = pos
+		}
+	}
+	nextRuns := make(map[string]time.Time)
+	for _, sched := range schedules {
+		nextRuns[sched.PRDName] = scheduler.NextRun(sched, time.Now())
+	}
+
+	for i := range p.entries {
+		p.entries[i].QueuePos = positions[p.entries[i].Name]
+		p.entries[i].NextRun = nextRuns[p.entries[i].Name]
+	}
+}
+
 // SetSize sets the modal dimensions.
 func (p *PRDPicker) SetSize(width, height int) {
 	p.width = width
@@ -684,6 +726,12 @@ func (p *PRDPicker) renderEntry(entry PRDEntry, selected bool, width int) string
 		line.WriteString(" ")
 		line.WriteString(p.renderLoopStateIndicator(entry))
 
+		// Run queue and schedule indicators
+		if badge := p.renderQueueBadge(entry); badge != "" {
+			line.WriteString(" ")
+			line.WriteString(badge)
+		}
+
 		// Orphaned worktree indicator (for entries with PRD but orphaned worktree)
 		if entry.Orphaned {
 			orphanedStyle := lipgloss.NewStyle().Foreground(WarningColor)
@@ -766,6 +814,22 @@ func (p *PRDPicker) formatBranchPath(branch, path string, maxWidth int) string {
 	return prefix + string(branchRunes)
 }
 
+// renderQueueBadge renders an entry's place in the run queue, or its next
+// scheduled run when it isn't queued.
+func (p *PRDPicker) renderQueueBadge(entry PRDEntry) string {
+	if entry.QueuePos > 0 {
+		return lipgloss.NewStyle().Foreground(WarningColor).Render(fmt.Sprintf("queued #%d", entry.QueuePos))
+	}
+	if !entry.NextRun.IsZero() {
+		format := "15:04"
+		if entry.NextRun.Sub(time.Now()) >= 24*time.Hour {
+			format = "Jan 2 15:04"
+		}
+		return lipgloss.NewStyle().Foreground(MutedColor).Render("⏰ " + entry.NextRun.Format(format))
+	}
+	return ""
+}
+
 // renderLoopStateIndicator renders a visual indicator for the loop state.
 func (p *PRDPicker) renderLoopStateIndicator(entry PRDEntry) string {
 	switch entry.LoopState {
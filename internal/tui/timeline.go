@@ -0,0 +1,215 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/izdrail/chief/internal/db"
+)
+
+// TimelineViewer lists a PRD's iterations, newest first, with the story each
+// worked on, how long it took, its tool calls, its outcome and the files it
+// changed. The selected iteration's log, transcript and diff are opened from
+// the app.
+type TimelineViewer struct {
+	store   *db.Store
+	records []db.IterationRecord // Newest first
+	cursor  int
+	width   int
+	height  int
+	err     error
+}
+
+// NewTimelineViewer creates a timeline reading the iteration history in
+// store, which may be nil.
+func NewTimelineViewer(store *db.Store) *TimelineViewer {
+	return &TimelineViewer{store: store}
+}
+
+// SetSize sets the viewport dimensions.
+func (t *TimelineViewer) SetSize(width, height int) {
+	t.width = width
+	t.height = height
+}
+
+// Load reads the iteration history of a PRD. The selection stays on the same
+// iteration when it's still listed.
+func (t *TimelineViewer) Load(prdName string) {
+	var selected int64
+	if r := t.Selected(); r != nil {
+		selected = r.ID
+	}
+	t.records = nil
+	t.cursor = 0
+	t.err = nil
+	if t.store == nil {
+		t.err = fmt.Errorf("the iteration timeline requires the SQLite store (.chief/chief.db)")
+		return
+	}
+
+	records, err := t.store.GetIterations(prdName)
+	if err != nil {
+		t.err = err
+		return
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].ID == selected {
+			t.cursor = len(t.records)
+		}
+		t.records = append(t.records, records[i])
+	}
+}
+
+// Selected returns the selected iteration, or nil if there are none.
+func (t *TimelineViewer) Selected() *db.IterationRecord {
+	if t.cursor >= len(t.records) {
+		return nil
+	}
+	return &t.records[t.cursor]
+}
+
+// ScrollUp selects the next newer iteration.
+func (t *TimelineViewer) ScrollUp() {
+	if t.cursor > 0 {
+		t.cursor--
+	}
+}
+
+// ScrollDown selects the next older iteration.
+func (t *TimelineViewer) ScrollDown() {
+	if t.cursor < len(t.records)-1 {
+		t.cursor++
+	}
+}
+
+// PageUp moves the selection up half a page.
+func (t *TimelineViewer) PageUp() {
+	t.cursor = max(0, t.cursor-t.listHeight()/2)
+}
+
+// PageDown moves the selection down half a page.
+func (t *TimelineViewer) PageDown() {
+	t.cursor = max(0, min(len(t.records)-1, t.cursor+t.listHeight()/2))
+}
+
+// ScrollToTop selects the newest iteration.
+func (t *TimelineViewer) ScrollToTop() {
+	t.cursor = 0
+}
+
+// ScrollToBottom selects the oldest iteration.
+func (t *TimelineViewer) ScrollToBottom() {
+	t.cursor = max(0, len(t.records)-1)
+}
+
+// detailHeight is the number of lines below the list given to the selected
+// iteration's details.
+const detailHeight = 8
+
+// listHeight returns the number of lines the iteration list gets.
+func (t *TimelineViewer) listHeight() int {
+	return max(1, t.height-detailHeight-1)
+}
+
+// iterationOutcome describes how an iteration ended.
+func iterationOutcome(r db.IterationRecord) (string, lipgloss.Style) {
+	switch {
+	case r.Failed:
+		return "✗ error", lipgloss.NewStyle().Foreground(ErrorColor)
+	case r.StoryPassed && r.Attempts > 1:
+		return fmt.Sprintf("✓ completed, %d retries", r.Attempts-1), lipgloss.NewStyle().Foreground(SuccessColor)
+	case r.StoryPassed:
+		return "✓ completed", lipgloss.NewStyle().Foreground(SuccessColor)
+	case r.Attempts > 1:
+		return fmt.Sprintf("↻ retried %d×", r.Attempts-1), lipgloss.NewStyle().Foreground(WarningColor)
+	default:
+		return "• no story completed", lipgloss.NewStyle().Foreground(MutedColor)
+	}
+}
+
+// Render renders the iteration list and the selected iteration's details.
+func (t *TimelineViewer) Render() string {
+	if t.err != nil {
+		return lipgloss.NewStyle().Foreground(ErrorColor).Render("Error loading iterations: " + t.err.Error())
+	}
+	if len(t.records) == 0 {
+		return lipgloss.NewStyle().Foreground(MutedColor).Render("No iterations yet. Start the loop to build the timeline.")
+	}
+
+	listHeight := t.listHeight()
+	start := 0
+	if t.cursor >= listHeight {
+		start = t.cursor - listHeight + 1
+	}
+	end := min(len(t.records), start+listHeight)
+
+	var lines []string
+	for i := start; i < end; i++ {
+		r := t.records[i]
+		story := r.StoryID
+		if story == "" {
+			story = "-"
+		}
+		outcome, outcomeStyle := iterationOutcome(r)
+		line := fmt.Sprintf("#%-3d %s  %-8s %8s  %3d tools  %2d files  ",
+			r.Iteration, r.StartedAt.Local().Format("01-02 15:04"), story,
+			formatDuration(r.Duration().Round(time.Second)), r.ToolCalls, len(r.Files))
+		line = truncateWithEllipsis(line, t.width)
+		if i == t.cursor {
+			line = lipgloss.NewStyle().Background(BgSelectedColor).Foreground(TextBrightColor).Render(line)
+		}
+		lines = append(lines, line+outcomeStyle.Render(outcome))
+	}
+	for len(lines) < listHeight {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, DividerStyle.Render(strings.Repeat("─", max(0, t.width))))
+	lines = append(lines, t.renderDetails(*t.Selected())...)
+	return strings.Join(lines, "\n")
+}
+
+// renderDetails renders the files an iteration changed and its errors.
+func (t *TimelineViewer) renderDetails(r db.IterationRecord) []string {
+	muted := lipgloss.NewStyle().Foreground(MutedColor)
+	var lines []string
+
+	commits := "no git history recorded"
+	if r.StartCommit != "" {
+		commits = shortHash(r.StartCommit) + ".." + shortHash(r.EndCommit)
+		if r.EndCommit == r.StartCommit {
+			commits = shortHash(r.StartCommit) + ", no commits"
+		}
+	}
+	lines = append(lines, muted.Render(fmt.Sprintf("Iteration %d  %s → %s  %s",
+		r.Iteration, r.StartedAt.Local().Format("15:04:05"), r.FinishedAt.Local().Format("15:04:05"), commits)))
+
+	for _, e := range r.Errors {
+		lines = append(lines, lipgloss.NewStyle().Foreground(ErrorColor).Render(truncateWithEllipsis("✗ "+e, t.width)))
+	}
+
+	if len(r.Files) == 0 {
+		lines = append(lines, muted.Render("No files changed"))
+	}
+	for i, f := range r.Files {
+		if len(lines) == detailHeight-1 && i < len(r.Files)-1 {
+			lines = append(lines, muted.Render(fmt.Sprintf("... and %d more", len(r.Files)-i)))
+			break
+		}
+		lines = append(lines, truncateWithEllipsis("  "+f, t.width))
+	}
+	if len(lines) > detailHeight {
+		lines = lines[:detailHeight]
+	}
+	return lines
+}
+
+// shortHash abbreviates a commit hash.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,338 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/izdrail/chief/internal/db"
+	"github.com/izdrail/chief/internal/transcript"
+)
+
+// TranscriptViewer lists a PRD's iteration transcripts and shows one at a
+// time, message by message, with tool calls, results, timings and tokens.
+type TranscriptViewer struct {
+	store   *db.Store
+	records []db.TranscriptRecord // Newest first
+	cursor  int
+
+	open   *db.TranscriptRecord // Transcript being shown, nil in the list
+	lines  []string
+	offset int
+	width  int
+	height int
+	err    error
+}
+
+// NewTranscriptViewer creates a transcript viewer reading the index in store,
+// which may be nil.
+func NewTranscriptViewer(store *db.Store) *TranscriptViewer {
+	return &TranscriptViewer{store: store}
+}
+
+// SetSize sets the viewport dimensions.
+func (t *TranscriptViewer) SetSize(width, height int) {
+	resized := width != t.width
+	t.width = width
+	t.height = height
+	if resized && t.open != nil {
+		t.render()
+	}
+}
+
+// Load lists the transcripts of a PRD and returns to the list.
+func (t *TranscriptViewer) Load(prdName string) {
+	t.open = nil
+	t.lines = nil
+	t.offset = 0
+	t.cursor = 0
+	t.records = nil
+	t.err = nil
+	if t.store == nil {
+		t.err = fmt.Errorf("transcripts require the SQLite store (.chief/chief.db)")
+		return
+	}
+
+	records, err := t.store.GetTranscripts(prdName)
+	if err != nil {
+		t.err = err
+		return
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		t.records = append(t.records, records[i])
+	}
+}
+
+// IsOpen reports whether a transcript is being shown rather than the list.
+func (t *TranscriptViewer) IsOpen() bool {
+	return t.open != nil
+}
+
+// Open shows the selected transcript.
+func (t *TranscriptViewer) Open() {
+	if t.cursor >= len(t.records) {
+		return
+	}
+	t.show(t.records[t.cursor])
+}
+
+// OpenPath lists a PRD's transcripts and shows the one at path.
+func (t *TranscriptViewer) OpenPath(prdName, path string) {
+	t.Load(prdName)
+	if t.err != nil {
+		return
+	}
+	for i, r := range t.records {
+		if r.Path == path {
+			t.cursor = i
+			t.show(r)
+			return
+		}
+	}
+	t.err = fmt.Errorf("transcript %s isn't indexed", path)
+}
+
+// show reads and renders a transcript.
+func (t *TranscriptViewer) show(record db.TranscriptRecord) {
+	t.open = &record
+	t.offset = 0
+	t.err = nil
+	t.render()
+}
+
+// Close returns to the list.
+func (t *TranscriptViewer) Close() {
+	t.open = nil
+	t.lines = nil
+	t.offset = 0
+	t.err = nil
+}
+
+// ScrollUp moves the selection, or scrolls the transcript, up one line.
+func (t *TranscriptViewer) ScrollUp() {
+	if t.open == nil {
+		if t.cursor > 0 {
+			t.cursor--
+		}
+		return
+	}
+	if t.offset > 0 {
+		t.offset--
+	}
+}
+
+// ScrollDown moves the selection, or scrolls the transcript, down one line.
+func (t *TranscriptViewer) ScrollDown() {
+	if t.open == nil {
+		if t.cursor < len(t.records)-1 {
+			t.cursor++
+		}
+		return
+	}
+	if t.offset < t.maxOffset() {
+		t.offset++
+	}
+}
+
+// PageUp scrolls up half a page.
+func (t *TranscriptViewer) PageUp() {
+	if t.open == nil {
+		t.cursor = max(0, t.cursor-t.height/2)
+		return
+	}
+	t.offset = max(0, t.offset-t.height/2)
+}
+
+// PageDown scrolls down half a page.
+func (t *TranscriptViewer) PageDown() {
+	if t.open == nil {
+		t.cursor = max(0, min(len(t.records)-1, t.cursor+t.height/2))
+		return
+	}
+	t.offset = min(t.maxOffset(), t.offset+t.height/2)
+}
+
+// ScrollToTop scrolls to the top.
+func (t *TranscriptViewer) ScrollToTop() {
+	if t.open == nil {
+		t.cursor = 0
+		return
+	}
+	t.offset = 0
+}
+
+// ScrollToBottom scrolls to the bottom.
+func (t *TranscriptViewer) ScrollToBottom() {
+	if t.open == nil {
+		t.cursor = max(0, len(t.records)-1)
+		return
+	}
+	t.offset = t.maxOffset()
+}
+
+func (t *TranscriptViewer) maxOffset() int {
+	if len(t.lines) <= t.height {
+		return 0
+	}
+	return len(t.lines) - t.height
+}
+
+// Title describes what the viewer is showing, for the header.
+func (t *TranscriptViewer) Title() string {
+	if t.open == nil {
+		return fmt.Sprintf("%d transcripts", len(t.records))
+	}
+	return fmt.Sprintf("iteration %d  %s", t.open.Iteration, t.open.StartedAt.Local().Format("2006-01-02 15:04:05"))
+}
+
+// Render renders the list or the open transcript.
+func (t *TranscriptViewer) Render() string {
+	if t.err != nil {
+		return lipgloss.NewStyle().Foreground(ErrorColor).Render("Error loading transcripts: " + t.err.Error())
+	}
+	if t.open == nil {
+		return t.renderList()
+	}
+
+	end := min(t.offset+t.height, len(t.lines))
+	return strings.Join(t.lines[t.offset:end], "\n")
+}
+
+// renderList renders one line per transcript, newest first.
+func (t *TranscriptViewer) renderList() string {
+	if len(t.records) == 0 {
+		return lipgloss.NewStyle().Foreground(MutedColor).Render("No transcripts yet. Each iteration's conversation is recorded when the loop runs.")
+	}
+
+	// Keep the cursor on screen
+	start := 0
+	if t.height > 0 && t.cursor >= t.height {
+		start = t.cursor - t.height + 1
+	}
+	end := min(len(t.records), start+max(t.height, 1))
+
+	var lines []string
+	for i := start; i < end; i++ {
+		r := t.records[i]
+		outcome := lipgloss.NewStyle().Foreground(SuccessColor).Render("✓")
+		if r.Failed {
+			outcome = lipgloss.NewStyle().Foreground(ErrorColor).Render("✗")
+		}
+		story := r.StoryID
+		if story == "" {
+			story = "-"
+		}
+		line := fmt.Sprintf("#%-3d %s  %-8s %8s  %3d msgs  %3d tools  %s",
+			r.Iteration, r.StartedAt.Local().Format("01-02 15:04:05"), story,
+			formatDuration(r.FinishedAt.Sub(r.StartedAt).Round(time.Second)), r.Messages, r.ToolCalls,
+			formatTokens(r.PromptTokens, r.OutputTokens))
+		line = truncateWithEllipsis(line, t.width-2)
+		if i == t.cursor {
+			line = lipgloss.NewStyle().Background(BgSelectedColor).Foreground(TextBrightColor).Render(line)
+		}
+		lines = append(lines, outcome+" "+line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// render reads the open transcript and lays it out as lines.
+func (t *TranscriptViewer) render() {
+	entries, err := transcript.Read(t.open.Path)
+	if err != nil {
+		t.err = err
+		t.lines = nil
+		return
+	}
+
+	width := max(t.width, 20)
+	headStyle := lipgloss.NewStyle().Bold(true)
+	metaStyle := lipgloss.NewStyle().Foreground(MutedColor)
+	toolStyle := lipgloss.NewStyle().Foreground(PrimaryColor)
+	errStyle := lipgloss.NewStyle().Foreground(ErrorColor)
+	roleColors := map[string]lipgloss.Color{
+		"system":    MutedColor,
+		"user":      WarningColor,
+		"assistant": PrimaryColor,
+		"tool":      SuccessColor,
+		"error":     ErrorColor,
+	}
+
+	var lines []string
+	for i, e := range entries {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+
+		// Header: role, attempt, timing, tokens
+		role := e.Role
+		if e.Role == "tool" && e.ToolName != "" {
+			role = "tool " + e.ToolName
+		}
+		meta := []string{e.Time.Local().Format("15:04:05")}
+		if e.Attempt > 1 {
+			meta = append(meta, fmt.Sprintf("attempt %d", e.Attempt))
+		}
+		if e.DurationMs > 0 {
+			meta = append(meta, e.Duration().Round(time.Millisecond).String())
+		}
+		if tokens := formatTokens(e.PromptTokens, e.OutputTokens); tokens != "" {
+			meta = append(meta, tokens)
+		}
+		if e.Model != "" {
+			meta = append(meta, e.Model)
+		}
+		if e.ToolCallID != "" {
+			meta = append(meta, "id "+e.ToolCallID)
+		}
+		lines = append(lines, headStyle.Foreground(roleColors[e.Role]).Render(role)+"  "+metaStyle.Render(strings.Join(meta, "  ")))
+
+		style := lipgloss.NewStyle().Foreground(TextColor)
+		if e.Role == "error" {
+			style = errStyle
+		}
+		for _, line := range hardWrap(e.Content, width) {
+			lines = append(lines, style.Render(line))
+		}
+		for _, tc := range e.ToolCalls {
+			call := "→ " + tc.Function.Name + " " + string(tc.Function.Arguments)
+			if tc.ID != "" {
+				call += "  (id " + tc.ID + ")"
+			}
+			for _, line := range hardWrap(call, width) {
+				lines = append(lines, toolStyle.Render(line))
+			}
+		}
+	}
+	if len(lines) == 0 {
+		lines = []string{metaStyle.Render("The transcript is empty.")}
+	}
+	t.lines = lines
+}
+
+// formatTokens formats prompt and output token counts, or returns "" when
+// neither is known.
+func formatTokens(prompt, output int) string {
+	if prompt == 0 && output == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d→%d tokens", prompt, output)
+}
+
+// hardWrap splits text into lines of at most width runes, keeping its own
+// line breaks and indentation.
+func hardWrap(text string, width int) []string {
+	if text == "" {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		runes := []rune(strings.ReplaceAll(line, "\t", "    "))
+		for len(runes) > width {
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		lines = append(lines, string(runes))
+	}
+	return lines
+}